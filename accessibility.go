@@ -0,0 +1,143 @@
+package epub
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	schemaAccessModeProperty           = "schema:accessMode"
+	schemaAccessModeSufficientProperty = "schema:accessModeSufficient"
+	schemaAccessibilityFeatureProperty = "schema:accessibilityFeature"
+	schemaAccessibilityHazardProperty  = "schema:accessibilityHazard"
+	schemaAccessibilitySummaryProperty = "schema:accessibilitySummary"
+)
+
+// Values of the schema.org accessibility vocabularies, as listed by the EPUB
+// Accessibility 1.1 spec
+var (
+	knownAccessibilityFeatures = map[string]bool{
+		"alternativeText":                   true,
+		"annotations":                       true,
+		"audioDescription":                  true,
+		"bookmarks":                         true,
+		"braille":                           true,
+		"captions":                          true,
+		"ChemML":                            true,
+		"describedMath":                     true,
+		"displayTransformability":           true,
+		"fullRubyAnnotations":               true,
+		"highContrastAudio":                 true,
+		"highContrastDisplay":               true,
+		"horizontalWriting":                 true,
+		"index":                             true,
+		"largePrint":                        true,
+		"latex":                             true,
+		"longDescription":                   true,
+		"MathML":                            true,
+		"none":                              true,
+		"pageBreakMarkers":                  true,
+		"pageNavigation":                    true,
+		"printPageNumbers":                  true,
+		"readingOrder":                      true,
+		"rubyAnnotations":                   true,
+		"signLanguage":                      true,
+		"structuralNavigation":              true,
+		"synchronizedAudioText":             true,
+		"tableOfContents":                   true,
+		"tactileGraphic":                    true,
+		"tactileObject":                     true,
+		"timingControl":                     true,
+		"transcript":                        true,
+		"ttsMarkup":                         true,
+		"unlocked":                          true,
+		"verticalWriting":                   true,
+		"withAdditionalWordSegmentation":    true,
+		"withoutAdditionalWordSegmentation": true,
+	}
+	knownAccessibilityHazards = map[string]bool{
+		"flashing":                      true,
+		"motionSimulation":              true,
+		"noFlashingHazard":              true,
+		"noMotionSimulationHazard":      true,
+		"none":                          true,
+		"noSoundHazard":                 true,
+		"sound":                         true,
+		"unknown":                       true,
+		"unknownFlashingHazard":         true,
+		"unknownMotionSimulationHazard": true,
+		"unknownSoundHazard":            true,
+	}
+	// The value is the sufficient access mode that the access mode falls under
+	knownAccessModes = map[string]string{
+		"auditory":         "auditory",
+		"chartOnVisual":    "visual",
+		"chemOnVisual":     "visual",
+		"colorDependent":   "visual",
+		"diagramOnTactile": "tactile",
+		"diagramOnVisual":  "visual",
+		"mathOnVisual":     "visual",
+		"musicOnVisual":    "visual",
+		"tactile":          "tactile",
+		"textOnVisual":     "visual",
+		"textual":          "textual",
+		"visual":           "visual",
+	}
+)
+
+// SetAccessibility sets the accessibility metadata of the EPUB, as required
+// by the EPUB Accessibility 1.1 spec and by a growing number of distributors:
+// the accessibility features (e.g. alternativeText, tableOfContents), the
+// hazards (e.g. noFlashingHazard, or none), the access modes (e.g. textual,
+// visual) and a human-readable summary. Each value is written as its own
+// schema:accessibilityFeature, schema:accessibilityHazard or schema:accessMode
+// metadata, replacing the values previously set (EPUB 3 only).
+//
+// The access modes are also written as schema:accessModeSufficient metadata,
+// stating that the content is fully accessible using all of them, e.g.
+// "textual,visual" for text with images; visual modes such as chartOnVisual
+// are written as visual.
+//
+// In strict mode, values that aren't part of the schema.org accessibility
+// vocabularies are rejected with UnknownPropertyError.
+func (e *Epub) SetAccessibility(features []string, hazards []string, accessMode []string, summary string) error {
+	if e.strict {
+		for _, feature := range features {
+			if !knownAccessibilityFeatures[feature] {
+				return &UnknownPropertyError{Property: feature}
+			}
+		}
+		for _, hazard := range hazards {
+			if !knownAccessibilityHazards[hazard] {
+				return &UnknownPropertyError{Property: hazard}
+			}
+		}
+		for _, mode := range accessMode {
+			if _, ok := knownAccessModes[mode]; !ok {
+				return &UnknownPropertyError{Property: mode}
+			}
+		}
+	}
+
+	sufficient := map[string]bool{}
+	for _, mode := range accessMode {
+		if s, ok := knownAccessModes[mode]; ok {
+			sufficient[s] = true
+		} else {
+			sufficient[mode] = true
+		}
+	}
+	sufficientModes := []string{}
+	for mode := range sufficient {
+		sufficientModes = append(sufficientModes, mode)
+	}
+	sort.Strings(sufficientModes)
+
+	e.pkg.setPropertyMetas(schemaAccessibilityFeatureProperty, features)
+	e.pkg.setPropertyMetas(schemaAccessibilityHazardProperty, hazards)
+	e.pkg.setPropertyMetas(schemaAccessModeProperty, accessMode)
+	e.pkg.setPropertyMeta(schemaAccessModeSufficientProperty, strings.Join(sufficientModes, ","))
+	e.pkg.setPropertyMeta(schemaAccessibilitySummaryProperty, summary)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetAccessibility(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAccessibility([]string{"tableOfContents"}, nil, []string{"textual"}, "Old summary")
+	err := e.SetAccessibility(
+		[]string{"alternativeText", "tableOfContents"},
+		[]string{"none"},
+		[]string{"textual", "chartOnVisual", "visual"},
+		"This publication meets WCAG 2.1 Level AA.")
+	if err != nil {
+		t.Errorf("Unexpected error setting accessibility metadata: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`<meta property="schema:accessibilityFeature">alternativeText</meta>
+    <meta property="schema:accessibilityFeature">tableOfContents</meta>
+    <meta property="schema:accessibilityHazard">none</meta>
+    <meta property="schema:accessMode">textual</meta>
+    <meta property="schema:accessMode">chartOnVisual</meta>
+    <meta property="schema:accessMode">visual</meta>
+    <meta property="schema:accessModeSufficient">textual,visual</meta>
+    <meta property="schema:accessibilitySummary">This publication meets WCAG 2.1 Level AA.</meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+		}
+	}
+	if strings.Contains(string(contents), "Old summary") {
+		t.Errorf("Package file metadata contains the old summary\nGot: %s", contents)
+	}
+
+	e.SetStrict(true)
+	err = e.SetAccessibility([]string{"alternativeText", "shiny"}, nil, nil, "")
+	if err, ok := err.(*UnknownPropertyError); !ok || err.Property != "shiny" {
+		t.Errorf("Expected UnknownPropertyError in strict mode\nGot: %v", err)
+	}
+}
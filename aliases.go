@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+)
+
+const aliasReferencePrefix = "epub-alias:"
+
+var (
+	aliasNameRegexp      = regexp.MustCompile(`^[\w.-]+$`)
+	aliasReferenceRegexp = regexp.MustCompile(aliasReferencePrefix + `([\w.-]+)`)
+)
+
+// InvalidAliasError is thrown by SetAlias if the alias contains characters
+// other than ASCII letters, digits, "_", "." and "-".
+type InvalidAliasError struct {
+	Alias string // The alias that was provided
+}
+
+func (e *InvalidAliasError) Error() string {
+	return fmt.Sprintf("Invalid alias: %q", e.Alias)
+}
+
+// AliasNotFoundError is thrown by Write if a section references an alias (see
+// PathOf) that wasn't set using SetAlias.
+type AliasNotFoundError struct {
+	Alias    string // The alias that was referenced
+	Filename string // The filename of the section
+}
+
+func (e *AliasNotFoundError) Error() string {
+	return fmt.Sprintf("Alias %q referenced by %s not found", e.Alias, e.Filename)
+}
+
+// SetAlias sets an alias for a file that has been added to the EPUB, e.g.
+// "logo" for an image, so that its path can be retrieved using PathOf. The
+// path is the one returned when the file was added, e.g. by AddImage or
+// AddSection. Setting an alias again makes it point to the new file.
+//
+// If the path doesn't match any file that has been added to the EPUB,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetAlias(alias string, internalPath string) error {
+	if !aliasNameRegexp.MatchString(alias) {
+		return &InvalidAliasError{Alias: alias}
+	}
+	// Paths are relative to the folder of the sections
+	p := path.Join(xhtmlFolderName, internalPath)
+	if !e.files()[p] {
+		return &FilenameNotFoundError{Filename: internalPath}
+	}
+	e.aliases[alias] = p
+
+	return nil
+}
+
+// PathOf returns the relative path to the file an alias was set for using
+// SetAlias, which can be used from a section.
+//
+// If the alias hasn't been set yet, a reference to it is returned instead,
+// which is replaced by the path when the EPUB is written. This allows the
+// content of sections to be generated before the files it uses are added. If
+// the alias still isn't set when the EPUB is written, AliasNotFoundError will
+// be returned by Write.
+func (e *Epub) PathOf(alias string) string {
+	if p, ok := e.aliases[alias]; ok {
+		return aliasPath(p)
+	}
+	return aliasReferencePrefix + alias
+}
+
+// Replace the references to aliases in a section body by the paths to their
+// files
+func (e *Epub) replaceAliases(filename string, body string) (string, error) {
+	var err error
+	body = aliasReferenceRegexp.ReplaceAllStringFunc(body, func(reference string) string {
+		alias := aliasReferenceRegexp.FindStringSubmatch(reference)[1]
+		p, ok := e.aliases[alias]
+		if !ok {
+			if err == nil {
+				err = &AliasNotFoundError{Alias: alias, Filename: filename}
+			}
+			return reference
+		}
+		return aliasPath(p)
+	})
+
+	return body, err
+}
+
+// Get the path to a file from the folder of the sections
+func aliasPath(p string) string {
+	if path.Dir(p) == xhtmlFolderName {
+		return path.Base(p)
+	}
+	return path.Join("..", p)
+}
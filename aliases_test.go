@@ -0,0 +1,55 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetAlias(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	// The section is composed before the image is added
+	body := `<img src="` + e.PathOf("logo") + `" alt="Logo" /><a href="` + e.PathOf("intro") + `">Intro</a>`
+	filename, err := e.AddSection(body, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	imagePath, err := e.AddImage(testImageFromFileSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	for alias, internalPath := range map[string]string{"logo": imagePath, "intro": filename} {
+		if err := e.SetAlias(alias, internalPath); err != nil {
+			t.Fatalf("Unexpected error setting alias: %s", err)
+		}
+		if p := e.PathOf(alias); p != internalPath {
+			t.Errorf("Path of alias %s doesn't match\nGot: %s\nExpected: %s", alias, p, internalPath)
+		}
+	}
+
+	if err := e.SetAlias("missing", "../images/missing.png"); err == nil {
+		t.Errorf("Expected error setting alias of missing file")
+	}
+	if _, ok := e.SetAlias("logo 2", imagePath).(*InvalidAliasError); !ok {
+		t.Errorf("Expected error setting invalid alias")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	expected := `<img src="` + imagePath + `" alt="Logo" /><a href="` + filename + `">Intro</a>`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("Section file doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+
+	e.AddSection(`<img src="`+e.PathOf("missing")+`" />`, testSectionTitle, "missing.xhtml", "")
+	err = e.Write(testEpubFilename)
+	if err, ok := err.(*AliasNotFoundError); !ok || err.Alias != "missing" || err.Filename != "missing.xhtml" {
+		t.Errorf("Expected error writing EPUB referencing a missing alias\nGot: %v\nExpected: AliasNotFoundError", err)
+	}
+}
@@ -0,0 +1,378 @@
+package epub
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	annotationClass         = "annotation"
+	annotationIDFormat      = "annotation-%d"
+	annotationMarkTemplate  = `<mark class="%s" id="%s">%s</mark>`
+	annotationNoteClass     = "annotation-note"
+	annotationNoteTemplate  = `<aside class="%s"><a href="#%s">%s</a></aside>`
+	kindleClippingSeparator = "=========="
+)
+
+// Closing tags after which a margin note can be inserted
+var annotationBlockEndTags = regexp.MustCompile(`</(p|h[1-6]|pre|table|ul|ol|dl|figure)>`)
+
+// Location information in the description line of a Kindle clipping,
+// e.g. "Location 120-125" or "location 120"
+var kindleLocation = regexp.MustCompile(`(?i)location (\d+)(?:-(\d+))?`)
+
+// Annotation is a highlight (and optional note) targeting a passage of the
+// EPUB.
+type Annotation struct {
+	// Internal filename of the section containing the passage. This is
+	// optional; if empty, all sections will be searched.
+	Section string
+	// The exact text of the highlighted passage
+	Exact string
+	// Text immediately before and after the passage, used to tell apart
+	// passages with the same text. Both are optional.
+	Prefix string
+	Suffix string
+	// The text of the note attached to the highlight. This is optional.
+	Note string
+}
+
+// Annotate injects the provided annotations into the sections of the EPUB in
+// order to produce an annotated edition. Each highlighted passage is wrapped in
+// a <mark class="annotation"> element, and each note is added as an
+// <aside class="annotation-note"> element after the block containing the
+// passage.
+//
+// Only passages contained in a single text node can be highlighted. The
+// annotations that couldn't be located are returned.
+func (e *Epub) Annotate(annotations []Annotation) []Annotation {
+	notFound := []Annotation{}
+	count := 0
+
+	for _, a := range annotations {
+		applied := false
+		if a.Exact != "" {
+			for i, section := range e.sections {
+				if a.Section != "" && section.filename != a.Section {
+					continue
+				}
+
+				body, ok := annotateBody(section.xhtml.xml.Body.XML, a, fmt.Sprintf(annotationIDFormat, count+1))
+				if ok {
+					e.sections[i].xhtml.xml.Body.XML = body
+					count++
+					applied = true
+					break
+				}
+			}
+		}
+		if !applied {
+			notFound = append(notFound, a)
+		}
+	}
+
+	return notFound
+}
+
+// Inject an annotation into the body of a section
+func annotateBody(body string, a Annotation, id string) (string, bool) {
+	exact := escapeText(a.Exact)
+	prefix := escapeText(a.Prefix)
+	suffix := escapeText(a.Suffix)
+
+	index := -1
+	for i := 0; i < len(body); {
+		found := strings.Index(body[i:], exact)
+		if found == -1 {
+			break
+		}
+		found += i
+		i = found + 1
+
+		if insideTag(body, found) {
+			continue
+		}
+		// Keep the first match as a fallback in case the context doesn't match
+		if index == -1 {
+			index = found
+		}
+		if strings.HasSuffix(body[:found], prefix) && strings.HasPrefix(body[found+len(exact):], suffix) {
+			index = found
+			break
+		}
+	}
+	if index == -1 {
+		return body, false
+	}
+
+	end := index + len(exact)
+	annotated := body[:index] + fmt.Sprintf(annotationMarkTemplate, annotationClass, id, exact)
+	rest := body[end:]
+
+	if a.Note != "" {
+		note := fmt.Sprintf(annotationNoteTemplate, annotationNoteClass, id, escapeText(a.Note))
+		loc := annotationBlockEndTags.FindStringIndex(rest)
+		if loc == nil {
+			rest += note
+		} else {
+			rest = rest[:loc[1]] + note + rest[loc[1]:]
+		}
+	}
+
+	return annotated + rest, true
+}
+
+// Check whether the given position of an XHTML string is within a tag
+func insideTag(s string, pos int) bool {
+	return strings.LastIndex(s[:pos], "<") > strings.LastIndex(s[:pos], ">")
+}
+
+// Escape the characters of a string which can't appear as-is in XHTML text
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// ParseWebAnnotations parses annotations in the W3C Web Annotation JSON format
+// (https://www.w3.org/TR/annotation-model/). The input can be a single
+// annotation, an array of annotations, or an annotation collection or page.
+//
+// Annotations must use a TextQuoteSelector to identify the targeted passage.
+// The source of the target, if provided, is used to identify the section.
+func ParseWebAnnotations(r io.Reader) ([]Annotation, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	return parseWebAnnotationJSON(raw)
+}
+
+type webAnnotation struct {
+	Items  json.RawMessage `json:"items"`
+	First  json.RawMessage `json:"first"`
+	Body   json.RawMessage `json:"body"`
+	Target json.RawMessage `json:"target"`
+}
+
+type webAnnotationTarget struct {
+	Source   string          `json:"source"`
+	Selector json.RawMessage `json:"selector"`
+}
+
+type webAnnotationSelector struct {
+	Type   string `json:"type"`
+	Exact  string `json:"exact"`
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix"`
+}
+
+type webAnnotationBody struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Purpose string `json:"purpose"`
+}
+
+func parseWebAnnotationJSON(raw json.RawMessage) ([]Annotation, error) {
+	annotations := []Annotation{}
+
+	raw = json.RawMessage(strings.TrimSpace(string(raw)))
+	if len(raw) == 0 || string(raw) == "null" {
+		return annotations, nil
+	}
+
+	// Arrays of annotations
+	if raw[0] == '[' {
+		items := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			a, err := parseWebAnnotationJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			annotations = append(annotations, a...)
+		}
+		return annotations, nil
+	}
+
+	w := webAnnotation{}
+	if err := json.Unmarshal(raw, &w); err != nil {
+		return nil, err
+	}
+
+	// Annotation collections and pages
+	if w.Items != nil || w.First != nil {
+		for _, items := range []json.RawMessage{w.First, w.Items} {
+			if items == nil {
+				continue
+			}
+			a, err := parseWebAnnotationJSON(items)
+			if err != nil {
+				return nil, err
+			}
+			annotations = append(annotations, a...)
+		}
+		return annotations, nil
+	}
+
+	a := Annotation{}
+	target := webAnnotationTarget{}
+	if err := unmarshalOneOrMany(w.Target, &target); err != nil {
+		return nil, fmt.Errorf("Error parsing annotation target: %s", err)
+	}
+	if target.Source != "" {
+		a.Section = path.Base(strings.SplitN(target.Source, "#", 2)[0])
+	}
+
+	selectors := []webAnnotationSelector{}
+	if err := unmarshalList(target.Selector, &selectors); err != nil {
+		return nil, fmt.Errorf("Error parsing annotation selector: %s", err)
+	}
+	for _, s := range selectors {
+		if s.Type == "TextQuoteSelector" {
+			a.Exact = s.Exact
+			a.Prefix = s.Prefix
+			a.Suffix = s.Suffix
+		}
+	}
+
+	bodies := []webAnnotationBody{}
+	if err := unmarshalList(w.Body, &bodies); err != nil {
+		// The body can also be a plain string
+		note := ""
+		if json.Unmarshal(w.Body, &note) != nil {
+			return nil, fmt.Errorf("Error parsing annotation body: %s", err)
+		}
+		bodies = append(bodies, webAnnotationBody{Value: note})
+	}
+	for _, b := range bodies {
+		if b.Value != "" && b.Purpose != "tagging" {
+			a.Note = b.Value
+			break
+		}
+	}
+
+	return append(annotations, a), nil
+}
+
+// Unmarshal either a single JSON object or the first item of a JSON array
+func unmarshalOneOrMany(raw json.RawMessage, v interface{}) error {
+	raw = json.RawMessage(strings.TrimSpace(string(raw)))
+	if len(raw) == 0 {
+		return nil
+	}
+	if raw[0] == '"' {
+		// A target can simply be the IRI of the source
+		s := ""
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		raw, _ = json.Marshal(map[string]string{"source": s})
+	}
+	if raw[0] == '[' {
+		items := []json.RawMessage{}
+		if err := json.Unmarshal(raw, &items); err != nil || len(items) == 0 {
+			return err
+		}
+		return unmarshalOneOrMany(items[0], v)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Unmarshal either a single JSON object or an array of objects into a slice
+func unmarshalList(raw json.RawMessage, v interface{}) error {
+	raw = json.RawMessage(strings.TrimSpace(string(raw)))
+	if len(raw) == 0 {
+		return nil
+	}
+	if raw[0] != '[' {
+		raw = json.RawMessage("[" + string(raw) + "]")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// ParseKindleClippings parses highlights and notes from a Kindle
+// "My Clippings.txt" file. Only clippings whose title starts with the provided
+// book title are returned; if the book title is empty, all clippings are
+// returned.
+//
+// Kindle notes are attached to the highlight whose location they fall within.
+// Notes without a matching highlight are ignored since they can't be located
+// in the text.
+func ParseKindleClippings(r io.Reader, bookTitle string) ([]Annotation, error) {
+	type clipping struct {
+		annotation Annotation
+		start, end int
+	}
+	highlights := []*clipping{}
+	notes := []clipping{}
+
+	lines := []string{}
+	flush := func() {
+		defer func() { lines = lines[:0] }()
+		if len(lines) < 3 {
+			return
+		}
+		title := strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff"))
+		if bookTitle != "" && !strings.HasPrefix(title, bookTitle) {
+			return
+		}
+
+		c := clipping{}
+		if m := kindleLocation.FindStringSubmatch(lines[1]); m != nil {
+			c.start, _ = strconv.Atoi(m[1])
+			c.end = c.start
+			if m[2] != "" {
+				c.end, _ = strconv.Atoi(m[2])
+			}
+		}
+		text := strings.TrimSpace(strings.Join(lines[2:], "\n"))
+
+		description := strings.ToLower(lines[1])
+		switch {
+		case strings.Contains(description, "highlight"):
+			c.annotation.Exact = text
+			highlights = append(highlights, &c)
+		case strings.Contains(description, "note"):
+			c.annotation.Note = text
+			notes = append(notes, c)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == kindleClippingSeparator {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	for _, n := range notes {
+		for _, h := range highlights {
+			if n.start >= h.start && n.start <= h.end && h.annotation.Note == "" {
+				h.annotation.Note = n.annotation.Note
+				break
+			}
+		}
+	}
+
+	annotations := []Annotation{}
+	for _, h := range highlights {
+		annotations = append(annotations, h.annotation)
+	}
+
+	return annotations, nil
+}
@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testAnnotationBody = `    <h1>Section 1</h1>
+    <p>This is a paragraph.</p>
+    <p>This is a paragraph &amp; a <em>second</em> paragraph.</p>`
+	testAnnotatedBody = `    <h1>Section 1</h1>
+    <p>This is a paragraph.</p>
+    <p>This is a <mark class="annotation" id="annotation-1">paragraph &amp; a</mark> <em>second</em> paragraph.</p><aside class="annotation-note"><a href="#annotation-1">Nice &lt;3</a></aside>`
+	testKindleClippings = "\ufeffMy title (Hingle McCringleberry)\r\n" +
+		"- Your Highlight on page 1 | Location 10-12 | Added on Monday, 1 January 2018 12:00:00\r\n" +
+		"\r\n" +
+		"paragraph & a\r\n" +
+		"==========\r\n" +
+		"My title (Hingle McCringleberry)\r\n" +
+		"- Your Note on page 1 | Location 12 | Added on Monday, 1 January 2018 12:01:00\r\n" +
+		"\r\n" +
+		"Nice <3\r\n" +
+		"==========\r\n" +
+		"Another book (Someone Else)\r\n" +
+		"- Your Highlight on page 3 | Location 30-31 | Added on Monday, 1 January 2018 12:02:00\r\n" +
+		"\r\n" +
+		"Not this one\r\n" +
+		"==========\r\n"
+	testWebAnnotations = `{
+  "@context": "http://www.w3.org/ns/anno.jsonld",
+  "type": "AnnotationCollection",
+  "first": {
+    "type": "AnnotationPage",
+    "items": [
+      {
+        "type": "Annotation",
+        "body": {"type": "TextualBody", "value": "Nice <3"},
+        "target": {
+          "source": "xhtml/section0001.xhtml",
+          "selector": {"type": "TextQuoteSelector", "exact": "paragraph & a", "prefix": "This is a "}
+        }
+      },
+      {
+        "type": "Annotation",
+        "target": {
+          "source": "xhtml/section0001.xhtml",
+          "selector": [{"type": "TextQuoteSelector", "exact": "missing"}]
+        }
+      }
+    ]
+  }
+}`
+)
+
+func TestAnnotate(t *testing.T) {
+	annotations, err := ParseWebAnnotations(strings.NewReader(testWebAnnotations))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing web annotations: %s", err)
+	}
+
+	testAnnotations := []Annotation{
+		{Section: testSectionFilename, Exact: "paragraph & a", Prefix: "This is a ", Note: "Nice <3"},
+		{Section: testSectionFilename, Exact: "missing"},
+	}
+	if !reflect.DeepEqual(annotations, testAnnotations) {
+		t.Errorf(
+			"Web annotations don't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			annotations,
+			testAnnotations)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testAnnotationBody, testSectionTitle, testSectionFilename, "")
+
+	notFound := e.Annotate(annotations)
+	if len(notFound) != 1 || notFound[0].Exact != "missing" {
+		t.Errorf("Unexpected annotations not found: %+v", notFound)
+	}
+
+	body := e.sections[0].xhtml.xml.Body.XML
+	if trimAllSpace(body) != trimAllSpace(testAnnotatedBody) {
+		t.Errorf(
+			"Annotated body doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			body,
+			testAnnotatedBody)
+	}
+}
+
+func TestParseKindleClippings(t *testing.T) {
+	annotations, err := ParseKindleClippings(strings.NewReader(testKindleClippings), testEpubTitle)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing Kindle clippings: %s", err)
+	}
+
+	testAnnotations := []Annotation{
+		{Exact: "paragraph & a", Note: "Nice <3"},
+	}
+	if !reflect.DeepEqual(annotations, testAnnotations) {
+		t.Errorf(
+			"Kindle clippings don't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			annotations,
+			testAnnotations)
+	}
+}
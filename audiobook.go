@@ -0,0 +1,265 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	audiobookConformsTo   = "https://www.w3.org/TR/audiobooks/"
+	audiobookManifestName = "publication.json"
+	audiobookTocFilename  = "toc.html"
+	audiobookTocItemTmpl  = `        <li><a href="%s">%s</a></li>`
+	audiobookTocTemplate  = `<!DOCTYPE html>
+<html lang="%s"%s>
+  <head>
+    <meta charset="utf-8" />
+    <title>%s</title>
+  </head>
+  <body>
+    <nav role="doc-toc">
+      <h1>%s</h1>
+      <ol>
+%s
+      </ol>
+    </nav>
+  </body>
+</html>
+`
+	audiobookType           = "Audiobook"
+	mediaTypeHTML           = "text/html"
+	pubManifestContextPub   = "https://www.w3.org/ns/pub-context"
+	pubManifestContextSchem = "https://schema.org"
+)
+
+type audiobookChapter struct {
+	title    string
+	filename string
+	duration time.Duration
+}
+
+// pubManifest implements the W3C Publication Manifest, as used by audiobooks
+//
+// Spec: https://www.w3.org/TR/audiobooks/
+// Spec: https://www.w3.org/TR/pub-manifest/
+type pubManifest struct {
+	Context            []string          `json:"@context"`
+	ConformsTo         string            `json:"conformsTo"`
+	Type               string            `json:"type"`
+	ID                 string            `json:"id"`
+	Name               string            `json:"name"`
+	Author             string            `json:"author,omitempty"`
+	InLanguage         string            `json:"inLanguage,omitempty"`
+	Description        string            `json:"description,omitempty"`
+	Publisher          string            `json:"publisher,omitempty"`
+	DatePublished      string            `json:"datePublished,omitempty"`
+	DateModified       string            `json:"dateModified"`
+	Duration           string            `json:"duration,omitempty"`
+	ReadingProgression string            `json:"readingProgression,omitempty"`
+	ReadingOrder       []pubManifestLink `json:"readingOrder"`
+	Resources          []pubManifestLink `json:"resources,omitempty"`
+}
+
+type pubManifestLink struct {
+	Type           string `json:"type"`
+	URL            string `json:"url"`
+	EncodingFormat string `json:"encodingFormat,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Rel            string `json:"rel,omitempty"`
+	Duration       string `json:"duration,omitempty"`
+}
+
+// AddAudiobookChapter adds a chapter to the audiobook edition of the EPUB,
+// which can be written using WriteAudiobook. Chapters are played in the same
+// order they were added.
+//
+// The internal path to an already-added audio file (as returned by AddAudio) is
+// required. If it doesn't match an audio file that has been added,
+// FilenameNotFoundError will be returned. The duration of the chapter is
+// optional but recommended.
+func (e *Epub) AddAudiobookChapter(title string, internalAudioPath string, duration time.Duration) error {
+	filename := filepath.Base(internalAudioPath)
+	if _, ok := e.audio[filename]; !ok {
+		return &FilenameNotFoundError{Filename: filename}
+	}
+
+	e.audiobookChapters = append(e.audiobookChapters, audiobookChapter{
+		title:    title,
+		filename: filename,
+		duration: duration,
+	})
+
+	return nil
+}
+
+// WriteAudiobook writes the audiobook edition of the EPUB as a W3C Audiobook
+// packaged using the Lightweight Packaging Format (LPF), which is a zip file
+// containing the publication manifest (publication.json), the audio files of
+// the chapters added using AddAudiobookChapter, the cover image if one was
+// set, and a table of contents (toc.html). The metadata (title, author, etc)
+// is the same as the metadata of the EPUB.
+//
+// The destination path must be the full path to the resulting file, including
+// filename and extension (usually .lpf).
+func (e *Epub) WriteAudiobook(destFilePath string) error {
+	tempDir, err := e.newTempDir()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			panic(fmt.Sprintf("Error removing temp directory: %s", err))
+		}
+	}()
+
+	e.resolveIdentifier()
+
+	m := pubManifest{
+		Context:            []string{pubManifestContextSchem, pubManifestContextPub},
+		ConformsTo:         audiobookConformsTo,
+		Type:               audiobookType,
+		ID:                 e.identifier,
+		Name:               e.title,
+		Author:             e.author,
+		InLanguage:         e.lang,
+		Description:        e.desc,
+		Publisher:          e.publisher,
+		DatePublished:      e.pkg.xml.Metadata.Date,
+		DateModified:       e.modifiedTime().Format(time.RFC3339),
+		ReadingProgression: e.ppd,
+		ReadingOrder:       []pubManifestLink{},
+	}
+
+	// Files to copy into the package, in the order they should be copied
+	files := []string{}
+	mediaSources := map[string]string{}
+	tocItems := []string{}
+	var totalDuration time.Duration
+
+	for _, chapter := range e.audiobookChapters {
+		href := path.Join(AudioFolderName, chapter.filename)
+		if _, ok := mediaSources[href]; !ok {
+			files = append(files, href)
+			mediaSources[href] = e.audio[chapter.filename]
+		}
+
+		link := pubManifestLink{
+			Type:           "LinkedResource",
+			URL:            href,
+			EncodingFormat: extensionMediaTypes[strings.ToLower(path.Ext(chapter.filename))],
+			Name:           chapter.title,
+		}
+		if chapter.duration > 0 {
+			link.Duration = formatISO8601Duration(chapter.duration)
+			totalDuration += chapter.duration
+		}
+		m.ReadingOrder = append(m.ReadingOrder, link)
+
+		if chapter.title != "" {
+			tocItems = append(tocItems, fmt.Sprintf(audiobookTocItemTmpl, html.EscapeString(href), html.EscapeString(chapter.title)))
+		}
+	}
+	if totalDuration > 0 {
+		m.Duration = formatISO8601Duration(totalDuration)
+	}
+
+	if e.cover.imageFilename != "" {
+		href := path.Join(ImageFolderName, e.cover.imageFilename)
+		files = append(files, href)
+		mediaSources[href] = e.images[e.cover.imageFilename]
+		m.Resources = append(m.Resources, pubManifestLink{
+			Type:           "LinkedResource",
+			URL:            href,
+			EncodingFormat: extensionMediaTypes[strings.ToLower(path.Ext(href))],
+			Rel:            "cover",
+		})
+	}
+
+	m.Resources = append(m.Resources, pubManifestLink{
+		Type:           "LinkedResource",
+		URL:            audiobookTocFilename,
+		EncodingFormat: mediaTypeHTML,
+		Rel:            "contents",
+	})
+
+	for _, href := range files {
+		destPath := filepath.Join(tempDir, filepath.FromSlash(href))
+		if err := os.MkdirAll(filepath.Dir(destPath), dirPermissions); err != nil {
+			panic(fmt.Sprintf("Unable to create directory: %s", err))
+		}
+		if err := e.copyMedia(path.Dir(href), path.Base(href), mediaSources[href], destPath); err != nil {
+			return err
+		}
+	}
+
+	manifestContent, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf("Error marshalling audiobook manifest: %s", err))
+	}
+	manifestContent = append(manifestContent, "\n"...)
+	if err := ioutil.WriteFile(filepath.Join(tempDir, audiobookManifestName), manifestContent, filePermissions); err != nil {
+		panic(fmt.Sprintf("Error writing audiobook manifest: %s", err))
+	}
+
+	tocContent := fmt.Sprintf(
+		audiobookTocTemplate,
+		html.EscapeString(e.lang),
+		dirAttribute(e.textDirection()),
+		html.EscapeString(e.title),
+		html.EscapeString(e.label(LabelTableOfContents, "")),
+		strings.Join(tocItems, "\n"),
+	)
+	if err := ioutil.WriteFile(filepath.Join(tempDir, audiobookTocFilename), []byte(tocContent), filePermissions); err != nil {
+		panic(fmt.Sprintf("Error writing audiobook table of contents: %s", err))
+	}
+
+	f, err := os.Create(destFilePath)
+	if err != nil {
+		return &UnableToCreateEpubError{
+			Path: destFilePath,
+			Err:  err,
+		}
+	}
+
+	z := zip.NewWriter(f)
+	// The manifest comes first so it can be found quickly
+	err = addFileToZip(z, filepath.Join(tempDir, audiobookManifestName), audiobookManifestName, zip.Deflate, e.zipTimestamp())
+	if err == nil {
+		err = addFileToZip(z, filepath.Join(tempDir, audiobookTocFilename), audiobookTocFilename, zip.Deflate, e.zipTimestamp())
+	}
+	for _, href := range files {
+		if err != nil {
+			break
+		}
+		// Audio and images are already compressed
+		err = addFileToZip(z, filepath.Join(tempDir, filepath.FromSlash(href)), href, zip.Store, e.zipTimestamp())
+	}
+	if closeErr := z.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return &UnableToCreateEpubError{
+			Path: destFilePath,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// Format a duration as an ISO 8601 duration in seconds, e.g. PT90.5S
+func formatISO8601Duration(d time.Duration) string {
+	return "PT" + strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S"
+}
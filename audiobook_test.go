@@ -0,0 +1,108 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testAudiobookFilename = "My audiobook.lpf"
+	testAudioFilename     = "chapter1.mp3"
+	testAudioTitle        = "Chapter 1"
+)
+
+func TestWriteAudiobook(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+
+	// Any file will do; the audio isn't decoded
+	audioPath, err := e.AddAudio(testImageFromFileSource, testAudioFilename)
+	if err != nil {
+		t.Fatalf("Error adding audio: %s", err)
+	}
+	if err := e.AddAudiobookChapter(testAudioTitle, audioPath, 90500*time.Millisecond); err != nil {
+		t.Errorf("Error adding audiobook chapter: %s", err)
+	}
+
+	err = e.AddAudiobookChapter(testAudioTitle, "../audio/doesnotexist.mp3", 0)
+	if _, ok := err.(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error *FilenameNotFoundError, got %#v", err)
+	}
+
+	if err := e.WriteAudiobook(testAudiobookFilename); err != nil {
+		t.Fatalf("Error writing audiobook: %s", err)
+	}
+	defer os.Remove(testAudiobookFilename)
+
+	r, err := zip.OpenReader(testAudiobookFilename)
+	if err != nil {
+		t.Fatalf("Error opening audiobook: %s", err)
+	}
+	defer r.Close()
+
+	contents := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Error opening %s: %s", f.Name, err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Error reading %s: %s", f.Name, err)
+		}
+		contents[f.Name] = string(b)
+	}
+
+	if r.File[0].Name != audiobookManifestName {
+		t.Errorf(
+			"First file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			r.File[0].Name,
+			audiobookManifestName)
+	}
+
+	source, _ := ioutil.ReadFile(filepath.FromSlash(testImageFromFileSource))
+	if contents["audio/"+testAudioFilename] != string(source) {
+		t.Errorf("Audio file content doesn't match source")
+	}
+
+	m := pubManifest{}
+	if err := json.Unmarshal([]byte(contents[audiobookManifestName]), &m); err != nil {
+		t.Fatalf("Error parsing audiobook manifest: %s", err)
+	}
+	if m.Type != audiobookType || m.ConformsTo != audiobookConformsTo || m.Name != testEpubTitle || m.Author != testEpubAuthor {
+		t.Errorf("Audiobook manifest metadata doesn't match: %+v", m)
+	}
+	if m.Duration != "PT90.5S" {
+		t.Errorf(
+			"Audiobook duration doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			m.Duration,
+			"PT90.5S")
+	}
+	if len(m.ReadingOrder) != 1 ||
+		m.ReadingOrder[0].URL != "audio/"+testAudioFilename ||
+		m.ReadingOrder[0].EncodingFormat != "audio/mpeg" ||
+		m.ReadingOrder[0].Name != testAudioTitle {
+		t.Errorf("Audiobook reading order doesn't match: %+v", m.ReadingOrder)
+	}
+
+	testTocItem := `<li><a href="audio/` + testAudioFilename + `">` + testAudioTitle + `</a></li>`
+	if !strings.Contains(contents[audiobookTocFilename], testTocItem) {
+		t.Errorf(
+			"Audiobook table of contents doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents[audiobookTocFilename],
+			testTocItem)
+	}
+}
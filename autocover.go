@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Start tags of HTML images and SVG images
+var imageTagRegexp = regexp.MustCompile(`(?is)<(img|image)\b[^>]*>`)
+
+// SetAutoCover sets whether an image is used as the cover when the EPUB is
+// written if no cover was set using SetCover, as catalogs often reject EPUBs
+// without a cover. The cover is the first image of the section with the
+// provided internal filename, or if it's empty, the first image of the EPUB
+// in reading order or else the added image whose filename comes first. A
+// warning is added to the build report (see LastBuildReport) when a cover is
+// chosen this way. Automatic covers are disabled by default.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetAutoCover(enabled bool, sectionFilename string) error {
+	if sectionFilename != "" {
+		found := false
+		for _, section := range e.sections {
+			if section.filename == sectionFilename {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &FilenameNotFoundError{Filename: sectionFilename}
+		}
+	}
+
+	e.autoCover = enabled
+	e.autoCoverSection = sectionFilename
+
+	return nil
+}
+
+// Set the cover when the EPUB is written if SetAutoCover was enabled and no
+// cover was set. The cover is removed by removeAutoCover.
+func (e *Epub) addAutoCover() {
+	if !e.autoCover || e.cover.xhtmlFilename != "" {
+		return
+	}
+
+	imageFilename := ""
+	for _, section := range e.spineSections() {
+		if e.autoCoverSection != "" && section.filename != e.autoCoverSection {
+			continue
+		}
+		if imageFilename = e.firstImage(section.xhtml.xml.Body.XML); imageFilename != "" {
+			break
+		}
+	}
+	if imageFilename == "" && e.autoCoverSection == "" && len(e.images) > 0 {
+		filenames := make([]string, 0, len(e.images))
+		for filename := range e.images {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+		imageFilename = filenames[0]
+	}
+	if imageFilename == "" {
+		e.buildWarnings = append(e.buildWarnings, "No cover set and no image found to use as the cover")
+		return
+	}
+
+	e.SetCover(path.Join("..", ImageFolderName, imageFilename), "")
+	e.cover.auto = true
+	e.buildWarnings = append(e.buildWarnings, fmt.Sprintf("No cover set, %s used as the cover", imageFilename))
+}
+
+// Remove the cover set by addAutoCover. The cover section is removed along
+// with the other sections added while building.
+func (e *Epub) removeAutoCover() {
+	if !e.cover.auto {
+		return
+	}
+
+	e.removeCover()
+}
+
+// Get the filename of the first image of a section body
+func (e *Epub) firstImage(body string) string {
+	for _, m := range imageTagRegexp.FindAllStringSubmatch(body, -1) {
+		tag := m[0]
+		attrs := []string{"src"}
+		if strings.EqualFold(m[1], "image") {
+			attrs = []string{"href", "xlink:href"}
+		}
+		for _, attr := range attrs {
+			src, ok := tagAttribute(tag, attr)
+			if !ok {
+				continue
+			}
+			p := path.Join(xhtmlFolderName, src)
+			if path.Dir(p) != ImageFolderName {
+				continue
+			}
+			if _, ok := e.images[path.Base(p)]; ok {
+				return path.Base(p)
+			}
+		}
+	}
+
+	return ""
+}
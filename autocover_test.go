@@ -0,0 +1,63 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetAutoCover(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	firstImagePath, _ := e.AddImage(testImageFromFileSource, "first.png")
+	secondImagePath, _ := e.AddImage(testImageFromFileSource, "second.png")
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+	e.AddSection(`<p><img src="`+secondImagePath+`" alt="" /></p>`, testSectionTitle, "", "")
+	imageSection, _ := e.AddSection(`<p><img src="`+firstImagePath+`" alt="" /></p>`, testSectionTitle, "", "")
+
+	if err := e.SetAutoCover(true, "missing.xhtml"); err == nil {
+		t.Errorf("Expected error setting an automatic cover from a missing section")
+	}
+
+	for _, testCase := range []struct {
+		section string
+		image   string
+	}{
+		{"", "second.png"},
+		{imageSection, "first.png"},
+	} {
+		if err := e.SetAutoCover(true, testCase.section); err != nil {
+			t.Fatalf("Unexpected error setting automatic cover: %s", err)
+		}
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, defaultCoverXhtmlFilename))
+		if err != nil {
+			t.Errorf("Unexpected error reading cover file: %s", err)
+		}
+		testImage := "../images/" + testCase.image
+		if !strings.Contains(string(contents), testImage) {
+			t.Errorf("Cover file doesn't match\nGot: %s\nExpected to contain: %s", contents, testImage)
+		}
+		warnings := e.LastBuildReport().Warnings
+		if len(warnings) != 1 || !strings.Contains(warnings[0], testCase.image) {
+			t.Errorf("Build report warnings don't match\nGot: %v\nExpected to mention: %s", warnings, testCase.image)
+		}
+
+		cleanup(testEpubFilename, tempDir)
+
+		// The cover is only set while writing
+		if e.cover.xhtmlFilename != "" || len(e.sections) != 3 || len(e.css) != 0 {
+			t.Errorf("Automatic cover wasn't removed after writing\nCover: %+v\nSections: %d\nCSS: %v", e.cover, len(e.sections), e.css)
+		}
+	}
+
+	// A cover set using SetCover is kept
+	e.SetCover(firstImagePath, "")
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+	if warnings := e.LastBuildReport().Warnings; len(warnings) != 0 {
+		t.Errorf("Build report warnings don't match\nGot: %v\nExpected: none", warnings)
+	}
+}
@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"html/template"
+)
+
+// BackMatter is promotional content appended to EPUBs when they're written,
+// such as a newsletter signup page or an excerpt of the next book. The same
+// back matter can be shared by many EPUBs, as its sections are rendered from
+// templates using the metadata of each EPUB.
+type BackMatter struct {
+	// The sections appended to the EPUB, in order
+	Sections []BackMatterSection
+	// Variants of the sections for specific markets (e.g. "US"), used instead
+	// of Sections by EPUBs whose market (set using SetMarket) matches the key
+	Markets map[string][]BackMatterSection
+}
+
+// BackMatterSection is a section of back matter.
+type BackMatterSection struct {
+	// The title and internal filename work the same way as for AddSection.
+	// The internal filename must not be used by any section of the EPUB.
+	Title    string
+	Filename string
+	// The internal path to an already-added CSS file (as returned by AddCSS),
+	// optional
+	CSSPath string
+	// The template of the body of the section, which is executed with a
+	// BackMatterData
+	Template *template.Template
+}
+
+// BackMatterData is the data used to render the templates of back matter.
+type BackMatterData struct {
+	Title  string
+	Author string
+	Lang   string
+	Market string
+	// The data provided to SetBackMatter, e.g. the next book of the series
+	Data interface{}
+}
+
+// SetBackMatter sets the back matter appended to the EPUB each time it's
+// written, after all the other sections. The sections are only added while the
+// EPUB is written, so the EPUB itself is left unchanged. The data is optional
+// and specific to the EPUB; it's available to the templates as the Data field
+// of BackMatterData.
+//
+// Write will return the same errors as AddSection if a section can't be
+// added, and SectionTemplateError if a template can't be rendered. A nil back
+// matter removes the back matter.
+func (e *Epub) SetBackMatter(backMatter *BackMatter, data interface{}) {
+	e.backMatter = backMatter
+	e.backMatterData = data
+}
+
+// SetMarket sets the market the EPUB is published in, e.g. "US", which selects
+// the variant of the back matter used. It isn't written to the EPUB.
+func (e *Epub) SetMarket(market string) {
+	e.market = market
+}
+
+// Add the sections of the back matter. The number of sections before they
+// were added is returned, to remove them once the EPUB is written.
+func (e *Epub) addBackMatter() (int, error) {
+	count := len(e.sections)
+	if e.backMatter == nil {
+		return count, nil
+	}
+
+	sections := e.backMatter.Sections
+	if variant, ok := e.backMatter.Markets[e.market]; ok {
+		sections = variant
+	}
+
+	data := BackMatterData{
+		Title:  e.Title(),
+		Author: e.Author(),
+		Lang:   e.Lang(),
+		Market: e.market,
+		Data:   e.backMatterData,
+	}
+	for _, section := range sections {
+		if _, err := e.AddSection("", section.Title, section.Filename, section.CSSPath); err != nil {
+			return count, err
+		}
+		e.sections[len(e.sections)-1].template = &sectionTemplate{
+			tmpl: section.Template,
+			data: data,
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,109 @@
+package epub
+
+import (
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetBackMatter(t *testing.T) {
+	backMatter := &BackMatter{
+		Sections: []BackMatterSection{
+			{
+				Title:    "Newsletter",
+				Filename: "newsletter.xhtml",
+				Template: template.Must(template.New("").Parse(`<p>Liked {{.Title}}? Sign up!</p>`)),
+			},
+			{
+				Title:    "Excerpt",
+				Filename: "excerpt.xhtml",
+				Template: template.Must(template.New("").Parse(`<p>{{.Data}} by {{.Author}}</p>`)),
+			},
+		},
+		Markets: map[string][]BackMatterSection{
+			"UK": {
+				{
+					Title:    "Newsletter",
+					Filename: "newsletter.xhtml",
+					Template: template.Must(template.New("").Parse(`<p>Liked {{.Title}}? Sign up, {{.Market}}!</p>`)),
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		market    string
+		testFiles map[string]string
+	}{
+		{
+			testFiles: map[string]string{
+				"newsletter.xhtml": `<p>Liked Go &amp; gophers? Sign up!</p>`,
+				"excerpt.xhtml":    `<p>Next book by Jane Doe</p>`,
+			},
+		},
+		{
+			market: "UK",
+			testFiles: map[string]string{
+				"newsletter.xhtml": `<p>Liked Go &amp; gophers? Sign up, UK!</p>`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub("Go & gophers")
+		e.SetAuthor("Jane Doe")
+		e.SetMarket(test.market)
+		e.AddSection("<p>Chapter</p>", "Chapter", "chapter.xhtml", "")
+		e.SetBackMatter(backMatter, "Next book")
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		for filename, testBody := range test.testFiles {
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+			if err != nil {
+				t.Errorf("Unexpected error reading back matter section: %s", err)
+			}
+			if !strings.Contains(string(contents), testBody) {
+				t.Errorf(
+					"Back matter section doesn't match\n"+
+						"Got: %s\n"+
+						"Expected to contain: %s",
+					contents,
+					testBody)
+			}
+		}
+		if _, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "excerpt.xhtml")); (err == nil) != (test.market == "") {
+			t.Errorf("Excerpt section presence doesn't match market %q", test.market)
+		}
+
+		// The EPUB itself is left unchanged
+		if len(e.sections) != 1 {
+			t.Errorf("Back matter sections weren't removed after writing\nGot: %d sections", len(e.sections))
+		}
+
+		cleanup(testEpubFilename, tempDir)
+	}
+}
+
+func TestSetBackMatterFilenameAlreadyUsed(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection("<p>Chapter</p>", "Chapter", "newsletter.xhtml", "")
+	e.SetBackMatter(&BackMatter{
+		Sections: []BackMatterSection{
+			{
+				Filename: "newsletter.xhtml",
+				Template: template.Must(template.New("").Parse(`<p>Sign up!</p>`)),
+			},
+		},
+	}, nil)
+
+	err := e.Write(testEpubFilename)
+	if _, ok := err.(*FilenameAlreadyUsedError); !ok {
+		t.Errorf("Expected error FilenameAlreadyUsedError not returned. Returned instead: %+v", err)
+	}
+	if len(e.sections) != 1 {
+		t.Errorf("Back matter sections weren't removed after writing\nGot: %d sections", len(e.sections))
+	}
+}
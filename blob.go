@@ -0,0 +1,97 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BlobStore gives access to the blobs of an object storage service such as
+// Amazon S3 or Google Cloud Storage, identified by URLs such as
+// s3://bucket/key. See RegisterBlobStore.
+type BlobStore interface {
+	// Open opens the blob at the provided URL for reading.
+	Open(url string) (io.ReadCloser, error)
+	// Create returns a writer to the blob at the provided URL, which is
+	// stored once the writer is closed.
+	Create(url string) (io.WriteCloser, error)
+}
+
+var (
+	blobStores   = map[string]BlobStore{}
+	blobStoresMu sync.RWMutex
+)
+
+// RegisterBlobStore registers a blob store for the URLs with the provided
+// scheme, e.g. s3 or gs. Once registered, such URLs can be used as the source
+// of any file of an EPUB (e.g. by AddImage) and as the destination of Write,
+// which uploads the EPUB to the blob store. Registering nil removes the blob
+// store for the scheme. Blob stores can't be registered for the http, https
+// and data schemes.
+func RegisterBlobStore(scheme string, store BlobStore) {
+	scheme = strings.ToLower(scheme)
+	if scheme == "http" || scheme == "https" || scheme == "data" {
+		panic(fmt.Sprintf("Blob store can't be registered for scheme %q", scheme))
+	}
+
+	blobStoresMu.Lock()
+	defer blobStoresMu.Unlock()
+	if store == nil {
+		delete(blobStores, scheme)
+		return
+	}
+	blobStores[scheme] = store
+}
+
+// Get the blob store registered for the scheme of a source or destination, if
+// any
+func blobStoreFor(location string) BlobStore {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme == "" {
+		return nil
+	}
+
+	blobStoresMu.RLock()
+	defer blobStoresMu.RUnlock()
+	return blobStores[strings.ToLower(u.Scheme)]
+}
+
+// Upload a written EPUB file to a blob store
+func uploadBlob(store BlobStore, srcFilePath string, destURL string) error {
+	r, err := os.Open(srcFilePath)
+	if err != nil {
+		panic(fmt.Sprintf("Error opening EPUB file: %s", err))
+	}
+	defer r.Close()
+
+	w, err := store.Create(destURL)
+	if err != nil {
+		return &UnableToCreateEpubError{Path: destURL, Err: err}
+	}
+	_, err = io.Copy(w, r)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return &UnableToCreateEpubError{Path: destURL, Err: err}
+	}
+
+	return nil
+}
+
+// Create a temporary file to write an EPUB to before uploading it to a blob
+// store
+func (e *Epub) newBlobTempFile() (string, error) {
+	f, err := e.newTempFile()
+	if err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,89 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+)
+
+// A blob store keeping the blobs in memory
+type memoryBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+type memoryBlobWriter struct {
+	bytes.Buffer
+	store *memoryBlobStore
+	url   string
+}
+
+func (s *memoryBlobStore) Open(url string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[url]
+	if !ok {
+		return nil, errors.New("blob not found")
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBlobStore) Create(url string) (io.WriteCloser, error) {
+	return &memoryBlobWriter{store: s, url: url}, nil
+}
+
+func (w *memoryBlobWriter) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.store.blobs[w.url] = w.Bytes()
+	return nil
+}
+
+func TestRegisterBlobStore(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	store := &memoryBlobStore{
+		blobs: map[string][]byte{
+			"mem://bucket/images/gopher.png": image,
+		},
+	}
+	RegisterBlobStore("mem", store)
+	defer RegisterBlobStore("mem", nil)
+
+	e := NewEpub(testEpubTitle)
+	imagePath, err := e.AddImage("mem://bucket/images/gopher.png", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image from blob store: %s", err)
+	}
+	if imagePath != "../images/gopher.png" {
+		t.Errorf("Image path doesn't match\nGot: %s\nExpected: ../images/gopher.png", imagePath)
+	}
+	_, err = e.AddImage("mem://bucket/images/missing.png", "")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+
+	if err := e.Write("mem://bucket/books/test.epub"); err != nil {
+		t.Fatalf("Unexpected error writing EPUB to blob store: %s", err)
+	}
+	epub := store.blobs["mem://bucket/books/test.epub"]
+	z, err := zip.NewReader(bytes.NewReader(epub), int64(len(epub)))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB from blob store: %s", err)
+	}
+	found := false
+	for _, f := range z.File {
+		if f.Name == "EPUB/images/gopher.png" {
+			found = f.UncompressedSize64 == uint64(len(image))
+		}
+	}
+	if !found {
+		t.Errorf("Image from blob store not found in the EPUB")
+	}
+}
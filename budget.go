@@ -0,0 +1,214 @@
+package epub
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResourceTransformRecompressed is the transform applied to JPEG images whose
+// quality was reduced to fit the size budget.
+const ResourceTransformRecompressed = "recompressed"
+
+const (
+	// The JPEG quality of the first degradation step, reduced by
+	// budgetQualityStep at each step until budgetMinQuality is reached
+	budgetMaxQuality  = 85
+	budgetMinQuality  = 55
+	budgetQualityStep = 10
+	// Once the minimum quality is reached, images are scaled down by this
+	// factor at each step until budgetMinScale is reached
+	budgetScaleStep = 0.8
+	budgetMinScale  = 0.2
+)
+
+// SizeBudgetExceededError is thrown by Write if the EPUB can't fit in the size
+// budget set using SetSizeBudget, even once its images have been degraded as
+// much as possible. The EPUB is still written.
+type SizeBudgetExceededError struct {
+	Budget int64 // The size budget, in bytes
+	Size   int64 // The size of the EPUB that was written, in bytes
+}
+
+func (e *SizeBudgetExceededError) Error() string {
+	return fmt.Sprintf("EPUB size of %d bytes exceeds budget of %d bytes", e.Size, e.Budget)
+}
+
+// SetSizeBudget sets the maximum size of the EPUB in bytes, e.g. the file size
+// limit of a store. If the EPUB is larger once written, its JPEG and PNG
+// images other than the cover are degraded step by step until it fits: the
+// quality of JPEG images is reduced first, then all the images are scaled
+// down. The cover is kept at full quality.
+//
+// The degraded images are reported by LastBuildReport, with the transforms
+// ResourceTransformRecompressed and ResourceTransformResized. A budget of 0
+// (the default) disables the size budget.
+func (e *Epub) SetSizeBudget(maxSize int64) {
+	e.sizeBudget = maxSize
+}
+
+// Degrade the images until the EPUB written to buf fits in the size budget,
+// writing it again after each step
+func (e *Epub) fitSizeBudget(tempDir string, buf *bytes.Buffer) error {
+	if e.sizeBudget <= 0 {
+		return nil
+	}
+
+	// The original images, as each step starts from them
+	originals := map[string][]byte{}
+	for step := 0; ; step++ {
+		size := int64(buf.Len())
+		if size <= e.sizeBudget {
+			return nil
+		}
+
+		// A step may not degrade any image, e.g. if the images already have
+		// a lower quality, but the next ones can
+		for ; ; step++ {
+			quality, scale := budgetStep(step)
+			if scale < budgetMinScale {
+				return &SizeBudgetExceededError{
+					Budget: e.sizeBudget,
+					Size:   size,
+				}
+			}
+			if e.degradeImages(tempDir, originals, quality, scale) {
+				break
+			}
+		}
+
+		buf.Reset()
+		if err := e.writeEpub(tempDir, buf); err != nil {
+			// Writing to a buffer doesn't fail
+			panic(fmt.Sprintf("Error writing EPUB: %s", err))
+		}
+	}
+}
+
+// Get the JPEG quality and the scale of the images for a degradation step
+func budgetStep(step int) (int, float64) {
+	quality := budgetMaxQuality - step*budgetQualityStep
+	if quality >= budgetMinQuality {
+		return quality, 1
+	}
+
+	scaleSteps := (budgetMinQuality - quality) / budgetQualityStep
+	return budgetMinQuality, math.Pow(budgetScaleStep, float64(scaleSteps))
+}
+
+// Degrade the images of the temporary directory other than the cover, using
+// the provided JPEG quality and scale. Whether any image was degraded is
+// returned.
+func (e *Epub) degradeImages(tempDir string, originals map[string][]byte, quality int, scale float64) bool {
+	filenames := []string{}
+	for filename := range e.images {
+		ext := strings.ToLower(filepath.Ext(filename))
+		if filename != e.cover.imageFilename && (ext == ".jpg" || ext == ".jpeg" || ext == ".png") {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	degraded := false
+	for _, filename := range filenames {
+		imageFilePath := filepath.Join(tempDir, contentFolderName, ImageFolderName, filename)
+		original, ok := originals[filename]
+		if !ok {
+			var err error
+			original, err = ioutil.ReadFile(imageFilePath)
+			if err != nil {
+				panic(fmt.Sprintf("Error reading image file: %s", err))
+			}
+			originals[filename] = original
+		}
+
+		img, format, err := decodeImage(original)
+		// Images that can't be decoded are left as-is
+		if err != nil {
+			continue
+		}
+
+		transforms := []string{}
+		if scale < 1 {
+			bounds := img.Bounds()
+			width := int(math.Max(1, math.Round(float64(bounds.Dx())*scale)))
+			height := int(math.Max(1, math.Round(float64(bounds.Dy())*scale)))
+			img = scaleImage(img, width, height)
+			transforms = append(transforms, ResourceTransformResized)
+		}
+
+		buf := &bytes.Buffer{}
+		if format == "jpeg" {
+			err = jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+			transforms = append([]string{ResourceTransformRecompressed}, transforms...)
+		} else if len(transforms) > 0 {
+			err = png.Encode(buf, img)
+		} else {
+			continue
+		}
+		if err != nil {
+			panic(fmt.Sprintf("Error encoding image: %s", err))
+		}
+
+		// Keep the original if it's smaller
+		if buf.Len() >= len(original) {
+			continue
+		}
+		if err := ioutil.WriteFile(imageFilePath, buf.Bytes(), filePermissions); err != nil {
+			panic(fmt.Sprintf("Error writing image file: %s", err))
+		}
+		e.resourceTransforms[path.Join(contentFolderName, ImageFolderName, filename)] = &resourceTransform{
+			originalSize: int64(len(original)),
+			transforms:   transforms,
+		}
+		degraded = true
+	}
+
+	return degraded
+}
+
+// Scale an image down to the provided dimensions, averaging the source pixels
+// covered by each destination pixel
+func scaleImage(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Create a JPEG image that doesn't compress well
+func testNoiseJPEG(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), 255})
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("Unexpected error encoding image: %s", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestSetSizeBudget(t *testing.T) {
+	noise := newDataURL(mediaTypeJpeg, testNoiseJPEG(t, 300, 300))
+
+	e := NewEpub(testEpubTitle)
+	coverPath, _ := e.AddImage(noise, "cover.jpg")
+	cssPath, _ := e.AddCSS(newDataURL(mediaTypeCSS, []byte("img { width: 100%; }")), "cover.css")
+	e.SetCover(coverPath, cssPath)
+	imagePath, _ := e.AddImage(noise, "noise.jpg")
+	e.AddSection(`<img src="`+imagePath+`" alt="" />`, testSectionTitle, "", "")
+
+	if err := e.Write(testEpubFilename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	info, _ := os.Stat(testEpubFilename)
+	fullSize := info.Size()
+	os.Remove(testEpubFilename)
+
+	// The cover takes about half of the EPUB, so the other image must be
+	// scaled down
+	e.SetSizeBudget(fullSize * 55 / 100)
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	info, _ = os.Stat(testEpubFilename)
+	if info.Size() > fullSize*55/100 {
+		t.Errorf("EPUB size %d exceeds budget %d", info.Size(), fullSize*55/100)
+	}
+
+	cover, _ := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "cover.jpg"))
+	original, _, _ := decodeDataURL(noise)
+	if !bytes.Equal(cover, original) {
+		t.Error("Cover image was degraded")
+	}
+
+	found := false
+	for _, r := range e.LastBuildReport().Resources {
+		if r.Path != contentFolderName+"/"+ImageFolderName+"/noise.jpg" {
+			continue
+		}
+		found = true
+		if r.OriginalSize != int64(len(original)) || r.Size >= r.OriginalSize ||
+			!reflect.DeepEqual(r.Transforms, []string{ResourceTransformRecompressed, ResourceTransformResized}) {
+			t.Errorf("Degraded image report doesn't match\nGot: %+v", r)
+		}
+	}
+	if !found {
+		t.Errorf("Degraded image not found in build report\nGot: %s", e.LastBuildReport())
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	e.SetSizeBudget(1000)
+	err := e.Write(testEpubFilename)
+	if _, ok := err.(*SizeBudgetExceededError); !ok {
+		t.Errorf("Expected error SizeBudgetExceededError not returned. Returned instead: %+v", err)
+	}
+	os.Remove(testEpubFilename)
+}
+
+func TestScaleImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 4; x++ {
+		src.Set(x, 0, color.RGBA{0, 0, 0, 255})
+		src.Set(x, 1, color.RGBA{200, 100, 50, 255})
+	}
+
+	dst := scaleImage(src, 2, 1)
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 1 {
+		t.Errorf("Scaled image bounds don't match\nGot: %v", dst.Bounds())
+	}
+	testColor := color.RGBA{100, 50, 25, 255}
+	if got := dst.RGBAAt(1, 0); got != testColor {
+		t.Errorf(
+			"Scaled image color doesn't match\n"+
+				"Got: %v\n"+
+				"Expected: %v",
+			got,
+			testColor)
+	}
+}
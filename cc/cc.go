@@ -0,0 +1,123 @@
+/*
+Package cc defines the Creative Commons licenses and public domain dedication,
+with their names, URLs and notices, for use with Epub.SetCreativeCommons.
+
+Licenses: https://creativecommons.org/licenses/
+
+Basic usage:
+
+	fmt.Println(cc.BY_NC_SA.URL("4.0")) // https://creativecommons.org/licenses/by-nc-sa/4.0/
+	fmt.Println(cc.BY_NC_SA.Name("4.0")) // Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International
+*/
+package cc
+
+import (
+	"fmt"
+)
+
+// License is a Creative Commons license, identified by its code (e.g. by-sa).
+type License string
+
+// Creative Commons licenses
+const (
+	// Attribution
+	BY License = "by"
+	// Attribution-ShareAlike
+	BY_SA License = "by-sa"
+	// Attribution-NoDerivatives
+	BY_ND License = "by-nd"
+	// Attribution-NonCommercial
+	BY_NC License = "by-nc"
+	// Attribution-NonCommercial-ShareAlike
+	BY_NC_SA License = "by-nc-sa"
+	// Attribution-NonCommercial-NoDerivatives
+	BY_NC_ND License = "by-nc-nd"
+	// CC0, the public domain dedication, which only exists in version 1.0
+	CC0 License = "zero"
+)
+
+// LatestVersion is the latest version of the licenses other than CC0.
+const LatestVersion = "4.0"
+
+const (
+	cc0Name           = "CC0"
+	cc0NoticeTemplate = "This work is dedicated to the public domain under %s. To view a copy of this dedication, visit %s"
+	cc0URLTemplate    = "https://creativecommons.org/publicdomain/zero/%s/"
+	cc0Version        = "1.0"
+	namePrefix        = "Creative Commons "
+	noticeTemplate    = "This work is licensed under the %s License. To view a copy of this license, visit %s"
+	urlTemplate       = "https://creativecommons.org/licenses/%s/%s/"
+)
+
+var (
+	names = map[License]string{
+		BY:       "Attribution",
+		BY_SA:    "Attribution-ShareAlike",
+		BY_ND:    "Attribution-NoDerivatives",
+		BY_NC:    "Attribution-NonCommercial",
+		BY_NC_SA: "Attribution-NonCommercial-ShareAlike",
+		BY_NC_ND: "Attribution-NonCommercial-NoDerivatives",
+	}
+	// The jurisdiction of each version
+	versions = map[string]string{
+		"2.0": "Generic",
+		"2.5": "Generic",
+		"3.0": "Unported",
+		"4.0": "International",
+	}
+)
+
+// Valid returns whether the license exists in the provided version, e.g. 4.0.
+func (l License) Valid(version string) bool {
+	if l == CC0 {
+		return version == cc0Version
+	}
+	_, ok := names[l]
+	_, versionOK := versions[version]
+
+	return ok && versionOK
+}
+
+// Name returns the full name of the license in the provided version, e.g.
+// Creative Commons Attribution-ShareAlike 4.0 International. An empty string is
+// returned if the license doesn't exist in that version.
+func (l License) Name(version string) string {
+	if !l.Valid(version) {
+		return ""
+	}
+	if l == CC0 {
+		return cc0Name + " " + version + " Universal"
+	}
+
+	return namePrefix + names[l] + " " + version + " " + versions[version]
+}
+
+// URL returns the URL of the deed of the license in the provided version. An
+// empty string is returned if the license doesn't exist in that version.
+func (l License) URL(version string) string {
+	if !l.Valid(version) {
+		return ""
+	}
+	if l == CC0 {
+		return fmt.Sprintf(cc0URLTemplate, version)
+	}
+
+	return fmt.Sprintf(urlTemplate, l, version)
+}
+
+// Notice returns the human-readable notice of the license in the provided
+// version, as recommended by Creative Commons, e.g. This work is licensed under
+// the Creative Commons Attribution 4.0 International License. To view a copy
+// of this license, visit https://creativecommons.org/licenses/by/4.0/
+//
+// An empty string is returned if the license doesn't exist in that version.
+func (l License) Notice(version string) string {
+	if !l.Valid(version) {
+		return ""
+	}
+	if l == CC0 {
+		return fmt.Sprintf(cc0NoticeTemplate, l.Name(version), l.URL(version))
+	}
+
+	return fmt.Sprintf(noticeTemplate, l.Name(version), l.URL(version))
+}
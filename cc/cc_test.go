@@ -0,0 +1,56 @@
+package cc
+
+import (
+	"testing"
+)
+
+func TestLicense(t *testing.T) {
+	tests := []struct {
+		license    License
+		version    string
+		testName   string
+		testURL    string
+		testNotice string
+	}{
+		{
+			license:    BY_NC_SA,
+			version:    "4.0",
+			testName:   "Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International",
+			testURL:    "https://creativecommons.org/licenses/by-nc-sa/4.0/",
+			testNotice: "This work is licensed under the Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International License. To view a copy of this license, visit https://creativecommons.org/licenses/by-nc-sa/4.0/",
+		},
+		{
+			license:    BY,
+			version:    "3.0",
+			testName:   "Creative Commons Attribution 3.0 Unported",
+			testURL:    "https://creativecommons.org/licenses/by/3.0/",
+			testNotice: "This work is licensed under the Creative Commons Attribution 3.0 Unported License. To view a copy of this license, visit https://creativecommons.org/licenses/by/3.0/",
+		},
+		{
+			license:    CC0,
+			version:    "1.0",
+			testName:   "CC0 1.0 Universal",
+			testURL:    "https://creativecommons.org/publicdomain/zero/1.0/",
+			testNotice: "This work is dedicated to the public domain under CC0 1.0 Universal. To view a copy of this dedication, visit https://creativecommons.org/publicdomain/zero/1.0/",
+		},
+		// Invalid versions
+		{license: CC0, version: "4.0"},
+		{license: BY_SA, version: "5.0"},
+		{license: License("by-foo"), version: "4.0"},
+	}
+
+	for _, test := range tests {
+		if test.license.Valid(test.version) != (test.testName != "") {
+			t.Errorf("Validity of %s %s doesn't match\nGot: %t", test.license, test.version, test.license.Valid(test.version))
+		}
+		if name := test.license.Name(test.version); name != test.testName {
+			t.Errorf("Name doesn't match\nGot: %s\nExpected: %s", name, test.testName)
+		}
+		if url := test.license.URL(test.version); url != test.testURL {
+			t.Errorf("URL doesn't match\nGot: %s\nExpected: %s", url, test.testURL)
+		}
+		if notice := test.license.Notice(test.version); notice != test.testNotice {
+			t.Errorf("Notice doesn't match\nGot: %s\nExpected: %s", notice, test.testNotice)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+/*
+Package cfi generates and resolves EPUB Canonical Fragment Identifiers (CFIs),
+which identify a location in an EPUB (a spine item, an element within its
+content document, and optionally a character offset) in a standard way that
+can be used for deep links and annotation anchoring.
+
+Spec: http://idpf.org/epub/linking/cfi/epub-cfi.html
+
+Basic usage:
+
+	// Get a CFI pointing to the 10th character of the element with the ID
+	// "para05" in the third item of the spine
+	c, err := cfi.Generate(2, "chap03", contentDocument, "para05", 10)
+	if err != nil {
+		// handle error
+	}
+	fmt.Println(c) // epubcfi(/6/6[chap03]!/4/2[para05]/1:10)
+
+	// Resolve it back to a location in the content document
+	loc, err := cfi.Resolve(c, contentDocument)
+*/
+package cfi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Step of the package document path leading to the spine element. This
+// assumes the spine is the third child element of the package element, after
+// the metadata and manifest elements.
+const spineStep = 6
+
+// CFI is a parsed EPUB Canonical Fragment Identifier.
+type CFI struct {
+	// Zero-based index of the item in the spine
+	SpineIndex int
+	// Optional ID assertion of the spine itemref
+	ItemRef string
+	// Steps within the content document, starting from the root element
+	Steps []Step
+	// Character offset within the text targeted by the last step, or -1 if
+	// the CFI targets an element
+	Offset int
+}
+
+// Step is a single step of a CFI path. Even indexes refer to child elements
+// and odd indexes refer to the text between them.
+type Step struct {
+	Index int
+	// Optional ID assertion
+	ID string
+}
+
+// Location is the result of resolving a CFI against a content document.
+type Location struct {
+	// Names of the elements leading to the target, starting with the root
+	// element
+	Path []string
+	// ID of the targeted element, or of the element containing the targeted
+	// text
+	ElementID string
+	// Targeted text, if the CFI targets a character offset
+	Text string
+	// Character offset within Text, or -1 if the CFI targets an element
+	Offset int
+}
+
+// InvalidCFIError is returned by Parse or Resolve if a CFI is malformed or
+// doesn't match the content document.
+type InvalidCFIError struct {
+	CFI    string // The CFI that caused the error
+	Reason string // Why the CFI is invalid
+}
+
+func (e *InvalidCFIError) Error() string {
+	return fmt.Sprintf("Invalid CFI %q: %s", e.CFI, e.Reason)
+}
+
+// ElementNotFoundError is returned by Generate if no element in the content
+// document has the requested ID.
+type ElementNotFoundError struct {
+	ID string // The ID that wasn't found
+}
+
+func (e *ElementNotFoundError) Error() string {
+	return fmt.Sprintf("Element not found: %s", e.ID)
+}
+
+// String returns the CFI in its canonical string form, e.g.
+// epubcfi(/6/4[chap01ref]!/4[body01]/10[para05]/3:10)
+func (c *CFI) String() string {
+	var b strings.Builder
+	b.WriteString("epubcfi(/")
+	b.WriteString(strconv.Itoa(spineStep))
+	b.WriteString(Step{Index: (c.SpineIndex + 1) * 2, ID: c.ItemRef}.String())
+	b.WriteString("!")
+	for _, s := range c.Steps {
+		b.WriteString(s.String())
+	}
+	if c.Offset >= 0 {
+		b.WriteString(":")
+		b.WriteString(strconv.Itoa(c.Offset))
+	}
+	b.WriteString(")")
+
+	return b.String()
+}
+
+// String returns the step in CFI syntax, e.g. /4[body01]
+func (s Step) String() string {
+	str := "/" + strconv.Itoa(s.Index)
+	if s.ID != "" {
+		str += "[" + escapeAssertion(s.ID) + "]"
+	}
+	return str
+}
+
+// Parse parses a CFI string such as epubcfi(/6/4[chap01ref]!/4/10/3:10).
+// Only the subset of the syntax used to identify a single location in a
+// content document is supported; ranges and spatial/temporal offsets aren't.
+func Parse(s string) (*CFI, error) {
+	invalid := func(reason string) (*CFI, error) {
+		return nil, &InvalidCFIError{CFI: s, Reason: reason}
+	}
+
+	str := strings.TrimSpace(s)
+	if !strings.HasPrefix(str, "epubcfi(") || !strings.HasSuffix(str, ")") {
+		return invalid("missing epubcfi() wrapper")
+	}
+	str = str[len("epubcfi(") : len(str)-1]
+
+	parts := strings.SplitN(str, "!", 2)
+	if len(parts) != 2 {
+		return invalid("missing indirection step")
+	}
+
+	pkgSteps, _, err := parseSteps(parts[0])
+	if err != nil {
+		return invalid(err.Error())
+	}
+	if len(pkgSteps) != 2 || pkgSteps[0].Index != spineStep || pkgSteps[1].Index%2 != 0 || pkgSteps[1].Index == 0 {
+		return invalid("package document path must point to a spine item")
+	}
+
+	steps, offset, err := parseSteps(parts[1])
+	if err != nil {
+		return invalid(err.Error())
+	}
+
+	return &CFI{
+		SpineIndex: pkgSteps[1].Index/2 - 1,
+		ItemRef:    pkgSteps[1].ID,
+		Steps:      steps,
+		Offset:     offset,
+	}, nil
+}
+
+// Parse a path such as /4[body01]/10/3:10
+func parseSteps(path string) ([]Step, int, error) {
+	steps := []Step{}
+	offset := -1
+
+	for len(path) > 0 {
+		if path[0] == ':' {
+			o, err := strconv.Atoi(path[1:])
+			if err != nil || o < 0 {
+				return nil, 0, fmt.Errorf("invalid character offset %q", path[1:])
+			}
+			offset = o
+			break
+		}
+		if path[0] != '/' {
+			return nil, 0, fmt.Errorf("unexpected %q", path)
+		}
+		path = path[1:]
+
+		end := strings.IndexAny(path, "/[:")
+		if end == -1 {
+			end = len(path)
+		}
+		index, err := strconv.Atoi(path[:end])
+		if err != nil || index < 0 {
+			return nil, 0, fmt.Errorf("invalid step %q", path[:end])
+		}
+		path = path[end:]
+
+		step := Step{Index: index}
+		if len(path) > 0 && path[0] == '[' {
+			id, rest, err := parseAssertion(path)
+			if err != nil {
+				return nil, 0, err
+			}
+			step.ID = id
+			path = rest
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, offset, nil
+}
+
+// Parse an ID assertion such as [chap01], handling ^ escapes
+func parseAssertion(path string) (string, string, error) {
+	var b strings.Builder
+	for i := 1; i < len(path); i++ {
+		switch path[i] {
+		case '^':
+			if i+1 < len(path) {
+				i++
+				b.WriteByte(path[i])
+			}
+		case ']':
+			return b.String(), path[i+1:], nil
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated assertion %q", path)
+}
+
+func escapeAssertion(s string) string {
+	return strings.NewReplacer(
+		"^", "^^",
+		"[", "^[",
+		"]", "^]",
+		"(", "^(",
+		")", "^)",
+		",", "^,",
+		";", "^;",
+		"=", "^=",
+	).Replace(s)
+}
+
+// Generate returns a CFI pointing to the element with the given ID in the
+// content document of the spine item at the given (zero-based) index. The
+// itemref ID is optional and is only used as an assertion.
+//
+// If the offset is zero or greater, the CFI points to that character offset
+// within the text content of the element instead of the element itself.
+func Generate(spineIndex int, itemref string, doc io.Reader, elementID string, offset int) (*CFI, error) {
+	root, err := parseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	target, path := root.find(elementID)
+	if target == nil {
+		return nil, &ElementNotFoundError{ID: elementID}
+	}
+
+	c := &CFI{
+		SpineIndex: spineIndex,
+		ItemRef:    itemref,
+		Steps:      path,
+		Offset:     -1,
+	}
+
+	if offset >= 0 {
+		textPath, textOffset, ok := target.locateOffset(offset)
+		if !ok {
+			return nil, fmt.Errorf("Offset %d is out of range for element %s", offset, elementID)
+		}
+		c.Steps = append(c.Steps, textPath...)
+		c.Offset = textOffset
+	}
+
+	return c, nil
+}
+
+// Resolve resolves a CFI against the content document of the spine item it
+// refers to.
+func Resolve(c *CFI, doc io.Reader) (*Location, error) {
+	invalid := func(reason string) (*Location, error) {
+		return nil, &InvalidCFIError{CFI: c.String(), Reason: reason}
+	}
+
+	root, err := parseDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := &Location{
+		Path:      []string{root.name},
+		ElementID: root.id,
+		Offset:    -1,
+	}
+
+	n := root
+	for i, step := range c.Steps {
+		if step.Index%2 == 1 {
+			if i != len(c.Steps)-1 {
+				return invalid("text step must be the last step")
+			}
+			chunk := step.Index / 2
+			if chunk >= len(n.text) {
+				return invalid(fmt.Sprintf("step %d is out of range", step.Index))
+			}
+			loc.Text = n.text[chunk]
+			loc.Offset = 0
+			break
+		}
+
+		index := step.Index/2 - 1
+		if index < 0 || index >= len(n.children) {
+			return invalid(fmt.Sprintf("step %d is out of range", step.Index))
+		}
+		n = n.children[index]
+		if step.ID != "" && step.ID != n.id {
+			return invalid(fmt.Sprintf("ID assertion %q doesn't match %q", step.ID, n.id))
+		}
+		loc.Path = append(loc.Path, n.name)
+		loc.ElementID = n.id
+	}
+
+	if c.Offset >= 0 {
+		if loc.Offset == -1 {
+			// An offset on an element refers to its first text chunk
+			if len(n.text[0]) == 0 && c.Offset > 0 {
+				return invalid("character offset without text")
+			}
+			loc.Text = n.text[0]
+		}
+		if c.Offset > len([]rune(loc.Text)) {
+			return invalid(fmt.Sprintf("character offset %d is out of range", c.Offset))
+		}
+		loc.Offset = c.Offset
+	}
+
+	return loc, nil
+}
+
+// node is a minimal representation of an element of a content document
+type node struct {
+	name     string
+	id       string
+	children []*node
+	// text[i] is the text before children[i]; the last item is the text after
+	// the last child
+	text []string
+}
+
+// Parse a content document into a tree of nodes, returning the root element
+func parseDocument(doc io.Reader) (*node, error) {
+	d := xml.NewDecoder(doc)
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var root *node
+	stack := []*node{}
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, text: []string{""}}
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "id" {
+					n.id = attr.Value
+				}
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, fmt.Errorf("Content document has more than one root element")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+				parent.text = append(parent.text, "")
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				n := stack[len(stack)-1]
+				n.text[len(n.text)-1] += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("Content document has no root element")
+	}
+
+	return root, nil
+}
+
+// Find the element with the given ID, returning it along with the steps
+// leading to it from n
+func (n *node) find(id string) (*node, []Step) {
+	if n.id == id {
+		return n, []Step{}
+	}
+	for i, child := range n.children {
+		if found, path := child.find(id); found != nil {
+			return found, append([]Step{{Index: (i + 1) * 2, ID: child.id}}, path...)
+		}
+	}
+
+	return nil, nil
+}
+
+// Locate a character offset within the text content of n, returning the steps
+// leading to the text chunk containing it and the offset within the chunk
+func (n *node) locateOffset(offset int) ([]Step, int, bool) {
+	for i, text := range n.text {
+		length := len([]rune(text))
+		if offset <= length && (offset < length || i == len(n.text)-1) {
+			return []Step{{Index: i*2 + 1}}, offset, true
+		}
+		offset -= length
+
+		if i < len(n.children) {
+			child := n.children[i]
+			childLength := child.textLength()
+			if offset < childLength {
+				path, o, ok := child.locateOffset(offset)
+				return append([]Step{{Index: (i + 1) * 2, ID: child.id}}, path...), o, ok
+			}
+			offset -= childLength
+		}
+	}
+
+	return nil, 0, false
+}
+
+// Length in characters of the text content of n
+func (n *node) textLength() int {
+	length := 0
+	for i, text := range n.text {
+		length += len([]rune(text))
+		if i < len(n.children) {
+			length += n.children[i].textLength()
+		}
+	}
+
+	return length
+}
@@ -0,0 +1,117 @@
+package cfi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testCFI             = "epubcfi(/6/4[chap01ref]!/4[body01]/2[para01]/2/1:3)"
+	testContentDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <title>Section 1</title>
+  </head>
+  <body id="body01">
+    <p id="para01">This is <em>a paragraph</em> with&nbsp;text.</p>
+  </body>
+</html>`
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse(testCFI)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing CFI: %s", err)
+	}
+
+	testParsed := &CFI{
+		SpineIndex: 1,
+		ItemRef:    "chap01ref",
+		Steps: []Step{
+			{Index: 4, ID: "body01"},
+			{Index: 2, ID: "para01"},
+			{Index: 2},
+			{Index: 1},
+		},
+		Offset: 3,
+	}
+	if !reflect.DeepEqual(c, testParsed) {
+		t.Errorf(
+			"Parsed CFI doesn't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			c,
+			testParsed)
+	}
+
+	if c.String() != testCFI {
+		t.Errorf(
+			"CFI string doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			c,
+			testCFI)
+	}
+
+	for _, s := range []string{"/6/4!/4", "epubcfi(/6/4)", "epubcfi(/2/4!/4)", "epubcfi(/6/4!/4[body)"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Expected error parsing invalid CFI %q", s)
+		}
+	}
+}
+
+func TestGenerateAndResolve(t *testing.T) {
+	// "This is a p" - the offset lands inside the <em> element
+	c, err := Generate(1, "chap01ref", strings.NewReader(testContentDocument), "para01", 11)
+	if err != nil {
+		t.Fatalf("Unexpected error generating CFI: %s", err)
+	}
+	if c.String() != testCFI {
+		t.Errorf(
+			"Generated CFI doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			c,
+			testCFI)
+	}
+
+	loc, err := Resolve(c, strings.NewReader(testContentDocument))
+	if err != nil {
+		t.Fatalf("Unexpected error resolving CFI: %s", err)
+	}
+	testLocation := &Location{
+		Path:      []string{"html", "body", "p", "em"},
+		ElementID: "",
+		Text:      "a paragraph",
+		Offset:    3,
+	}
+	if !reflect.DeepEqual(loc, testLocation) {
+		t.Errorf(
+			"Resolved location doesn't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			loc,
+			testLocation)
+	}
+
+	// Element-only CFI
+	c, err = Generate(0, "", strings.NewReader(testContentDocument), "para01", -1)
+	if err != nil {
+		t.Fatalf("Unexpected error generating CFI: %s", err)
+	}
+	if c.String() != "epubcfi(/6/2!/4[body01]/2[para01])" {
+		t.Errorf("Unexpected element CFI: %s", c)
+	}
+
+	if _, err := Generate(0, "", strings.NewReader(testContentDocument), "missing", -1); err == nil {
+		t.Errorf("Expected ElementNotFoundError")
+	}
+
+	// Mismatched ID assertion
+	c.Steps[1].ID = "para02"
+	if _, err := Resolve(c, strings.NewReader(testContentDocument)); err == nil {
+		t.Errorf("Expected error resolving CFI with a mismatched ID assertion")
+	}
+}
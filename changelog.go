@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	versionHistoryPageFilename = "history.xhtml"
+	versionHistoryBodyTemplate = `<h1>%s</h1>
+<dl class="version-history">
+%s</dl>`
+	versionHistoryChangeTemplate = "<li>%s</li>\n"
+	versionHistoryEntryTemplate  = `<dt>%s</dt>
+<dd><ul>
+%s</ul></dd>
+`
+)
+
+// ChangelogEntry is an entry of the changelog of the EPUB, describing the
+// changes of a version.
+type ChangelogEntry struct {
+	// The version, e.g. 1.0.1. If empty, the publication version set when the
+	// EPUB is written is used (see SetPublicationVersion).
+	Version string `json:"version,omitempty"`
+	// The date of the version. If zero, the date the EPUB is written is used.
+	Date time.Time `json:"date"`
+	// The changes, as plain text
+	Changes []string `json:"changes"`
+}
+
+// AddChangelogEntry adds an entry to the changelog of the EPUB, which is
+// useful for living documents such as standards and manuals. The entry for the
+// version being built can leave its version and date empty to use the
+// publication version and the date of each build. See AddVersionHistoryPage to
+// show the changelog in the EPUB.
+func (e *Epub) AddChangelogEntry(entry ChangelogEntry) {
+	entry.Changes = append([]string{}, entry.Changes...)
+	e.changelog = append(e.changelog, entry)
+}
+
+// Changelog returns the entries of the changelog, in the order they were
+// added.
+func (e *Epub) Changelog() []ChangelogEntry {
+	return append([]ChangelogEntry{}, e.changelog...)
+}
+
+// AddVersionHistoryPage adds a page showing the changelog of the EPUB, as set
+// when the EPUB is written, with the latest entries first. The date of the
+// last modification of the EPUB is also written to its metadata
+// (dcterms:modified); EPUB only allows a single modification date, so the
+// dates of the previous versions are only in the page. The dates are
+// formatted according to the language of the EPUB (see SetDateFormatter).
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The changelog is a <dl> element with the
+// class "version-history", which can be used to style it.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddVersionHistoryPage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelVersionHistory, ""), versionHistoryPageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		title: func() string {
+			return e.label(LabelVersionHistory, "")
+		},
+		body: func() string {
+			entries := ""
+			for i := len(e.changelog) - 1; i >= 0; i-- {
+				entry := e.changelog[i]
+				if entry.Version == "" {
+					entry.Version = e.PublicationVersion()
+				}
+				if entry.Date.IsZero() {
+					entry.Date = e.modifiedTime()
+				}
+
+				heading := e.formatDate(entry.Date)
+				if entry.Version != "" {
+					heading = fmt.Sprintf("%s (%s)", entry.Version, heading)
+				}
+				changes := ""
+				for _, change := range entry.Changes {
+					changes += fmt.Sprintf(versionHistoryChangeTemplate, escapeText(change))
+				}
+				entries += fmt.Sprintf(versionHistoryEntryTemplate, escapeText(heading), changes)
+			}
+
+			return fmt.Sprintf(versionHistoryBodyTemplate, escapeText(x.Title()), entries)
+		},
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddVersionHistoryPage(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetPublicationVersion("1.1.0")
+	e.AddChangelogEntry(ChangelogEntry{
+		Version: "1.0.0",
+		Date:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Changes: []string{"First release"},
+	})
+	e.AddChangelogEntry(ChangelogEntry{
+		Changes: []string{"Fixed <typos>", "Added an index"},
+	})
+	filename, err := e.AddVersionHistoryPage("")
+	if err != nil {
+		t.Errorf("Unexpected error adding version history page: %s", err)
+	}
+
+	if len(e.Changelog()) != 2 || e.Changelog()[1].Version != "" {
+		t.Errorf("Changelog doesn't match\nGot: %+v", e.Changelog())
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading version history page: %s", err)
+	}
+	testBody := `<h1>Version history</h1>
+<dl class="version-history">
+<dt>1.1.0 (` + FormatDate(time.Now(), "en") + `)</dt>
+<dd><ul>
+<li>Fixed &lt;typos&gt;</li>
+<li>Added an index</li>
+</ul></dd>
+<dt>1.0.0 (January 15, 2024)</dt>
+<dd><ul>
+<li>First release</li>
+</ul></dd>
+</dl>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"Version history page doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+}
@@ -0,0 +1,342 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	// Register the GIF decoder
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const comicJpegQuality = 90
+
+// UnsupportedComicFormatError is thrown by NewEpubFromComic if the format of
+// the comic archive isn't supported, e.g. CBR (RAR) archives.
+type UnsupportedComicFormatError struct {
+	Source string // The source of the comic that was provided
+}
+
+func (e *UnsupportedComicFormatError) Error() string {
+	return fmt.Sprintf("Unsupported comic format: %s", e.Source)
+}
+
+// NoComicPagesError is thrown by NewEpubFromComic if the comic doesn't contain
+// any supported images (GIF, JPEG, or PNG).
+type NoComicPagesError struct {
+	Source string // The source of the comic that was provided
+}
+
+func (e *NoComicPagesError) Error() string {
+	return fmt.Sprintf("No pages found in comic: %s", e.Source)
+}
+
+// DoublePageMode defines how NewEpubFromComic handles double pages, i.e.
+// landscape images such as double-page scans.
+type DoublePageMode int
+
+// Double page modes
+const (
+	// Keep double pages as single landscape pages (the default)
+	DoublePagesKeep DoublePageMode = iota
+	// Split double pages into two images, each displayed on its own page
+	DoublePagesSplit
+	// Display double pages across the two pages of a spread, each page
+	// showing one half of the image, which keeps the original image intact
+	DoublePagesSpread
+)
+
+// ComicOptions configures the conversion of a comic by NewEpubFromComic.
+type ComicOptions struct {
+	// Title of the EPUB. If empty, the name of the comic archive or folder
+	// (without extension) is used.
+	Title string
+	// Read the pages from right to left, as is the case for manga.
+	RightToLeft bool
+	// How double pages are handled. When they're split or displayed across a
+	// spread, the halves are ordered according to the reading direction and
+	// placed on the left and right pages of the spread.
+	DoublePages DoublePageMode
+	// Split landscape pages (double-page scans) into two pages.
+	//
+	// Deprecated: use DoublePages: DoublePagesSplit instead.
+	SplitDoublePages bool
+}
+
+// A comic page, before being added to the EPUB
+type comicPage struct {
+	name string
+	data []byte
+}
+
+// NewEpubFromComic creates a fixed-layout EPUB from a comic. The source should
+// either be a path to a CBZ archive or a path to a folder of images. Pages are
+// sorted by name, taking numbers into account (page2 comes before page10), and
+// the first page is used as the cover image. Double pages are handled
+// according to ComicOptions.DoublePages.
+//
+// CBR archives aren't supported; UnsupportedComicFormatError will be returned.
+// If the comic doesn't contain any images, NoComicPagesError will be returned.
+func NewEpubFromComic(source string, opts ComicOptions) (*Epub, error) {
+	pages, err := readComicPages(source)
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, &NoComicPagesError{Source: source}
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+
+	e, cssPath, err := newImagePagesEpub(title)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RightToLeft {
+		e.SetPpd("rtl")
+	}
+
+	doublePages := opts.DoublePages
+	if doublePages == DoublePagesKeep && opts.SplitDoublePages {
+		doublePages = DoublePagesSplit
+	}
+	// The first half read is on the left for left-to-right comics, and on the
+	// right for right-to-left comics
+	spreads := []string{pageSpreadLeft, pageSpreadRight}
+	if opts.RightToLeft {
+		spreads[0], spreads[1] = spreads[1], spreads[0]
+	}
+
+	pageNumber := 0
+	for _, page := range pages {
+		config, format, err := image.DecodeConfig(bytes.NewReader(page.data))
+		if err != nil {
+			// Skip files that have an image extension but can't be decoded
+			continue
+		}
+		ext := strings.ToLower(path.Ext(page.name))
+
+		if doublePages == DoublePagesKeep || config.Width <= config.Height {
+			pageNumber++
+			if err := e.addImagePage(pageNumber, pageTitle(pageNumber, title), ext, page.data, config.Width, config.Height, cssPath, ""); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if doublePages == DoublePagesSpread {
+			imagePath, err := e.addPageImage(pageNumber+1, ext, page.data)
+			if err != nil {
+				return nil, err
+			}
+			for _, spread := range spreads {
+				class := imagePageSpreadLeftClass
+				if spread == pageSpreadRight {
+					class = imagePageSpreadRightClass
+				}
+				pageNumber++
+				if err := e.addImagePageSection(pageNumber, pageTitle(pageNumber, title), imagePath, class, config.Width/2, config.Height, cssPath, spread); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		halves, ext, err := splitComicPage(page.data, format)
+		if err != nil {
+			return nil, &FileRetrievalError{Source: page.name, Err: err}
+		}
+		if opts.RightToLeft {
+			halves[0], halves[1] = halves[1], halves[0]
+		}
+		for i, half := range halves {
+			pageNumber++
+			bounds := half.bounds
+			if err := e.addImagePage(pageNumber, pageTitle(pageNumber, title), ext, half.data, bounds.Dx(), bounds.Dy(), cssPath, spreads[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if pageNumber == 0 {
+		return nil, &NoComicPagesError{Source: source}
+	}
+
+	return e, nil
+}
+
+// Read the pages of a comic, which can either be a CBZ archive or a folder of
+// images, sorted by name
+func readComicPages(source string) ([]comicPage, error) {
+	pages := []comicPage{}
+
+	files, err := ioutil.ReadDir(source)
+	if err == nil {
+		for _, f := range files {
+			if f.IsDir() || !isComicPage(f.Name()) {
+				continue
+			}
+			data, err := ioutil.ReadFile(filepath.Join(source, f.Name()))
+			if err != nil {
+				return nil, &FileRetrievalError{Source: source, Err: err}
+			}
+			pages = append(pages, comicPage{name: f.Name(), data: data})
+		}
+
+	} else {
+		if strings.ToLower(filepath.Ext(source)) == ".cbr" {
+			return nil, &UnsupportedComicFormatError{Source: source}
+		}
+
+		r, err := zip.OpenReader(source)
+		if err != nil {
+			return nil, &FileRetrievalError{Source: source, Err: err}
+		}
+		defer r.Close()
+
+		var total int64
+		for _, f := range r.File {
+			if f.FileInfo().IsDir() || !isComicPage(f.Name) {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, &FileRetrievalError{Source: source, Err: err}
+			}
+			data, err := readArchiveFile(rc, &total)
+			rc.Close()
+			if err != nil {
+				return nil, &FileRetrievalError{Source: source, Err: err}
+			}
+			pages = append(pages, comicPage{name: f.Name, data: data})
+		}
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return naturalLess(pages[i].name, pages[j].name)
+	})
+
+	return pages, nil
+}
+
+// Check if a file of a comic is a page image, ignoring hidden files and the
+// metadata some archivers add
+func isComicPage(name string) bool {
+	name = filepath.ToSlash(name)
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return false
+		}
+	}
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".gif", ".jpeg", ".jpg", ".png":
+		return true
+	}
+
+	return false
+}
+
+// A half of a double page
+type comicPageHalf struct {
+	bounds image.Rectangle
+	data   []byte
+}
+
+// Split a double page into its left and right halves. JPEG pages stay JPEG;
+// other formats are encoded as PNG.
+func splitComicPage(data []byte, format string) ([]comicPageHalf, string, error) {
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b := img.Bounds()
+	middle := b.Min.X + b.Dx()/2
+	rects := []image.Rectangle{
+		image.Rect(b.Min.X, b.Min.Y, middle, b.Max.Y),
+		image.Rect(middle, b.Min.Y, b.Max.X, b.Max.Y),
+	}
+
+	ext := ".png"
+	if format == "jpeg" {
+		ext = ".jpg"
+	}
+
+	halves := []comicPageHalf{}
+	for _, r := range rects {
+		half := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.Draw(half, half.Bounds(), img, r.Min, draw.Src)
+
+		buf := &bytes.Buffer{}
+		if format == "jpeg" {
+			err = jpeg.Encode(buf, half, &jpeg.Options{Quality: comicJpegQuality})
+		} else {
+			err = png.Encode(buf, half)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		halves = append(halves, comicPageHalf{bounds: half.Bounds(), data: buf.Bytes()})
+	}
+
+	return halves, ext, nil
+}
+
+// Only the first page of a comic has a title, so that the TOC has an entry
+func pageTitle(pageNumber int, title string) string {
+	if pageNumber == 1 {
+		return title
+	}
+	return ""
+}
+
+// Compare two names, treating runs of digits as numbers so that page2 sorts
+// before page10
+func naturalLess(a string, b string) bool {
+	for a != "" && b != "" {
+		aDigits := leadingDigits(a)
+		bDigits := leadingDigits(b)
+
+		if aDigits != "" && bDigits != "" {
+			aNumber := strings.TrimLeft(aDigits, "0")
+			bNumber := strings.TrimLeft(bDigits, "0")
+			if len(aNumber) != len(bNumber) {
+				return len(aNumber) < len(bNumber)
+			}
+			if aNumber != bNumber {
+				return aNumber < bNumber
+			}
+			a = a[len(aDigits):]
+			b = b[len(bDigits):]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a = a[1:]
+		b = b[1:]
+	}
+
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
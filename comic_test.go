@@ -0,0 +1,206 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	testComicFilename = "My comic.cbz"
+	testComicTitle    = "My comic"
+)
+
+func TestNewEpubFromComic(t *testing.T) {
+	f, err := os.Create(testComicFilename)
+	if err != nil {
+		t.Fatalf("Error creating comic: %s", err)
+	}
+	defer os.Remove(testComicFilename)
+
+	z := zip.NewWriter(f)
+	// Pages are out of order to test sorting; page 10 is a double page
+	for _, page := range []struct {
+		name          string
+		width, height int
+	}{
+		{"comic/page10.png", 40, 20},
+		{"comic/page2.png", 20, 30},
+		{"comic/.hidden.png", 20, 30},
+		{"comic/info.txt", 0, 0},
+	} {
+		w, err := z.Create(page.name)
+		if err != nil {
+			t.Fatalf("Error adding page: %s", err)
+		}
+		if page.width == 0 {
+			w.Write([]byte("Not an image"))
+			continue
+		}
+		buf := &bytes.Buffer{}
+		png.Encode(buf, image.NewRGBA(image.Rect(0, 0, page.width, page.height)))
+		w.Write(buf.Bytes())
+	}
+	if err := z.Close(); err != nil {
+		t.Fatalf("Error closing comic: %s", err)
+	}
+	f.Close()
+
+	e, err := NewEpubFromComic(testComicFilename, ComicOptions{
+		RightToLeft:      true,
+		SplitDoublePages: true,
+	})
+	if err != nil {
+		t.Fatalf("Error converting comic: %s", err)
+	}
+	if e.Title() != testComicTitle {
+		t.Errorf(
+			"Title doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e.Title(),
+			testComicTitle)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	output, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+		`<spine toc="ncx" page-progression-direction="rtl">`,
+		`<itemref idref="page0001.xhtml"></itemref>`,
+		`<itemref idref="page0002.xhtml" properties="page-spread-right"></itemref>`,
+		`<itemref idref="page0003.xhtml" properties="page-spread-left"></itemref>`,
+		`<item id="page0001.png" href="images/page0001.png" media-type="image/png" properties="cover-image"></item>`,
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf(
+				"Package file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				output,
+				expected)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, contentFolderName, ImageFolderName, "page0004.png")); err == nil {
+		t.Errorf("Unexpected page: page0004.png")
+	}
+
+	output, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "page0002.xhtml"))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testViewport := `<meta name="viewport" content="width=20, height=20"></meta>`
+	if !strings.Contains(string(output), testViewport) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			output,
+			testViewport)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	_, err = NewEpubFromComic("My comic.cbr", ComicOptions{})
+	if _, ok := err.(*UnsupportedComicFormatError); !ok {
+		t.Errorf("Expected error *UnsupportedComicFormatError, got %#v", err)
+	}
+}
+
+func TestNewEpubFromComicSpread(t *testing.T) {
+	comicDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Error creating comic folder: %s", err)
+	}
+	defer os.RemoveAll(comicDir)
+
+	for name, bounds := range map[string]image.Rectangle{
+		"page1.png": image.Rect(0, 0, 20, 30),
+		"page2.png": image.Rect(0, 0, 40, 30),
+	} {
+		buf := &bytes.Buffer{}
+		png.Encode(buf, image.NewRGBA(bounds))
+		if err := ioutil.WriteFile(filepath.Join(comicDir, name), buf.Bytes(), filePermissions); err != nil {
+			t.Fatalf("Error adding page: %s", err)
+		}
+	}
+
+	e, err := NewEpubFromComic(comicDir, ComicOptions{DoublePages: DoublePagesSpread})
+	if err != nil {
+		t.Fatalf("Error converting comic: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	output, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<itemref idref="page0002.xhtml" properties="page-spread-left"></itemref>`,
+		`<itemref idref="page0003.xhtml" properties="page-spread-right"></itemref>`,
+		`href="images/page0002.png"`,
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf(
+				"Package file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				output,
+				expected)
+		}
+	}
+	if strings.Contains(string(output), "page0003.png") {
+		t.Errorf("Unexpected image: page0003.png")
+	}
+
+	for filename, expected := range map[string]string{
+		"page0002.xhtml": `<img src="../images/page0002.png" alt="" class="spread-left" />`,
+		"page0003.xhtml": `<img src="../images/page0002.png" alt="" class="spread-right" />`,
+	} {
+		output, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+		if err != nil {
+			t.Errorf("Unexpected error reading section file: %s", err)
+		}
+		for _, expected := range []string{expected, `<meta name="viewport" content="width=20, height=30"></meta>`} {
+			if !strings.Contains(string(output), expected) {
+				t.Errorf(
+					"Section file doesn't match\n"+
+						"Got: %s\n"+
+						"Expected to contain: %s",
+					output,
+					expected)
+			}
+		}
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"page10.png", "page2.png", "page002b.png", "page1.png"}
+	expected := []string{"page1.png", "page2.png", "page002b.png", "page10.png"}
+	sorted := append([]string(nil), names...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return naturalLess(sorted[i], sorted[j])
+	})
+	if strings.Join(sorted, ",") != strings.Join(expected, ",") {
+		t.Errorf(
+			"Sorted names don't match\n"+
+				"Got: %v\n"+
+				"Expected: %v",
+			sorted,
+			expected)
+	}
+}
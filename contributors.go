@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MARC relator codes (https://id.loc.gov/vocabulary/relators.html) of common
+// contributor roles, as used by AddContributor
+const (
+	ContributorRoleAdapter      = "adp"
+	ContributorRoleCoverDesign  = "cov"
+	ContributorRoleEditor       = "edt"
+	ContributorRoleIllustrator  = "ill"
+	ContributorRoleIntroduction = "aui"
+	ContributorRoleNarrator     = "nrt"
+	ContributorRolePhotographer = "pht"
+	ContributorRoleTranslator   = "trl"
+)
+
+const (
+	pkgContributorIDFormat = "contributor%d"
+	pkgContributorRefines  = "#contributor"
+)
+
+// Contributor is a person or organization who contributed to the EPUB other
+// than its authors, e.g. an illustrator.
+type Contributor struct {
+	Name string
+	// The MARC relator code of the role, e.g. ContributorRoleIllustrator
+	Role string
+}
+
+// AddAuthor adds an author to the EPUB, for EPUBs with several authors. The
+// first author is the one set using SetAuthor; if none was set, the author
+// added becomes it. The authors are listed in the package metadata in the
+// order they were added, followed by the authors of sections set using
+// SetSectionAuthor.
+func (e *Epub) AddAuthor(author string) {
+	if e.author == "" {
+		e.SetAuthor(author)
+		return
+	}
+	e.authors = append(e.authors, author)
+}
+
+// Authors returns the authors of the EPUB: the author set using SetAuthor
+// followed by the ones added using AddAuthor.
+func (e *Epub) Authors() []string {
+	if e.author == "" {
+		return []string{}
+	}
+	return append([]string{e.author}, e.authors...)
+}
+
+// AddContributor adds a contributor to the EPUB (dc:contributor) with a role,
+// which is the MARC relator code of the role, e.g. ContributorRoleTranslator
+// (trl). The role is optional.
+func (e *Epub) AddContributor(name string, role string) {
+	e.contributors = append(e.contributors, Contributor{Name: name, Role: role})
+}
+
+// Contributors returns the contributors of the EPUB, in the order they were
+// added.
+func (e *Epub) Contributors() []Contributor {
+	return append([]Contributor{}, e.contributors...)
+}
+
+// Get the authors of the EPUB other than the first one, followed by the
+// authors of the sections, without duplicates
+func (e *Epub) otherAuthors() []string {
+	authors := []string{}
+	found := map[string]bool{e.author: true}
+	for _, author := range append(append([]string{}, e.authors...), e.sectionAuthors()...) {
+		if !found[author] {
+			found[author] = true
+			authors = append(authors, author)
+		}
+	}
+
+	return authors
+}
+
+// Set the contributors of the package and their roles, replacing the ones
+// previously set
+func (p *pkg) setContributors(contributors []Contributor) {
+	p.xml.Metadata.Contributor = nil
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if !strings.HasPrefix(m.Refines, pkgContributorRefines) {
+			meta = append(meta, m)
+		}
+	}
+
+	for i, contributor := range contributors {
+		id := fmt.Sprintf(pkgContributorIDFormat, i+1)
+		p.xml.Metadata.Contributor = append(p.xml.Metadata.Contributor, pkgContributor{
+			ID:   id,
+			role: contributor.Role,
+			Data: contributor.Name,
+		})
+		if contributor.Role != "" {
+			meta = append(meta, pkgMeta{
+				Data:     contributor.Role,
+				Property: pkgAuthorProperty,
+				Refines:  "#" + id,
+				Scheme:   pkgAuthorScheme,
+			})
+		}
+	}
+	p.xml.Metadata.Meta = meta
+}
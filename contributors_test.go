@@ -0,0 +1,85 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddAuthorAndContributor(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddAuthor(testEpubAuthor)
+	e.AddAuthor("Ann Smith")
+	e.AddContributor("Bo <Lee>", ContributorRoleIllustrator)
+	e.AddContributor("Cy Park", ContributorRoleTranslator)
+	e.AddContributor("Di Ross", "")
+	filename, _ := e.AddSection(testSectionBody, testSectionTitle, "", "")
+	// Authors of sections that are already authors of the EPUB aren't repeated
+	if err := e.SetSectionAuthor(filename, "Ann Smith"); err != nil {
+		t.Fatalf("Unexpected error setting section author: %s", err)
+	}
+
+	expectedAuthors := []string{testEpubAuthor, "Ann Smith"}
+	if authors := e.Authors(); !reflect.DeepEqual(authors, expectedAuthors) {
+		t.Errorf("Authors don't match\nGot: %v\nExpected: %v", authors, expectedAuthors)
+	}
+	if e.Author() != testEpubAuthor {
+		t.Errorf("Author doesn't match\nGot: %s\nExpected: %s", e.Author(), testEpubAuthor)
+	}
+	if contributors := e.Contributors(); len(contributors) != 3 || contributors[1] != (Contributor{Name: "Cy Park", Role: "trl"}) {
+		t.Errorf("Contributors don't match\nGot: %v", contributors)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<dc:creator id="creator">` + testEpubAuthor + `</dc:creator>`,
+		`<dc:creator id="creator2">Ann Smith</dc:creator>`,
+		`<meta refines="#creator2" property="role" scheme="marc:relators">aut</meta>`,
+		`<dc:contributor id="contributor1">Bo &lt;Lee&gt;</dc:contributor>`,
+		`<dc:contributor id="contributor3">Di Ross</dc:contributor>`,
+		`<meta refines="#contributor1" property="role" scheme="marc:relators">ill</meta>`,
+		`<meta refines="#contributor2" property="role" scheme="marc:relators">trl</meta>`,
+	} {
+		if !strings.Contains(string(pkg), expected) {
+			t.Errorf("Package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+		}
+	}
+	for _, unexpected := range []string{"creator3", "#contributor3", "opf:role"} {
+		if strings.Contains(string(pkg), unexpected) {
+			t.Errorf("Package file doesn't match\nGot: %s\nExpected not to contain: %s", pkg, unexpected)
+		}
+	}
+
+	// Writing the EPUB again doesn't duplicate the contributors
+	e.AddContributor("Ed Wu", ContributorRoleEditor)
+	if err := e.SetVersion(EPUBVersion2); err != nil {
+		t.Fatalf("Unexpected error setting version: %s", err)
+	}
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	pkg, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<dc:contributor id="contributor1" opf:role="ill">Bo &lt;Lee&gt;</dc:contributor>`,
+		`<dc:contributor id="contributor3">Di Ross</dc:contributor>`,
+		`<dc:contributor id="contributor4" opf:role="edt">Ed Wu</dc:contributor>`,
+	} {
+		if !strings.Contains(string(pkg), expected) {
+			t.Errorf("EPUB 2 package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+		}
+	}
+	if strings.Contains(string(pkg), "contributor5") {
+		t.Errorf("Duplicate contributor in package file\nGot: %s", pkg)
+	}
+}
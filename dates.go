@@ -0,0 +1,155 @@
+package epub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dctermsAvailableProperty = "dcterms:available"
+	// The format of dates in languages without a long date format, as in ISO
+	// 8601
+	isoDateFormat = "2006-01-02"
+	// Publication dates before this year are considered implausible
+	minPlausibleYear = 1000
+	// Publication dates more than this many years after the modification date
+	// are considered implausible
+	maxPlausibleYearsAhead = 10
+	// The format of date-times of the package metadata, as in W3CDTF
+	w3cDateTimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// A long date format, where {d}, {m} and {y} are replaced by the day, the name
+// of the month and the year
+type longDateFormat struct {
+	format string
+	months [12]string
+}
+
+// Long date formats per primary language subtag
+var longDateFormats = map[string]longDateFormat{
+	"de": {"{d}. {m} {y}", [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}},
+	"en": {"{m} {d}, {y}", [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}},
+	"es": {"{d} de {m} de {y}", [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"}},
+	"fr": {"{d} {m} {y}", [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}},
+	"it": {"{d} {m} {y}", [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}},
+	"nl": {"{d} {m} {y}", [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"}},
+	"pt": {"{d} de {m} de {y}", [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}},
+}
+
+// DateFormatter formats a date shown in generated pages, such as the version
+// history, for the provided language of the EPUB, e.g. fr-CA.
+type DateFormatter func(date time.Time, lang string) string
+
+// SetDateFormatter sets the function formatting the dates shown in generated
+// pages, which is FormatDate by default. Passing nil restores the default.
+func (e *Epub) SetDateFormatter(formatter DateFormatter) {
+	e.dateFormatter = formatter
+}
+
+// FormatDate formats a date in the long format of a language, e.g. January 2,
+// 2006 in English or 2 janvier 2006 in French. Dates in languages without a
+// known format are formatted as in ISO 8601, e.g. 2006-01-02.
+func FormatDate(date time.Time, lang string) string {
+	f, ok := longDateFormats[primaryLanguageSubtag(lang)]
+	if !ok {
+		return date.Format(isoDateFormat)
+	}
+
+	return strings.NewReplacer(
+		"{d}", strconv.Itoa(date.Day()),
+		"{m}", f.months[date.Month()-1],
+		"{y}", strconv.Itoa(date.Year()),
+	).Replace(f.format)
+}
+
+// Format a date shown in generated pages for the language of the EPUB
+func (e *Epub) formatDate(date time.Time) string {
+	if e.dateFormatter != nil {
+		return e.dateFormatter(date, e.lang)
+	}
+
+	return FormatDate(date, e.lang)
+}
+
+// Format a date of the package metadata as in W3CDTF: only the date if it has
+// no time of day, e.g. 2024-01-15, or else the date-time in UTC
+func formatW3CDate(date time.Time) string {
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 && date.Nanosecond() == 0 {
+		return date.Format(isoDateFormat)
+	}
+	return date.UTC().Format(w3cDateTimeFormat)
+}
+
+// ImplausibleDateError is thrown by Write in strict mode (see SetStrict) if the
+// publication date of the EPUB is implausible, e.g. in the year 20 or 2204,
+// which usually results from a parsing error.
+type ImplausibleDateError struct {
+	Date   time.Time // The implausible date
+	Reason string    // Why it's implausible
+}
+
+func (e *ImplausibleDateError) Error() string {
+	return fmt.Sprintf("Implausible publication date %s: %s", e.Date.Format(isoDateFormat), e.Reason)
+}
+
+// OnSaleDate returns the on-sale date of the EPUB, or the zero time if it
+// isn't set.
+func (e *Epub) OnSaleDate() time.Time {
+	return e.onSaleDate
+}
+
+// SetOnSaleDate sets the date from which the EPUB may be sold or made
+// available, e.g. the end of an embargo, which can differ from the publication
+// date (see SetPubDate). It's written as dcterms:available metadata, formatted
+// as the publication date (EPUB 3 only). Passing the zero time removes it.
+func (e *Epub) SetOnSaleDate(date time.Time) {
+	e.onSaleDate = date
+	if date.IsZero() {
+		e.pkg.setPropertyMeta(dctermsAvailableProperty, "")
+		return
+	}
+	e.pkg.setPropertyMeta(dctermsAvailableProperty, formatW3CDate(date))
+}
+
+// Check the dates of the EPUB when it's written, as some aggregators silently
+// reject EPUBs with inconsistent dates. An implausible publication date is
+// rejected in strict mode, otherwise it's reported as a build warning, as are
+// a modification date before the publication date and an on-sale date before
+// the publication date.
+func (e *Epub) checkDates() error {
+	if e.pubDate.IsZero() {
+		return nil
+	}
+
+	modified := e.modifiedTime()
+	reason := ""
+	switch {
+	case e.pubDate.Year() < minPlausibleYear:
+		reason = fmt.Sprintf("before the year %d", minPlausibleYear)
+	case e.pubDate.After(modified.AddDate(maxPlausibleYearsAhead, 0, 0)):
+		reason = fmt.Sprintf("more than %d years after the modification date", maxPlausibleYearsAhead)
+	}
+	if reason != "" {
+		err := &ImplausibleDateError{Date: e.pubDate, Reason: reason}
+		if e.strict {
+			return err
+		}
+		e.buildWarnings = append(e.buildWarnings, err.Error())
+	}
+
+	if modified.Before(e.pubDate) {
+		e.buildWarnings = append(e.buildWarnings, fmt.Sprintf(
+			"Modification date %s is before the publication date %s",
+			formatW3CDate(modified), formatW3CDate(e.pubDate)))
+	}
+	if !e.onSaleDate.IsZero() && e.onSaleDate.Before(e.pubDate) {
+		e.buildWarnings = append(e.buildWarnings, fmt.Sprintf(
+			"On-sale date %s is before the publication date %s",
+			formatW3CDate(e.onSaleDate), formatW3CDate(e.pubDate)))
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		lang     string
+		expected string
+	}{
+		{"en", "March 5, 2024"},
+		{"de-AT", "5. März 2024"},
+		{"FR", "5 mars 2024"},
+		{"pt-BR", "5 de março de 2024"},
+		{"tlh", "2024-03-05"},
+	} {
+		if output := FormatDate(date, test.lang); output != test.expected {
+			t.Errorf("Date for %s doesn't match\nGot: %s\nExpected: %s", test.lang, output, test.expected)
+		}
+	}
+}
+
+func TestSetDateFormatter(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetLang("fr")
+	e.AddChangelogEntry(ChangelogEntry{
+		Version: "1.0.0",
+		Date:    time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+	})
+	filename, err := e.AddVersionHistoryPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error adding version history page: %s", err)
+	}
+	e.SetDateFormatter(func(date time.Time, lang string) string {
+		return lang + " " + date.Format("02/01/2006")
+	})
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading version history page: %s", err)
+	}
+	expected := "<dt>1.0.0 (fr 05/03/2024)</dt>"
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("Version history page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+}
+
+func TestFormatW3CDate(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("Time zone database not available: %s", err)
+	}
+	for _, test := range []struct {
+		date     time.Time
+		expected string
+	}{
+		{time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC), "2024-01-15"},
+		{time.Date(2024, time.January, 15, 0, 0, 0, 0, paris), "2024-01-15"},
+		{time.Date(2024, time.January, 15, 9, 30, 0, 0, paris), "2024-01-15T08:30:00Z"},
+	} {
+		if output := formatW3CDate(test.date); output != test.expected {
+			t.Errorf("Date %s doesn't match\nGot: %s\nExpected: %s", test.date, output, test.expected)
+		}
+	}
+}
+
+func TestSetOnSaleDate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetPubDate(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+	e.SetOnSaleDate(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if !e.OnSaleDate().Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("On-sale date doesn't match\nGot: %s", e.OnSaleDate())
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	testMetadata := `<meta property="dcterms:available">2024-03-01</meta>`
+	if !strings.Contains(string(contents), testMetadata) {
+		t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+	}
+	if warnings := e.LastBuildReport().Warnings; len(warnings) != 0 {
+		t.Errorf("Unexpected build warnings: %v", warnings)
+	}
+
+	e.SetOnSaleDate(time.Time{})
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.Property == dctermsAvailableProperty {
+			t.Errorf("On-sale date metadata wasn't removed")
+		}
+	}
+}
+
+func TestCheckDates(t *testing.T) {
+	modified := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		pubDate      time.Time
+		onSaleDate   time.Time
+		testWarnings []string
+		strictError  bool
+	}{
+		{
+			pubDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			pubDate:     time.Date(24, 1, 1, 0, 0, 0, 0, time.UTC),
+			strictError: true,
+			testWarnings: []string{
+				"Implausible publication date 0024-01-01: before the year 1000",
+			},
+		},
+		{
+			pubDate:     time.Date(2204, 1, 1, 0, 0, 0, 0, time.UTC),
+			strictError: true,
+			testWarnings: []string{
+				"Implausible publication date 2204-01-01: more than 10 years after the modification date",
+				"Modification date 2024-01-15T12:00:00Z is before the publication date 2204-01-01",
+			},
+		},
+		{
+			pubDate:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			onSaleDate: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC),
+			testWarnings: []string{
+				"Modification date 2024-01-15T12:00:00Z is before the publication date 2024-02-01",
+				"On-sale date 2024-01-20 is before the publication date 2024-02-01",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub(testEpubTitle)
+		e.SetModified(modified)
+		e.SetPubDate(test.pubDate)
+		e.SetOnSaleDate(test.onSaleDate)
+		if err := e.checkDates(); err != nil {
+			t.Errorf("Unexpected error checking dates: %s", err)
+		}
+		if strings.Join(e.buildWarnings, "\n") != strings.Join(test.testWarnings, "\n") {
+			t.Errorf("Build warnings don't match\nGot: %v\nExpected: %v", e.buildWarnings, test.testWarnings)
+		}
+
+		e.buildWarnings = nil
+		e.SetStrict(true)
+		_, isDateError := e.checkDates().(*ImplausibleDateError)
+		if isDateError != test.strictError {
+			t.Errorf("Strict mode error doesn't match for %s\nExpected error: %t", test.pubDate, test.strictError)
+		}
+	}
+}
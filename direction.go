@@ -0,0 +1,64 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Text directions, set using SetDirection
+const (
+	// The direction of the language of the EPUB (the default)
+	DirectionAuto = ""
+	DirectionLTR  = "ltr"
+	DirectionRTL  = "rtl"
+)
+
+// Primary language subtags and script subtags of the languages written from
+// right to left
+var (
+	rtlLanguages = map[string]bool{
+		"ar": true, "arc": true, "ckb": true, "dv": true, "fa": true, "he": true,
+		"ps": true, "sd": true, "syr": true, "ug": true, "ur": true, "yi": true,
+	}
+	rtlScripts = map[string]bool{"arab": true, "hebr": true, "syrc": true, "thaa": true}
+)
+
+// SetDirection sets the text direction of the navigation document and the
+// generated pages (e.g. GenerateTOCPage), which flips their alignment, margins
+// and list markers: either DirectionLTR or DirectionRTL. By default
+// (DirectionAuto), the direction of the language of the EPUB when it's written
+// is used, e.g. right to left for Arabic and Hebrew.
+//
+// This doesn't change the page progression direction; see SetPpd.
+func (e *Epub) SetDirection(direction string) {
+	e.direction = direction
+}
+
+// Get the text direction of the generated content. It's empty if the direction
+// is left to right by default, so that it doesn't need to be written.
+func (e *Epub) textDirection() string {
+	if e.direction != DirectionAuto {
+		return e.direction
+	}
+	if isRTLLanguage(e.lang) {
+		return DirectionRTL
+	}
+
+	return ""
+}
+
+// Get the dir attribute of an HTML element, if there is a direction
+func dirAttribute(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(` dir="%s"`, dir)
+}
+
+// Whether a language is written from right to left
+func isRTLLanguage(lang string) bool {
+	subtags := strings.Split(strings.ToLower(lang), "-")
+	// e.g. az-Arab
+	return rtlLanguages[subtags[0]] || (len(subtags) > 1 && rtlScripts[subtags[1]])
+}
@@ -0,0 +1,61 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDirection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetLang("ar")
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+	tocPage, err := e.GenerateTOCPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error generating TOC page: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	for _, filename := range []string{tocNavFilename, filepath.Join(xhtmlFolderName, tocPage)} {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, filename))
+		if err != nil {
+			t.Fatalf("Unexpected error reading %s: %s", filename, err)
+		}
+		if !strings.Contains(string(contents), `dir="rtl"`) {
+			t.Errorf("Direction of %s doesn't match\nGot: %s\nExpected: rtl", filename, contents)
+		}
+	}
+
+	// Sections that aren't generated keep their direction
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "section0001.xhtml"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	if strings.Contains(string(contents), "dir=") {
+		t.Errorf("Unexpected direction of section\nGot: %s", contents)
+	}
+}
+
+func TestSetDirection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	for _, test := range []struct {
+		lang      string
+		direction string
+		expected  string
+	}{
+		{"en", DirectionAuto, ""},
+		{"he-IL", DirectionAuto, DirectionRTL},
+		{"az-Arab", DirectionAuto, DirectionRTL},
+		{"ar", DirectionLTR, DirectionLTR},
+		{"en", DirectionRTL, DirectionRTL},
+	} {
+		e.SetLang(test.lang)
+		e.SetDirection(test.direction)
+		if output := e.textDirection(); output != test.expected {
+			t.Errorf("Direction for %s doesn't match\nGot: %s\nExpected: %s", test.lang, output, test.expected)
+		}
+	}
+}
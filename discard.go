@@ -0,0 +1,138 @@
+package epub
+
+import (
+	"context"
+	"sync"
+)
+
+// EpubDiscardedError is thrown by Write, WriteTo, WriteWithContext, and
+// Finalize if the EPUB was discarded using Discard, including when it's
+// discarded while being written.
+type EpubDiscardedError struct{}
+
+func (e *EpubDiscardedError) Error() string {
+	return "EPUB was discarded"
+}
+
+// Whether an EPUB was discarded, shared with the writes in progress. Whether
+// it was discarded and the number of writes in progress are updated together,
+// so that a write can't start once the content of the EPUB may be released.
+type discardState struct {
+	mu sync.Mutex
+	// Closed once the EPUB is discarded
+	done      chan struct{}
+	discarded bool
+	// Number of writes in progress
+	writing int
+	// Whether the content of the EPUB was released
+	released bool
+}
+
+func newDiscardState() *discardState {
+	return &discardState{done: make(chan struct{})}
+}
+
+// Discard releases the resources held by an EPUB that won't be written, e.g.
+// because the user canceled the build: the temp files are removed (see Close),
+// and the sections and media files, including the files retrieved by eager
+// fetching or added from memory, are dropped.
+//
+// Discard can be called from another goroutine while the EPUB is being
+// written: the write stops as soon as possible, returns EpubDiscardedError,
+// and releases the resources once it's done. The EPUB can't be written
+// afterwards. Discard can be called more than once.
+func (e *Epub) Discard() error {
+	d := e.discard
+	d.mu.Lock()
+	if !d.discarded {
+		d.discarded = true
+		close(d.done)
+	}
+	// Otherwise the last write in progress releases the resources when it
+	// returns
+	release := d.writing == 0 && !d.released
+	d.released = d.released || release
+	d.mu.Unlock()
+
+	if !release {
+		return nil
+	}
+	return e.release()
+}
+
+// Whether the EPUB was discarded
+func (e *Epub) discarded() bool {
+	select {
+	case <-e.discard.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Start a write, returning a context canceled once the EPUB is discarded and
+// a function to call when the write returns. EpubDiscardedError is returned if
+// the EPUB was already discarded.
+func (e *Epub) startWrite(ctx context.Context) (context.Context, func(), error) {
+	d := e.discard
+	d.mu.Lock()
+	if d.discarded {
+		d.mu.Unlock()
+		return nil, nil, &EpubDiscardedError{}
+	}
+	d.writing++
+	d.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-d.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		cancel()
+		d.mu.Lock()
+		d.writing--
+		release := d.writing == 0 && d.discarded && !d.released
+		d.released = d.released || release
+		d.mu.Unlock()
+		if release {
+			e.release()
+		}
+	}, nil
+}
+
+// Remove the temp files of a discarded EPUB and drop its content, keeping it
+// usable but empty. The fields are reset one by one rather than replacing the
+// whole EPUB, as writes started concurrently read its discard state.
+func (e *Epub) release() error {
+	err := e.Close()
+	empty := NewEpub("")
+	e.aliases = empty.aliases
+	e.audio = empty.audio
+	e.audiobookChapters = nil
+	e.backMatterData = nil
+	e.cover = empty.cover
+	e.css = empty.css
+	e.fonts = empty.fonts
+	e.footnotes = nil
+	e.foreign = empty.foreign
+	e.images = empty.images
+	e.imageSources = empty.imageSources
+	e.landmarks = nil
+	e.mediaOverlays = nil
+	e.pageBreaks = nil
+	e.placeIndex = nil
+	e.pkg = empty.pkg
+	e.sections = nil
+	e.toc = empty.toc
+	e.tocEntries = nil
+	e.transcodedMedia = empty.transcodedMedia
+	e.videoInfo = empty.videoInfo
+	e.videos = empty.videos
+
+	return err
+}
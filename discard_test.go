@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiscard(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, err := e.AddImage(testImageFromFileSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	e.SetCover(imagePath, "")
+	cssTempFile := e.cover.cssTempFile
+
+	if err := e.Discard(); err != nil {
+		t.Errorf("Unexpected error discarding EPUB: %s", err)
+	}
+	if _, err := os.Stat(cssTempFile); !os.IsNotExist(err) {
+		t.Errorf("Cover CSS temp file wasn't removed")
+	}
+	if len(e.images) != 0 || len(e.sections) != 0 {
+		t.Errorf("Content wasn't released\nGot: %d images, %d sections\nExpected: 0 images, 0 sections", len(e.images), len(e.sections))
+	}
+
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*EpubDiscardedError); !ok {
+		t.Errorf("Expected error EpubDiscardedError not returned. Returned instead: %+v", err)
+	}
+	if _, err := e.Finalize(); err == nil {
+		t.Errorf("Expected error finalizing a discarded EPUB")
+	}
+	// Discard can be called more than once
+	if err := e.Discard(); err != nil {
+		t.Errorf("Unexpected error discarding EPUB again: %s", err)
+	}
+}
+
+func TestDiscardWhileWriting(t *testing.T) {
+	// The body of the image never ends
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	if _, err := e.AddImage(server.URL+"/gopher.png", "gopher.png"); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	errs := make(chan error)
+	go func() {
+		errs <- e.Write(testEpubFilename)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := e.Discard(); err != nil {
+		t.Errorf("Unexpected error discarding EPUB: %s", err)
+	}
+
+	select {
+	case err := <-errs:
+		if _, ok := err.(*EpubDiscardedError); !ok {
+			t.Errorf("Expected error EpubDiscardedError not returned. Returned instead: %+v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Write didn't stop once the EPUB was discarded")
+	}
+	if _, err := os.Stat(testEpubFilename); !os.IsNotExist(err) {
+		t.Errorf("EPUB was created despite being discarded")
+		os.Remove(testEpubFilename)
+	}
+	if len(e.images) != 0 {
+		t.Errorf("Content wasn't released once the write stopped")
+	}
+}
+
+// Run with -race to check that Discard doesn't release the EPUB under a write
+func TestDiscardConcurrentWrite(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp directory: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for i := 0; i < 20; i++ {
+		e := NewEpub(testEpubTitle)
+		e.AddImage(newDataURL("image/png", image), "gopher.png")
+		e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+		errs := make(chan error)
+		go func() {
+			errs <- e.Write(filepath.Join(tempDir, fmt.Sprintf("%d.epub", i)))
+		}()
+		if err := e.Discard(); err != nil {
+			t.Errorf("Unexpected error discarding EPUB: %s", err)
+		}
+		err := <-errs
+		if _, ok := err.(*EpubDiscardedError); err != nil && !ok {
+			t.Errorf("Unexpected error writing EPUB: %s", err)
+		}
+		if len(e.images) != 0 || len(e.sections) != 0 {
+			t.Errorf("Content wasn't released\nGot: %d images, %d sections\nExpected: 0 images, 0 sections", len(e.images), len(e.sections))
+		}
+	}
+}
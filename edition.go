@@ -0,0 +1,41 @@
+package epub
+
+const (
+	ibooksVersionProperty = "ibooks:version"
+	schemaEditionProperty = "schema:bookEdition"
+	schemaVersionProperty = "schema:version"
+)
+
+// Edition returns the edition of the EPUB.
+func (e *Epub) Edition() string {
+	return e.edition
+}
+
+// SetEdition sets the edition of the EPUB, e.g. Second edition, which is
+// written as schema:bookEdition metadata (EPUB 3 only).
+func (e *Epub) SetEdition(edition string) {
+	e.edition = edition
+	e.pkg.setPropertyMeta(schemaEditionProperty, edition)
+}
+
+// PublicationVersion returns the version of the publication.
+func (e *Epub) PublicationVersion() string {
+	return e.publicationVersion
+}
+
+// SetPublicationVersion sets the version of the publication, e.g. 1.0.1, to be
+// increased each time a corrected EPUB is uploaded to stores so that it's
+// recognized as an update of the same book rather than a new book. Not to be
+// confused with the EPUB version set using SetVersion.
+//
+// The version is written as schema:version metadata as well as ibooks:version
+// metadata, which Apple Books uses to detect updates and requires to be in the
+// format major.minor.patch (EPUB 3 only).
+func (e *Epub) SetPublicationVersion(version string) {
+	e.publicationVersion = version
+	if version != "" {
+		e.pkg.addPrefix(ibooksPrefix, ibooksPrefixURI)
+	}
+	e.pkg.setPropertyMeta(schemaVersionProperty, version)
+	e.pkg.setPropertyMeta(ibooksVersionProperty, version)
+}
@@ -0,0 +1,48 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetEditionAndPublicationVersion(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetEdition("Second edition")
+	e.SetPublicationVersion("2.0.1")
+	if e.Edition() != "Second edition" || e.PublicationVersion() != "2.0.1" {
+		t.Errorf("Edition or version doesn't match\nGot: %s, %s", e.Edition(), e.PublicationVersion())
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`prefix="ibooks: http://vocabulary.itunes.apple.com/rdf/ibooks/vocabulary-extensions-1.0/"`,
+		`<meta property="schema:bookEdition">Second edition</meta>`,
+		`<meta property="schema:version">2.0.1</meta>`,
+		`<meta property="ibooks:version">2.0.1</meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf(
+				"Package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				testMetadata)
+		}
+	}
+
+	// An empty value removes the metadata
+	e.SetEdition("")
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.Property == schemaEditionProperty {
+			t.Errorf("Edition metadata wasn't removed")
+		}
+	}
+}
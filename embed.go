@@ -0,0 +1,133 @@
+package epub
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// EmbedPolicy defines how embedded content (<iframe> and <embed> elements) is
+// handled when sections are added. Embedded content is common in content
+// scraped from the web but isn't allowed by many reading systems and often
+// breaks validation.
+type EmbedPolicy int
+
+// Embed policies
+const (
+	// Leave embedded content as-is (the default)
+	EmbedPolicyKeep EmbedPolicy = iota
+	// Remove embedded content
+	EmbedPolicyStrip
+	// Replace embedded content by a link to it, with a screenshot if available
+	EmbedPolicyPlaceholder
+	// Add the embedded document as a section following the section that
+	// embeds it, and replace the embedded content by a link to that section
+	EmbedPolicyInline
+)
+
+const (
+	embedLinkTemplate       = `<p class="embed"><a href="%s">%s</a></p>`
+	embedScreenshotTemplate = `<img src="%s" alt="%s" />`
+)
+
+var (
+	htmlEmbedRegexp  = regexp.MustCompile(`(?is)<embed\b[^>]*>(?:\s*</embed>)?`)
+	htmlIframeRegexp = regexp.MustCompile(`(?is)<iframe\b[^>]*?(?:/>|>.*?</iframe>)`)
+)
+
+// EmbedScreenshotSource returns the source of an image (a URL, a data URL, or
+// a path to a local file) representing the content embedded from the provided
+// URL, which is used by EmbedPolicyPlaceholder. If an empty string is
+// returned, the placeholder is a link without an image.
+type EmbedScreenshotSource func(url string) string
+
+// SetEmbedPolicy sets how the embedded content of sections added afterwards
+// is handled. The screenshot source is optional and only used by
+// EmbedPolicyPlaceholder.
+//
+// With EmbedPolicyInline, the embedded documents are retrieved (from a URL or
+// a local file) when the section is added and must be XHTML; their body is
+// used as the body of the new section and their title as its title. Content
+// embedded by these documents is replaced by placeholders.
+func (e *Epub) SetEmbedPolicy(policy EmbedPolicy, screenshotSource EmbedScreenshotSource) {
+	e.embedPolicy = policy
+	e.embedScreenshotSource = screenshotSource
+}
+
+// An embedded document added as a section
+type inlinedEmbed struct {
+	body     string
+	title    string
+	filename string
+}
+
+// Apply the embed policy to the body of a section being added. The embedded
+// documents to add as sections when the policy is EmbedPolicyInline are
+// returned; their filenames are generated starting after the provided number
+// of sections.
+func (e *Epub) applyEmbedPolicy(body string, policy EmbedPolicy, sectionCount int) (string, []inlinedEmbed, error) {
+	if policy == EmbedPolicyKeep {
+		return body, nil, nil
+	}
+
+	inlined := []inlinedEmbed{}
+	var err error
+	replace := func(element string) string {
+		if err != nil {
+			return element
+		}
+		src, _ := tagAttribute(element, "src")
+		if policy == EmbedPolicyStrip || src == "" {
+			return ""
+		}
+
+		title, _ := tagAttribute(element, "title")
+		if title == "" {
+			title = src
+		}
+
+		if policy == EmbedPolicyInline {
+			var content []byte
+			content, err = readMediaSource(e.context(), e.httpClient(), unescapeText(src))
+			if err != nil {
+				return element
+			}
+
+			embed := inlinedEmbed{
+				body:     string(content),
+				filename: fmt.Sprintf(sectionFileFormat, sectionCount+len(inlined)+1),
+			}
+			if m := xhtmlBodyRegexp.FindStringSubmatch(embed.body); m != nil {
+				embed.body = strings.TrimSpace(m[1])
+			}
+			if m := xhtmlTitleRegexp.FindStringSubmatch(string(content)); m != nil {
+				embed.title = strings.TrimSpace(unescapeText(m[1]))
+				title = m[1]
+			}
+			// Don't inline recursively
+			embed.body, _, err = e.applyEmbedPolicy(embed.body, EmbedPolicyPlaceholder, 0)
+			inlined = append(inlined, embed)
+
+			return fmt.Sprintf(embedLinkTemplate, embed.filename, title)
+		}
+
+		link := title
+		if e.embedScreenshotSource != nil {
+			if source := e.embedScreenshotSource(unescapeText(src)); source != "" {
+				var imagePath string
+				imagePath, err = e.AddImage(source, "")
+				if err != nil {
+					return element
+				}
+				link = fmt.Sprintf(embedScreenshotTemplate, imagePath, title) + "<br />" + title
+			}
+		}
+
+		return fmt.Sprintf(embedLinkTemplate, src, link)
+	}
+
+	body = htmlIframeRegexp.ReplaceAllStringFunc(body, replace)
+	body = htmlEmbedRegexp.ReplaceAllStringFunc(body, replace)
+
+	return body, inlined, err
+}
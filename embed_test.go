@@ -0,0 +1,95 @@
+package epub
+
+import (
+	"testing"
+)
+
+const testEmbedBody = `<p>Intro</p>
+<iframe src="https://example.com/video" title="Video"></iframe>
+<embed src="https://example.com/widget.swf" />`
+
+func TestSetEmbedPolicy(t *testing.T) {
+	tests := []struct {
+		policy   EmbedPolicy
+		expected string
+	}{
+		{EmbedPolicyKeep, testEmbedBody},
+		{EmbedPolicyStrip, "<p>Intro</p>\n\n"},
+		{
+			EmbedPolicyPlaceholder,
+			`<p>Intro</p>
+<p class="embed"><a href="https://example.com/video"><img src="../images/gophercolor16x16.png" alt="Video" /><br />Video</a></p>
+<p class="embed"><a href="https://example.com/widget.swf">https://example.com/widget.swf</a></p>`,
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub(testEpubTitle)
+		e.SetEmbedPolicy(test.policy, func(url string) string {
+			if url == "https://example.com/video" {
+				return testImageFromFileSource
+			}
+			return ""
+		})
+		e.AddSection(testEmbedBody, "Section", "", "")
+
+		if got := e.sections[0].xhtml.xml.Body.XML; got != "\n"+test.expected+"\n" {
+			t.Errorf(
+				"Section body doesn't match for policy %d\n"+
+					"Got: %s\n"+
+					"Expected: %s",
+				test.policy,
+				got,
+				test.expected)
+		}
+	}
+}
+
+func TestSetEmbedPolicyInline(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetEmbedPolicy(EmbedPolicyInline, nil)
+
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Embedded</title></head>
+<body>
+<p>Embedded content</p>
+<iframe src="https://example.com/nested"></iframe>
+</body>
+</html>`
+	body := `<iframe src="` + newDataURL("application/xhtml+xml", []byte(document)) + `"></iframe>`
+	if _, err := e.AddSection(body, "Section", "", ""); err != nil {
+		t.Errorf("Unexpected error adding section: %s", err)
+	}
+
+	if len(e.sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(e.sections))
+	}
+	testBody := "\n" + `<p class="embed"><a href="section0002.xhtml">Embedded</a></p>` + "\n"
+	if got := e.sections[0].xhtml.xml.Body.XML; got != testBody {
+		t.Errorf(
+			"Section body doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			got,
+			testBody)
+	}
+	testInlinedBody := "\n" + `<p>Embedded content</p>
+<p class="embed"><a href="https://example.com/nested">https://example.com/nested</a></p>` + "\n"
+	if got := e.sections[1].xhtml.xml.Body.XML; got != testInlinedBody {
+		t.Errorf(
+			"Inlined section body doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			got,
+			testInlinedBody)
+	}
+	if e.sections[1].filename != "section0002.xhtml" || e.sections[1].xhtml.Title() != "Embedded" {
+		t.Errorf("Inlined section doesn't match\nGot: %s %q", e.sections[1].filename, e.sections[1].xhtml.Title())
+	}
+
+	_, err := e.AddSection(`<embed src="testdata/missing.xhtml" />`, "Broken", "", "")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+}
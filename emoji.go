@@ -0,0 +1,337 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	emojiFontCSS = `.emoji {
+  font-family: "%s";
+}
+`
+	emojiImageCSS = `img.emoji {
+  height: 1em;
+  width: 1em;
+  vertical-align: -0.1em;
+}
+`
+	emojiImageFileFormat = "emoji-%s%s"
+	emojiImageTemplate   = `<img class="emoji" src="%s" alt="%s" />`
+	emojiSpanTemplate    = `<span class="emoji">%s</span>`
+)
+
+// Characters handled by the emoji fallback: emoji, pictographs, dingbats and
+// other symbols that e-ink reading systems often can't render
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x2300, Hi: 0x23ff, Stride: 1}, // Miscellaneous Technical
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1}, // Miscellaneous Symbols, Dingbats
+		{Lo: 0x2b00, Hi: 0x2bff, Stride: 1}, // Miscellaneous Symbols and Arrows
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1faff, Stride: 1}, // Emoji and pictographs
+	},
+}
+
+const (
+	emojiKeycap              = '\u20e3'
+	emojiVariationSelector15 = '\ufe0e'
+	emojiVariationSelector16 = '\ufe0f'
+	emojiZWJ                 = '\u200d'
+)
+
+// FontSubsetter reduces a font to the glyphs needed to render the provided
+// characters and returns the data of the resulting font. It's used by
+// SetEmojiFallbackFont, as there is no font subsetter in the standard library.
+type FontSubsetter func(font []byte, runes []rune) ([]byte, error)
+
+// EmojiImageSource returns the source of an image (a URL, a data URL, or a
+// path to a local file) representing an emoji. The emoji is identified by its
+// code points in lowercase hexadecimal joined by dashes, without variation
+// selector 16 (e.g. "1f600" or "1f469-200d-1f4bb"), which is the convention
+// used by emoji image sets such as Twemoji. If an empty string is returned,
+// the emoji is left as-is.
+type EmojiImageSource func(codePoints string) string
+
+// How emoji are handled when the EPUB is written
+type epubEmojiFallback struct {
+	// Font mode
+	fontFamily   string
+	fontFilename string
+	subsetter    FontSubsetter
+
+	// Image mode
+	imageSource EmojiImageSource
+	// The key is the emoji code points, the value is the image filename
+	imageFilenames map[string]string
+}
+
+// SetEmojiFallbackFont embeds a font covering emoji and other rare symbols, as
+// many reading systems (especially e-ink ones) would otherwise render them as
+// empty boxes. When the EPUB is written, these characters are wrapped in
+// <span class="emoji"> elements styled with the font using the default
+// stylesheet.
+//
+// If a subsetter is provided, the font is reduced to the characters actually
+// used by the EPUB when it's written. The path to the font file is returned in
+// the format: ../FontFolderName/internalFilename
+func (e *Epub) SetEmojiFallbackFont(fontSource string, familyName string, subsetter FontSubsetter) (string, error) {
+	fontPath, err := e.AddFont(fontSource, "")
+	if err != nil {
+		return "", err
+	}
+
+	e.emojiFallback = &epubEmojiFallback{
+		fontFamily:   familyName,
+		fontFilename: filepath.Base(fontPath),
+		subsetter:    subsetter,
+	}
+
+	return fontPath, nil
+}
+
+// SetEmojiFallbackImages replaces emoji and other rare symbols with inline
+// images when the EPUB is written, as many reading systems (especially e-ink
+// ones) would otherwise render them as empty boxes. The images are retrieved
+// using the provided function and added to the EPUB when it's written; the
+// original characters are kept as the alternative text of the images.
+func (e *Epub) SetEmojiFallbackImages(imageSource EmojiImageSource) {
+	e.emojiFallback = &epubEmojiFallback{
+		imageSource:    imageSource,
+		imageFilenames: map[string]string{},
+	}
+}
+
+// Add the images of the emoji used by the sections, if emoji are replaced by
+// images
+func (e *Epub) addEmojiImages() error {
+	if e.emojiFallback == nil || e.emojiFallback.imageSource == nil {
+		return nil
+	}
+
+	for _, section := range e.sections {
+		for _, sequence := range findEmoji(section.xhtml.xml.Body.XML) {
+			codePoints := emojiCodePoints(sequence)
+			if _, ok := e.emojiFallback.imageFilenames[codePoints]; ok {
+				continue
+			}
+
+			source := e.emojiFallback.imageSource(codePoints)
+			if source == "" {
+				e.emojiFallback.imageFilenames[codePoints] = ""
+				continue
+			}
+
+			imagePath, err := e.AddImage(source, fmt.Sprintf(emojiImageFileFormat, codePoints, mediaSourceExt(source)))
+			if err != nil {
+				return err
+			}
+			e.emojiFallback.imageFilenames[codePoints] = filepath.Base(imagePath)
+		}
+	}
+
+	return nil
+}
+
+// Subset the emoji fallback font, which has already been written to the
+// temporary directory, to the characters used by the sections
+func (e *Epub) subsetEmojiFont(tempDir string) error {
+	if e.emojiFallback == nil || e.emojiFallback.subsetter == nil {
+		return nil
+	}
+
+	used := map[rune]bool{}
+	for _, section := range e.sections {
+		for _, sequence := range findEmoji(section.xhtml.xml.Body.XML) {
+			for _, r := range sequence {
+				used[r] = true
+			}
+		}
+	}
+	runes := []rune{}
+	for r := range used {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	fontFilePath := filepath.Join(tempDir, contentFolderName, FontFolderName, e.emojiFallback.fontFilename)
+	font, err := ioutil.ReadFile(fontFilePath)
+	if err != nil {
+		panic(fmt.Sprintf("Error reading font file: %s", err))
+	}
+	subset, err := e.emojiFallback.subsetter(font, runes)
+	if err != nil {
+		return &FileRetrievalError{Source: e.fonts[e.emojiFallback.fontFilename], Err: err}
+	}
+	if err := ioutil.WriteFile(fontFilePath, subset, filePermissions); err != nil {
+		panic(fmt.Sprintf("Error writing font file: %s", err))
+	}
+	e.recordTransform(FontFolderName, e.emojiFallback.fontFilename, int64(len(font)), ResourceTransformSubsetted)
+
+	return nil
+}
+
+// Get the default CSS needed by the emoji fallback
+func (e *Epub) emojiCSS() string {
+	if e.emojiFallback == nil {
+		return ""
+	}
+
+	if e.emojiFallback.imageSource != nil {
+		return emojiImageCSS
+	}
+
+	family := strings.Replace(e.emojiFallback.fontFamily, `"`, `\"`, -1)
+	return fmt.Sprintf(fontFaceTemplate, family, path.Join("..", FontFolderName, e.emojiFallback.fontFilename)) +
+		fmt.Sprintf(emojiFontCSS, family)
+}
+
+// Apply the emoji fallback to the body of a section
+func (e *Epub) replaceEmoji(body string) string {
+	if e.emojiFallback == nil {
+		return body
+	}
+
+	return mapText(body, func(text string) string {
+		return replaceEmojiSequences(text, func(sequence string) string {
+			if e.emojiFallback.imageSource == nil {
+				return fmt.Sprintf(emojiSpanTemplate, sequence)
+			}
+			filename := e.emojiFallback.imageFilenames[emojiCodePoints(sequence)]
+			if filename == "" {
+				return sequence
+			}
+			return fmt.Sprintf(emojiImageTemplate, path.Join("..", ImageFolderName, filename), sequence)
+		})
+	})
+}
+
+// Find the emoji sequences used in the text of a section body
+func findEmoji(body string) []string {
+	sequences := []string{}
+	mapText(body, func(text string) string {
+		return replaceEmojiSequences(text, func(sequence string) string {
+			sequences = append(sequences, sequence)
+			return sequence
+		})
+	})
+
+	return sequences
+}
+
+// Replace each emoji sequence of a text using the provided function. A
+// sequence is an emoji followed by modifiers (variation selectors, skin tones,
+// keycaps), other emoji joined using zero-width joiners, or a pair of regional
+// indicators (flags).
+func replaceEmojiSequences(text string, replace func(sequence string) string) string {
+	runes := []rune(text)
+	b := strings.Builder{}
+
+	for i := 0; i < len(runes); i++ {
+		if !unicode.Is(emojiRanges, runes[i]) {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		end := i + 1
+		if isRegionalIndicator(runes[i]) && end < len(runes) && isRegionalIndicator(runes[end]) {
+			end++
+		}
+		for end < len(runes) {
+			r := runes[end]
+			if r == emojiVariationSelector15 || r == emojiVariationSelector16 || r == emojiKeycap || isSkinToneModifier(r) {
+				end++
+			} else if r == emojiZWJ && end+1 < len(runes) && unicode.Is(emojiRanges, runes[end+1]) {
+				end += 2
+			} else {
+				break
+			}
+		}
+
+		b.WriteString(replace(string(runes[i:end])))
+		i = end - 1
+	}
+
+	return b.String()
+}
+
+// Get the code points of an emoji sequence, e.g. 1f469-200d-1f4bb
+func emojiCodePoints(sequence string) string {
+	codePoints := []string{}
+	for _, r := range sequence {
+		if r != emojiVariationSelector16 {
+			codePoints = append(codePoints, fmt.Sprintf("%x", r))
+		}
+	}
+
+	return strings.Join(codePoints, "-")
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func isSkinToneModifier(r rune) bool {
+	return r >= 0x1f3fb && r <= 0x1f3ff
+}
+
+// Apply a function to the text of an XHTML fragment, leaving markup, comments,
+// and the content of <script> and <style> elements unchanged
+func mapText(body string, fn func(text string) string) string {
+	b := strings.Builder{}
+	// The element whose content is being skipped, if any
+	skipUntil := ""
+
+	for len(body) > 0 {
+		start := strings.Index(body, "<")
+		if start == -1 {
+			start = len(body)
+		}
+		if start > 0 {
+			if skipUntil == "" {
+				b.WriteString(fn(body[:start]))
+			} else {
+				b.WriteString(body[:start])
+			}
+			body = body[start:]
+			continue
+		}
+
+		end := ">"
+		if strings.HasPrefix(body, "<!--") {
+			end = "-->"
+		} else if strings.HasPrefix(body, "<![CDATA[") {
+			end = "]]>"
+		}
+		i := strings.Index(body, end)
+		if i == -1 {
+			i = len(body)
+		} else {
+			i += len(end)
+		}
+		tag := body[:i]
+		b.WriteString(tag)
+		body = body[i:]
+
+		name := strings.TrimLeft(tag, "</")
+		if i := strings.IndexFunc(name, func(r rune) bool {
+			return r == '>' || r == '/' || unicode.IsSpace(r)
+		}); i != -1 {
+			name = name[:i]
+		}
+		name = strings.ToLower(name)
+		if skipUntil == "" && !strings.HasPrefix(tag, "</") && !strings.HasSuffix(tag, "/>") && (name == "script" || name == "style") {
+			skipUntil = name
+		} else if skipUntil != "" && strings.HasPrefix(tag, "</") && name == skipUntil {
+			skipUntil = ""
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package epub
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testEmojiSectionBody = `<p title="Smile 😀">Hello 😀 and 👩‍💻, from 🇫🇷!</p>
+<style>p::after { content: "😀"; }</style>
+`
+
+func TestSetEmojiFallbackImages(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testEmojiSectionBody, testSectionTitle, testSectionFilename, "")
+
+	requested := []string{}
+	e.SetEmojiFallbackImages(func(codePoints string) string {
+		requested = append(requested, codePoints)
+		if codePoints == "1f1eb-1f1f7" {
+			return ""
+		}
+		return testImageFromFileSource
+	})
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	testRequested := "1f600,1f469-200d-1f4bb,1f1eb-1f1f7"
+	if strings.Join(requested, ",") != testRequested {
+		t.Errorf(
+			"Requested emoji don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			strings.Join(requested, ","),
+			testRequested)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, testSectionFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testParagraph := `<p title="Smile 😀">Hello <img class="emoji" src="../images/emoji-1f600.png" alt="😀" /> and ` +
+		`<img class="emoji" src="../images/emoji-1f469-200d-1f4bb.png" alt="👩‍💻" />, from 🇫🇷!</p>` + "\n" +
+		`<style>p::after { content: "😀"; }</style>`
+	if !strings.Contains(string(contents), testParagraph) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testParagraph)
+	}
+
+	if _, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "emoji-1f600.png")); err != nil {
+		t.Errorf("Unexpected error reading emoji image: %s", err)
+	}
+}
+
+func TestSetEmojiFallbackFont(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testEmojiSectionBody, testSectionTitle, testSectionFilename, "")
+
+	subsetRunes := []rune{}
+	_, err := e.SetEmojiFallbackFont(testFontFromFileSource, "Emoji", func(font []byte, runes []rune) ([]byte, error) {
+		subsetRunes = runes
+		return []byte("subset"), nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error setting emoji fallback font: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	testRunes := []rune("😀👩‍💻🇫🇷")
+	if len(subsetRunes) != len(testRunes) {
+		t.Errorf(
+			"Subset characters don't match\n"+
+				"Got: %q\n"+
+				"Expected: %q",
+			subsetRunes,
+			testRunes)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, FontFolderName, "redacted-script-regular.ttf"))
+	if err != nil || string(contents) != "subset" {
+		t.Errorf("Emoji fallback font wasn't subsetted: %s", err)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, testSectionFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testParagraph := `<p title="Smile 😀">Hello <span class="emoji">😀</span> and <span class="emoji">👩‍💻</span>, from <span class="emoji">🇫🇷</span>!</p>`
+	if !strings.Contains(string(contents), testParagraph) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testParagraph)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, defaultCSSFilename))
+	if err != nil || !strings.Contains(string(contents), `font-family: "Emoji";`) {
+		t.Errorf("Default CSS file doesn't style emoji: %s", contents)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	e.SetEmojiFallbackFont(testFontFromFileSource, "Emoji", func(font []byte, runes []rune) ([]byte, error) {
+		return nil, errors.New("Unable to subset font")
+	})
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+	cleanup(testEpubFilename, tempDir)
+}
@@ -0,0 +1,279 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// CharsetDecoder converts text in a character encoding to UTF-8. The decoders
+// of golang.org/x/text/encoding can be used, e.g.
+// japanese.ShiftJIS.NewDecoder().Bytes for Shift-JIS.
+type CharsetDecoder func(data []byte) ([]byte, error)
+
+// Canonical name of the Shift-JIS encoding, whose decoder is also used to
+// decode text without an encoding declaration that looks like Shift-JIS
+const charsetShiftJIS = "shift_jis"
+
+var (
+	charsetDecoders   = map[string]CharsetDecoder{}
+	charsetDecodersMu sync.RWMutex
+)
+
+// Labels of the encodings decoded without a registered decoder, or whose name
+// is canonicalized for the lookup of the registered decoders
+var charsetAliases = map[string]string{
+	"cp1252":       "windows-1252",
+	"csshiftjis":   charsetShiftJIS,
+	"iso-8859-1":   "windows-1252",
+	"iso8859-1":    "windows-1252",
+	"l1":           "windows-1252",
+	"latin1":       "windows-1252",
+	"ms_kanji":     charsetShiftJIS,
+	"shift-jis":    charsetShiftJIS,
+	"sjis":         charsetShiftJIS,
+	"us-ascii":     "windows-1252",
+	"utf8":         "utf-8",
+	"windows-31j":  charsetShiftJIS,
+	"windows-1252": "windows-1252",
+	"x-sjis":       charsetShiftJIS,
+}
+
+// The characters of Windows-1252 from 0x80 to 0x9f, which are C1 control
+// characters in Latin-1. Unassigned bytes are kept as control characters.
+var windows1252Chars = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16BEBOM = []byte{0xfe, 0xff}
+	utf16LEBOM = []byte{0xff, 0xfe}
+)
+
+// Encoding declarations of XML, HTML and CSS, whose first group is the name of
+// the encoding
+var charsetDeclRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)<\?xml[^>]*?\bencoding\s*=\s*["']([^"']+)["']`),
+	regexp.MustCompile(`(?i)<meta[^>]*?\bcharset\s*=\s*["']?([\w.:-]+)`),
+	regexp.MustCompile(`(?i)^\s*@charset\s+["']([^"']+)["']`),
+}
+
+// RegisterCharsetDecoder registers the decoder used by DecodeText for text in
+// the provided encoding, e.g. Shift-JIS or EUC-KR, which aren't supported
+// without a decoder. The name is case insensitive, and the usual aliases of
+// Shift-JIS (e.g. sjis or windows-31j) share the decoder of shift_jis.
+// Registering nil removes the decoder for the encoding.
+func RegisterCharsetDecoder(charset string, decode CharsetDecoder) {
+	charset = canonicalCharset(charset)
+
+	charsetDecodersMu.Lock()
+	defer charsetDecodersMu.Unlock()
+	if decode == nil {
+		delete(charsetDecoders, charset)
+		return
+	}
+	charsetDecoders[charset] = decode
+}
+
+// DecodeText converts the contents of an HTML, CSS or text file to UTF-8,
+// removing its byte order mark if it has one. The encoding is detected in the
+// following order:
+//   - The byte order mark of UTF-8 or UTF-16
+//   - UTF-16 without a byte order mark, recognized by its null bytes
+//   - UTF-8, if the contents are valid UTF-8
+//   - The encoding declared by the contents: the encoding of the XML
+//     declaration, the charset of the HTML meta element, or the @charset rule
+//     of CSS. Encodings other than UTF-8, UTF-16 and Latin-1 require a decoder
+//     (see RegisterCharsetDecoder).
+//   - Shift-JIS, if a decoder is registered for shift_jis and the contents
+//     are valid Shift-JIS
+//   - Latin-1, decoded as its superset Windows-1252 as browsers do
+//
+// The encoding declarations of transcoded contents are changed to UTF-8.
+func DecodeText(data []byte) (string, error) {
+	s, _, err := decodeText(data)
+	return s, err
+}
+
+// Convert text to UTF-8 as DecodeText does, also returning whether it was
+// transcoded from another encoding
+func decodeText(data []byte) (string, bool, error) {
+	if bytes.HasPrefix(data, utf8BOM) {
+		return string(data[len(utf8BOM):]), false, nil
+	}
+	if bytes.HasPrefix(data, utf16BEBOM) {
+		s, err := decodeUTF16(data[len(utf16BEBOM):], true)
+		return s, true, err
+	}
+	if bytes.HasPrefix(data, utf16LEBOM) {
+		s, err := decodeUTF16(data[len(utf16LEBOM):], false)
+		return s, true, err
+	}
+	if bigEndian, ok := detectUTF16(data); ok {
+		s, err := decodeUTF16(data, bigEndian)
+		return s, true, err
+	}
+	if utf8.Valid(data) {
+		return string(data), false, nil
+	}
+
+	charset := declaredCharset(data)
+	switch charset {
+	case "", "utf-8":
+		if charsetDecoder(charsetShiftJIS) != nil && isShiftJIS(data) {
+			charset = charsetShiftJIS
+		} else {
+			charset = "windows-1252"
+		}
+	case "utf-16", "utf-16be", "utf-16le":
+		// The byte order mark or null bytes would have been found otherwise
+		return "", false, errors.New("Invalid UTF-16 text")
+	}
+
+	var s string
+	if charset == "windows-1252" {
+		s = decodeWindows1252(data)
+	} else {
+		decode := charsetDecoder(charset)
+		if decode == nil {
+			return "", false, fmt.Errorf("No decoder registered for encoding %q", charset)
+		}
+		decoded, err := decode(data)
+		if err != nil {
+			return "", false, err
+		}
+		s = string(bytes.TrimPrefix(decoded, utf8BOM))
+	}
+
+	return replaceCharsetDecls(s), true, nil
+}
+
+// Get the canonical name of an encoding
+func canonicalCharset(charset string) string {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if alias, ok := charsetAliases[charset]; ok {
+		return alias
+	}
+	return charset
+}
+
+// Get the decoder registered for an encoding, if any
+func charsetDecoder(charset string) CharsetDecoder {
+	charsetDecodersMu.RLock()
+	defer charsetDecodersMu.RUnlock()
+	return charsetDecoders[charset]
+}
+
+// Get the canonical name of the encoding declared by the contents of a file,
+// if any. The declarations are in ASCII in the encodings supported.
+func declaredCharset(data []byte) string {
+	// The declarations are at the start of the file
+	if len(data) > 1024 {
+		data = data[:1024]
+	}
+	for _, re := range charsetDeclRegexps {
+		if m := re.FindSubmatch(data); m != nil {
+			return canonicalCharset(string(m[1]))
+		}
+	}
+	return ""
+}
+
+// Change the encoding declarations of transcoded text to UTF-8
+func replaceCharsetDecls(s string) string {
+	for _, re := range charsetDeclRegexps {
+		if m := re.FindStringSubmatchIndex(s); m != nil {
+			s = s[:m[2]] + "UTF-8" + s[m[3]:]
+		}
+	}
+	return s
+}
+
+// Detect text in UTF-16 without a byte order mark, in which the characters of
+// ASCII have a null byte. Text files don't otherwise contain null bytes.
+func detectUTF16(data []byte) (bigEndian bool, ok bool) {
+	if len(data) < 2 || len(data)%2 != 0 {
+		return false, false
+	}
+	var evenNulls, oddNulls int
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNulls++
+		} else {
+			oddNulls++
+		}
+	}
+	units := len(data) / 2
+	if evenNulls > units/2 && oddNulls == 0 {
+		return true, true
+	}
+	if oddNulls > units/2 && evenNulls == 0 {
+		return false, true
+	}
+	return false, false
+}
+
+// Decode UTF-16 text without its byte order mark
+func decodeUTF16(data []byte, bigEndian bool) (string, error) {
+	if len(data)%2 != 0 {
+		return "", errors.New("Invalid UTF-16 text: odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return replaceCharsetDecls(string(utf16.Decode(units))), nil
+}
+
+// Decode Windows-1252 text
+func decodeWindows1252(data []byte) string {
+	var b strings.Builder
+	for _, c := range data {
+		if c >= 0x80 && c <= 0x9f {
+			b.WriteRune(windows1252Chars[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// Whether text is valid Shift-JIS containing at least one double-byte
+// character
+func isShiftJIS(data []byte) bool {
+	doubleByte := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c < 0x80 || (c >= 0xa1 && c <= 0xdf):
+			// ASCII or half-width katakana
+		case (c >= 0x81 && c <= 0x9f) || (c >= 0xe0 && c <= 0xfc):
+			if i+1 >= len(data) {
+				return false
+			}
+			trail := data[i+1]
+			if trail < 0x40 || trail == 0x7f || trail > 0xfc {
+				return false
+			}
+			doubleByte = true
+			i++
+		default:
+			return false
+		}
+	}
+	return doubleByte
+}
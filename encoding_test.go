@@ -0,0 +1,115 @@
+package epub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	// 日本 in Shift-JIS
+	shiftJIS := []byte{0x93, 0xfa, 0x96, 0x7b}
+	RegisterCharsetDecoder("Shift_JIS", func(data []byte) ([]byte, error) {
+		return bytes.Replace(data, shiftJIS, []byte("日本"), -1), nil
+	})
+	defer RegisterCharsetDecoder(charsetShiftJIS, nil)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"UTF-8", []byte("Café"), "Café"},
+		{"UTF-8 BOM", []byte("\xef\xbb\xbfCafé"), "Café"},
+		{"UTF-16BE BOM", []byte{0xfe, 0xff, 0x00, 'C', 0x00, 0xe9}, "Cé"},
+		{"UTF-16LE BOM", []byte{0xff, 0xfe, 'C', 0x00, 0xe9, 0x00}, "Cé"},
+		{"UTF-16LE", []byte{'<', 0x00, 'p', 0x00, '>', 0x00, 0xe9, 0x00}, "<p>é"},
+		{"UTF-16BE declaration", append([]byte{0xfe, 0xff}, utf16BE(`<?xml version="1.0" encoding="UTF-16"?>`)...),
+			`<?xml version="1.0" encoding="UTF-8"?>`},
+		{"Latin-1", []byte("Caf\xe9 \x93quoted\x94"), "Café “quoted”"},
+		{"Latin-1 declaration", []byte(`<meta charset="iso-8859-1" /><p>Caf` + "\xe9</p>"),
+			`<meta charset="UTF-8" /><p>Café</p>`},
+		{"Shift-JIS", append([]byte("p { content: '"), append(shiftJIS, "'; }"...)...), "p { content: '日本'; }"},
+		{"Shift-JIS declaration", append([]byte(`@charset "sjis"; p::before { content: "`), append(shiftJIS, `"; }`...)...),
+			`@charset "UTF-8"; p::before { content: "日本"; }`},
+	}
+	for _, test := range tests {
+		got, err := DecodeText(test.data)
+		if err != nil {
+			t.Errorf("Unexpected error decoding %s text: %s", test.name, err)
+		}
+		if got != test.expected {
+			t.Errorf("Decoded %s text doesn't match\nGot: %q\nExpected: %q", test.name, got, test.expected)
+		}
+	}
+
+	if _, err := DecodeText([]byte(`<?xml version="1.0" encoding="EUC-KR"?><p>` + "\xc7\xd1</p>")); err == nil {
+		t.Error("Expected error decoding text without a registered decoder")
+	}
+}
+
+func TestDecodeTextFromDir(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	for _, folder := range []string{dirChaptersFolderName, dirStylesFolderName} {
+		os.Mkdir(filepath.Join(dirPath, folder), dirPermissions)
+	}
+	for path, content := range map[string][]byte{
+		"chapters/01-chapter.html": []byte("<html><head><meta charset=\"windows-1252\" /></head><body><h1>Caf\xe9</h1></body></html>"),
+		"styles/style.css":         append([]byte{0xff, 0xfe}, utf16LE(`p::before { content: "é"; }`)...),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dirPath, filepath.FromSlash(path)), content, filePermissions); err != nil {
+			t.Fatalf("Unexpected error writing %s: %s", path, err)
+		}
+	}
+
+	e, err := FromDir(dirPath)
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB from directory: %s", err)
+	}
+	if e.sections[0].xhtml.Title() != "Café" {
+		t.Errorf("Section title doesn't match\nGot: %s\nExpected: %s", e.sections[0].xhtml.Title(), "Café")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	section, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "01-chapter.xhtml"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section: %s", err)
+	}
+	if !strings.Contains(string(section), "<h1>Café</h1>") {
+		t.Errorf("Section doesn't match\nGot: %s\nExpected to contain: %s", section, "<h1>Café</h1>")
+	}
+	css, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, "style.css"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSS file: %s", err)
+	}
+	if string(css) != `p::before { content: "é"; }` {
+		t.Errorf("CSS file doesn't match\nGot: %q\nExpected: %q", css, `p::before { content: "é"; }`)
+	}
+}
+
+// Encode ASCII and Latin-1 text in UTF-16
+func utf16BE(s string) []byte {
+	b := []byte{}
+	for _, r := range s {
+		b = append(b, byte(r>>8), byte(r))
+	}
+	return b
+}
+
+func utf16LE(s string) []byte {
+	b := []byte{}
+	for _, r := range s {
+		b = append(b, byte(r), byte(r>>8))
+	}
+	return b
+}
@@ -26,22 +26,20 @@ Basic usage:
 package epub
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
-
-	// TODO: Eventually this should include the major version (e.g. github.com/gofrs/uuid/v3) but that would break
-	// compatibility with Go < 1.9 (https://github.com/golang/go/wiki/Modules#semantic-import-versioning)
-	"github.com/gofrs/uuid"
+	"time"
 )
 
-// FilenameAlreadyUsedError is thrown by AddCSS, AddFont, AddImage, or AddSection
-// if the same filename is used more than once.
+// FilenameAlreadyUsedError is thrown by AddAudio, AddCSS, AddFont, AddImage, or
+// AddSection if the same filename is used more than once.
 type FilenameAlreadyUsedError struct {
 	Filename string // Filename that caused the error
 }
@@ -50,8 +48,9 @@ func (e *FilenameAlreadyUsedError) Error() string {
 	return fmt.Sprintf("Filename already used: %s", e.Filename)
 }
 
-// FileRetrievalError is thrown by AddCSS, AddFont, AddImage, or Write if there was a
-// problem retrieving the source file that was provided.
+// FileRetrievalError is thrown by AddAudio, AddCSS, AddFont, AddImage,
+// AddImageFromReader, or Write if there was a problem retrieving the source
+// file that was provided.
 type FileRetrievalError struct {
 	Source string // The source of the file whose retrieval failed
 	Err    error  // The underlying error that was thrown
@@ -61,14 +60,59 @@ func (e *FileRetrievalError) Error() string {
 	return fmt.Sprintf("Error retrieving %q from source: %+v", e.Source, e.Err)
 }
 
+// FilenameNotFoundError is thrown by AddAudiobookChapter, AddVideoTrack,
+// ExtractVideoPoster, SetSectionLinear, SetSpineItemProperties, SetVideoPoster,
+// or VideoElement if the provided filename doesn't match any file that has
+// been added to the EPUB.
+type FilenameNotFoundError struct {
+	Filename string // Filename that caused the error
+}
+
+func (e *FilenameNotFoundError) Error() string {
+	return fmt.Sprintf("Filename not found: %s", e.Filename)
+}
+
+// UnsupportedVersionError is thrown by SetVersion if the EPUB version isn't
+// supported.
+type UnsupportedVersionError struct {
+	Version string // Version that caused the error
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("Unsupported EPUB version: %s", e.Version)
+}
+
+// UnknownPropertyError is thrown by SetSpineItemProperties and SetAccessibility
+// in strict mode if a property isn't part of a known vocabulary.
+type UnknownPropertyError struct {
+	Property string // Property that caused the error
+}
+
+func (e *UnknownPropertyError) Error() string {
+	return fmt.Sprintf("Unknown property: %s", e.Property)
+}
+
+// EPUB versions that can be written
+const (
+	// EPUB 2.0.1 (OPF 2.0, NCX, XHTML 1.1, no navigation document), for
+	// distributors that still require it
+	EPUBVersion2 = "2.0"
+	// EPUB 3, which also includes an NCX for EPUB 2 reading systems
+	EPUBVersion3 = "3.0"
+)
+
 // Folder names used for resources inside the EPUB
 const (
-	CSSFolderName   = "css"
-	FontFolderName  = "fonts"
-	ImageFolderName = "images"
+	AudioFolderName   = "audio"
+	CSSFolderName     = "css"
+	FontFolderName    = "fonts"
+	ForeignFolderName = "foreign"
+	ImageFolderName   = "images"
+	VideoFolderName   = "video"
 )
 
 const (
+	audioFileFormat        = "audio%04d%s"
 	cssFileFormat          = "css%04d%s"
 	defaultCoverBody       = `<img src="%s" alt="Cover Image" />`
 	defaultCoverCSSContent = `body {
@@ -92,32 +136,192 @@ img {
 	fontFileFormat            = "font%04d%s"
 	imageFileFormat           = "image%04d%s"
 	sectionFileFormat         = "section%04d.xhtml"
+	trackFileFormat           = "track%04d%s"
+	videoFileFormat           = "video%04d%s"
 	urnUUIDPrefix             = "urn:uuid:"
 )
 
 // Epub implements an EPUB file.
 type Epub struct {
-	author string
+	// The key is an alias set using SetAlias, the value is the path of its file
+	// relative to the content folder
+	aliases map[string]string
+	// Whether an image is used as the cover if none is set, and the section
+	// it's taken from
+	autoCover        bool
+	autoCoverSection string
+	// The key is the audio filename, the value is the audio source
+	audio map[string]string
+	// Audiobook chapters, in reading order
+	audiobookChapters []audiobookChapter
+	author            string
+	// Authors other than the first one, added using AddAuthor
+	authors []string
+	// Appended to the sections when the EPUB is written
+	backMatter     *BackMatter
+	backMatterData interface{}
+	// Report of the last EPUB written
+	buildReport *BuildReport
+	// Entries of the changelog, in the order they were added
+	changelog []ChangelogEntry
+	// Used to retrieve remote files if set
+	client *http.Client
 	cover  *epubCover
+	// Formats the dates shown in generated pages if set
+	dateFormatter DateFormatter
+	// Text direction of the generated content
+	direction string
+	// Closed by Discard
+	discard *discardState
+	// Number of media files retrieved at the same time when the EPUB is
+	// written
+	fetchConcurrency int
+	// Size in bytes of the files prepared by the write in progress, and its
+	// limit (0 if there is none)
+	diskUsage    int64
+	maxDiskUsage int64
+	// Warnings of the write in progress, other than CSS warnings
+	buildWarnings []string
 	// The key is the css filename, the value is the css source
 	css map[string]string
+	// Whether print-oriented CSS is converted, and the warnings of the write
+	// in progress
+	convertPrintCSS bool
+	cssWarnings     []CSSWarning
+	// Default font, applied using the default stylesheet
+	defaultFont *epubDefaultFont
+	// Edition, e.g. Second edition
+	edition string
+	// How emoji are handled when the EPUB is written
+	emojiFallback *epubEmojiFallback
+	// Whether remote files are retrieved when they're added
+	eagerFetch bool
+	// Filename of the page added by CollectEndnotes, empty if there is none
+	endnotesFilename string
+	// How embedded content of added sections is handled
+	embedPolicy           EmbedPolicy
+	embedScreenshotSource EmbedScreenshotSource
+	// CSS rules applying font features, added to the default stylesheet
+	fontFeatureCSS []string
+	// Notes added using AddFootnote, in the order they were added
+	footnotes []epubFootnote
 	// The key is the font filename, the value is the font source
 	fonts      map[string]string
 	identifier string
+	// Identifiers other than the unique identifier
+	identifiers []Identifier
+	// Whether the identifier was randomly generated by NewEpub
+	identifierGenerated bool
+	// Whether the entry of the unique identifier in identifiers was added by
+	// SetUniqueIdentifier rather than AddIdentifier
+	uniqueIdentifierAdded bool
+	// Used to generate the identifier if set
+	identifierStrategy IdentifierStrategy
+	// Context of the write in progress, nil otherwise
+	ctx context.Context
+	// Used to generate random identifiers if set
+	idGenerator func() string
 	// The key is the image filename, the value is the image source
 	images map[string]string
+	// The key is the source of an image as it was added (see imageSourceKey),
+	// the value is the filename of the first image added from it
+	imageSources map[string]string
+	// Overrides of the labels of generated content
+	labels map[Label]string
 	// Language
 	lang string
+	// URL of the license set using SetCreativeCommons
+	licenseURL string
+	// Number of maps added using AddMap
+	mapCount int
+	// The modification date set using SetModified, zero if not set
+	modified time.Time
+	// Contributors other than the authors, e.g. illustrators
+	contributors []Contributor
 	// Description
 	desc string
+	// Publication date, zero if not set
+	pubDate time.Time
+	// Date from which the EPUB may be sold, zero if not set
+	onSaleDate time.Time
+	// Publisher
+	publisher string
+	// Whether fonts are obfuscated when the EPUB is written
+	obfuscateFonts bool
+	// Page breaks added using AddPageBreak, in the order they were added
+	pageBreaks []epubPageBreak
+	// The places of the maps listed by the place index page
+	placeIndex []placeIndexEntry
 	// Page progression direction
 	ppd string
+	// Related works
+	relations []Relation
+	// Restrictions applied to untrusted content, if any
+	sandbox *SandboxOptions
+	// Whether the EPUB is written reproducibly
+	reproducible bool
+	// Rights statement
+	rights string
+	// Version of the publication, e.g. 1.0.1
+	publicationVersion string
 	// The package file (package.opf)
 	pkg      *pkg
 	sections []epubSection
-	title    string
+	// Series set using SetSeries
+	series         string
+	seriesPosition float64
+	// The market the EPUB is published in, used to select back matter variants
+	market string
+	// Gets a new URL for remote sources that can't be retrieved
+	sourceRefresher SourceRefresher
+	// Maximum size of the EPUB in bytes, 0 if there is none
+	sizeBudget int64
+	// Whether ResetCSS is added to the default stylesheet
+	resetCSS bool
+	// Whether to reject values that aren't part of a known vocabulary
+	strict bool
+	// Whether the tracking is removed from the sections when the EPUB is
+	// written
+	stripTracking bool
+	subtitle      string
+	// How the text is normalized when the EPUB is written
+	textNormalization TextNormalization
+	title             string
+	// The first error of the options passed to NewEpub
+	optionErr error
+	// The settings of the distributor WriteFor is writing the EPUB for
+	target *targetPreset
+	// Directory in which temp files are created, os.TempDir if empty
+	tempDir string
+	// Transforms applied to the files while the EPUB is being written
+	resourceTransforms map[string]*resourceTransform
 	// Table of contents
 	toc *toc
+	// Entries of the table of contents pointing to fragments of sections, in
+	// the order they were added
+	tocEntries []tocEntry
+	// In the order they were set
+	landmarks []landmark
+	// The key is the path of a file to transcode relative to the package file,
+	// the value is the extension of its source
+	transcodedMedia map[string]string
+	transcoder      MediaTranscoder
+	// Whether the EPUB is validated when it's written
+	validateOnWrite bool
+	// Whether media copies are compared with their sources using a hash
+	verifyCopies bool
+	// EPUB version to write
+	version string
+	// The key is the filename of a section with a media overlay
+	mediaOverlays map[string]*epubMediaOverlay
+	// Order of the files in the EPUB
+	zipOrder ZipOrder
+	// The key is the video or track filename, the value is the source
+	videos map[string]string
+	// The key is the filename of a foreign resource
+	foreign map[string]*epubForeign
+	// The key is the video filename
+	videoInfo map[string]*epubVideo
 }
 
 type epubCover struct {
@@ -125,15 +329,56 @@ type epubCover struct {
 	cssTempFile   string
 	imageFilename string
 	xhtmlFilename string
+	// Whether the cover was set by SetAutoCover while writing
+	auto bool
 }
 
 type epubSection struct {
 	filename string
-	xhtml    *xhtml
+	// The filename of the section this section is nested under, if any
+	parent string
+	// Whether the section is excluded from the linear reading order
+	nonLinear bool
+	// Whether the section is excluded from the table of contents
+	hiddenFromTOC bool
+	// Properties of the spine itemref, e.g. page-spread-left
+	properties []string
+	// Template used to render the body when the EPUB is written, if any
+	template *sectionTemplate
+	// Generates the section when the EPUB is written, if any
+	generator *sectionGenerator
+	// Function used to stream the body when the EPUB is written, if any
+	writer SectionWriter
+	xhtml  *xhtml
 }
 
-// NewEpub returns a new Epub.
-func NewEpub(title string) *Epub {
+// Properties allowed on spine itemrefs
+// Spec: http://www.idpf.org/epub/301/spec/epub-publications.html#sec-itemref-property-values
+// Spec: http://www.idpf.org/epub/fxl/#property-defs
+var knownSpineItemProperties = map[string]bool{
+	"page-spread-left":                   true,
+	"page-spread-right":                  true,
+	"rendition:align-x-center":           true,
+	"rendition:flow-auto":                true,
+	"rendition:flow-paginated":           true,
+	"rendition:flow-scrolled-continuous": true,
+	"rendition:flow-scrolled-doc":        true,
+	"rendition:layout-pre-paginated":     true,
+	"rendition:layout-reflowable":        true,
+	"rendition:orientation-auto":         true,
+	"rendition:orientation-landscape":    true,
+	"rendition:orientation-portrait":     true,
+	"rendition:page-spread-center":       true,
+	"rendition:spread-auto":              true,
+	"rendition:spread-both":              true,
+	"rendition:spread-landscape":         true,
+	"rendition:spread-none":              true,
+	"rendition:spread-portrait":          true,
+}
+
+// NewEpub returns a new Epub, configured with the provided options if any (see
+// Option).
+func NewEpub(title string, opts ...Option) *Epub {
 	e := &Epub{}
 	e.cover = &epubCover{
 		cssFilename:   "",
@@ -141,62 +386,151 @@ func NewEpub(title string) *Epub {
 		imageFilename: "",
 		xhtmlFilename: "",
 	}
+	e.aliases = make(map[string]string)
+	e.audio = make(map[string]string)
 	e.css = make(map[string]string)
 	e.fonts = make(map[string]string)
 	e.images = make(map[string]string)
+	e.imageSources = make(map[string]string)
+	e.transcodedMedia = make(map[string]string)
+	e.videos = make(map[string]string)
+	e.foreign = make(map[string]*epubForeign)
+	e.videoInfo = make(map[string]*epubVideo)
+	e.discard = newDiscardState()
 	e.pkg = newPackage()
 	e.toc = newToc()
 	// Set minimal required attributes
-	e.SetIdentifier(urnUUIDPrefix + uuid.Must(uuid.NewV4()).String())
+	e.SetIdentifier(RandomIdentifier(e))
+	e.identifierGenerated = true
 	e.SetLang(defaultEpubLang)
 	e.SetTitle(title)
+	e.version = EPUBVersion3
+	e.applyOptions(opts)
 
 	return e
 }
 
+// AddAudio adds an audio file to the EPUB and returns a relative path to the
+// audio file that can be used in EPUB sections in the format:
+// ../AudioFolderName/internalFilename
+//
+// The audio source should either be a URL, a data URL, or a path to a local
+// file; in any case, the audio file will be retrieved and stored in the EPUB.
+// It's transcoded if needed by the transcoder set using SetMediaTranscoder.
+//
+// The internal filename will be used when storing the audio file in the EPUB
+// and must be unique among all audio files. If the same filename is used more
+// than once, FilenameAlreadyUsedError will be returned. The internal filename is
+// optional; if no filename is provided, one will be generated.
+func (e *Epub) AddAudio(source string, internalFilename string) (string, error) {
+	return e.addTranscodedMedia(source, internalFilename, audioFileFormat, AudioFolderName, e.audio)
+}
+
 // AddCSS adds a CSS file to the EPUB and returns a relative path to the CSS
 // file that can be used in EPUB sections in the format:
 // ../CSSFolderName/internalFilename
 //
-// The CSS source should either be a URL or a path to a local file; in either
-// case, the CSS file will be retrieved and stored in the EPUB.
+// The CSS source should either be a URL, a data URL, or a path to a local
+// file; in any case, the CSS file will be retrieved and stored in the EPUB.
+// CSS files that aren't in UTF-8 are transcoded when the EPUB is written (see
+// DecodeText).
 //
 // The internal filename will be used when storing the CSS file in the EPUB
 // and must be unique among all CSS files. If the same filename is used more
 // than once, FilenameAlreadyUsedError will be returned. The internal filename is
 // optional; if no filename is provided, one will be generated.
 func (e *Epub) AddCSS(source string, internalFilename string) (string, error) {
-	return addMedia(source, internalFilename, cssFileFormat, CSSFolderName, e.css)
+	return e.addMedia(source, internalFilename, cssFileFormat, CSSFolderName, e.css)
 }
 
 // AddFont adds a font file to the EPUB and returns a relative path to the font
 // file that can be used in EPUB sections in the format:
 // ../FontFolderName/internalFilename
 //
-// The font source should either be a URL or a path to a local file; in either
-// case, the font file will be retrieved and stored in the EPUB.
+// The font source should either be a URL, a data URL, or a path to a local
+// file; in any case, the font file will be retrieved and stored in the EPUB.
 //
 // The internal filename will be used when storing the font file in the EPUB
 // and must be unique among all font files. If the same filename is used more
 // than once, FilenameAlreadyUsedError will be returned. The internal filename is
 // optional; if no filename is provided, one will be generated.
+//
+// TrueType, OpenType, WOFF and WOFF2 fonts are supported; see
+// SetFontObfuscation to obfuscate them.
 func (e *Epub) AddFont(source string, internalFilename string) (string, error) {
-	return addMedia(source, internalFilename, fontFileFormat, FontFolderName, e.fonts)
+	return e.addMedia(source, internalFilename, fontFileFormat, FontFolderName, e.fonts)
 }
 
 // AddImage adds an image to the EPUB and returns a relative path to the image
 // file that can be used in EPUB sections in the format:
 // ../ImageFolderName/internalFilename
 //
-// The image source should either be a URL or a path to a local file; in either
-// case, the image file will be retrieved and stored in the EPUB.
+// The image source should either be a URL, a data URL, or a path to a local
+// file; in any case, the image file will be retrieved and stored in the EPUB.
 //
 // The internal filename will be used when storing the image file in the EPUB
 // and must be unique among all image files. If the same filename is used more
 // than once, FilenameAlreadyUsedError will be returned. The internal filename is
 // optional; if no filename is provided, one will be generated.
 func (e *Epub) AddImage(source string, imageFilename string) (string, error) {
-	return addMedia(source, imageFilename, imageFileFormat, ImageFolderName, e.images)
+	imagePath, err := e.addMedia(source, imageFilename, imageFileFormat, ImageFolderName, e.images)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := e.imageSources[imageSourceKey(source)]; !ok {
+		e.imageSources[imageSourceKey(source)] = filepath.Base(imagePath)
+	}
+
+	return imagePath, nil
+}
+
+// AddImageOnce adds an image to the EPUB as AddImage does, unless an image was
+// already added from the exact same source (e.g. the same path or URL), in
+// which case the path to the existing image is returned and the internal
+// filename is ignored. This allows content generators to add the images they
+// use without keeping track of them.
+func (e *Epub) AddImageOnce(source string, imageFilename string) (string, error) {
+	if filename, ok := e.imageSources[imageSourceKey(source)]; ok {
+		// The image may have been replaced, e.g. by SetCover
+		if _, ok := e.images[filename]; ok {
+			return filepath.Join("..", ImageFolderName, filename), nil
+		}
+		delete(e.imageSources, imageSourceKey(source))
+	}
+
+	return e.AddImage(source, imageFilename)
+}
+
+// AddImageFromBytes adds an image to the EPUB from its contents, e.g. an image
+// stored in a database, and returns a relative path to the image file that can
+// be used from a section. The media type of the image is detected from its
+// contents; GIF, JPEG, PNG and SVG images are supported.
+//
+// The internal filename works the same way as for AddImage. If it isn't
+// provided, the extension of the generated filename matches the detected media
+// type.
+func (e *Epub) AddImageFromBytes(data []byte, imageFilename string) (string, error) {
+	return e.AddImage(newDataURL(sniffImageMediaType(data), data), imageFilename)
+}
+
+// AddImageFromReader adds an image to the EPUB from a reader, which is read
+// until EOF, as AddImageFromBytes does. If the reader returns an error,
+// FileRetrievalError will be returned.
+func (e *Epub) AddImageFromReader(r io.Reader, imageFilename string) (string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", &FileRetrievalError{Source: imageFilename, Err: err}
+	}
+	return e.AddImageFromBytes(data, imageFilename)
+}
+
+// Get the key of an image source in imageSources. Data URLs are replaced by
+// their digest, as they can be large.
+func imageSourceKey(source string) string {
+	if strings.HasPrefix(source, dataURLPrefix) {
+		return fmt.Sprintf("%s%x", dataURLPrefix, sha256.Sum256([]byte(source)))
+	}
+	return source
 }
 
 // AddSection adds a new section (chapter, etc) to the EPUB and returns a
@@ -209,7 +543,8 @@ func (e *Epub) AddImage(source string, imageFilename string) (string, error) {
 // The title will be used for the table of contents. The section will be shown
 // in the table of contents in the same order it was added to the EPUB. The
 // title is optional; if no title is provided, the section will not be added to
-// the table of contents.
+// the table of contents. Sections with a title can be excluded from the table
+// of contents using SetSectionInTOC.
 //
 // The internal filename will be used when storing the section file in the EPUB
 // and must be unique among all section files. If the same filename is used more
@@ -218,10 +553,19 @@ func (e *Epub) AddImage(source string, imageFilename string) (string, error) {
 //
 // The internal path to an already-added CSS file (as returned by AddCSS) to be
 // used for the section is optional.
+//
+// Embedded content (<iframe> and <embed> elements) is handled according to
+// the policy set using SetEmbedPolicy.
 func (e *Epub) AddSection(body string, sectionTitle string, internalFilename string, internalCSSPath string) (string, error) {
 	// Generate a filename if one isn't provided
 	if internalFilename == "" {
-		internalFilename = fmt.Sprintf(sectionFileFormat, len(e.sections)+1)
+		// Sections may have been removed, see RemoveSection
+		for n := len(e.sections) + 1; internalFilename == "" || e.sectionIndex(internalFilename) != -1; n++ {
+			internalFilename = fmt.Sprintf(sectionFileFormat, n)
+		}
+	}
+	if err := validateFilename(internalFilename); err != nil {
+		return "", err
 	}
 
 	for _, section := range e.sections {
@@ -230,6 +574,18 @@ func (e *Epub) AddSection(body string, sectionTitle string, internalFilename str
 		}
 	}
 
+	if e.sandbox != nil {
+		var err error
+		if body, err = e.sandboxSection(body); err != nil {
+			return "", err
+		}
+	}
+
+	body, inlined, err := e.applyEmbedPolicy(body, e.embedPolicy, len(e.sections)+1)
+	if err != nil {
+		return "", err
+	}
+
 	x := newXhtml(body)
 	x.setTitle(sectionTitle)
 
@@ -243,9 +599,46 @@ func (e *Epub) AddSection(body string, sectionTitle string, internalFilename str
 	}
 	e.sections = append(e.sections, s)
 
+	// Add the embedded documents after the section that embeds them
+	for _, embed := range inlined {
+		x := newXhtml(embed.body)
+		x.setTitle(embed.title)
+		if internalCSSPath != "" {
+			x.setCSS(internalCSSPath)
+		}
+		e.sections = append(e.sections, epubSection{
+			filename: embed.filename,
+			xhtml:    x,
+		})
+	}
+
 	return internalFilename, nil
 }
 
+// AddVideo adds a video file to the EPUB and returns a relative path to the
+// video file that can be used in EPUB sections in the format:
+// ../VideoFolderName/internalFilename
+//
+// The video source should either be a URL, a data URL, or a path to a local
+// file; in any case, the video file will be retrieved and stored in the EPUB.
+// It's transcoded if needed by the transcoder set using SetMediaTranscoder.
+//
+// The internal filename will be used when storing the video file in the EPUB
+// and must be unique among all video and track files. If the same filename is
+// used more than once, FilenameAlreadyUsedError will be returned. The internal
+// filename is optional; if no filename is provided, one will be generated.
+//
+// See VideoElement for the markup to use to embed the video in a section.
+func (e *Epub) AddVideo(source string, internalFilename string) (string, error) {
+	videoPath, err := e.addTranscodedMedia(source, internalFilename, videoFileFormat, VideoFolderName, e.videos)
+	if err != nil {
+		return "", err
+	}
+	e.videoInfo[filepath.Base(videoPath)] = &epubVideo{}
+
+	return videoPath, nil
+}
+
 // Author returns the author of the EPUB.
 func (e *Epub) Author() string {
 	return e.author
@@ -253,6 +646,7 @@ func (e *Epub) Author() string {
 
 // Identifier returns the unique identifier of the EPUB.
 func (e *Epub) Identifier() string {
+	e.resolveIdentifier()
 	return e.identifier
 }
 
@@ -266,6 +660,17 @@ func (e *Epub) Description() string {
 	return e.desc
 }
 
+// Publisher returns the publisher of the EPUB.
+func (e *Epub) Publisher() string {
+	return e.publisher
+}
+
+// PubDate returns the publication date of the EPUB, or the zero time if it
+// isn't set.
+func (e *Epub) PubDate() time.Time {
+	return e.pubDate
+}
+
 // Ppd returns the page progression direction of the EPUB.
 func (e *Epub) Ppd() string {
 	return e.ppd
@@ -286,6 +691,11 @@ func (e *Epub) SetAuthor(author string) {
 // The internal path to an already-added CSS file (as returned by AddCSS) to be
 // used for the cover is optional. If the CSS path isn't provided, default CSS
 // will be used.
+//
+// The image is identified as the cover in the package file, using both the
+// cover-image property and the EPUB 2 cover meta element. The cover page comes
+// first in the reading order, even if sections were added before, and isn't
+// added to the TOC.
 func (e *Epub) SetCover(internalImagePath string, internalCSSPath string) {
 	// If a cover already exists
 	if e.cover.xhtmlFilename != "" {
@@ -312,24 +722,26 @@ func (e *Epub) SetCover(internalImagePath string, internalCSSPath string) {
 
 	// Use default cover stylesheet if one isn't provided
 	if internalCSSPath == "" {
-		// Create a temporary file to hold the default cover CSS
-		tempFile, err := ioutil.TempFile("", tempDirPrefix)
-		if err != nil {
-			panic(fmt.Sprintf("Error creating temp file: %s", err))
-		}
-		defer func() {
-			if err := tempFile.Close(); err != nil {
-				panic(fmt.Sprintf("Error closing temp file: %s", err))
-			}
-		}()
-		e.cover.cssTempFile = tempFile.Name()
+		// Create a temporary file to hold the default cover CSS, or keep it in
+		// memory if the temp directory can't be used
+		source := newDataURL(mediaTypeCSS, []byte(defaultCoverCSSContent))
+		tempFile, err := e.newTempFile()
+		if err == nil {
+			defer func() {
+				if err := tempFile.Close(); err != nil {
+					panic(fmt.Sprintf("Error closing temp file: %s", err))
+				}
+			}()
+			e.cover.cssTempFile = tempFile.Name()
+			source = e.cover.cssTempFile
 
-		// Write the default cover CSS to the temp file
-		if _, err = tempFile.WriteString(defaultCoverCSSContent); err != nil {
-			panic(fmt.Sprintf("Error writing CSS file: %s", err))
+			// Write the default cover CSS to the temp file
+			if _, err = tempFile.WriteString(defaultCoverCSSContent); err != nil {
+				panic(fmt.Sprintf("Error writing CSS file: %s", err))
+			}
 		}
 
-		internalCSSPath, err = e.AddCSS(e.cover.cssTempFile, defaultCoverCSSFilename)
+		internalCSSPath, err = e.AddCSS(source, defaultCoverCSSFilename)
 		// If that doesn't work, generate a filename
 		if _, ok := err.(*FilenameAlreadyUsedError); ok {
 			coverCSSFilename := fmt.Sprintf(
@@ -338,7 +750,7 @@ func (e *Epub) SetCover(internalImagePath string, internalCSSPath string) {
 				".css",
 			)
 
-			internalCSSPath, err = e.AddCSS(e.cover.cssTempFile, coverCSSFilename)
+			internalCSSPath, err = e.AddCSS(source, coverCSSFilename)
 			if _, ok := err.(*FilenameAlreadyUsedError); ok {
 				// This shouldn't cause an error
 				panic(fmt.Sprintf("Error adding default cover CSS file: %s", err))
@@ -369,8 +781,14 @@ func (e *Epub) SetCover(internalImagePath string, internalCSSPath string) {
 
 // SetIdentifier sets the unique identifier of the EPUB, such as a UUID, DOI,
 // ISBN or ISSN. If no identifier is set, a UUID will be automatically
-// generated.
+// generated. See SetUniqueIdentifier to also set the scheme of the identifier.
 func (e *Epub) SetIdentifier(identifier string) {
+	e.identifierGenerated = false
+	e.identifierStrategy = nil
+	e.setIdentifier(identifier)
+}
+
+func (e *Epub) setIdentifier(identifier string) {
 	e.identifier = identifier
 	e.pkg.setIdentifier(identifier)
 	e.toc.setIdentifier(identifier)
@@ -388,12 +806,110 @@ func (e *Epub) SetDescription(desc string) {
 	e.pkg.setDescription(desc)
 }
 
+// SetPublisher sets the publisher of the EPUB (dc:publisher).
+func (e *Epub) SetPublisher(publisher string) {
+	e.publisher = publisher
+	e.pkg.setPublisher(publisher)
+}
+
+// SetPubDate sets the publication date of the EPUB (dc:date), which is
+// formatted as in W3CDTF: only the date is written if it has no time of day,
+// e.g. 2024-01-15, or else the date-time in UTC. Passing the zero time removes
+// it. Implausible dates are reported when the EPUB is written (see
+// ImplausibleDateError).
+func (e *Epub) SetPubDate(date time.Time) {
+	e.pubDate = date
+	if date.IsZero() {
+		e.pkg.setDate("")
+		return
+	}
+	e.pkg.setDate(formatW3CDate(date))
+}
+
 // SetPpd sets the page progression direction of the EPUB.
 func (e *Epub) SetPpd(direction string) {
 	e.ppd = direction
 	e.pkg.setPpd(direction)
 }
 
+// SetSectionLinear sets whether the section with the provided internal
+// filename is part of the linear reading order. Sections are linear by
+// default; non-linear sections (such as answer keys or notes) are still in the
+// spine but reading systems may skip them when paging through the EPUB.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetSectionLinear(filename string, linear bool) error {
+	for i, section := range e.sections {
+		if section.filename == filename {
+			e.sections[i].nonLinear = !linear
+			return nil
+		}
+	}
+
+	return &FilenameNotFoundError{Filename: filename}
+}
+
+// SetSectionInTOC sets whether the section with the provided internal filename
+// is listed in the table of contents (both the EPUB 3 navigation document and
+// the EPUB 2 NCX) and on the page added by GenerateTOCPage. Sections with a
+// title are listed by default; sections excluded from the table of contents
+// (such as a dedication or ads) are still part of the reading order. Their
+// sub-sections are nested under the closest ancestor in the table of contents.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetSectionInTOC(filename string, inTOC bool) error {
+	for i, section := range e.sections {
+		if section.filename == filename {
+			e.sections[i].hiddenFromTOC = !inTOC
+			return nil
+		}
+	}
+
+	return &FilenameNotFoundError{Filename: filename}
+}
+
+// SetSpineItemProperties sets the properties of the spine item (itemref) of
+// the section with the provided internal filename, replacing any properties
+// previously set. This can be used for properties such as page-spread-left or
+// rendition:layout-pre-paginated as well as custom vendor properties.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned. In strict mode, properties that
+// aren't part of a known vocabulary are rejected with UnknownPropertyError.
+func (e *Epub) SetSpineItemProperties(filename string, properties ...string) error {
+	if e.strict {
+		for _, property := range properties {
+			if !knownSpineItemProperties[property] {
+				return &UnknownPropertyError{Property: property}
+			}
+		}
+	}
+
+	for i, section := range e.sections {
+		if section.filename == filename {
+			e.sections[i].properties = append([]string{}, properties...)
+			return nil
+		}
+	}
+
+	return &FilenameNotFoundError{Filename: filename}
+}
+
+// SetStrict enables or disables strict mode. In strict mode, values that
+// aren't part of a known vocabulary (such as spine item properties) are
+// rejected instead of being written to the EPUB as-is, and invalid ISBNs are
+// rejected when the EPUB is written.
+func (e *Epub) SetStrict(strict bool) {
+	e.strict = strict
+}
+
+// Strict returns whether strict mode is enabled.
+func (e *Epub) Strict() bool {
+	return e.strict
+}
+
 // SetTitle sets the title of the EPUB.
 func (e *Epub) SetTitle(title string) {
 	e.title = title
@@ -401,15 +917,66 @@ func (e *Epub) SetTitle(title string) {
 	e.toc.setTitle(title)
 }
 
+// SetSubtitle sets the subtitle of the EPUB, which is written as a second
+// dc:title refined with the subtitle title type. An empty subtitle removes it.
+func (e *Epub) SetSubtitle(subtitle string) {
+	e.subtitle = subtitle
+	e.pkg.setSubtitle(subtitle)
+}
+
+// Subtitle returns the subtitle of the EPUB.
+func (e *Epub) Subtitle() string {
+	return e.subtitle
+}
+
 // Title returns the title of the EPUB.
 func (e *Epub) Title() string {
 	return e.title
 }
 
+// SetVersion sets the EPUB version to write, either EPUBVersion3 (the default)
+// or EPUBVersion2. EPUB 2 output is generated from the same content, but Write
+// will return IncompatibleVersionError if the EPUB uses features that EPUB 2
+// doesn't support, such as audio or fixed layout.
+//
+// If the version isn't supported, UnsupportedVersionError will be returned.
+func (e *Epub) SetVersion(version string) error {
+	if err := validateVersion(version); err != nil {
+		return err
+	}
+	e.version = version
+
+	return nil
+}
+
+func validateVersion(version string) error {
+	if version != EPUBVersion2 && version != EPUBVersion3 {
+		return &UnsupportedVersionError{Version: version}
+	}
+
+	return nil
+}
+
+// Version returns the EPUB version that will be written.
+func (e *Epub) Version() string {
+	return e.version
+}
+
+// Generate a filename for a media file that isn't used yet
+func generateMediaFilename(mediaFileFormat string, ext string, mediaMap map[string]string) string {
+	// Files may have been removed, see RemoveImage
+	for n := len(mediaMap) + 1; ; n++ {
+		filename := fmt.Sprintf(mediaFileFormat, n, ext)
+		if _, ok := mediaMap[filename]; !ok {
+			return filename
+		}
+	}
+}
+
 // Add a media file to the EPUB and return the path relative to the EPUB section
 // files
-func addMedia(source string, internalFilename string, mediaFileFormat string, mediaFolderName string, mediaMap map[string]string) (string, error) {
-	err := validateFileSource(source)
+func addMedia(client *http.Client, source string, internalFilename string, mediaFileFormat string, mediaFolderName string, mediaMap map[string]string) (string, error) {
+	err := validateFileSource(client, source)
 	if err != nil {
 		return "", &FileRetrievalError{
 			Source: source,
@@ -420,16 +987,16 @@ func addMedia(source string, internalFilename string, mediaFileFormat string, me
 	if internalFilename == "" {
 		// If a filename isn't provided, use the filename from the source
 		internalFilename = filepath.Base(source)
-		// If that's already used, try to generate a unique filename
-		if _, ok := mediaMap[internalFilename]; ok {
-			internalFilename = fmt.Sprintf(
-				mediaFileFormat,
-				len(mediaMap)+1,
-				strings.ToLower(filepath.Ext(source)),
-			)
+		// If that's already used, can't be used, or the source is a data URL,
+		// try to generate a unique filename
+		if _, ok := mediaMap[internalFilename]; ok || validateFilename(internalFilename) != nil || strings.HasPrefix(source, dataURLPrefix) {
+			internalFilename = generateMediaFilename(mediaFileFormat, mediaSourceExt(source), mediaMap)
 		}
 	}
 
+	if err := validateMediaFilename(internalFilename); err != nil {
+		return "", err
+	}
 	if _, ok := mediaMap[internalFilename]; ok {
 		return "", &FilenameAlreadyUsedError{Filename: internalFilename}
 	}
@@ -443,26 +1010,8 @@ func addMedia(source string, internalFilename string, mediaFileFormat string, me
 	), nil
 }
 
-func validateFileSource(source string) error {
-	u, err := url.Parse(source)
-	if err != nil {
-		return err
-	}
-
-	var r io.ReadCloser
-	var resp *http.Response
-	// If it's a URL
-	if u.Scheme == "http" || u.Scheme == "https" {
-		resp, err = http.Get(source)
-		if err != nil {
-			return err
-		}
-		r = resp.Body
-
-		// Otherwise, assume it's a local file
-	} else {
-		r, err = os.Open(source)
-	}
+func validateFileSource(client *http.Client, source string) error {
+	r, err := openMediaSource(context.Background(), client, source)
 	if err != nil {
 		return err
 	}
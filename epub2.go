@@ -0,0 +1,108 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Elements introduced by HTML5, which aren't valid XHTML 1.1
+var html5Elements = map[string]bool{
+	"article":    true,
+	"aside":      true,
+	"audio":      true,
+	"bdi":        true,
+	"canvas":     true,
+	"details":    true,
+	"dialog":     true,
+	"figcaption": true,
+	"figure":     true,
+	"footer":     true,
+	"header":     true,
+	"main":       true,
+	"mark":       true,
+	"meter":      true,
+	"nav":        true,
+	"output":     true,
+	"progress":   true,
+	"section":    true,
+	"source":     true,
+	"summary":    true,
+	"template":   true,
+	"time":       true,
+	"track":      true,
+	"video":      true,
+	"wbr":        true,
+}
+
+// Get the features of the EPUB that can't be expressed in EPUB 2
+func (e *Epub) epub2IncompatibleFeatures() []string {
+	features := []string{}
+
+	if len(e.audio) > 0 {
+		features = append(features, "audio files")
+	}
+	if len(e.videos) > 0 {
+		features = append(features, "video files")
+	}
+	if len(e.mediaOverlays) > 0 {
+		features = append(features, "media overlays")
+	}
+	if len(e.footnotes) > 0 {
+		features = append(features, "footnotes")
+	}
+	if e.ppd != "" {
+		features = append(features, "page progression direction")
+	}
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if strings.HasPrefix(m.Property, "rendition:") {
+			features = append(features, fmt.Sprintf("rendition metadata (%s)", m.Property))
+		}
+	}
+
+	for _, section := range e.sections {
+		if len(section.properties) > 0 {
+			features = append(features, fmt.Sprintf("spine item properties (%s)", section.filename))
+		}
+		for _, feature := range xhtml11IncompatibleFeatures(section.xhtml.xml.Body.XML) {
+			features = append(features, fmt.Sprintf("%s (%s)", feature, section.filename))
+		}
+	}
+
+	return features
+}
+
+// Get the HTML5 elements and EPUB 3 attributes used in a section body, which
+// aren't valid XHTML 1.1
+func xhtml11IncompatibleFeatures(body string) []string {
+	found := map[string]bool{}
+
+	d := newSectionDecoder(body)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			break
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if html5Elements[strings.ToLower(start.Name.Local)] {
+			found[fmt.Sprintf("<%s> element", strings.ToLower(start.Name.Local))] = true
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Space == "epub" || attr.Name.Space == xmlnsEpub {
+				found[fmt.Sprintf("epub:%s attribute", attr.Name.Local)] = true
+			}
+		}
+	}
+
+	features := []string{}
+	for feature := range found {
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+
+	return features
+}
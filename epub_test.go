@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -50,6 +51,7 @@ const (
 	testEpubPpd               = "rtl"
 	testEpubTitle             = "My title"
 	testEpubDescription       = "My description"
+	testEpubPublisher         = "Gopher Press"
 	testFontCSSFilename       = "font.css"
 	testFontCSSSource         = "testdata/font.css"
 	testFontFromFileSource    = "testdata/redacted-script-regular.ttf"
@@ -57,8 +59,11 @@ const (
 	testImageFromFileFilename = "testfromfile.png"
 	testImageFromFileSource   = "testdata/gophercolor16x16.png"
 	testImageFromURLSource    = "https://golang.org/doc/gopher/gophercolor16x16.png"
+	testItemrefTemplate       = `<itemref idref="%s" properties="%s"></itemref>`
 	testLangTemplate          = `<dc:language>%s</dc:language>`
 	testDescTemplate          = `<dc:description>%s</dc:description>`
+	testDateTemplate          = `<dc:date>%s</dc:date>`
+	testPublisherTemplate     = `<dc:publisher>%s</dc:publisher>`
 	testPpdTemplate           = `page-progression-direction="%s"`
 	testMimetypeContents      = "application/epub+zip"
 	testPkgContentTemplate    = `<?xml version="1.0" encoding="UTF-8"?>
@@ -145,6 +150,55 @@ func TestEpubWrite(t *testing.T) {
 	cleanup(testEpubFilename, tempDir)
 }
 
+func TestEpubWriteTo(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	buf := &bytes.Buffer{}
+	n, err := e.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Number of bytes written doesn't match\nGot: %d\nExpected: %d", n, buf.Len())
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	if z.File[0].Name != mimetypeFilename {
+		t.Errorf("First file of EPUB doesn't match\nGot: %s\nExpected: %s", z.File[0].Name, mimetypeFilename)
+	}
+
+	// Write is a wrapper of WriteTo
+	if err := e.Write(testEpubFilename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB file: %s", err)
+	}
+	defer os.Remove(testEpubFilename)
+	info, err := os.Stat(testEpubFilename)
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB file: %s", err)
+	}
+	if info.Size() != n {
+		t.Errorf("EPUB file size doesn't match\nGot: %d\nExpected: %d", info.Size(), n)
+	}
+
+	// Write errors are returned as-is
+	writeErr := errors.New("disconnected")
+	if _, err := e.WriteTo(failingWriter{err: writeErr}); err != writeErr {
+		t.Errorf("Error doesn't match\nGot: %v\nExpected: %v", err, writeErr)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (f failingWriter) Write(p []byte) (int, error) {
+	return 0, f.err
+}
+
 func TestAddCSS(t *testing.T) {
 	e := NewEpub(testEpubTitle)
 	testCSS1Path, err := e.AddCSS(testCoverCSSSource, testCoverCSSFilename)
@@ -291,6 +345,97 @@ func TestAddImage(t *testing.T) {
 	cleanup(testEpubFilename, tempDir)
 }
 
+func TestAddImageOnce(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	testImagePath, err := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	if err != nil {
+		t.Fatalf("Error adding image: %s", err)
+	}
+
+	for _, source := range []string{testImageFromFileSource, testImageFromFileSource} {
+		imagePath, err := e.AddImageOnce(source, "other.png")
+		if err != nil {
+			t.Errorf("Error adding image once: %s", err)
+		}
+		if imagePath != testImagePath {
+			t.Errorf("Image path doesn't match\nGot: %s\nExpected: %s", imagePath, testImagePath)
+		}
+	}
+	if len(e.images) != 1 {
+		t.Errorf("Number of images doesn't match\nGot: %d\nExpected: %d", len(e.images), 1)
+	}
+
+	// Data URLs are compared as a whole
+	dataURL := newDataURL("image/png", []byte("image"))
+	dataURLPath, _ := e.AddImageOnce(dataURL, "")
+	if imagePath, _ := e.AddImageOnce(dataURL, ""); imagePath != dataURLPath {
+		t.Errorf("Image path doesn't match\nGot: %s\nExpected: %s", imagePath, dataURLPath)
+	}
+	if imagePath, _ := e.AddImageOnce(newDataURL("image/png", []byte("other")), ""); imagePath == dataURLPath {
+		t.Errorf("Image from a different data URL wasn't added")
+	}
+
+	// An image that was removed is added again
+	delete(e.images, testImageFromFileFilename)
+	if _, err := e.AddImageOnce(testImageFromFileSource, ""); err != nil {
+		t.Errorf("Error adding image once: %s", err)
+	}
+	if len(e.images) != 3 {
+		t.Errorf("Number of images doesn't match\nGot: %d\nExpected: %d", len(e.images), 3)
+	}
+}
+
+func TestAddImageFromBytes(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+
+	e := NewEpub(testEpubTitle)
+	imagePath, err := e.AddImageFromBytes(image, "")
+	if err != nil {
+		t.Fatalf("Error adding image from bytes: %s", err)
+	}
+	if filepath.Ext(imagePath) != ".png" {
+		t.Errorf("Image path doesn't match\nGot: %s\nExpected extension: %s", imagePath, ".png")
+	}
+	svgPath, err := e.AddImageFromReader(strings.NewReader(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "")
+	if err != nil {
+		t.Fatalf("Error adding image from reader: %s", err)
+	}
+	if filepath.Ext(svgPath) != ".svg" {
+		t.Errorf("Image path doesn't match\nGot: %s\nExpected extension: %s", svgPath, ".svg")
+	}
+	_, err = e.AddImageFromReader(failingReader{}, "failing.png")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error adding image from failing reader\nGot: %v\nExpected: FileRetrievalError", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, filepath.Base(imagePath)))
+	if err != nil {
+		t.Fatalf("Unexpected error reading image file from EPUB: %s", err)
+	}
+	if !bytes.Equal(contents, image) {
+		t.Errorf("Image file contents don't match")
+	}
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	if !strings.Contains(string(pkg), `media-type="image/svg+xml"`) {
+		t.Errorf("Package file doesn't match\nGot: %s\nExpected SVG image", pkg)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
 func TestAddSection(t *testing.T) {
 	e := NewEpub(testEpubTitle)
 	testSection1Path, err := e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
@@ -403,6 +548,79 @@ func TestEpubLang(t *testing.T) {
 	cleanup(testEpubFilename, tempDir)
 }
 
+func TestEpubPublisher(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetPublisher(testEpubPublisher)
+
+	if e.Publisher() != testEpubPublisher {
+		t.Errorf(
+			"Publisher doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e.Publisher(),
+			testEpubPublisher)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+
+	testPublisherElement := fmt.Sprintf(testPublisherTemplate, testEpubPublisher)
+	if !strings.Contains(string(contents), testPublisherElement) {
+		t.Errorf(
+			"Publisher doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testPublisherElement)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestEpubPubDate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	date := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+	e.SetPubDate(date)
+
+	if !e.PubDate().Equal(date) {
+		t.Errorf(
+			"Publication date doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e.PubDate(),
+			date)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+
+	testDateElement := fmt.Sprintf(testDateTemplate, "2024-01-15")
+	if !strings.Contains(string(contents), testDateElement) {
+		t.Errorf(
+			"Publication date doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testDateElement)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	// The zero time removes the date
+	e.SetPubDate(time.Time{})
+	if e.pkg.xml.Metadata.Date != "" {
+		t.Errorf("Publication date wasn't removed\nGot: %s", e.pkg.xml.Metadata.Date)
+	}
+}
+
 func TestEpubPpd(t *testing.T) {
 	e := NewEpub(testEpubTitle)
 	e.SetPpd(testEpubPpd)
@@ -567,6 +785,7 @@ func TestEpubIdentifier(t *testing.T) {
 
 func TestSetCover(t *testing.T) {
 	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
 	testImagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
 	testCSSPath, _ := e.AddCSS(testCoverCSSSource, testCoverCSSFilename)
 	e.SetCover(testImagePath, testCSSPath)
@@ -588,9 +807,142 @@ func TestSetCover(t *testing.T) {
 			testCoverContents)
 	}
 
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		fmt.Sprintf(`<item id="%s" href="%s/%s" media-type="image/png" properties="cover-image"></item>`, testImageFromFileFilename, ImageFolderName, testImageFromFileFilename),
+		fmt.Sprintf(`<meta name="cover" content="%s"></meta>`, testImageFromFileFilename),
+		fmt.Sprintf(`<itemref idref="%s"></itemref>`+"\n"+`    <itemref idref="%s"></itemref>`, defaultCoverXhtmlFilename, testSectionFilename),
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("Package file contents don't match\nGot: %s\nExpected to contain: %s", contents, expected)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading nav file: %s", err)
+	}
+	if strings.Contains(string(contents), defaultCoverXhtmlFilename) {
+		t.Errorf("Cover page was added to the TOC\nGot: %s", contents)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestSetSpineItemProperties(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
+
+	err := e.SetSpineItemProperties(testSectionFilename, "page-spread-left", "vendor:custom")
+	if err != nil {
+		t.Errorf("Unexpected error setting spine item properties: %s", err)
+	}
+
+	err = e.SetSpineItemProperties("missing.xhtml", "page-spread-left")
+	if _, ok := err.(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error FilenameNotFoundError not returned. Returned instead: %+v", err)
+	}
+
+	e.SetStrict(true)
+	err = e.SetSpineItemProperties(testSectionFilename, "vendor:custom")
+	if _, ok := err.(*UnknownPropertyError); !ok {
+		t.Errorf("Expected error UnknownPropertyError not returned. Returned instead: %+v", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+
+	testItemrefElement := fmt.Sprintf(testItemrefTemplate, testSectionFilename, "page-spread-left vendor:custom")
+	if !strings.Contains(string(contents), testItemrefElement) {
+		t.Errorf(
+			"Spine item properties don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testItemrefElement)
+	}
+
 	cleanup(testEpubFilename, tempDir)
 }
 
+func TestSetVersion(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+	testImagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e.SetCover(testImagePath, "")
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
+
+	err := e.SetVersion("4.0")
+	if _, ok := err.(*UnsupportedVersionError); !ok {
+		t.Errorf("Expected error UnsupportedVersionError not returned. Returned instead: %+v", err)
+	}
+	err = e.SetVersion(EPUBVersion2)
+	if err != nil {
+		t.Errorf("Unexpected error setting version: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="pub-id" version="2.0">`,
+		`<dc:creator id="creator" opf:role="aut">` + testEpubAuthor + `</dc:creator>`,
+		`<meta name="cover" content="` + testImageFromFileFilename + `"></meta>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf(
+				"Package file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				expected)
+		}
+	}
+	for _, unexpected := range []string{"property=", "properties=", tocNavFilename} {
+		if strings.Contains(string(contents), unexpected) {
+			t.Errorf("Unexpected %q in EPUB 2 package file: %s", unexpected, contents)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, contentFolderName, tocNavFilename)); err == nil {
+		t.Errorf("Unexpected navigation document in EPUB 2")
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, testSectionFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	if !strings.Contains(string(contents), xhtml11Doctype) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			xhtml11Doctype)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	e.AddSection(`<section epub:type="chapter"><p>Text</p></section>`, "", "", "")
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*IncompatibleVersionError); !ok {
+		t.Errorf("Expected error IncompatibleVersionError not returned. Returned instead: %+v", err)
+	} else if len(err.(*IncompatibleVersionError).Features) != 2 {
+		t.Errorf("Unexpected incompatible features: %+v", err)
+	}
+	os.Remove(testEpubFilename)
+}
+
 func TestFilenameAlreadyUsedError(t *testing.T) {
 	e := NewEpub(testEpubTitle)
 
@@ -621,6 +973,18 @@ func TestUnableToCreateEpubError(t *testing.T) {
 	if _, ok := err.(*UnableToCreateEpubError); !ok {
 		t.Errorf("Expected error UnableToCreateEpubError not returned. Returned instead: %+v", err)
 	}
+
+	// The file isn't created if the EPUB can't be built
+	e.SetVersion(EPUBVersion2)
+	e.SetPpd("rtl")
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*IncompatibleVersionError); !ok {
+		t.Errorf("Expected error IncompatibleVersionError not returned. Returned instead: %+v", err)
+	}
+	if _, err := os.Stat(testEpubFilename); !os.IsNotExist(err) {
+		os.Remove(testEpubFilename)
+		t.Errorf("EPUB file was created although the EPUB couldn't be built")
+	}
 }
 
 func TestEpubValidity(t *testing.T) {
@@ -799,3 +1163,46 @@ func writeAndExtractEpub(t *testing.T, e *Epub, epubFilename string) string {
 
 	return tempDir
 }
+
+func TestSetSectionInTOC(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	dedication, _ := e.AddSection("<p>For Jane</p>", "Dedication", "dedication.xhtml", "")
+	e.AddSection("<p>One</p>", "Chapter 1", "one.xhtml", "")
+	e.AddSubSection(dedication, "<p>Note</p>", "Note", "note.xhtml", "")
+	if err := e.SetSectionInTOC(dedication, false); err != nil {
+		t.Fatalf("Unexpected error excluding section from TOC: %s", err)
+	}
+	if _, ok := e.SetSectionInTOC("missing.xhtml", false).(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected FilenameNotFoundError excluding missing section from TOC")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	nav, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading nav file: %s", err)
+	}
+	// The sub-section is moved to the top level
+	expectedNav := `<ol><li><a href="xhtml/note.xhtml">Note</a></li><li><a href="xhtml/one.xhtml">Chapter 1</a></li></ol>`
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(nav), "><"), expectedNav) {
+		t.Errorf("Nav file doesn't match\nGot: %s\nExpected to contain: %s", nav, expectedNav)
+	}
+	ncx, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading NCX file: %s", err)
+	}
+	if strings.Contains(string(ncx), "Dedication") {
+		t.Errorf("NCX file contains the section excluded from the TOC\nGot: %s", ncx)
+	}
+
+	// The section is still in the reading order
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	testSpine := `<itemref idref="dedication.xhtml"></itemref>`
+	if !strings.Contains(string(pkg), testSpine) {
+		t.Errorf("Package file spine doesn't match\nGot: %s\nExpected to contain: %s", pkg, testSpine)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Package epubtest provides utilities to write golden tests of the EPUBs
+generated by an application.
+
+The files of an EPUB are extracted into a map keyed by their path inside the
+EPUB. The values that change each time an EPUB is written (timestamps and
+UUIDs, e.g. the modification date and the default random identifier) are
+masked, and binary files are replaced by their SHA-256 digest, so that the map
+can be compared to a golden file.
+
+Basic usage:
+
+	files, err := epubtest.Files(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden, _ := json.MarshalIndent(files, "", "  ")
+	// Compare golden to testdata/book.golden.json
+*/
+package epubtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bmaupin/go-epub"
+)
+
+const (
+	// MaskedTimestamp replaces the timestamps of the files
+	MaskedTimestamp = "0000-00-00T00:00:00Z"
+	// MaskedUUID replaces the UUIDs of the files
+	MaskedUUID = "00000000-0000-0000-0000-000000000000"
+)
+
+var (
+	timestampRegexp = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})`)
+	uuidRegexp      = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+
+	// Extensions of the files compared as text
+	textExtensions = map[string]bool{
+		"":       true, // mimetype
+		".css":   true,
+		".html":  true,
+		".json":  true,
+		".ncx":   true,
+		".opf":   true,
+		".smil":  true,
+		".svg":   true,
+		".txt":   true,
+		".vtt":   true,
+		".xhtml": true,
+		".xml":   true,
+	}
+)
+
+// Files writes an EPUB in memory and returns its normalized files, as
+// returned by Unzip.
+func Files(e *epub.Epub) (map[string]string, error) {
+	buf := &bytes.Buffer{}
+	if _, err := e.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	return Unzip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
+
+// ReadFile reads an EPUB file and returns its normalized files, as returned by
+// Unzip.
+func ReadFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return Unzip(f, info.Size())
+}
+
+// Unzip reads an EPUB and returns its files keyed by their path inside the
+// EPUB, e.g. EPUB/package.opf. Text files (XHTML, XML, CSS, etc.) are
+// normalized using Normalize; the content of other files, such as images, is
+// replaced by its SHA-256 digest, e.g. sha256:e3b0c442….
+func Unzip(r io.ReaderAt, size int64) (map[string]string, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	for _, f := range z.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if textExtensions[strings.ToLower(path.Ext(f.Name))] {
+			files[f.Name] = Normalize(string(contents))
+		} else {
+			files[f.Name] = fmt.Sprintf("sha256:%x", sha256.Sum256(contents))
+		}
+	}
+
+	return files, nil
+}
+
+// Normalize masks the timestamps (ISO 8601 date-times, e.g.
+// 2006-01-02T15:04:05Z) and UUIDs of the content of a file using
+// MaskedTimestamp and MaskedUUID, and normalizes line endings.
+func Normalize(s string) string {
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = timestampRegexp.ReplaceAllString(s, MaskedTimestamp)
+	return uuidRegexp.ReplaceAllString(s, MaskedUUID)
+}
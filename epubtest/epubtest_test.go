@@ -0,0 +1,85 @@
+package epubtest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bmaupin/go-epub"
+)
+
+func newTestEpub() *epub.Epub {
+	e := epub.NewEpub("My title")
+	e.AddImage("../testdata/gophercolor16x16.png", "gopher.png")
+	e.AddSection("<h1>Section 1</h1>", "Section 1", "section0001.xhtml", "")
+	return e
+}
+
+func TestFiles(t *testing.T) {
+	// Two EPUBs with random identifiers written at different times are equal
+	// once normalized
+	first, err := Files(newTestEpub())
+	if err != nil {
+		t.Fatalf("Unexpected error getting files: %s", err)
+	}
+	second, err := Files(newTestEpub())
+	if err != nil {
+		t.Fatalf("Unexpected error getting files: %s", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Normalized files don't match\nGot: %v\nExpected: %v", second, first)
+	}
+
+	if first["mimetype"] != "application/epub+zip" {
+		t.Errorf("mimetype doesn't match\nGot: %s\nExpected: %s", first["mimetype"], "application/epub+zip")
+	}
+	if !strings.HasPrefix(first["EPUB/images/gopher.png"], "sha256:") {
+		t.Errorf("Image doesn't match\nGot: %s\nExpected a digest", first["EPUB/images/gopher.png"])
+	}
+	pkg := first["EPUB/package.opf"]
+	for _, expected := range []string{"urn:uuid:" + MaskedUUID, `<meta property="dcterms:modified">` + MaskedTimestamp + `</meta>`} {
+		if !strings.Contains(pkg, expected) {
+			t.Errorf("Package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "epubtest")
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp directory: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "My EPUB.epub")
+	if err := newTestEpub().Write(filename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	files, err := ReadFile(filename)
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	if _, ok := files["EPUB/xhtml/section0001.xhtml"]; !ok {
+		t.Errorf("Section not found in files\nGot: %v", files)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected string
+	}{
+		{"2024-01-15T08:30:00Z", MaskedTimestamp},
+		{"2024-01-15T08:30:00.123+02:00", MaskedTimestamp},
+		{"2024-01-15", "2024-01-15"},
+		{"urn:uuid:FE93046F-AF57-475A-A0CB-A0D4BC99BA6D", "urn:uuid:" + MaskedUUID},
+		{"a\r\nb", "a\nb"},
+	} {
+		if output := Normalize(test.input); output != test.expected {
+			t.Errorf("Normalized %q doesn't match\nGot: %s\nExpected: %s", test.input, output, test.expected)
+		}
+	}
+}
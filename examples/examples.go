@@ -0,0 +1,149 @@
+// Package examples contains runnable builders for the major features of the
+// epub package. Each builder returns an EPUB ready to be written, and is run
+// as a testable example so that the features keep working as the package
+// grows.
+package examples
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bmaupin/go-epub"
+)
+
+const (
+	// Header of an MPEG-1 Layer III frame at 128 kbit/s and 44.1 kHz
+	mp3FrameHeader = "\xff\xfb\x90\x64"
+	// Size of such a frame and the duration of its 1152 samples
+	mp3FrameSize     = 417
+	mp3FrameDuration = 1152 * time.Second / 44100
+)
+
+// Cover builds an EPUB with a cover image. The cover page is generated and
+// comes first in the reading order, outside of the TOC.
+func Cover() (*epub.Epub, error) {
+	e := epub.NewEpub("A Book with a Cover")
+	e.SetAuthor("Jane Doe")
+
+	imagePath, err := e.AddImage(placeholderImage(600, 800, color.RGBA{0x1a, 0x4d, 0x80, 0xff}), "cover.png")
+	if err != nil {
+		return nil, err
+	}
+	// The default cover stylesheet is used as none is provided
+	e.SetCover(imagePath, "")
+
+	if _, err := e.AddSection("<h1>Chapter 1</h1>\n<p>It was a dark and stormy night.</p>", "Chapter 1", "", ""); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// NestedTOC builds an EPUB with parts containing chapters, which are nested in
+// the TOC, and a visible table of contents page.
+func NestedTOC() (*epub.Epub, error) {
+	e := epub.NewEpub("A Book in Parts")
+
+	if _, err := e.GenerateTOCPage(""); err != nil {
+		return nil, err
+	}
+	for part := 1; part <= 2; part++ {
+		title := fmt.Sprintf("Part %d", part)
+		partFilename, err := e.AddSection(fmt.Sprintf("<h1>%s</h1>", title), title, "", "")
+		if err != nil {
+			return nil, err
+		}
+		for chapter := 1; chapter <= 2; chapter++ {
+			title := fmt.Sprintf("Chapter %d.%d", part, chapter)
+			if _, err := e.AddSubSection(partFilename, fmt.Sprintf("<h2>%s</h2>\n<p>…</p>", title), title, "", ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// FixedLayout builds a fixed-layout EPUB from a folder of page images, e.g. a
+// comic or a picture book, where each page is an image filling the viewport.
+// The first page is used as the cover image.
+func FixedLayout() (*epub.Epub, error) {
+	dir, err := ioutil.TempDir("", "go-epub-examples")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	colors := []color.RGBA{
+		{0xe0, 0x6c, 0x4c, 0xff},
+		{0x4c, 0xa0, 0x6c, 0xff},
+		{0x4c, 0x6c, 0xe0, 0xff},
+	}
+	for i, c := range colors {
+		filename := filepath.Join(dir, fmt.Sprintf("page%d.png", i+1))
+		if err := ioutil.WriteFile(filename, placeholderPNG(600, 800, c), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	// The pages are read when the EPUB is created, so the folder can be
+	// removed afterwards
+	return epub.NewEpubFromComic(dir, epub.ComicOptions{Title: "A Picture Book"})
+}
+
+// MediaOverlay builds a read-aloud EPUB, whose section is synchronized with an
+// audio narration: reading systems highlight each paragraph as it's read.
+func MediaOverlay() (*epub.Epub, error) {
+	e := epub.NewEpub("A Read-Aloud Book")
+
+	filename, err := e.AddSection(`<p id="p1">Once upon a time…</p>
+<p id="p2">…they lived happily ever after.</p>`, "Chapter 1", "", "")
+	if err != nil {
+		return nil, err
+	}
+	clips := []epub.Clip{
+		{FragmentID: "p1", Begin: 0, End: time.Second},
+		{FragmentID: "p2", Begin: time.Second, End: 2 * time.Second},
+	}
+	if _, err := e.AddMediaOverlay(filename, placeholderAudio(2*time.Second), clips); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Get a data URL of a silent MP3 file lasting at least the duration
+func placeholderAudio(duration time.Duration) string {
+	buf := &bytes.Buffer{}
+	for d := time.Duration(0); d < duration; d += mp3FrameDuration {
+		buf.WriteString(mp3FrameHeader)
+		buf.Write(make([]byte, mp3FrameSize-len(mp3FrameHeader)))
+	}
+	return "data:audio/mpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// Get a data URL of a PNG image filled with a color
+func placeholderImage(width int, height int, c color.Color) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(placeholderPNG(width, height, c))
+}
+
+// Get a PNG image filled with a color
+func placeholderPNG(width int, height int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		panic(fmt.Sprintf("Error encoding image: %s", err))
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,96 @@
+package examples_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/bmaupin/go-epub"
+	"github.com/bmaupin/go-epub/examples"
+)
+
+// Write an EPUB to a temporary file and print its reading order
+func writeAndPrintSpine(e *epub.Epub) {
+	dir, err := ioutil.TempDir("", "go-epub-examples")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := e.Write(filepath.Join(dir, "example.epub")); err != nil {
+		log.Fatal(err)
+	}
+	for _, item := range e.Spine() {
+		fmt.Println(item.Href)
+	}
+}
+
+func ExampleCover() {
+	e, err := examples.Cover()
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeAndPrintSpine(e)
+
+	for _, item := range e.Manifest() {
+		if len(item.Properties) > 0 {
+			fmt.Println(item.Href, item.Properties)
+		}
+	}
+
+	// Output:
+	// xhtml/cover.xhtml
+	// xhtml/section0002.xhtml
+	// nav.xhtml [nav]
+	// images/cover.png [cover-image]
+}
+
+func ExampleNestedTOC() {
+	e, err := examples.NestedTOC()
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeAndPrintSpine(e)
+
+	// Output:
+	// xhtml/contents.xhtml
+	// xhtml/section0002.xhtml
+	// xhtml/section0003.xhtml
+	// xhtml/section0004.xhtml
+	// xhtml/section0005.xhtml
+	// xhtml/section0006.xhtml
+	// xhtml/section0007.xhtml
+}
+
+func ExampleFixedLayout() {
+	e, err := examples.FixedLayout()
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeAndPrintSpine(e)
+
+	// Output:
+	// xhtml/page0001.xhtml
+	// xhtml/page0002.xhtml
+	// xhtml/page0003.xhtml
+}
+
+func ExampleMediaOverlay() {
+	e, err := examples.MediaOverlay()
+	if err != nil {
+		log.Fatal(err)
+	}
+	writeAndPrintSpine(e)
+
+	for _, item := range e.Manifest() {
+		if item.MediaOverlay != "" {
+			fmt.Println(item.Href, item.MediaOverlay)
+		}
+	}
+
+	// Output:
+	// xhtml/section0001.xhtml
+	// xhtml/section0001.xhtml section0001.smil
+}
@@ -0,0 +1,132 @@
+package epub
+
+import (
+	"fmt"
+)
+
+const (
+	buyPageBodyTemplate = `<h1>%s</h1>
+<p class="buy-full-book">%s</p>`
+	buyPageFilename     = "buy.xhtml"
+	buyPageLinkTemplate = `<a href="%s">%s</a>`
+	// The value of the dcterms:type metadata of excerpts
+	excerptType         = "excerpt"
+	dctermsTypeProperty = "dcterms:type"
+)
+
+// ExcerptOptions are the options of Excerpt.
+type ExcerptOptions struct {
+	// The title of the excerpt; by default, the title of the EPUB followed by
+	// "(Excerpt)", translated according to the language of the EPUB (see
+	// SetLabels)
+	Title string
+	// The URL of the store page of the full book, linked from the back page,
+	// optional
+	BuyURL string
+	// The body of the back page, replacing the generated one, optional
+	BuyPageBody string
+	// The internal path to an already-added CSS file (as returned by AddCSS)
+	// to be used for the back page, optional
+	BuyPageCSSPath string
+}
+
+// Excerpt creates a standalone EPUB from some sections of the EPUB, e.g. the
+// first chapters of a finished book to give away as a marketing excerpt. The
+// internal filenames of the sections (as returned by AddSection) are required;
+// their sub-sections and the cover page are kept as well. The EPUB itself is
+// left unchanged.
+//
+// The excerpt is a copy of the EPUB made using MarshalJSON, so the same
+// limitations apply: e.g. the back matter isn't copied, and sections rendered
+// when the EPUB is written have the body they had when it was last written.
+// Media files are kept whether or not the sections use them, except the
+// foreign resources whose fallback section isn't kept.
+//
+// The excerpt gets a new unique identifier and preview metadata: a
+// dcterms:isVersionOf relation to the identifier of the EPUB (see AddRelation)
+// and dcterms:type metadata with the value "excerpt". A back page is appended,
+// titled "Buy the full book" (translated, see SetLabels) and linking to the
+// store page if a URL is provided. It's generated when the excerpt is written,
+// using the title of the EPUB and the author; its filename is buy.xhtml and its
+// paragraph has the class "buy-full-book".
+//
+// If a filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned. The same errors as AddSection are
+// returned if the back page can't be added, e.g. FilenameAlreadyUsedError if
+// buy.xhtml is used by a section of the EPUB.
+func (e *Epub) Excerpt(sections []string, opts ExcerptOptions) (*Epub, error) {
+	keep := map[string]bool{}
+	for _, filename := range sections {
+		if e.sectionIndex(filename) == -1 {
+			return nil, &FilenameNotFoundError{Filename: filename}
+		}
+		keep[filename] = true
+	}
+	if e.cover.xhtmlFilename != "" {
+		keep[e.cover.xhtmlFilename] = true
+	}
+	// Sub-sections are listed after their parents
+	for _, section := range e.sections {
+		if section.parent != "" && keep[section.parent] {
+			keep[section.filename] = true
+		}
+	}
+
+	data, err := e.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	x := &Epub{}
+	if err := x.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	// Foreign resources can't be left without a fallback
+	for filename, foreign := range x.foreign {
+		if foreign.fallback != "" && !keep[foreign.fallback] {
+			delete(x.foreign, filename)
+		}
+	}
+	for _, section := range e.sections {
+		if !keep[section.filename] {
+			if err := x.RemoveSection(section.filename); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	title := opts.Title
+	if title == "" {
+		title = e.label(LabelExcerpt, e.Title())
+	}
+	x.SetTitle(title)
+	x.SetIdentifier(RandomIdentifier(x))
+	x.identifierGenerated = true
+	x.AddRelation(RelationIsVersionOf, e.Identifier())
+	x.pkg.setPropertyMeta(dctermsTypeProperty, excerptType)
+
+	if _, err := x.AddSection(opts.BuyPageBody, x.label(LabelBuyFullBook, ""), buyPageFilename, opts.BuyPageCSSPath); err != nil {
+		return nil, err
+	}
+	if opts.BuyPageBody == "" {
+		buyURL := opts.BuyURL
+		fullTitle := e.Title()
+		x.sections[len(x.sections)-1].generator = &sectionGenerator{
+			title: func() string {
+				return x.label(LabelBuyFullBook, "")
+			},
+			body: func() string {
+				book := escapeText(fullTitle)
+				if x.Author() != "" {
+					book = escapeText(fmt.Sprintf("%s, %s", fullTitle, x.Author()))
+				}
+				if buyURL != "" {
+					book = fmt.Sprintf(buyPageLinkTemplate, escapeAttribute(buyURL), book)
+				}
+				return fmt.Sprintf(buyPageBodyTemplate, escapeText(x.label(LabelBuyFullBook, "")), book)
+			},
+		}
+	}
+
+	return x, nil
+}
@@ -0,0 +1,106 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	e := NewEpub("Gophers & Co")
+	e.SetAuthor("Jane Doe")
+	e.SetIdentifier("urn:isbn:9780306406157")
+	chapter1, _ := e.AddSection("<p>Chapter 1</p>", "Chapter 1", "chapter1.xhtml", "")
+	e.AddSubSection(chapter1, "<p>Section 1.1</p>", "Section 1.1", "section1.xhtml", "")
+	chapter2, _ := e.AddSection("<p>Chapter 2</p>", "Chapter 2", "chapter2.xhtml", "")
+	model := newDataURL("model/gltf-binary", []byte("glTF"))
+	e.AddForeignResource(model, "cube1.glb", "model/gltf-binary", chapter1)
+	e.AddForeignResource(model, "cube2.glb", "model/gltf-binary", chapter2)
+
+	x, err := e.Excerpt([]string{chapter1}, ExcerptOptions{BuyURL: "https://example.com/?id=1&store=1"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating excerpt: %s", err)
+	}
+
+	// The EPUB is left unchanged
+	if len(e.sections) != 3 || e.Title() != "Gophers & Co" {
+		t.Errorf("EPUB was changed by Excerpt")
+	}
+	if x.Title() != "Gophers & Co (Excerpt)" {
+		t.Errorf("Excerpt title doesn't match\nGot: %s\nExpected: %s", x.Title(), "Gophers & Co (Excerpt)")
+	}
+	if x.Identifier() == e.Identifier() {
+		t.Errorf("Excerpt identifier is the identifier of the EPUB: %s", x.Identifier())
+	}
+	// Foreign resources falling back to removed sections are removed
+	if _, ok := x.foreign["cube1.glb"]; !ok || len(x.foreign) != 1 {
+		t.Errorf("Excerpt foreign resources don't match\nGot: %v", x.foreign)
+	}
+
+	tempDir := writeAndExtractEpub(t, x, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	filenames := []string{}
+	for _, section := range x.sections {
+		filenames = append(filenames, section.filename)
+	}
+	testFilenames := []string{"chapter1.xhtml", "section1.xhtml", buyPageFilename}
+	if !reflect.DeepEqual(filenames, testFilenames) {
+		t.Errorf("Excerpt sections don't match\nGot: %v\nExpected: %v", filenames, testFilenames)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, buyPageFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading back page: %s", err)
+	}
+	testBody := `<h1>Buy the full book</h1>
+<p class="buy-full-book"><a href="https://example.com/?id=1&amp;store=1">Gophers &amp; Co, Jane Doe</a></p>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf("Back page doesn't match\nGot: %s\nExpected to contain: %s", contents, testBody)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`<meta property="dcterms:isVersionOf">urn:isbn:9780306406157</meta>`,
+		`<meta property="dcterms:type">excerpt</meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+		}
+	}
+}
+
+func TestExcerptOptions(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetLang("fr")
+	filename, _ := e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	x, err := e.Excerpt([]string{filename}, ExcerptOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error creating excerpt: %s", err)
+	}
+	if x.Title() != testEpubTitle+" (Extrait)" {
+		t.Errorf("Excerpt title doesn't match\nGot: %s\nExpected: %s", x.Title(), testEpubTitle+" (Extrait)")
+	}
+
+	x, err = e.Excerpt([]string{filename}, ExcerptOptions{Title: "Sample", BuyPageBody: "<p>Coming soon</p>"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating excerpt: %s", err)
+	}
+	if x.Title() != "Sample" {
+		t.Errorf("Excerpt title doesn't match\nGot: %s\nExpected: Sample", x.Title())
+	}
+	if body := x.sections[len(x.sections)-1].xhtml.xml.Body.XML; !strings.Contains(body, "<p>Coming soon</p>") {
+		t.Errorf("Back page doesn't match\nGot: %s\nExpected to contain: <p>Coming soon</p>", body)
+	}
+
+	_, err = e.Excerpt([]string{"missing.xhtml"}, ExcerptOptions{})
+	if _, ok := err.(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected FilenameNotFoundError, got %#v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const dataURLPrefix = "data:"
+
+// Open a media source, which can either be a URL (including data URLs) or a
+// path to a local file. The context and client are used for HTTP requests, and
+// the context is checked before opening other sources.
+func openMediaSource(ctx context.Context, client *http.Client, source string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(source, dataURLPrefix) {
+		data, _, err := decodeDataURL(source)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if store := blobStoreFor(source); store != nil {
+		return store.Open(source)
+	}
+
+	// If it's a URL
+	if u.Scheme == "http" || u.Scheme == "https" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		// e.g. an expired signed URL
+		if resp.StatusCode >= http.StatusBadRequest {
+			resp.Body.Close()
+			return nil, fmt.Errorf("HTTP status %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+
+	// Otherwise, assume it's a local file
+	return os.Open(source)
+}
+
+// Get a media file from its source and save it to the destination path. The
+// file is streamed rather than loaded in memory, and local files are copied
+// by the kernel where possible. If verify is true, the source is read again
+// once copied, and its hash compared to the hash of the copy.
+func copyMediaSource(ctx context.Context, client *http.Client, source string, destFilePath string, verify bool) error {
+	r, err := openMediaSource(ctx, client, source)
+	if err != nil {
+		return &FileRetrievalError{Source: source, Err: err}
+	}
+
+	w, err := os.Create(destFilePath)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to create file: %s", err))
+	}
+
+	// The size of local files is known beforehand
+	expectedSize := int64(-1)
+	if f, ok := r.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			expectedSize = info.Size()
+		}
+	}
+	n, err := io.Copy(w, r)
+	// Close the reader and writer manually. If we use a defer instead, they
+	// won't close until the function exits.
+	func() {
+		if err := r.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	func() {
+		if err := w.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	if err != nil {
+		// There shouldn't be any problem with the writer, but the reader might
+		// have an issue
+		return &FileRetrievalError{Source: source, Err: err}
+	}
+	if expectedSize != -1 && n != expectedSize {
+		return &FileRetrievalError{
+			Source: source,
+			Err:    fmt.Errorf("copied %d bytes instead of %d, the file may have changed while being copied", n, expectedSize),
+		}
+	}
+	if verify {
+		// The source is hashed separately from the copy, so that a copy that
+		// doesn't match it is detected
+		sum, err := hashMediaSource(ctx, client, source)
+		if err != nil {
+			return &FileRetrievalError{Source: source, Err: err}
+		}
+		if err := verifyFileHash(destFilePath, sum); err != nil {
+			return &FileRetrievalError{Source: source, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Get the SHA-256 hash of a media source, streaming it
+func hashMediaSource(ctx context.Context, client *http.Client, source string) ([]byte, error) {
+	r, err := openMediaSource(ctx, client, source)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// Check that the SHA-256 hash of a file matches the expected hash
+func verifyFileHash(filePath string, expected []byte) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), expected) {
+		return fmt.Errorf("hash of the copy doesn't match the source: %x", h.Sum(nil))
+	}
+
+	return nil
+}
+
+// Create a data URL containing the provided data, which allows media that only
+// exists in memory to be used as a media source
+//
+// Spec: https://tools.ietf.org/html/rfc2397
+func newDataURL(mediaType string, data []byte) string {
+	return dataURLPrefix + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// Detect the media type of an image from its contents
+func sniffImageMediaType(data []byte) string {
+	mediaType := http.DetectContentType(data)
+	// SVG images are detected as XML or plain text
+	if strings.HasPrefix(mediaType, "text/") && bytes.Contains(data, []byte("<svg")) {
+		return "image/svg+xml"
+	}
+	if i := strings.Index(mediaType, ";"); i != -1 {
+		mediaType = mediaType[:i]
+	}
+	return mediaType
+}
+
+// Decode a data URL, returning its data and media type
+func decodeDataURL(source string) ([]byte, string, error) {
+	i := strings.Index(source, ",")
+	if !strings.HasPrefix(source, dataURLPrefix) || i == -1 {
+		return nil, "", errors.New("Invalid data URL")
+	}
+	mediaType := strings.TrimPrefix(source[:i], dataURLPrefix)
+	data := source[i+1:]
+
+	if strings.HasSuffix(mediaType, ";base64") {
+		b, err := base64.StdEncoding.DecodeString(data)
+		return b, strings.TrimSuffix(mediaType, ";base64"), err
+	}
+
+	unescaped, err := url.PathUnescape(data)
+	return []byte(unescaped), mediaType, err
+}
+
+// Get the file extension to use for a media source, including the leading dot.
+// For data URLs, the extension is derived from the media type.
+func mediaSourceExt(source string) string {
+	if !strings.HasPrefix(source, dataURLPrefix) {
+		return strings.ToLower(filepath.Ext(source))
+	}
+
+	i := strings.IndexAny(source, ";,")
+	if i == -1 {
+		return ""
+	}
+	mediaType := strings.ToLower(strings.TrimPrefix(source[:i], dataURLPrefix))
+
+	// Sort the extensions so that the result is always the same
+	exts := []string{}
+	for ext := range extensionMediaTypes {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		if extensionMediaTypes[ext] == mediaType {
+			return ext
+		}
+	}
+
+	return ""
+}
+
+// SourceRefresher returns a new URL for a remote source that can no longer be
+// retrieved, e.g. a new signature for an expired pre-signed URL.
+type SourceRefresher func(source string) (string, error)
+
+// SetEagerFetch sets whether the files with remote (http or https) sources are
+// retrieved when they're added (e.g. by AddImage) rather than when the EPUB is
+// written, which prevents long builds from failing because pre-signed URLs
+// expired before Write. The files are kept in memory as data URLs.
+func (e *Epub) SetEagerFetch(eager bool) {
+	e.eagerFetch = eager
+}
+
+// SetClient sets the HTTP client used to retrieve the files with remote (http
+// or https) sources, e.g. to use a proxy, custom TLS settings, or to add
+// authentication headers using its Transport. Passing nil restores
+// http.DefaultClient.
+func (e *Epub) SetClient(client *http.Client) {
+	e.client = client
+}
+
+// SetFetchConcurrency sets the number of media files retrieved at the same
+// time when the EPUB is written, e.g. to speed up writing books with hundreds
+// of remote images. Files are retrieved one at a time by default or if n is
+// less than 2. The files are written the same way whatever the number of
+// workers, and if several files can't be retrieved, the error of the first one
+// in order of filename is returned.
+//
+// Files that are transcoded (see SetMediaTranscoder) are still retrieved one
+// at a time, but the source refresher (see SetSourceRefresher) may be called
+// from several goroutines at the same time.
+func (e *Epub) SetFetchConcurrency(n int) {
+	e.fetchConcurrency = n
+}
+
+// SetVerifyCopies sets whether the media files copied when the EPUB is written
+// are compared with their sources using a SHA-256 hash, e.g. to detect
+// corrupted copies of large video files, or sources that changed while being
+// copied. This costs an extra read of each source (an extra request for URLs)
+// and of each copy, but the files are never loaded in memory as a whole.
+func (e *Epub) SetVerifyCopies(verify bool) {
+	e.verifyCopies = verify
+}
+
+// SetSourceRefresher sets a function called when a file with a remote source
+// can't be retrieved while the EPUB is written, to get a new URL to retry
+// with, e.g. by signing the URL again. Passing nil removes the refresher.
+func (e *Epub) SetSourceRefresher(refresher SourceRefresher) {
+	e.sourceRefresher = refresher
+}
+
+// Add a media file as addMedia does, applying sandbox mode and retrieving it
+// right away if eager fetching is enabled
+func (e *Epub) addMedia(source string, internalFilename string, mediaFileFormat string, mediaFolderName string, mediaMap map[string]string) (string, error) {
+	// The default cover CSS is written to a temporary file by SetCover
+	if e.sandbox != nil && source != e.cover.cssTempFile {
+		var err error
+		source, internalFilename, err = e.sandboxMediaSource(source, internalFilename)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	mediaPath, err := addMedia(e.httpClient(), source, internalFilename, mediaFileFormat, mediaFolderName, mediaMap)
+	if err != nil || !e.eagerFetch || !isRemoteSource(source) {
+		return mediaPath, err
+	}
+
+	data, err := readMediaSource(e.context(), e.httpClient(), source)
+	if err != nil {
+		delete(mediaMap, filepath.Base(mediaPath))
+		return "", err
+	}
+	mediaType := "application/octet-stream"
+	if u, err := url.Parse(source); err == nil {
+		if t, ok := extensionMediaTypes[strings.ToLower(path.Ext(u.Path))]; ok {
+			mediaType = t
+		}
+	}
+	mediaMap[filepath.Base(mediaPath)] = newDataURL(mediaType, data)
+
+	return mediaPath, nil
+}
+
+// Copy the media files that aren't transcoded using the number of workers set
+// by SetFetchConcurrency, returning the error of each file copied. No file is
+// copied if files are retrieved one at a time, and the files left are skipped
+// if the context is done.
+func (e *Epub) fetchMedia(mediaFolderName string, mediaFolderPath string, filenames []string, mediaMap map[string]string) map[string]error {
+	errs := map[string]error{}
+	if e.fetchConcurrency < 2 {
+		return errs
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < e.fetchConcurrency && i < len(filenames); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range jobs {
+				err := e.copyMedia(mediaFolderName, filename, mediaMap[filename], filepath.Join(mediaFolderPath, filename))
+				mu.Lock()
+				errs[filename] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, filename := range filenames {
+		if e.context().Err() != nil {
+			break
+		}
+		if _, ok := e.transcodedMedia[path.Join(mediaFolderName, filename)]; !ok {
+			jobs <- filename
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// Call f with the source of each file of the EPUB, replacing the source with
+// the one returned. Every field holding a source must be covered here, as this
+// is how the sources of untrusted books are checked.
+func (e *Epub) mapSources(f func(source string) (string, error)) error {
+	for _, sources := range []map[string]string{e.audio, e.css, e.fonts, e.images, e.videos} {
+		for filename, source := range sources {
+			source, err := f(source)
+			if err != nil {
+				return err
+			}
+			sources[filename] = source
+		}
+	}
+	for _, foreign := range e.foreign {
+		source, err := f(foreign.source)
+		if err != nil {
+			return err
+		}
+		foreign.source = source
+	}
+
+	return nil
+}
+
+// Whether a source is an http or https URL
+func isRemoteSource(source string) bool {
+	u, err := url.Parse(source)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// Get the HTTP client used to retrieve remote files
+func (e *Epub) httpClient() *http.Client {
+	if e.client == nil {
+		return http.DefaultClient
+	}
+	return e.client
+}
+
+// Get the context of the write in progress, used to retrieve the sources of
+// the files
+func (e *Epub) context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
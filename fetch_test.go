@@ -0,0 +1,351 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Serve an image using signed URLs, with a signature that can be changed to
+// expire previous URLs
+type signedImageServer struct {
+	mu        sync.Mutex
+	image     []byte
+	signature string
+}
+
+func (s *signedImageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Query().Get("sig") != s.signature {
+		http.Error(w, "Expired", http.StatusForbidden)
+		return
+	}
+	w.Write(s.image)
+}
+
+func (s *signedImageServer) setSignature(signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signature = signature
+}
+
+func TestSetEagerFetch(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	s := &signedImageServer{image: image, signature: "1"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	e.SetEagerFetch(true)
+	_, err = e.AddImage(server.URL+"/gopher.png?sig=1", "gopher.png")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	if !strings.HasPrefix(e.images["gopher.png"], "data:image/png;base64,") {
+		t.Errorf("Image wasn't retrieved when added\nGot: %s", e.images["gopher.png"])
+	}
+
+	// The URL expires before the EPUB is written
+	s.setSignature("2")
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "gopher.png"))
+	if err != nil {
+		t.Errorf("Unexpected error reading image: %s", err)
+	}
+	if string(contents) != string(image) {
+		t.Errorf("Image doesn't match")
+	}
+}
+
+func TestSetSourceRefresher(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	s := &signedImageServer{image: image, signature: "1"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	_, err = e.AddImage(server.URL+"/gopher.png?sig=1", "gopher.png")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	// The URL expires before the EPUB is written
+	s.setSignature("2")
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+
+	refreshed := []string{}
+	e.SetSourceRefresher(func(source string) (string, error) {
+		refreshed = append(refreshed, source)
+		return strings.Replace(source, "sig=1", "sig=2", 1), nil
+	})
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	if len(refreshed) != 1 || refreshed[0] != server.URL+"/gopher.png?sig=1" {
+		t.Errorf("Refreshed sources don't match\nGot: %v", refreshed)
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "gopher.png"))
+	if err != nil {
+		t.Errorf("Unexpected error reading image: %s", err)
+	}
+	if string(contents) != string(image) {
+		t.Errorf("Image doesn't match")
+	}
+}
+
+func TestSetVerifyCopies(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Large enough to be copied in several chunks
+	video := make([]byte, 4<<20)
+	if _, err := rand.Read(video); err != nil {
+		t.Fatalf("Unexpected error generating video: %s", err)
+	}
+	videoSource := filepath.Join(tempDir, "video.mp4")
+	if err := ioutil.WriteFile(videoSource, video, filePermissions); err != nil {
+		t.Fatalf("Unexpected error writing video: %s", err)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.SetVerifyCopies(true)
+	if _, err := e.AddVideo(videoSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding video: %s", err)
+	}
+	extractedDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, extractedDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(extractedDir, contentFolderName, VideoFolderName, "video.mp4"))
+	if err != nil {
+		t.Errorf("Unexpected error reading video: %s", err)
+	}
+	if !bytes.Equal(contents, video) {
+		t.Errorf("Video doesn't match")
+	}
+
+	// A copy that doesn't match its source
+	sum := sha256.Sum256(append(video, 0))
+	if err := verifyFileHash(videoSource, sum[:]); err == nil {
+		t.Errorf("Expected error verifying a file with the wrong hash")
+	}
+
+	// A source that changes each time it's read
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "video %d", atomic.AddInt32(&requests, 1))
+	}))
+	defer server.Close()
+	destFilePath := filepath.Join(tempDir, "copy.mp4")
+	err = copyMediaSource(context.Background(), http.DefaultClient, server.URL+"/video.mp4", destFilePath, false)
+	if err != nil {
+		t.Errorf("Unexpected error copying video: %s", err)
+	}
+	err = copyMediaSource(context.Background(), http.DefaultClient, server.URL+"/video.mp4", destFilePath, true)
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned for a changed source. Returned instead: %+v", err)
+	}
+}
+
+// Add an authorization header to the requests
+type authTransport struct {
+	token string
+}
+
+func (t *authTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSetClient(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write(image)
+	}))
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	_, err = e.AddImage(server.URL+"/gopher.png", "gopher.png")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+
+	e.SetClient(&http.Client{Transport: &authTransport{token: "secret"}})
+	_, err = e.AddImage(server.URL+"/gopher.png", "gopher.png")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "gopher.png"))
+	if err != nil {
+		t.Errorf("Unexpected error reading image: %s", err)
+	}
+	if string(contents) != string(image) {
+		t.Errorf("Image doesn't match")
+	}
+}
+
+func TestWriteWithContext(t *testing.T) {
+	// The body of the image never ends
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	if _, err := e.AddImage(server.URL+"/gopher.png", "gopher.png"); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := e.WriteWithContext(ctx, testEpubFilename)
+	if err, ok := err.(*FileRetrievalError); !ok || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("Expected error writing EPUB once the deadline is exceeded\nGot: %v\nExpected: FileRetrievalError", err)
+	}
+	if _, err := os.Stat(testEpubFilename); !os.IsNotExist(err) {
+		t.Errorf("EPUB was created despite the error")
+		os.Remove(testEpubFilename)
+	}
+
+	// A context that is already canceled stops the EPUB before it's built
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.writeTo(ctx, ioutil.Discard); err == nil {
+		t.Errorf("Expected error writing EPUB with a canceled context")
+	}
+}
+
+func TestSetFetchConcurrency(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write(image)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		concurrency    int
+		maxConcurrency int
+	}{
+		{0, 1},
+		{4, 4},
+	}
+	for _, test := range tests {
+		e := NewEpub(testEpubTitle)
+		e.SetFetchConcurrency(test.concurrency)
+		for i := 0; i < 8; i++ {
+			if _, err := e.AddImage(fmt.Sprintf("%s/gopher%d.png", server.URL, i), ""); err != nil {
+				t.Fatalf("Unexpected error adding image: %s", err)
+			}
+		}
+		mu.Lock()
+		maxInFlight = 0
+		mu.Unlock()
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+		for i := 0; i < 8; i++ {
+			contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, fmt.Sprintf("gopher%d.png", i)))
+			if err != nil {
+				t.Errorf("Unexpected error reading image: %s", err)
+			}
+			if !bytes.Equal(contents, image) {
+				t.Errorf("Image %d doesn't match", i)
+			}
+		}
+		cleanup(testEpubFilename, tempDir)
+
+		if maxInFlight > test.maxConcurrency || (test.concurrency > 1 && maxInFlight < 2) {
+			t.Errorf("Concurrent requests don't match for concurrency %d\nGot: %d\nExpected at most: %d", test.concurrency, maxInFlight, test.maxConcurrency)
+		}
+	}
+}
+
+func TestSetFetchConcurrencyError(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	var broken int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&broken) == 1 && r.URL.Path != "/a.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(image)
+	}))
+	defer server.Close()
+
+	e := NewEpub(testEpubTitle)
+	e.SetFetchConcurrency(3)
+	for _, filename := range []string{"d.png", "a.png", "c.png", "b.png"} {
+		if _, err := e.AddImage(server.URL+"/"+filename, filename); err != nil {
+			t.Fatalf("Unexpected error adding image: %s", err)
+		}
+	}
+	atomic.StoreInt32(&broken, 1)
+
+	// The error of the first file in order of filename is returned
+	err = e.Write(testEpubFilename)
+	defer os.Remove(testEpubFilename)
+	retrievalErr, ok := err.(*FileRetrievalError)
+	if !ok {
+		t.Fatalf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+	if retrievalErr.Source != server.URL+"/b.png" {
+		t.Errorf("Error source doesn't match\nGot: %s\nExpected: %s", retrievalErr.Source, server.URL+"/b.png")
+	}
+}
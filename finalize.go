@@ -0,0 +1,104 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FinalizedEpub is the fully resolved content of an EPUB, as returned by
+// Finalize.
+type FinalizedEpub struct {
+	// The files listed in the package file and the reading order, including
+	// the generated ones, e.g. the back matter
+	Manifest []ManifestItem
+	Spine    []SpineItem
+	// The entries of the navigation document
+	Nav []NavEntry
+	// The contents of the files of the EPUB, keyed by their path inside the
+	// EPUB, e.g. EPUB/package.opf
+	Files map[string][]byte
+}
+
+// NavEntry is an entry of the navigation document of an EPUB.
+type NavEntry struct {
+	Title string
+	// Path to the section relative to the package file
+	Href     string
+	Children []NavEntry
+}
+
+// Finalize builds the EPUB as Write does, without zipping it, and returns its
+// fully resolved content: the final filenames and paths, the manifest, the
+// navigation document, and the files once the sections have been rendered and
+// transformed. This allows the EPUB to be inspected, e.g. in tests, before
+// it's written. The same errors as Write are returned, except for the errors
+// related to the destination and SizeBudgetExceededError, as the size budget
+// is only applied to the archive.
+//
+// The EPUB can still be modified and written afterwards.
+func (e *Epub) Finalize() (*FinalizedEpub, error) {
+	if e.discarded() {
+		return nil, &EpubDiscardedError{}
+	}
+
+	tempDir, err := e.newTempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			panic(fmt.Sprintf("Error removing temp directory: %s", err))
+		}
+	}()
+
+	restore, err := e.build(tempDir)
+	defer restore()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &FinalizedEpub{
+		Manifest: e.Manifest(),
+		Spine:    e.Spine(),
+		Nav:      navEntries(e.toc.navXML.Links),
+		Files:    map[string][]byte{},
+	}
+	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(tempDir, path)
+		if err != nil {
+			return err
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f.Files[filepath.ToSlash(relativePath)] = contents
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Error reading finalized EPUB files: %s", err))
+	}
+
+	return f, nil
+}
+
+func navEntries(items []*tocNavItem) []NavEntry {
+	entries := []NavEntry{}
+	for _, item := range items {
+		entry := NavEntry{
+			Title:    item.A.Data,
+			Href:     item.A.Href,
+			Children: []NavEntry{},
+		}
+		if item.Children != nil {
+			entry.Children = navEntries(item.Children.Items)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
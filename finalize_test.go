@@ -0,0 +1,65 @@
+package epub
+
+import (
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFinalize(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, "")
+	parent, err := e.AddSection(`<img src="`+e.PathOf("gopher")+`" alt="" />`, "Parent", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	child, err := e.AddSubSection(parent, testSectionBody, "Child", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding sub-section: %s", err)
+	}
+	if err := e.SetAlias("gopher", imagePath); err != nil {
+		t.Fatalf("Unexpected error setting alias: %s", err)
+	}
+
+	f, err := e.Finalize()
+	if err != nil {
+		t.Fatalf("Unexpected error finalizing EPUB: %s", err)
+	}
+
+	expectedNav := []NavEntry{
+		{
+			Title: "Parent",
+			Href:  path.Join(xhtmlFolderName, parent),
+			Children: []NavEntry{
+				{Title: "Child", Href: path.Join(xhtmlFolderName, child), Children: []NavEntry{}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(f.Nav, expectedNav) {
+		t.Errorf("Nav doesn't match\nGot: %+v\nExpected: %+v", f.Nav, expectedNav)
+	}
+	if len(f.Spine) != 2 || f.Spine[1].Href != path.Join(xhtmlFolderName, child) {
+		t.Errorf("Spine doesn't match\nGot: %+v", f.Spine)
+	}
+	for _, item := range f.Manifest {
+		if _, ok := f.Files[path.Join(contentFolderName, item.Href)]; !ok {
+			t.Errorf("Manifest item %s not found in files", item.Href)
+		}
+	}
+	for _, filename := range []string{"mimetype", "META-INF/container.xml", path.Join(contentFolderName, pkgFilename)} {
+		if _, ok := f.Files[filename]; !ok {
+			t.Errorf("File %s not found in finalized EPUB", filename)
+		}
+	}
+	section := string(f.Files[path.Join(contentFolderName, xhtmlFolderName, parent)])
+	if !strings.Contains(section, `<img src="`+imagePath+`" alt="" />`) {
+		t.Errorf("Section doesn't match\nGot: %s\nExpected alias to be resolved", section)
+	}
+
+	// Validation errors are returned as for Write
+	e.AddSection(`<img src="`+e.PathOf("missing")+`" />`, "", "", "")
+	if _, err := e.Finalize(); err == nil {
+		t.Errorf("Expected error finalizing EPUB referencing a missing alias")
+	}
+}
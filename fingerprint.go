@@ -0,0 +1,116 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"path/filepath"
+	"strings"
+)
+
+// Number of consecutive words hashed together by SimHash
+const simHashShingleSize = 3
+
+// Fingerprint is the content fingerprint of an EPUB, which catalog systems can
+// store to detect duplicate or near-duplicate submissions. It only depends on
+// the text of the sections: the markup, punctuation, case and whitespace are
+// ignored, as are the metadata and media files.
+type Fingerprint struct {
+	// The SHA-256 hash of the normalized text of the EPUB, in hex. EPUBs with
+	// the same text have the same hash.
+	Hash string `json:"hash"`
+	// The SimHash of the normalized text of the EPUB. EPUBs with similar text
+	// have SimHashes that differ by a few bits (see SimHashDistance).
+	SimHash  uint64               `json:"simhash,string"`
+	Sections []SectionFingerprint `json:"sections"`
+}
+
+// SectionFingerprint is the content fingerprint of a section, e.g. to find the
+// chapters copied from another EPUB.
+type SectionFingerprint struct {
+	// Path to the section relative to the package file, e.g. xhtml/section0001.xhtml
+	Href    string `json:"href"`
+	Hash    string `json:"hash"`
+	SimHash uint64 `json:"simhash,string"`
+}
+
+// Fingerprint computes the content fingerprint of the EPUB and of each of its
+// sections. The text is normalized the same way as for SearchIndex: it's split
+// into lowercase words, ignoring the content of script and style elements.
+// Sections rendered when the EPUB is written (e.g. by AddSectionTemplate) are
+// fingerprinted using the body they had when the EPUB was last written.
+func (e *Epub) Fingerprint() (*Fingerprint, error) {
+	f := &Fingerprint{Sections: []SectionFingerprint{}}
+
+	terms := []string{}
+	for _, section := range e.sections {
+		sectionTerms := []string{}
+		err := walkSectionText(section.xhtml.xml.Body.XML, func(anchor string, text string) {
+			sectionTerms = append(sectionTerms, searchTerms(text)...)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error fingerprinting section %q: %s", section.filename, err)
+		}
+
+		f.Sections = append(f.Sections, SectionFingerprint{
+			Href:    filepath.ToSlash(filepath.Join(xhtmlFolderName, section.filename)),
+			Hash:    textHash(sectionTerms),
+			SimHash: simHash(sectionTerms),
+		})
+		terms = append(terms, sectionTerms...)
+	}
+	f.Hash = textHash(terms)
+	f.SimHash = simHash(terms)
+
+	return f, nil
+}
+
+// SimHashDistance returns the number of bits that differ between two
+// SimHashes, from 0 for identical text to 64. Texts whose SimHashes differ by 3
+// bits or less are usually near-duplicates.
+func SimHashDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Get the SHA-256 hash of normalized text, in hex
+func textHash(terms []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(terms, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get the SimHash of normalized text, using shingles of consecutive words so
+// that the order of the words matters
+func simHash(terms []string) uint64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	var votes [64]int
+	for i := 0; i+simHashShingleSize <= len(terms) || i == 0; i++ {
+		end := i + simHashShingleSize
+		if end > len(terms) {
+			end = len(terms)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(terms[i:end], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, vote := range votes {
+		if vote > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+
+	return hash
+}
@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+const testFingerprintText = `It was the best of times, it was the worst of times, it was the age of
+wisdom, it was the age of foolishness, it was the epoch of belief, it was the
+epoch of incredulity, it was the season of Light, it was the season of
+Darkness, it was the spring of hope, it was the winter of despair, we had
+everything before us, we had nothing before us, we were all going direct to
+Heaven, we were all going direct the other way`
+
+func testFingerprint(t *testing.T, bodies ...string) *Fingerprint {
+	e := NewEpub(testEpubTitle)
+	for _, body := range bodies {
+		e.AddSection(body, "", "", "")
+	}
+	f, err := e.Fingerprint()
+	if err != nil {
+		t.Fatalf("Unexpected error computing fingerprint: %s", err)
+	}
+
+	return f
+}
+
+func TestFingerprint(t *testing.T) {
+	f := testFingerprint(t, "<p>"+testFingerprintText+"</p>", "<p>The end</p>")
+	if len(f.Sections) != 2 {
+		t.Fatalf("Section fingerprints don't match\nGot: %d\nExpected: 2", len(f.Sections))
+	}
+	if f.Sections[0].Href != "xhtml/section0001.xhtml" {
+		t.Errorf("Section fingerprint href doesn't match\nGot: %s\nExpected: xhtml/section0001.xhtml", f.Sections[0].Href)
+	}
+
+	// The markup, case, punctuation and whitespace are ignored
+	same := testFingerprint(t,
+		`<div><h1 id="start">`+strings.ToUpper(testFingerprintText[:6])+"</h1>"+
+			strings.Replace(testFingerprintText[6:], ",", " ;", -1)+
+			`<style>p { color: red; }</style></div>`,
+		"<p><em>The</em>   end!</p>")
+	if same.Hash != f.Hash || same.SimHash != f.SimHash {
+		t.Errorf("Fingerprint of the same text doesn't match\nGot: %+v\nExpected: %+v", same, f)
+	}
+
+	similar := testFingerprint(t, "<p>"+strings.Replace(testFingerprintText, "Heaven", "Paradise", 1)+"</p>", "<p>The end</p>")
+	different := testFingerprint(t, "<p>Call me Ishmael. Some years ago, never mind how long precisely, having little or no money in my purse</p>")
+	if similar.Hash == f.Hash {
+		t.Errorf("Fingerprint hash of similar text matches: %s", f.Hash)
+	}
+	similarDistance := SimHashDistance(f.SimHash, similar.SimHash)
+	differentDistance := SimHashDistance(f.SimHash, different.SimHash)
+	if similarDistance >= differentDistance {
+		t.Errorf("SimHash distance of similar text isn't smaller than distance of different text\nGot: %d\nExpected less than: %d", similarDistance, differentDistance)
+	}
+	if f.Sections[1].Hash != similar.Sections[1].Hash {
+		t.Errorf("Fingerprint of identical section doesn't match\nGot: %s\nExpected: %s", similar.Sections[1].Hash, f.Sections[1].Hash)
+	}
+}
+
+func TestSimHashDistance(t *testing.T) {
+	if d := SimHashDistance(0xff, 0x0f); d != 4 {
+		t.Errorf("SimHash distance doesn't match\nGot: %d\nExpected: 4", d)
+	}
+}
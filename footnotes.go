@@ -0,0 +1,188 @@
+package epub
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	endnotesBodyTemplate = `<section epub:type="endnotes">
+<h1>%s</h1>
+<ol>
+%s</ol>
+</section>`
+	endnotesFilename = "notes.xhtml"
+	endnoteTemplate  = `<li epub:type="endnote" id="%s">%s</li>
+`
+	footnoteTemplate = `
+<aside epub:type="footnote" id="%s">%s</aside>`
+)
+
+// Valid values of the id attribute of notes
+var footnoteIDRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// InvalidFootnoteError is thrown by AddFootnote if a note can't be added, e.g.
+// because there is no link to it in its section.
+type InvalidFootnoteError struct {
+	Filename string // Filename of the section
+	ID       string // ID of the note
+	Reason   string // Why it can't be added
+}
+
+func (e *InvalidFootnoteError) Error() string {
+	return fmt.Sprintf("Invalid footnote %q in %s: %s", e.ID, e.Filename, e.Reason)
+}
+
+// A note added using AddFootnote
+type epubFootnote struct {
+	filename string
+	id       string
+	xhtml    string
+}
+
+// AddFootnote adds a note to a section, which reading systems such as Apple
+// Books and Kobo show in a popup. The section must contain a link to the note,
+// e.g. <a href="#note1">1</a> for the ID note1: when the EPUB is written, the
+// link is marked with epub:type="noteref" and the note is appended to the
+// section as an <aside epub:type="footnote"> element containing noteXHTML.
+// If CollectEndnotes was called, the notes are gathered in the notes page
+// instead, and the links point to it.
+//
+// The internal filename of an already-added section (as returned by
+// AddSection) is required; if it doesn't match a section,
+// FilenameNotFoundError is returned. InvalidFootnoteError is returned if the
+// ID isn't a valid XML ID, is already used by a note, or isn't linked to.
+// In sandbox mode, the note is sanitized as the body of a section.
+func (e *Epub) AddFootnote(sectionFilename string, id string, noteXHTML string) error {
+	filename := ""
+	body := ""
+	for _, section := range e.sections {
+		if section.filename == sectionFilename {
+			filename = section.filename
+			body = section.xhtml.xml.Body.XML
+			break
+		}
+	}
+	if filename == "" {
+		return &FilenameNotFoundError{Filename: sectionFilename}
+	}
+
+	reason := ""
+	switch {
+	case !footnoteIDRegexp.MatchString(id):
+		reason = "not a valid ID"
+	case !noteRefRegexp(id).MatchString(body):
+		reason = "no link to the note"
+	}
+	for _, note := range e.footnotes {
+		if note.id == id {
+			reason = "ID already used"
+		}
+	}
+	if reason != "" {
+		return &InvalidFootnoteError{Filename: filename, ID: id, Reason: reason}
+	}
+
+	if e.sandbox != nil {
+		var err error
+		noteXHTML, err = e.sandboxBody(noteXHTML)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.footnotes = append(e.footnotes, epubFootnote{
+		filename: filename,
+		id:       id,
+		xhtml:    noteXHTML,
+	})
+
+	return nil
+}
+
+// CollectEndnotes adds a page gathering the notes added using AddFootnote, as
+// endnotes, in the order of their sections. The page is titled "Notes",
+// translated according to the language of the EPUB when it's written (see
+// SetLabels), and generated each time the EPUB is written, so that it includes
+// the notes added afterwards. The notes are <li epub:type="endnote"> elements
+// of a list.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The relative path to the page is returned, as
+// for AddSection.
+func (e *Epub) CollectEndnotes(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelNotes, ""), endnotesFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+	e.endnotesFilename = endnotesFilename
+
+	x := e.sections[len(e.sections)-1].xhtml
+	x.setXmlnsEpub(xmlnsEpub)
+	e.sections[len(e.sections)-1].generator = e.endnotesGenerator(x)
+
+	return filename, nil
+}
+
+// Generate the notes page added by CollectEndnotes
+func (e *Epub) endnotesGenerator(x *xhtml) *sectionGenerator {
+	return &sectionGenerator{
+		title: func() string {
+			return e.label(LabelNotes, "")
+		},
+		body: func() string {
+			items := ""
+			for _, section := range e.sections {
+				for _, note := range e.footnotes {
+					if note.filename == section.filename {
+						items += fmt.Sprintf(endnoteTemplate, note.id, note.xhtml)
+					}
+				}
+			}
+
+			return fmt.Sprintf(endnotesBodyTemplate, escapeText(x.Title()), items)
+		},
+	}
+}
+
+// Match the opening tags of the links to a note
+func noteRefRegexp(id string) *regexp.Regexp {
+	return regexp.MustCompile(`<a\s[^>]*\bhref\s*=\s*["']#` + regexp.QuoteMeta(id) + `["'][^>]*>`)
+}
+
+// Mark the links to the notes of a section and append the notes, unless they
+// are collected as endnotes, in which case the links point to them. The body
+// is returned unchanged if the section has no notes.
+func (e *Epub) applyFootnotes(filename string, body string) string {
+	asides := ""
+	for _, note := range e.footnotes {
+		if note.filename != filename {
+			continue
+		}
+		body = noteRefRegexp(note.id).ReplaceAllStringFunc(body, func(tag string) string {
+			if e.endnotesFilename != "" {
+				tag = strings.Replace(tag, "#"+note.id, e.endnotesFilename+"#"+note.id, 1)
+			}
+			if strings.Contains(tag, "epub:type") {
+				return tag
+			}
+			return `<a epub:type="noteref"` + tag[len("<a"):]
+		})
+		if e.endnotesFilename == "" {
+			asides += fmt.Sprintf(footnoteTemplate, note.id, note.xhtml)
+		}
+	}
+
+	return body + asides
+}
+
+// Whether notes were added to a section
+func (e *Epub) hasFootnotes(filename string) bool {
+	for _, note := range e.footnotes {
+		if note.filename == filename {
+			return true
+		}
+	}
+	return false
+}
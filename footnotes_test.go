@@ -0,0 +1,108 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testFootnoteBody = `<p>Text<a href="#note1">1</a></p>`
+
+func TestAddFootnote(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddSection(testFootnoteBody, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+
+	if err := e.AddFootnote("missing.xhtml", "note1", "Note"); err == nil {
+		t.Errorf("Expected error adding a note to a missing section")
+	} else if _, ok := err.(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error FilenameNotFoundError not returned. Returned instead: %+v", err)
+	}
+	for _, id := range []string{"note 1", "note2"} {
+		err := e.AddFootnote(filename, id, "Note")
+		if _, ok := err.(*InvalidFootnoteError); !ok {
+			t.Errorf("Expected error InvalidFootnoteError not returned for %q. Returned instead: %+v", id, err)
+		}
+	}
+	if err := e.AddFootnote(filename, "note1", "<p>The <em>note</em></p>"); err != nil {
+		t.Fatalf("Unexpected error adding note: %s", err)
+	}
+	if _, ok := e.AddFootnote(filename, "note1", "Note").(*InvalidFootnoteError); !ok {
+		t.Errorf("Expected error InvalidFootnoteError not returned for a duplicate ID")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	for _, expected := range []string{
+		`xmlns:epub="http://www.idpf.org/2007/ops"`,
+		`<a epub:type="noteref" href="#note1">1</a>`,
+		`<aside epub:type="footnote" id="note1"><p>The <em>note</em></p></aside>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("Section doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+		}
+	}
+	// Notes are added when the EPUB is written
+	if body := e.sections[0].xhtml.xml.Body.XML; strings.TrimSpace(body) != testFootnoteBody {
+		t.Errorf("Section body was modified\nGot: %s\nExpected: %s", body, testFootnoteBody)
+	}
+}
+
+func TestCollectEndnotes(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddSection(testFootnoteBody, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	notesPath, err := e.CollectEndnotes("")
+	if err != nil {
+		t.Fatalf("Unexpected error adding notes page: %s", err)
+	}
+	if err := e.AddFootnote(filename, "note1", "The note"); err != nil {
+		t.Fatalf("Unexpected error adding note: %s", err)
+	}
+
+	// The notes page is generated again once restored from a snapshot
+	data, err := e.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling: %s", err)
+	}
+	e = NewEpub("")
+	if err := e.UnmarshalJSON(data); err != nil {
+		t.Fatalf("Unexpected error unmarshalling: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	expected := `<a epub:type="noteref" href="notes.xhtml#note1">1</a>`
+	if !strings.Contains(string(contents), expected) || strings.Contains(string(contents), "<aside") {
+		t.Errorf("Section doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filepath.Base(notesPath)))
+	if err != nil {
+		t.Fatalf("Unexpected error reading notes file: %s", err)
+	}
+	for _, expected := range []string{
+		`<section epub:type="endnotes">`,
+		`<h1>Notes</h1>`,
+		`<li epub:type="endnote" id="note1">The note</li>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("Notes page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+		}
+	}
+}
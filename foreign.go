@@ -0,0 +1,202 @@
+package epub
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	foreignElementTemplate = `<object data="%s" type="%s">
+%s
+</object>`
+	foreignFileFormat = "foreign%04d%s"
+)
+
+// InvalidForeignResourceError is thrown by AddForeignResource and
+// UnmarshalJSON if the media type of a foreign resource is missing or is
+// supported by EPUB, in which case the file must be added using e.g. AddImage
+// instead.
+type InvalidForeignResourceError struct {
+	Source    string // The source of the file
+	MediaType string // The media type of the file
+}
+
+func (e *InvalidForeignResourceError) Error() string {
+	return fmt.Sprintf("Invalid media type %q of foreign resource %q", e.MediaType, e.Source)
+}
+
+// A resource added using AddForeignResource
+type epubForeign struct {
+	source    string
+	mediaType string
+	// Filename of the section used as a fallback
+	fallback string
+}
+
+// AddForeignResource adds a file whose media type isn't supported by EPUB
+// reading systems, such as a 3D model or an interactive widget, and returns a
+// relative path to the file that can be used in EPUB sections in the format:
+// ../ForeignFolderName/internalFilename
+//
+// As reading systems may not be able to render the file, it must have a
+// fallback: either pass the internal filename of an already-added section
+// (as returned by AddSection), which is listed as the fallback of the file in
+// the manifest, or embed the file using ForeignElement, which provides the
+// fallback content inline. If the fallback section doesn't exist,
+// FilenameNotFoundError will be returned.
+//
+// The source should either be a URL, a data URL, or a path to a local file.
+// The media type is required, e.g. model/gltf-binary; if it's missing or is
+// supported by EPUB, InvalidForeignResourceError will be returned. The
+// internal filename works the same way as for AddImage, including in sandbox
+// mode.
+func (e *Epub) AddForeignResource(source string, internalFilename string, mediaType string, fallbackSectionFilename string) (string, error) {
+	if err := validateForeignMediaType(source, mediaType); err != nil {
+		return "", err
+	}
+	if e.sandbox != nil {
+		var err error
+		source, internalFilename, err = e.sandboxMediaSource(source, internalFilename)
+		if err != nil {
+			return "", err
+		}
+	}
+	if fallbackSectionFilename != "" {
+		found := false
+		for _, section := range e.sections {
+			if section.filename == fallbackSectionFilename {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", &FilenameNotFoundError{Filename: fallbackSectionFilename}
+		}
+	}
+	if err := validateFileSource(e.httpClient(), source); err != nil {
+		return "", &FileRetrievalError{
+			Source: source,
+			Err:    err,
+		}
+	}
+
+	if internalFilename == "" {
+		internalFilename = filepath.Base(source)
+		if _, ok := e.foreign[internalFilename]; ok || validateFilename(internalFilename) != nil || strings.HasPrefix(source, dataURLPrefix) {
+			ext := ""
+			if !strings.HasPrefix(source, dataURLPrefix) {
+				ext = path.Ext(source)
+			}
+			internalFilename = fmt.Sprintf(foreignFileFormat, len(e.foreign)+1, ext)
+		}
+	}
+	if err := validateFilename(internalFilename); err != nil {
+		return "", err
+	}
+	if _, ok := e.foreign[internalFilename]; ok {
+		return "", &FilenameAlreadyUsedError{Filename: internalFilename}
+	}
+
+	e.foreign[internalFilename] = &epubForeign{
+		source:    source,
+		mediaType: mediaType,
+		fallback:  fallbackSectionFilename,
+	}
+
+	return filepath.Join("..", ForeignFolderName, internalFilename), nil
+}
+
+// Check that a foreign resource has a media type that isn't supported by EPUB
+func validateForeignMediaType(source string, mediaType string) error {
+	if mediaType == "" || mediaType == mediaTypeXhtml || isCoreMediaType(mediaType) {
+		return &InvalidForeignResourceError{Source: source, MediaType: mediaType}
+	}
+
+	return nil
+}
+
+// Whether a media type is one of the media types supported by EPUB
+func isCoreMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range extensionMediaTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForeignElement returns the markup to embed a foreign resource in a section,
+// with the provided XHTML shown by reading systems that can't render it, e.g.
+// an image of a 3D model.
+//
+// The internal path to an already-added foreign resource (as returned by
+// AddForeignResource) is required. If it doesn't match a resource that has been
+// added, FilenameNotFoundError will be returned.
+func (e *Epub) ForeignElement(internalPath string, fallbackXHTML string) (string, error) {
+	foreign, ok := e.foreign[filepath.Base(internalPath)]
+	if !ok {
+		return "", &FilenameNotFoundError{Filename: filepath.Base(internalPath)}
+	}
+
+	return fmt.Sprintf(
+		foreignElementTemplate,
+		html.EscapeString(filepath.ToSlash(internalPath)),
+		html.EscapeString(foreign.mediaType),
+		fallbackXHTML,
+	), nil
+}
+
+// Get the manifest items of the foreign resources, sorted by filename
+func (e *Epub) foreignManifestItems() []ManifestItem {
+	filenames := make([]string, 0, len(e.foreign))
+	for filename := range e.foreign {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	items := []ManifestItem{}
+	for _, filename := range filenames {
+		items = append(items, ManifestItem{
+			ID:        filename,
+			Href:      path.Join(ForeignFolderName, filename),
+			MediaType: e.foreign[filename].mediaType,
+			Fallback:  e.foreign[filename].fallback,
+		})
+	}
+
+	return items
+}
+
+// Get foreign resources from their source and save them in the temporary
+// directory
+func (e *Epub) writeForeign(tempDir string) error {
+	if len(e.foreign) == 0 {
+		return nil
+	}
+
+	folderPath := filepath.Join(tempDir, contentFolderName, ForeignFolderName)
+	if err := os.Mkdir(folderPath, dirPermissions); err != nil {
+		panic(fmt.Sprintf("Unable to create directory: %s", err))
+	}
+	for filename, foreign := range e.foreign {
+		if err := e.context().Err(); err != nil {
+			return err
+		}
+		filePath := filepath.Join(folderPath, filename)
+		if err := e.copyMedia(ForeignFolderName, filename, foreign.source, filePath); err != nil {
+			return err
+		}
+		if err := e.useDisk(filePath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAddForeignResource(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	fallbackFilename, err := e.AddSection(`<p>A 3D model of a cube</p>`, "Cube", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	modelSource := newDataURL("model/gltf-binary", []byte("glTF"))
+
+	for _, mediaType := range []string{"", "image/png", mediaTypeXhtml} {
+		_, err := e.AddForeignResource(modelSource, "", mediaType, "")
+		if _, ok := err.(*InvalidForeignResourceError); !ok {
+			t.Errorf("Expected error InvalidForeignResourceError not returned for %q. Returned instead: %+v", mediaType, err)
+		}
+	}
+	_, err = e.AddForeignResource(modelSource, "", "model/gltf-binary", "missing.xhtml")
+	if _, ok := err.(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error FilenameNotFoundError not returned. Returned instead: %+v", err)
+	}
+
+	modelPath, err := e.AddForeignResource(modelSource, "cube.glb", "model/gltf-binary", fallbackFilename)
+	if err != nil {
+		t.Fatalf("Unexpected error adding foreign resource: %s", err)
+	}
+	if _, err := e.AddForeignResource(modelSource, "cube.glb", "model/gltf-binary", ""); err == nil {
+		t.Errorf("Expected error adding a foreign resource with the same filename")
+	}
+	widgetPath, err := e.AddForeignResource(newDataURL("text/html", []byte("<p>Slides</p>")), "", "text/html", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding foreign resource: %s", err)
+	}
+
+	element, err := e.ForeignElement(widgetPath, `<p>Slideshow</p>`)
+	if err != nil {
+		t.Errorf("Unexpected error getting foreign element: %s", err)
+	}
+	testElement := `<object data="../foreign/foreign0002" type="text/html">
+<p>Slideshow</p>
+</object>`
+	if element != testElement {
+		t.Errorf("Foreign element doesn't match\nGot: %s\nExpected: %s", element, testElement)
+	}
+	if _, err := e.ForeignElement("../foreign/missing.glb", ""); err == nil {
+		t.Errorf("Expected error getting the element of a missing foreign resource")
+	}
+	e.AddSection(element, "Slideshow", "", "")
+
+	// Foreign resources are kept in snapshots
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+	e = &Epub{}
+	if err := json.Unmarshal(data, e); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, modelPath))
+	if err != nil {
+		t.Errorf("Unexpected error reading foreign resource: %s", err)
+	}
+	if string(contents) != "glTF" {
+		t.Errorf("Foreign resource doesn't match\nGot: %s\nExpected: %s", contents, "glTF")
+	}
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testItem := range []string{
+		`<item id="cube.glb" href="foreign/cube.glb" media-type="model/gltf-binary" fallback="section0001.xhtml"></item>`,
+		`<item id="foreign0002" href="foreign/foreign0002" media-type="text/html"></item>`,
+	} {
+		if !strings.Contains(string(contents), testItem) {
+			t.Errorf("Package file manifest doesn't match\nGot: %s\nExpected to contain: %s", contents, testItem)
+		}
+	}
+}
+
+func TestAddForeignResourceSandbox(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetSandbox(&SandboxOptions{
+		FS: fstest.MapFS{"models/cube.glb": {Data: []byte("glTF")}},
+	})
+	modelPath, err := e.AddForeignResource("models/cube.glb", "", "model/gltf-binary", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding foreign resource from the sandbox file system: %s", err)
+	}
+	if _, err := e.AddForeignResource(newDataURL("model/gltf-binary", []byte("glTF")), "", "model/gltf-binary", ""); err != nil {
+		t.Errorf("Unexpected error adding foreign resource from a data URL: %s", err)
+	}
+	for _, source := range []string{"https://example.com/cube.glb", "/models/cube.glb", "../cube.glb"} {
+		_, err := e.AddForeignResource(source, "", "model/gltf-binary", "")
+		if _, ok := err.(*SandboxViolationError); !ok {
+			t.Errorf("Expected error adding foreign resource %s\nGot: %v\nExpected: SandboxViolationError", source, err)
+		}
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, modelPath))
+	if err != nil {
+		t.Errorf("Unexpected error reading foreign resource: %s", err)
+	}
+	if string(contents) != "glTF" {
+		t.Errorf("Foreign resource doesn't match\nGot: %s\nExpected: %s", contents, "glTF")
+	}
+}
+
+func TestForeignResourceSnapshot(t *testing.T) {
+	secret, err := ioutil.TempFile("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating file: %s", err)
+	}
+	defer os.Remove(secret.Name())
+	secret.Close()
+
+	snapshot := func(mediaType string) []byte {
+		e := NewEpub(testEpubTitle)
+		e.AddSection(testSectionBody, testSectionTitle, "s.xhtml", "")
+		e.foreign["leak.txt"] = &epubForeign{source: secret.Name(), mediaType: mediaType, fallback: "s.xhtml"}
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+		}
+		return data
+	}
+
+	// Foreign resources must have a media type unsupported by EPUB
+	err = json.Unmarshal(snapshot("image/png"), &Epub{})
+	if _, ok := err.(*InvalidForeignResourceError); !ok {
+		t.Errorf("Expected error InvalidForeignResourceError not returned. Returned instead: %+v", err)
+	}
+
+	// Restored sources are checked in sandbox mode
+	e := &Epub{}
+	if err := json.Unmarshal(snapshot("text/plain"), e); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+	e.SetSandbox(&SandboxOptions{})
+	_, err = e.WriteTo(ioutil.Discard)
+	if _, ok := err.(*SandboxViolationError); !ok {
+		t.Errorf("Expected error SandboxViolationError not returned. Returned instead: %+v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package epub
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Files and folders of a book directory, as used by FromDir
+const (
+	dirChaptersFolderName = "chapters"
+	dirImagesFolderName   = "images"
+	dirMetadataFilename   = "metadata.yaml"
+	dirStylesFolderName   = "styles"
+)
+
+// Cover image filenames of a book directory, in order of preference
+var dirCoverFilenames = []string{"cover.jpg", "cover.jpeg", "cover.png", "cover.gif", "cover.svg"}
+
+var (
+	xhtmlBodyRegexp  = regexp.MustCompile(`(?is)<body[^>]*>(.*)</body>`)
+	xhtmlH1Regexp    = regexp.MustCompile(`(?is)<h1[^>]*>(.*?)</h1>`)
+	xhtmlTagRegexp   = regexp.MustCompile(`<[^>]*>`)
+	xhtmlTitleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// FromDir creates an EPUB from a directory laid out using the following
+// conventions, so that simple books can be built without writing any code:
+//
+//	metadata.yaml  Metadata of the EPUB (optional)
+//	cover.jpg      Cover image (optional; cover.jpeg, cover.png, cover.gif
+//	               and cover.svg are also accepted)
+//	chapters/      Sections, as Markdown (.md) or XHTML (.xhtml, .html)
+//	               files, added in lexical order
+//	styles/        CSS files, linked to every section in lexical order
+//	images/        Images, which sections can reference as ../images/name
+//
+// The metadata file contains one "key: value" pair per line. The supported
+// keys are title, author, language, description, identifier and direction
+// (the page progression direction). The title defaults to the name of the
+// directory.
+//
+// The title of each section is taken from its first level 1 heading. XHTML
+// files can either contain a full XHTML document or the content of its body.
+// Files that aren't in UTF-8 are transcoded, as are the CSS files (see
+// DecodeText).
+func FromDir(dirPath string) (*Epub, error) {
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return nil, &FileRetrievalError{Source: dirPath, Err: err}
+	}
+	if !info.IsDir() {
+		return nil, &FileRetrievalError{Source: dirPath, Err: &os.PathError{Op: "open", Path: dirPath, Err: os.ErrInvalid}}
+	}
+
+	e := NewEpub(filepath.Base(filepath.Clean(dirPath)))
+
+	if err := e.readDirMetadata(filepath.Join(dirPath, dirMetadataFilename)); err != nil {
+		return nil, err
+	}
+
+	filenames, err := readDirFilenames(filepath.Join(dirPath, dirImagesFolderName))
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		if _, err := e.AddImage(filepath.Join(dirPath, dirImagesFolderName, filename), filename); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, filename := range dirCoverFilenames {
+		coverPath := filepath.Join(dirPath, filename)
+		if _, err := os.Stat(coverPath); err != nil {
+			continue
+		}
+		imagePath, err := e.AddImage(coverPath, "")
+		if err != nil {
+			return nil, err
+		}
+		e.SetCover(imagePath, "")
+		break
+	}
+
+	cssPaths := []string{}
+	filenames, err = readDirFilenames(filepath.Join(dirPath, dirStylesFolderName))
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		if strings.ToLower(filepath.Ext(filename)) != ".css" {
+			continue
+		}
+		cssPath, err := e.AddCSS(filepath.Join(dirPath, dirStylesFolderName, filename), filename)
+		if err != nil {
+			return nil, err
+		}
+		cssPaths = append(cssPaths, cssPath)
+	}
+
+	filenames, err = readDirFilenames(filepath.Join(dirPath, dirChaptersFolderName))
+	if err != nil {
+		return nil, err
+	}
+	for _, filename := range filenames {
+		ext := strings.ToLower(filepath.Ext(filename))
+		if ext != ".md" && ext != ".xhtml" && ext != ".html" {
+			continue
+		}
+
+		chapterPath := filepath.Join(dirPath, dirChaptersFolderName, filename)
+		content, err := ioutil.ReadFile(chapterPath)
+		if err != nil {
+			return nil, &FileRetrievalError{Source: chapterPath, Err: err}
+		}
+		text, err := DecodeText(content)
+		if err != nil {
+			return nil, &FileRetrievalError{Source: chapterPath, Err: err}
+		}
+
+		body := text
+		if ext == ".md" {
+			body = markdownToXHTML(body)
+		} else if m := xhtmlBodyRegexp.FindStringSubmatch(body); m != nil {
+			body = strings.TrimSpace(m[1])
+		}
+
+		title := ""
+		if m := xhtmlH1Regexp.FindStringSubmatch(body); m != nil {
+			title = strings.TrimSpace(unescapeText(xhtmlTagRegexp.ReplaceAllString(m[1], "")))
+		} else if m := xhtmlTitleRegexp.FindStringSubmatch(text); m != nil && ext != ".md" {
+			title = strings.TrimSpace(unescapeText(m[1]))
+		}
+
+		sectionFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".xhtml"
+		cssPath := ""
+		if len(cssPaths) > 0 {
+			cssPath = cssPaths[0]
+		}
+		if _, err := e.AddSection(body, title, sectionFilename, cssPath); err != nil {
+			return nil, err
+		}
+		// Link the remaining stylesheets as well
+		section := e.sections[len(e.sections)-1]
+		for i, cssPath := range cssPaths {
+			if i > 0 {
+				section.xhtml.addCSS(cssPath)
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// Read the metadata file of a book directory, if there is one
+func (e *Epub) readDirMetadata(metadataPath string) error {
+	f, err := os.Open(metadataPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return &FileRetrievalError{Source: metadataPath, Err: err}
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+
+		i := strings.Index(line, ":")
+		if i == -1 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := unquoteYAML(strings.TrimSpace(line[i+1:]))
+
+		switch key {
+		case "title":
+			e.SetTitle(value)
+		case "author":
+			e.SetAuthor(value)
+		case "language", "lang":
+			e.SetLang(value)
+		case "description":
+			e.SetDescription(value)
+		case "identifier":
+			e.SetIdentifier(value)
+		case "direction", "ppd":
+			e.SetPpd(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return &FileRetrievalError{Source: metadataPath, Err: err}
+	}
+
+	return nil
+}
+
+// Remove the quotes around a YAML scalar, if any
+func unquoteYAML(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		inner := value[1 : len(value)-1]
+		if value[0] == '\'' {
+			return strings.Replace(inner, "''", "'", -1)
+		}
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(inner)
+	}
+
+	// Remove trailing comments
+	if i := strings.Index(value, " #"); i != -1 {
+		value = strings.TrimSpace(value[:i])
+	}
+
+	return value
+}
+
+// Get the names of the files of a directory in lexical order, ignoring hidden
+// files. If the directory doesn't exist, no names are returned.
+func readDirFilenames(dirPath string) ([]string, error) {
+	files, err := ioutil.ReadDir(dirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &FileRetrievalError{Source: dirPath, Err: err}
+	}
+
+	filenames := []string{}
+	for _, f := range files {
+		if !f.IsDir() && !strings.HasPrefix(f.Name(), ".") {
+			filenames = append(filenames, f.Name())
+		}
+	}
+	sort.Strings(filenames)
+
+	return filenames, nil
+}
+
+// Unescape the predefined XML entities of a string
+func unescapeText(s string) string {
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&amp;", "&").Replace(s)
+}
@@ -0,0 +1,138 @@
+package epub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testDirMetadata = `# Book metadata
+title: "My book"
+author: Hingle McCringleberry # The author
+language: fr
+`
+	testDirChapterMarkdown = "# Introduction\n\nSome *text*.\n"
+	testDirChapterXHTML    = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <title>Ignored</title>
+  </head>
+  <body>
+    <h1>Chapter &amp; verse</h1>
+    <p><img src="../images/gopher.png" alt="Gopher" /></p>
+  </body>
+</html>
+`
+)
+
+func TestFromDir(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dirPath)
+
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	for _, folder := range []string{dirChaptersFolderName, dirImagesFolderName, dirStylesFolderName} {
+		os.Mkdir(filepath.Join(dirPath, folder), dirPermissions)
+	}
+	for path, content := range map[string]string{
+		dirMetadataFilename:           testDirMetadata,
+		"cover.png":                   string(image),
+		"chapters/01-introduction.md": testDirChapterMarkdown,
+		"chapters/02-chapter.xhtml":   testDirChapterXHTML,
+		"chapters/notes.txt":          "Not a chapter",
+		"images/gopher.png":           string(image),
+		"styles/a.css":                "body { margin: 0; }",
+		"styles/b.css":                "p { text-indent: 1em; }",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dirPath, filepath.FromSlash(path)), []byte(content), filePermissions); err != nil {
+			t.Fatalf("Unexpected error writing %s: %s", path, err)
+		}
+	}
+
+	e, err := FromDir(dirPath)
+	if err != nil {
+		t.Fatalf("Unexpected error reading directory: %s", err)
+	}
+	if e.Title() != "My book" || e.Author() != testEpubAuthor || e.Lang() != "fr" {
+		t.Errorf(
+			"Metadata doesn't match\n"+
+				"Got: %s, %s, %s\n"+
+				"Expected: %s, %s, %s",
+			e.Title(), e.Author(), e.Lang(),
+			"My book", testEpubAuthor, "fr")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading nav file: %s", err)
+	}
+	for _, expected := range []string{
+		`<a href="xhtml/01-introduction.xhtml">Introduction</a>`,
+		`<a href="xhtml/02-chapter.xhtml">Chapter &amp; verse</a>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf(
+				"Nav file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				expected)
+		}
+	}
+	if strings.Contains(string(contents), "notes") {
+		t.Errorf("Unexpected section in nav file: %s", contents)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "01-introduction.xhtml"))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	for _, expected := range []string{
+		`<p>Some <em>text</em>.</p>`,
+		`href="../css/a.css"`,
+		`href="../css/b.css"`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf(
+				"Section file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				expected)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<item id="cover.png" href="images/cover.png" media-type="image/png" properties="cover-image"></item>`,
+		`<item id="gopher.png" href="images/gopher.png" media-type="image/png"></item>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf(
+				"Package file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				expected)
+		}
+	}
+
+	_, err = FromDir(testImageFromFileSource)
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	alsoByPageBodyTemplate = `<h1>%s</h1>
+<%s class="also-by"%s>
+%s</%s>`
+	alsoByPageFilename        = "alsoby.xhtml"
+	alsoByPageItemTemplate    = "<li>%s</li>\n"
+	alsoByPageLinkTemplate    = `<a href="%s">%s</a>`
+	halfTitlePageBodyTemplate = `<h1 class="half-title">%s</h1>`
+	halfTitlePageFilename     = "halftitle.xhtml"
+	praiseCSSContent          = `blockquote.praise {
+  margin: 1.5em 2em;
+}
+blockquote.praise p.praise-source {
+  text-align: right;
+}
+[dir="rtl"] blockquote.praise p.praise-source {
+  text-align: left;
+}
+blockquote.praise cite {
+  font-style: italic;
+}
+`
+	praiseCSSFilename  = "praise.css"
+	praiseItemTemplate = `<blockquote class="praise">
+<p>%s</p>
+%s</blockquote>
+`
+	praisePageBodyTemplate = "<h1>%s</h1>\n%s"
+	praisePageFilename     = "praise.xhtml"
+	praiseSourceTemplate   = `<p class="praise-source">—%s</p>
+`
+)
+
+// BookListing is a book listed on the page added by AddAlsoByPage.
+type BookListing struct {
+	// The title of the book
+	Title string
+	// A link to the book in a store, optional
+	URL string
+}
+
+// AddHalfTitlePage adds a half-title page to the EPUB, the page showing only
+// the title of the book that precedes the title page in trade publishing. The
+// title is the one set when the EPUB is written. The page isn't in the table
+// of contents.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The title is an <h1> element with the class
+// "half-title", which can be used to style it.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddHalfTitlePage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", "", halfTitlePageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		body: func() string {
+			return fmt.Sprintf(halfTitlePageBodyTemplate, escapeText(e.Title()))
+		},
+	}
+
+	return filename, nil
+}
+
+// AddAlsoByPage adds a page listing other books to the EPUB, usually at the
+// end of the back matter. If the name of a series is provided, the page lists
+// the books of the series in order under the title "Books in the <series>
+// series"; otherwise it lists other books by the author under the title "Also
+// by <author>", using the author set when the EPUB is written. The titles are
+// translated according to the language of the EPUB (see SetLabels). Books with
+// a URL are linked to it.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The list of books is an element with the
+// class "also-by", which can be used to style it.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddAlsoByPage(series string, books []BookListing, internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelAlsoByThisAuthor, ""), alsoByPageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	books = append([]BookListing{}, books...)
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		title: func() string {
+			if series != "" {
+				return e.label(LabelSeries, series)
+			}
+			if e.Author() != "" {
+				return e.label(LabelAlsoBy, e.Author())
+			}
+			return e.label(LabelAlsoByThisAuthor, "")
+		},
+		body: func() string {
+			items := ""
+			for _, book := range books {
+				item := escapeText(book.Title)
+				if book.URL != "" {
+					item = fmt.Sprintf(alsoByPageLinkTemplate, escapeAttribute(book.URL), item)
+				}
+				items += fmt.Sprintf(alsoByPageItemTemplate, item)
+			}
+
+			// The books of a series are listed in order
+			list := "ul"
+			style := ""
+			if series != "" {
+				list = "ol"
+				style = e.listStyleAttribute()
+			}
+
+			return fmt.Sprintf(alsoByPageBodyTemplate, escapeText(x.Title()), list, style, items, list)
+		},
+	}
+
+	return filename, nil
+}
+
+// Praise is a review quote listed on the page added by AddPraisePage.
+type Praise struct {
+	// The quote, without quotation marks
+	Quote string
+	// The person quoted, optional
+	Source string
+	// The publication the quote is from, optional
+	Publication string
+}
+
+// Quotation marks per primary language subtag; other languages use the
+// English ones
+var praiseQuotationMarks = map[string][2]string{
+	"cs": {"\u201e", "\u201c"},
+	"da": {"\u00bb", "\u00ab"},
+	"de": {"\u201e", "\u201c"},
+	"es": {"\u00ab", "\u00bb"},
+	"fi": {"\u201d", "\u201d"},
+	"fr": {"\u00ab\u00a0", "\u00a0\u00bb"},
+	"it": {"\u00ab", "\u00bb"},
+	"ja": {"\u300c", "\u300d"},
+	"pl": {"\u201e", "\u201d"},
+	"pt": {"\u00ab", "\u00bb"},
+	"ru": {"\u00ab", "\u00bb"},
+	"sv": {"\u201d", "\u201d"},
+	"uk": {"\u00ab", "\u00bb"},
+}
+
+// AddPraisePage adds a page of review quotes to the EPUB, usually at the start
+// of the front matter, titled "Praise for <title>" using the title set when
+// the EPUB is written and translated according to its language (see
+// SetLabels). Each quote is a <blockquote> element with the class
+// "praise" ending with its source, the title of the publication being in a
+// <cite> element. The quotes are enclosed in the quotation marks of the
+// language of the EPUB when it's written, e.g. « » for French.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional; if none is provided, a default stylesheet is
+// added to the EPUB and used.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddPraisePage(praise []Praise, internalCSSPath string) (string, error) {
+	for _, section := range e.sections {
+		if section.filename == praisePageFilename {
+			return "", &FilenameAlreadyUsedError{Filename: praisePageFilename}
+		}
+	}
+	if internalCSSPath == "" {
+		var err error
+		internalCSSPath, err = e.AddCSS(newDataURL(mediaTypeCSS, []byte(praiseCSSContent)), praiseCSSFilename)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	filename, err := e.AddSection("", "", praisePageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	praise = append([]Praise{}, praise...)
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		title: func() string {
+			return e.label(LabelPraise, e.Title())
+		},
+		body: func() string {
+			marks, ok := praiseQuotationMarks[primaryLanguageSubtag(e.Lang())]
+			if !ok {
+				marks = [2]string{"\u201c", "\u201d"}
+			}
+
+			items := ""
+			for _, p := range praise {
+				source := []string{}
+				if p.Source != "" {
+					source = append(source, escapeText(p.Source))
+				}
+				if p.Publication != "" {
+					source = append(source, "<cite>"+escapeText(p.Publication)+"</cite>")
+				}
+				attribution := ""
+				if len(source) > 0 {
+					attribution = fmt.Sprintf(praiseSourceTemplate, strings.Join(source, ", "))
+				}
+				items += fmt.Sprintf(praiseItemTemplate, marks[0]+escapeText(p.Quote)+marks[1], attribution)
+			}
+
+			return fmt.Sprintf(praisePageBodyTemplate, escapeText(x.Title()), items)
+		},
+	}
+
+	return filename, nil
+}
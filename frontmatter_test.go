@@ -0,0 +1,167 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddHalfTitlePage(t *testing.T) {
+	e := NewEpub("Old title")
+	filename, err := e.AddHalfTitlePage("")
+	if err != nil {
+		t.Errorf("Unexpected error adding half-title page: %s", err)
+	}
+	e.SetTitle("Gophers & Co")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading half-title page: %s", err)
+	}
+	testBody := `<h1 class="half-title">Gophers &amp; Co</h1>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"Half-title page doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+}
+
+func TestAddAlsoByPage(t *testing.T) {
+	tests := []struct {
+		series    string
+		author    string
+		testTitle string
+		testBody  string
+	}{
+		{
+			author:    "Jane Doe",
+			testTitle: "Also by Jane Doe",
+			testBody: `<h1>Also by Jane Doe</h1>
+<ul class="also-by">
+<li><a href="https://example.com/?id=1&amp;store=1">First &amp; Second</a></li>
+<li>Third</li>
+</ul>`,
+		},
+		{
+			series:    "Gopher",
+			author:    "Jane Doe",
+			testTitle: "Books in the Gopher series",
+			testBody: `<h1>Books in the Gopher series</h1>
+<ol class="also-by">
+<li><a href="https://example.com/?id=1&amp;store=1">First &amp; Second</a></li>
+<li>Third</li>
+</ol>`,
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub(testEpubTitle)
+		toc, _ := e.GenerateTOCPage("")
+		filename, err := e.AddAlsoByPage(test.series, []BookListing{
+			{Title: "First & Second", URL: "https://example.com/?id=1&store=1"},
+			{Title: "Third"},
+		}, "")
+		if err != nil {
+			t.Errorf("Unexpected error adding also-by page: %s", err)
+		}
+		e.SetAuthor(test.author)
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+		if err != nil {
+			t.Errorf("Unexpected error reading also-by page: %s", err)
+		}
+		if !strings.Contains(string(contents), test.testBody) {
+			t.Errorf(
+				"Also-by page doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				test.testBody)
+		}
+
+		// The TOC page uses the generated title
+		contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, toc))
+		if err != nil {
+			t.Errorf("Unexpected error reading TOC page: %s", err)
+		}
+		if !strings.Contains(string(contents), ">"+test.testTitle+"</a>") {
+			t.Errorf(
+				"TOC page doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				test.testTitle)
+		}
+
+		cleanup(testEpubFilename, tempDir)
+	}
+}
+
+func TestAddPraisePage(t *testing.T) {
+	praise := []Praise{
+		{Quote: "A <great> read.", Source: "Jane Doe", Publication: "The Gopher Times"},
+		{Quote: "Wonderful."},
+	}
+	tests := []struct {
+		lang     string
+		testBody string
+	}{
+		{
+			lang: "en",
+			testBody: `<h1>Praise for Go &amp; gophers</h1>
+<blockquote class="praise">
+<p>“A &lt;great&gt; read.”</p>
+<p class="praise-source">—Jane Doe, <cite>The Gopher Times</cite></p>
+</blockquote>
+<blockquote class="praise">
+<p>“Wonderful.”</p>
+</blockquote>`,
+		},
+		{
+			lang:     "fr-CA",
+			testBody: "<p>« Wonderful. »</p>",
+		},
+		{
+			lang:     "de",
+			testBody: "<p>„Wonderful.“</p>",
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub("Go & gophers")
+		e.SetLang(test.lang)
+		filename, err := e.AddPraisePage(praise, "")
+		if err != nil {
+			t.Errorf("Unexpected error adding praise page: %s", err)
+		}
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+		if err != nil {
+			t.Errorf("Unexpected error reading praise page: %s", err)
+		}
+		if !strings.Contains(string(contents), test.testBody) {
+			t.Errorf(
+				"Praise page doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				test.testBody)
+		}
+		if _, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, praiseCSSFilename)); err != nil {
+			t.Errorf("Unexpected error reading praise CSS file: %s", err)
+		}
+
+		cleanup(testEpubFilename, tempDir)
+	}
+}
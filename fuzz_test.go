@@ -0,0 +1,135 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fuzz tests check that hostile input causes errors rather than panics,
+// and that the EPUBs that can be written only contain files in their folders.
+
+// Write an EPUB, checking the paths of its files
+func fuzzWrite(t *testing.T, e *Epub) {
+	buf := &bytes.Buffer{}
+	if _, err := e.WriteTo(buf); err != nil {
+		if _, ok := err.(*SizeBudgetExceededError); !ok {
+			return
+		}
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	for _, f := range z.File {
+		if path.IsAbs(f.Name) || path.Clean(f.Name) != f.Name || strings.HasPrefix(f.Name, "../") {
+			t.Errorf("Unexpected file in EPUB: %s", f.Name)
+		}
+	}
+}
+
+func FuzzAddSection(f *testing.F) {
+	f.Add(testSectionBody, testSectionTitle, testSectionFilename)
+	f.Add("<p>Unclosed", "", "")
+	f.Add(testSectionBody, "\x00\xff", "../../escape.xhtml")
+	f.Add(strings.Repeat("<b>", 1000), strings.Repeat("a", 1000), strings.Repeat("a", 300))
+
+	f.Fuzz(func(t *testing.T, body string, title string, filename string) {
+		e := NewEpub(title)
+		e.SetAuthor(title)
+		if _, err := e.AddSection(body, title, filename, ""); err != nil {
+			return
+		}
+		fuzzWrite(t, e)
+	})
+}
+
+func FuzzAddImageFromBytes(f *testing.F) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		f.Fatalf("Unexpected error reading image: %s", err)
+	}
+	f.Add(image)
+	f.Add(image[:len(image)/2])
+	f.Add([]byte("<svg"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e := NewEpub(testEpubTitle)
+		// Images are decoded to fit in the size budget
+		e.SetSizeBudget(1)
+		if _, err := e.AddImageFromBytes(data, ""); err != nil {
+			return
+		}
+		fuzzWrite(t, e)
+	})
+}
+
+func FuzzNewEpubFromComic(f *testing.F) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		f.Fatalf("Unexpected error reading image: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	z := zip.NewWriter(buf)
+	for _, name := range []string{"01.png", "__MACOSX/02.png", "../03.png"} {
+		w, _ := z.Create(name)
+		w.Write(image)
+	}
+	z.Close()
+	f.Add(buf.Bytes())
+	f.Add([]byte("PK\x03\x04"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tempDir, err := ioutil.TempDir("", tempDirPrefix)
+		if err != nil {
+			t.Fatalf("Unexpected error creating temp directory: %s", err)
+		}
+		defer os.RemoveAll(tempDir)
+		source := filepath.Join(tempDir, "comic.cbz")
+		if err := ioutil.WriteFile(source, data, filePermissions); err != nil {
+			t.Fatalf("Unexpected error writing comic: %s", err)
+		}
+
+		e, err := NewEpubFromComic(source, ComicOptions{DoublePages: DoublePagesSplit})
+		if err != nil {
+			return
+		}
+		fuzzWrite(t, e)
+	})
+}
+
+func FuzzUnmarshalJSON(f *testing.F) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+	e.AddCSS(testCoverCSSSource, "")
+	snapshot, err := json.Marshal(e)
+	if err != nil {
+		f.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+	f.Add(snapshot)
+	f.Add([]byte(`{"format":1,"images":{"../../escape.png":"data:image/png;base64,"}}`))
+	f.Add([]byte(`{"format":1,"sections":[{"filename":"/etc/passwd"}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e := &Epub{}
+		if err := json.Unmarshal(data, e); err != nil {
+			return
+		}
+		// Local and remote sources are rejected by NewHandler
+		for _, media := range []map[string]string{e.audio, e.css, e.fonts, e.images, e.videos} {
+			for _, source := range media {
+				if !strings.HasPrefix(source, dataURLPrefix) {
+					return
+				}
+			}
+		}
+		fuzzWrite(t, e)
+	})
+}
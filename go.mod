@@ -1,3 +1 @@
 module github.com/bmaupin/go-epub
-
-require github.com/gofrs/uuid v3.1.0+incompatible
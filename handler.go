@@ -0,0 +1,242 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Prefix of the sources referring to the assets of a multipart request
+	handlerAssetPrefix = "asset:"
+	// Name of the part of a multipart request containing the book
+	handlerBookPart = "book"
+	// Default maximum size of a request, in bytes
+	handlerDefaultMaxRequestSize = 32 << 20
+	handlerFilename              = "book.epub"
+)
+
+// HandlerOptions configures the handler returned by NewHandler.
+type HandlerOptions struct {
+	// Whether sources can be http or https URLs, which the server retrieves.
+	// Disabled by default.
+	AllowRemoteSources bool
+	// Whether sources can be paths to local files of the server. Disabled by
+	// default; only enable it if the clients are trusted.
+	AllowLocalSources bool
+	// Maximum size of a request in bytes; 32 MiB if 0
+	MaxRequestSize int64
+}
+
+// NewHandler returns an http.Handler building EPUBs, so that services that
+// aren't written in Go can use this package over HTTP. The handler accepts
+// POST requests with a book in the JSON format of MarshalJSON and responds
+// with the EPUB.
+//
+// The book can be sent either as the body of the request (with the
+// Content-Type application/json), or as the part named "book" of a
+// multipart/form-data request along with its assets: each other part is an
+// asset, which sources can refer to using "asset:" followed by the name of
+// the part, e.g. asset:cover.png. The extension of the asset is taken from the
+// filename of the part, or else from its name.
+//
+// For security, the sources of all the files, including foreign resources,
+// must be assets or data URLs, or http or https URLs if
+// HandlerOptions.AllowRemoteSources is set, or paths to local files of the
+// server if HandlerOptions.AllowLocalSources is set. Invalid requests get a
+// 400 Bad Request response, errors of the server (e.g. when an asset can't be
+// saved) a 500 Internal Server Error response, and books that can't be
+// written a 422 Unprocessable Entity response, with the error as the body.
+// The EPUB stops being written if the request is canceled, e.g. when the
+// client disconnects.
+//
+// The EPUB is streamed to the response, except if it has a size budget (see
+// SetSizeBudget): whether the budget is exceeded is only known once it's
+// written, so it's kept in memory until then.
+func NewHandler(options HandlerOptions) http.Handler {
+	if options.MaxRequestSize <= 0 {
+		options.MaxRequestSize = handlerDefaultMaxRequestSize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, options.MaxRequestSize)
+
+		tempDir, err := ioutil.TempDir("", tempDirPrefix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error creating temp directory: %s", err), http.StatusInternalServerError)
+			return
+		}
+		// The response is already sent, so an error removing the directory
+		// can't be reported
+		defer os.RemoveAll(tempDir)
+
+		e, err := readHandlerRequest(r, tempDir, options)
+		if err != nil {
+			status := http.StatusBadRequest
+			if _, ok := err.(*handlerServerError); ok {
+				status = http.StatusInternalServerError
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		// The EPUB is streamed to the response, unless it has a size budget:
+		// whether it's exceeded is only known once it's written
+		var buf *bytes.Buffer
+		var dest io.Writer = &handlerResponseWriter{w: w}
+		if e.sizeBudget > 0 {
+			buf = &bytes.Buffer{}
+			dest = buf
+		}
+		n, err := e.writeTo(r.Context(), dest)
+		if err != nil {
+			// Once the response is started, the error can't be reported
+			if buf != nil || n == 0 {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			}
+			return
+		}
+		if buf != nil {
+			buf.WriteTo(&handlerResponseWriter{w: w})
+		}
+	})
+}
+
+// An error of the server rather than of the request, e.g. when an asset can't
+// be saved
+type handlerServerError struct {
+	err error
+}
+
+func (e *handlerServerError) Error() string {
+	return e.err.Error()
+}
+
+// Sets the headers of the EPUB response when the EPUB is first written
+type handlerResponseWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (h *handlerResponseWriter) Write(p []byte) (int, error) {
+	if !h.started {
+		h.w.Header().Set("Content-Type", mediaTypeEpub)
+		h.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": handlerFilename}))
+		h.started = true
+	}
+	return h.w.Write(p)
+}
+
+// Read the book of a request, saving its assets to the temporary directory and
+// checking its sources
+func readHandlerRequest(r *http.Request, tempDir string, options HandlerOptions) (*Epub, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var book []byte
+	assets := map[string]string{}
+	switch mediaType {
+	case "application/json":
+		var err error
+		book, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+	case "multipart/form-data":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			return nil, err
+		}
+		book, err = readHandlerParts(mr, tempDir, assets)
+		if err != nil {
+			return nil, err
+		}
+		if book == nil {
+			return nil, fmt.Errorf("Missing %q part", handlerBookPart)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported content type: %q", mediaType)
+	}
+
+	e := &Epub{}
+	if err := json.Unmarshal(book, e); err != nil {
+		return nil, err
+	}
+
+	err := e.mapSources(func(source string) (string, error) {
+		if strings.HasPrefix(source, handlerAssetPrefix) {
+			path, ok := assets[strings.TrimPrefix(source, handlerAssetPrefix)]
+			if !ok {
+				return "", fmt.Errorf("Asset not found: %q", source)
+			}
+			return path, nil
+		}
+		if strings.HasPrefix(source, dataURLPrefix) {
+			return source, nil
+		}
+		if isRemoteSource(source) && options.AllowRemoteSources || !isRemoteSource(source) && options.AllowLocalSources {
+			return source, nil
+		}
+		return "", fmt.Errorf("Source not allowed: %q", source)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Read the parts of a multipart request, returning the book and saving the
+// assets to the temporary directory. The key of the assets is the name of
+// their part, the value is the path to the saved file.
+func readHandlerParts(mr *multipart.Reader, tempDir string, assets map[string]string) ([]byte, error) {
+	var book []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return book, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := part.FormName()
+		if name == handlerBookPart {
+			book, err = ioutil.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ext := filepath.Ext(part.FileName())
+		if ext == "" {
+			ext = filepath.Ext(name)
+		}
+		path := filepath.Join(tempDir, fmt.Sprintf("asset%04d%s", len(assets)+1, ext))
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, &handlerServerError{err: fmt.Errorf("Unable to create file: %s", err)}
+		}
+		_, err = io.Copy(f, part)
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			return nil, &handlerServerError{err: fmt.Errorf("Unable to close file: %s", closeErr)}
+		}
+		if err != nil {
+			return nil, err
+		}
+		assets[name] = path
+	}
+}
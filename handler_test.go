@@ -0,0 +1,163 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewHandler(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddImage(testImageFromFileSource, "gopher.png")
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+	book := strings.Replace(string(data), testImageFromFileSource, "asset:gopher", 1)
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	part, _ := mw.CreateFormField("book")
+	part.Write([]byte(book))
+	part, _ = mw.CreateFormFile("gopher", "gopher.png")
+	part.Write(image)
+	mw.Close()
+
+	server := httptest.NewServer(NewHandler(HandlerOptions{}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, mw.FormDataContentType(), body)
+	if err != nil {
+		t.Fatalf("Unexpected error posting request: %s", err)
+	}
+	epub, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != mediaTypeEpub {
+		t.Fatalf("Unexpected response: %s %s", resp.Status, epub)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(epub), int64(len(epub)))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	found := false
+	for _, f := range z.File {
+		if f.Name == "EPUB/images/gopher.png" {
+			found = f.UncompressedSize64 == uint64(len(image))
+		}
+	}
+	if !found {
+		t.Errorf("Asset not found in the EPUB")
+	}
+
+	tests := []struct {
+		method      string
+		contentType string
+		body        string
+		testStatus  int
+	}{
+		{http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "text/plain", "", http.StatusBadRequest},
+		{http.MethodPost, "application/json", "{", http.StatusBadRequest},
+		// Local files of the server can't be used
+		{http.MethodPost, "application/json", strings.Replace(book, "asset:gopher", testImageFromFileSource, 1), http.StatusBadRequest},
+		// Remote sources are disabled by default
+		{http.MethodPost, "application/json", strings.Replace(book, "asset:gopher", "https://example.com/gopher.png", 1), http.StatusBadRequest},
+		// Missing asset
+		{http.MethodPost, "application/json", book, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, server.URL, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Unexpected error sending request: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.testStatus {
+			t.Errorf(
+				"Response status doesn't match\n"+
+					"Got: %d\n"+
+					"Expected: %d",
+				resp.StatusCode,
+				test.testStatus)
+		}
+	}
+}
+
+func TestNewHandlerForeignSource(t *testing.T) {
+	secret, err := ioutil.TempFile("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating file: %s", err)
+	}
+	defer os.Remove(secret.Name())
+	secret.WriteString("secret")
+	secret.Close()
+
+	book := `{"format":1,"identifier":"urn:uuid:1","lang":"en","title":"t","version":"3.0",` +
+		`"sections":[{"filename":"s.xhtml","body":"<p>s</p>"}],` +
+		`"foreign":{"leak.txt":{"source":` + strconv.Quote(secret.Name()) + `,"mediaType":"text/plain","fallback":"s.xhtml"}}}`
+
+	tests := []struct {
+		options    HandlerOptions
+		testStatus int
+	}{
+		// Local files of the server can't be used by foreign resources either
+		{HandlerOptions{}, http.StatusBadRequest},
+		{HandlerOptions{AllowRemoteSources: true}, http.StatusBadRequest},
+		{HandlerOptions{AllowLocalSources: true}, http.StatusOK},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(NewHandler(test.options))
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(book))
+		if err != nil {
+			t.Fatalf("Unexpected error posting request: %s", err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		server.Close()
+		if resp.StatusCode != test.testStatus {
+			t.Errorf(
+				"Response status doesn't match\n"+
+					"Got: %d\n"+
+					"Expected: %d",
+				resp.StatusCode,
+				test.testStatus)
+		}
+		if resp.StatusCode != http.StatusOK && bytes.Contains(body, []byte("secret")) {
+			t.Errorf("Local file leaked in the response: %s", body)
+		}
+	}
+}
+
+func TestNewHandlerServerError(t *testing.T) {
+	tempDir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", "/nonexistent")
+	defer os.Setenv("TMPDIR", tempDir)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	NewHandler(HandlerOptions{}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf(
+			"Response status doesn't match\n"+
+				"Got: %d\n"+
+				"Expected: %d",
+			rec.Code,
+			http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,262 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IdentifierScheme is the scheme of an identifier added using AddIdentifier.
+type IdentifierScheme string
+
+// Identifier schemes
+const (
+	// Amazon Standard Identification Number
+	IdentifierSchemeASIN IdentifierScheme = "ASIN"
+	// Digital Object Identifier
+	IdentifierSchemeDOI IdentifierScheme = "DOI"
+	// International Standard Book Number, either ISBN-10 or ISBN-13
+	IdentifierSchemeISBN IdentifierScheme = "ISBN"
+	// Uniform Resource Identifier, e.g. the URL of the book
+	IdentifierSchemeURI IdentifierScheme = "URI"
+	// Universally Unique Identifier
+	IdentifierSchemeUUID IdentifierScheme = "UUID"
+)
+
+// ONIX codes (code list 5) of the identifier schemes
+const (
+	onixCodeDOI         = "06"
+	onixCodeISBN10      = "02"
+	onixCodeISBN13      = "15"
+	onixCodeProprietary = "01"
+)
+
+// Identifier is an identifier of an EPUB, in addition to its unique identifier.
+type Identifier struct {
+	Value string `json:"value"`
+	// Optional
+	Scheme IdentifierScheme `json:"scheme,omitempty"`
+}
+
+// AddIdentifier adds an identifier to the EPUB along with its scheme, e.g. the
+// ISBNs of the EPUB and print editions, a DOI, or the ASIN of a store, as
+// distributors may require the full set of identifiers of a book. Identifiers
+// are written in the order they were added, after the unique identifier (see
+// SetIdentifier), which remains the one used by reading systems.
+//
+// If the value is the unique identifier, only its scheme is written. The scheme
+// is optional and written as an identifier-type refinement using the ONIX code
+// of the scheme, or as an opf:scheme attribute for EPUB 2; schemes without an
+// ONIX code, such as UUID, are only written for EPUB 2.
+//
+// Valid ISBNs are normalized as NormalizeISBN does, keeping their urn:isbn:
+// prefix if any, so ISBN-10s are converted to ISBN-13s, e.g. 0-306-40615-2
+// becomes 9780306406157. Invalid ISBNs are kept as they are and checked when
+// the EPUB is written (see ValidateISBN): in strict mode, Write returns
+// InvalidISBNError, otherwise a warning is added to the build report. The same
+// goes for a unique identifier starting with urn:isbn:.
+func (e *Epub) AddIdentifier(value string, scheme IdentifierScheme) {
+	if scheme == IdentifierSchemeISBN {
+		value = normalizeISBNIdentifier(value)
+	}
+	e.identifiers = append(e.identifiers, Identifier{
+		Value:  value,
+		Scheme: scheme,
+	})
+}
+
+// SetUniqueIdentifier sets the unique identifier of the EPUB along with its
+// scheme, e.g. an ISBN, DOI or URI rather than the UUID generated by default.
+// The identifier is written to the package file and the NCX as SetIdentifier
+// does, and its scheme as AddIdentifier does; it's also returned by
+// Identifiers. ISBNs are normalized as AddIdentifier does. An empty scheme only
+// sets the identifier.
+//
+// The previous unique identifier is replaced: it's only kept as an additional
+// identifier if it was added using AddIdentifier.
+func (e *Epub) SetUniqueIdentifier(value string, scheme IdentifierScheme) {
+	if scheme == IdentifierSchemeISBN {
+		value = normalizeISBNIdentifier(value)
+	}
+	if e.uniqueIdentifierAdded {
+		for i, identifier := range e.identifiers {
+			if identifier.Value == e.identifier {
+				e.identifiers = append(e.identifiers[:i], e.identifiers[i+1:]...)
+				break
+			}
+		}
+		e.uniqueIdentifierAdded = false
+	}
+
+	e.SetIdentifier(value)
+	if scheme == "" {
+		return
+	}
+	for i, identifier := range e.identifiers {
+		if identifier.Value == value {
+			e.identifiers[i].Scheme = scheme
+			return
+		}
+	}
+	e.AddIdentifier(value, scheme)
+	e.uniqueIdentifierAdded = true
+}
+
+// Normalize the value of an ISBN identifier, keeping its urn:isbn: prefix if
+// any. Invalid ISBNs are returned as they are.
+func normalizeISBNIdentifier(value string) string {
+	isbn, err := NormalizeISBN(value)
+	if err != nil {
+		return value
+	}
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), urnISBNPrefix) {
+		return urnISBNPrefix + isbn
+	}
+
+	return isbn
+}
+
+// Identifiers returns the identifiers added using AddIdentifier.
+func (e *Epub) Identifiers() []Identifier {
+	return append([]Identifier{}, e.identifiers...)
+}
+
+// Get the ONIX code of the scheme of the identifier
+func (i Identifier) onixCode() string {
+	switch i.Scheme {
+	case IdentifierSchemeASIN:
+		return onixCodeProprietary
+	case IdentifierSchemeDOI:
+		return onixCodeDOI
+	case IdentifierSchemeISBN:
+		if len(isbnCharacters(i.Value)) == 10 {
+			return onixCodeISBN10
+		}
+		return onixCodeISBN13
+	}
+
+	return ""
+}
+
+// IdentifierStrategy generates the unique identifier of an EPUB. Strategies
+// are evaluated whenever the identifier is needed (by Identifier or Write), so
+// identifiers derived from the content of the EPUB reflect its final state.
+type IdentifierStrategy func(e *Epub) string
+
+// Namespace used to derive content hash identifiers
+var contentHashNamespace = newUUIDv5(uuidNamespaceURL, "https://github.com/bmaupin/go-epub#content-hash")
+
+// RandomIdentifier generates a random identifier using the ID generator of
+// the EPUB (see SetIDGenerator). By default, this is a random (version 4) UUID
+// generated using crypto/rand. A random identifier is generated by NewEpub
+// unless another identifier or strategy is set.
+func RandomIdentifier(e *Epub) string {
+	if e.idGenerator != nil {
+		return e.idGenerator()
+	}
+
+	return urnUUIDPrefix + mustNewUUIDv4().String()
+}
+
+// SetIDGenerator sets a custom generator for random identifiers, e.g. one
+// backed by a FIPS-compliant randomness source. If the identifier of the EPUB
+// was generated automatically, it is regenerated using the new generator.
+//
+// Passing nil restores the default generator.
+func (e *Epub) SetIDGenerator(generator func() string) {
+	e.idGenerator = generator
+	if e.identifierGenerated {
+		e.setIdentifier(RandomIdentifier(e))
+	}
+}
+
+// UUIDv5Identifier returns a strategy that generates a deterministic (version
+// 5) UUID identifier from a namespace and a name, so regenerating the same
+// book always results in the same identifier.
+//
+// The namespace can be a UUID or any other string, such as the URL of the
+// publisher; the name is typically the title or ISBN of the book.
+func UUIDv5Identifier(namespace string, name string) IdentifierStrategy {
+	ns, err := parseUUID(namespace)
+	if err != nil {
+		ns = newUUIDv5(uuidNamespaceURL, namespace)
+	}
+	id := urnUUIDPrefix + newUUIDv5(ns, name).String()
+
+	return func(e *Epub) string {
+		return id
+	}
+}
+
+// ContentHashIdentifier generates a deterministic UUID identifier from a hash
+// of the metadata and sections of the EPUB as well as the filenames and
+// contents of the audio, CSS, font, image, and video files, which are read
+// from their sources. Books with identical content get identical identifiers,
+// wherever their files come from.
+func ContentHashIdentifier(e *Epub) string {
+	return urnUUIDPrefix + newUUIDv5(contentHashNamespace, e.contentHash()).String()
+}
+
+// SetIdentifierStrategy sets the strategy used to generate the unique
+// identifier of the EPUB, e.g.:
+//
+//	e.SetIdentifierStrategy(epub.UUIDv5Identifier("https://example.com", "My title"))
+//
+// Calling SetIdentifier afterwards overrides the strategy.
+func (e *Epub) SetIdentifierStrategy(strategy IdentifierStrategy) {
+	e.identifierStrategy = strategy
+	e.identifierGenerated = false
+	e.resolveIdentifier()
+}
+
+// Apply the identifier strategy if one is set
+func (e *Epub) resolveIdentifier() {
+	if e.identifierStrategy != nil {
+		e.setIdentifier(e.identifierStrategy(e))
+	} else if e.reproducible && e.identifierGenerated {
+		e.setIdentifier(ContentHashIdentifier(e))
+	}
+}
+
+// Compute a hex encoded hash of the content of the EPUB
+func (e *Epub) contentHash() string {
+	h := sha256.New()
+	write := func(values ...string) {
+		for _, v := range values {
+			// Prefix each value with its length so the hash is unambiguous
+			fmt.Fprintf(h, "%d:%s", len(v), v)
+		}
+	}
+
+	write(e.title, e.author, e.lang, e.desc, e.ppd)
+	for _, section := range e.sections {
+		link := ""
+		for _, l := range section.xhtml.xml.Head.Link {
+			link += l.Href
+		}
+		write(section.filename, section.xhtml.Title(), link, section.xhtml.xml.Body.XML)
+	}
+	for _, mediaMap := range []map[string]string{e.audio, e.css, e.fonts, e.images, e.videos} {
+		filenames := make([]string, 0, len(mediaMap))
+		for filename := range mediaMap {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+
+		for _, filename := range filenames {
+			source := mediaMap[filename]
+			// The source is hashed instead if it can't be read, e.g. a missing
+			// file, for which Write will return an error anyway
+			sum, err := hashMediaSource(e.context(), e.httpClient(), source)
+			if err != nil {
+				write(filename, source)
+				continue
+			}
+			write(filename, string(sum))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
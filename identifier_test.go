@@ -0,0 +1,333 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testUUIDv5Identifier = "urn:uuid:e877a66f-a837-5aa8-afb3-07d06acb93b6"
+	testUUIDv5Namespace  = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+)
+
+func TestUUIDv5Identifier(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetIdentifierStrategy(UUIDv5Identifier(testUUIDv5Namespace, testEpubTitle))
+
+	if e.Identifier() != testUUIDv5Identifier {
+		t.Errorf(
+			"Identifier doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e.Identifier(),
+			testUUIDv5Identifier)
+	}
+
+	// Namespaces that aren't UUIDs should still produce stable identifiers
+	id1 := UUIDv5Identifier("https://example.com", testEpubTitle)(e)
+	id2 := UUIDv5Identifier("https://example.com", testEpubTitle)(e)
+	if id1 != id2 || !strings.HasPrefix(id1, urnUUIDPrefix) {
+		t.Errorf("Unexpected identifiers: %s, %s", id1, id2)
+	}
+
+	e.SetIdentifier(testEpubIdentifier)
+	if e.Identifier() != testEpubIdentifier {
+		t.Errorf("SetIdentifier didn't override the identifier strategy")
+	}
+}
+
+func TestContentHashIdentifier(t *testing.T) {
+	e1 := NewEpub(testEpubTitle)
+	e1.SetIdentifierStrategy(ContentHashIdentifier)
+	e1.AddSection(testSectionBody, testSectionTitle, "", "")
+	imagePath, _ := e1.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e1.SetCover(imagePath, "")
+
+	e2 := NewEpub(testEpubTitle)
+	e2.SetIdentifierStrategy(ContentHashIdentifier)
+	e2.AddSection(testSectionBody, testSectionTitle, "", "")
+	imagePath, _ = e2.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e2.SetCover(imagePath, "")
+
+	if e1.Identifier() != e2.Identifier() {
+		t.Errorf(
+			"Identifiers of identical EPUBs don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e2.Identifier(),
+			e1.Identifier())
+	}
+
+	id := e1.Identifier()
+	e1.AddSection(testSectionBody, testSectionTitle, "", "")
+	if e1.Identifier() == id {
+		t.Errorf("Identifier didn't change after the content changed")
+	}
+
+	// The contents of the files are hashed rather than their sources
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+	e3 := NewEpub(testEpubTitle)
+	e3.SetIdentifierStrategy(ContentHashIdentifier)
+	e3.AddSection(testSectionBody, testSectionTitle, "", "")
+	imagePath, _ = e3.AddImage(newDataURL("image/png", image), testImageFromFileFilename)
+	e3.SetCover(imagePath, "")
+	if e3.Identifier() != e2.Identifier() {
+		t.Errorf(
+			"Identifiers of EPUBs with identical files don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e3.Identifier(),
+			e2.Identifier())
+	}
+	id = e3.Identifier()
+	e3.images[testImageFromFileFilename] = newDataURL("image/png", append(image, 0))
+	if e3.Identifier() == id {
+		t.Errorf("Identifier didn't change after the content of a file changed")
+	}
+
+	tempDir := writeAndExtractEpub(t, e1, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+
+	u, err := parseUUID(e.Identifier())
+	if err != nil {
+		t.Errorf("Default identifier isn't a UUID: %s", err)
+	}
+	if u[6]>>4 != 4 || u[8]&0xc0 != 0x80 {
+		t.Errorf("Default identifier isn't a version 4 UUID: %s", e.Identifier())
+	}
+
+	e.SetIDGenerator(func() string {
+		return testEpubIdentifier
+	})
+	if e.Identifier() != testEpubIdentifier {
+		t.Errorf(
+			"Identifier doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			e.Identifier(),
+			testEpubIdentifier)
+	}
+
+	// An identifier that was set explicitly shouldn't be regenerated
+	e.SetIdentifier("urn:isbn:9780101010101")
+	e.SetIDGenerator(nil)
+	if e.Identifier() != "urn:isbn:9780101010101" {
+		t.Errorf("Explicit identifier was replaced by the ID generator")
+	}
+}
+
+func TestAddIdentifier(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetIdentifier("urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d")
+	e.AddIdentifier("urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d", IdentifierSchemeUUID)
+	e.AddIdentifier("978-3-16-148410-0", IdentifierSchemeISBN)
+	e.AddIdentifier("10.1000/182", IdentifierSchemeDOI)
+	e.AddIdentifier("B000FC1PJI", IdentifierSchemeASIN)
+	e.AddIdentifier("0-306-40615-2", IdentifierSchemeISBN)
+
+	if len(e.Identifiers()) != 5 {
+		t.Errorf("Identifiers don't match\nGot: %+v", e.Identifiers())
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	testMetadata := []string{
+		`unique-identifier="pub-id"`,
+		`<dc:identifier id="pub-id">urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d</dc:identifier>
+    <dc:identifier id="identifier2">9783161484100</dc:identifier>
+    <dc:identifier id="identifier3">10.1000/182</dc:identifier>
+    <dc:identifier id="identifier4">B000FC1PJI</dc:identifier>
+    <dc:identifier id="identifier5">9780306406157</dc:identifier>`,
+		`<meta refines="#identifier2" property="identifier-type" scheme="onix:codelist5">15</meta>`,
+		`<meta refines="#identifier3" property="identifier-type" scheme="onix:codelist5">06</meta>`,
+		`<meta refines="#identifier4" property="identifier-type" scheme="onix:codelist5">01</meta>`,
+		`<meta refines="#identifier5" property="identifier-type" scheme="onix:codelist5">15</meta>`,
+	}
+	for _, test := range testMetadata {
+		if !strings.Contains(string(contents), test) {
+			t.Errorf(
+				"Package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				test)
+		}
+	}
+	if strings.Contains(string(contents), `refines="#pub-id"`) {
+		t.Errorf("Unexpected ONIX code for the UUID scheme\nGot: %s", contents)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	e.SetVersion(EPUBVersion2)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	testMetadata = []string{
+		`<dc:identifier id="pub-id" opf:scheme="UUID">urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d</dc:identifier>`,
+		`<dc:identifier id="identifier2" opf:scheme="ISBN">9783161484100</dc:identifier>`,
+		`<dc:identifier id="identifier4" opf:scheme="ASIN">B000FC1PJI</dc:identifier>`,
+	}
+	for _, test := range testMetadata {
+		if !strings.Contains(string(contents), test) {
+			t.Errorf(
+				"EPUB 2 package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				test)
+		}
+	}
+	if strings.Contains(string(contents), "identifier-type") {
+		t.Errorf("Unexpected identifier-type refinement in EPUB 2\nGot: %s", contents)
+	}
+}
+
+func TestAddIdentifierISBN(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddIdentifier("ISBN 0-306-40615-2", IdentifierSchemeISBN)
+	e.AddIdentifier("urn:isbn:0-306-40615-2", IdentifierSchemeISBN)
+	// Invalid ISBNs are reported when the EPUB is written
+	e.AddIdentifier("0-306-40615-3", IdentifierSchemeISBN)
+	e.SetUniqueIdentifier("978-0-306-40615-7", IdentifierSchemeISBN)
+
+	expected := []Identifier{
+		{Value: "9780306406157", Scheme: IdentifierSchemeISBN},
+		{Value: "urn:isbn:9780306406157", Scheme: IdentifierSchemeISBN},
+		{Value: "0-306-40615-3", Scheme: IdentifierSchemeISBN},
+	}
+	if !reflect.DeepEqual(e.Identifiers(), expected) {
+		t.Errorf("Identifiers don't match\nGot: %+v\nExpected: %+v", e.Identifiers(), expected)
+	}
+	if e.Identifier() != "9780306406157" {
+		t.Errorf("Identifier doesn't match\nGot: %s\nExpected: %s", e.Identifier(), "9780306406157")
+	}
+}
+
+func TestIdentifierONIXCode(t *testing.T) {
+	tests := []struct {
+		value    string
+		testCode string
+	}{
+		{"0-306-40615-2", onixCodeISBN10},
+		{"urn:isbn:0306406152", onixCodeISBN10},
+		{"ISBN 0-306-40615-2", onixCodeISBN10},
+		{"ISBN-10: 0-306-40615-2", onixCodeISBN10},
+		{"urn:isbn:978-0-306-40615-7", onixCodeISBN13},
+		{"ISBN-13: 978-0-306-40615-7", onixCodeISBN13},
+	}
+	for _, test := range tests {
+		code := Identifier{Value: test.value, Scheme: IdentifierSchemeISBN}.onixCode()
+		if code != test.testCode {
+			t.Errorf("ONIX code of %q doesn't match\nGot: %s\nExpected: %s", test.value, code, test.testCode)
+		}
+	}
+}
+
+func TestSetUniqueIdentifier(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddIdentifier("https://example.com/books/1", "")
+	e.SetUniqueIdentifier("urn:isbn:9783161484100", IdentifierSchemeISBN)
+	e.SetUniqueIdentifier("https://example.com/books/1", IdentifierSchemeURI)
+
+	if e.Identifier() != "https://example.com/books/1" {
+		t.Errorf("Identifier doesn't match\nGot: %s\nExpected: %s", e.Identifier(), "https://example.com/books/1")
+	}
+	// The replaced ISBN wasn't added using AddIdentifier
+	expected := []Identifier{
+		{Value: "https://example.com/books/1", Scheme: IdentifierSchemeURI},
+	}
+	if !reflect.DeepEqual(e.Identifiers(), expected) {
+		t.Errorf("Identifiers don't match\nGot: %+v\nExpected: %+v", e.Identifiers(), expected)
+	}
+
+	readPkg := func(tempDir string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+		if err != nil {
+			t.Fatalf("Unexpected error reading package file: %s", err)
+		}
+		return string(contents)
+	}
+
+	// URIs don't have an ONIX code
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	contents := readPkg(tempDir)
+	cleanup(testEpubFilename, tempDir)
+	if !strings.Contains(contents, `<dc:identifier id="pub-id">https://example.com/books/1</dc:identifier>`) ||
+		strings.Contains(contents, "urn:isbn:") || strings.Contains(contents, "identifier-type") {
+		t.Errorf("EPUB 3 package file metadata doesn't match\nGot: %s", contents)
+	}
+
+	e.SetUniqueIdentifier("10.1000/182", IdentifierSchemeDOI)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	contents = readPkg(tempDir)
+	cleanup(testEpubFilename, tempDir)
+	for _, test := range []string{
+		`<dc:identifier id="pub-id">10.1000/182</dc:identifier>
+    <dc:identifier id="identifier1">https://example.com/books/1</dc:identifier>`,
+		`<meta refines="#pub-id" property="identifier-type" scheme="onix:codelist5">06</meta>`,
+	} {
+		if !strings.Contains(contents, test) {
+			t.Errorf("EPUB 3 package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, test)
+		}
+	}
+
+	e.SetVersion(EPUBVersion2)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents = readPkg(tempDir)
+	for _, test := range []string{
+		`<dc:identifier id="pub-id" opf:scheme="DOI">10.1000/182</dc:identifier>`,
+		`<dc:identifier id="identifier1" opf:scheme="URI">https://example.com/books/1</dc:identifier>`,
+	} {
+		if !strings.Contains(contents, test) {
+			t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, test)
+		}
+	}
+
+	ncx, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading NCX file: %s", err)
+	}
+	if !strings.Contains(string(ncx), `<meta name="dtb:uid" content="10.1000/182"`) {
+		t.Errorf("NCX identifier doesn't match\nGot: %s", ncx)
+	}
+
+	// The unique identifier is still replaced once restored from a snapshot
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+	e = &Epub{}
+	if err := json.Unmarshal(data, e); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+	e.SetUniqueIdentifier("https://example.com/books/2", IdentifierSchemeURI)
+	expected = []Identifier{
+		{Value: "https://example.com/books/1", Scheme: IdentifierSchemeURI},
+		{Value: "https://example.com/books/2", Scheme: IdentifierSchemeURI},
+	}
+	if !reflect.DeepEqual(e.Identifiers(), expected) {
+		t.Errorf("Identifiers don't match\nGot: %+v\nExpected: %+v", e.Identifiers(), expected)
+	}
+}
@@ -0,0 +1,113 @@
+package epub
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	imagePageBody       = `<img src="%s" alt=""%s />`
+	imagePageCSSContent = `body {
+  margin: 0;
+  padding: 0;
+  overflow: hidden;
+}
+img {
+  display: block;
+  width: 100%;
+  height: 100%;
+}
+img.spread-left, img.spread-right {
+  width: 200%;
+}
+img.spread-right {
+  margin-left: -100%;
+}
+`
+	// Classes of the images displayed across a spread, showing their left or
+	// right half
+	imagePageSpreadLeftClass  = "spread-left"
+	imagePageSpreadRightClass = "spread-right"
+	imagePageCSSFilename      = "pages.css"
+	imagePageImageFormat      = "page%04d%s"
+	imagePageSectionFormat    = "page%04d.xhtml"
+
+	pageSpreadLeft  = "page-spread-left"
+	pageSpreadRight = "page-spread-right"
+)
+
+// Create a fixed-layout EPUB made of page images, as used when importing
+// comics or scanned documents. The path to the stylesheet of the pages is
+// returned along with the EPUB.
+func newImagePagesEpub(title string) (*Epub, string, error) {
+	e := NewEpub(title)
+	e.SetLayout(FixedLayout)
+	e.SetSpread(SpreadLandscape)
+
+	cssPath, err := e.AddCSS(newDataURL(mediaTypeCSS, []byte(imagePageCSSContent)), imagePageCSSFilename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return e, cssPath, nil
+}
+
+// Add a page image and the fixed-layout section that displays it. The first
+// page is used as the cover image.
+func (e *Epub) addImagePage(pageNumber int, sectionTitle string, ext string, data []byte, width int, height int, cssPath string, spread string) error {
+	imagePath, err := e.addPageImage(pageNumber, ext, data)
+	if err != nil {
+		return err
+	}
+
+	return e.addImagePageSection(pageNumber, sectionTitle, imagePath, "", width, height, cssPath, spread)
+}
+
+// Add the image of a page and return its path. The first page is used as the
+// cover image.
+func (e *Epub) addPageImage(pageNumber int, ext string, data []byte) (string, error) {
+	imagePath, err := e.AddImage(
+		newDataURL(extensionMediaTypes[ext], data),
+		fmt.Sprintf(imagePageImageFormat, pageNumber, ext),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if pageNumber == 1 {
+		e.cover.imageFilename = filepath.Base(imagePath)
+	}
+
+	return imagePath, nil
+}
+
+// Add the fixed-layout section of a page, which displays an image that was
+// already added. The optional class is applied to the image.
+func (e *Epub) addImagePageSection(pageNumber int, sectionTitle string, imagePath string, class string, width int, height int, cssPath string, spread string) error {
+	classAttribute := ""
+	if class != "" {
+		classAttribute = fmt.Sprintf(` class="%s"`, class)
+	}
+
+	sectionFilename := fmt.Sprintf(imagePageSectionFormat, pageNumber)
+	_, err := e.AddSection(
+		fmt.Sprintf(imagePageBody, filepath.ToSlash(imagePath), classAttribute),
+		sectionTitle,
+		sectionFilename,
+		cssPath,
+	)
+	if err != nil {
+		return err
+	}
+	for _, section := range e.sections {
+		if section.filename == sectionFilename {
+			section.xhtml.setViewport(width, height)
+		}
+	}
+
+	if spread != "" {
+		return e.SetSpineItemProperties(sectionFilename, spread)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	isbn13Prefix   = "978"
+	isbn13Prefix2  = "979"
+	urnISBNPrefix  = "urn:isbn:"
+	isbnLabel      = "ISBN"
+	isbnSeparators = "- "
+)
+
+// InvalidISBNError is thrown by ValidateISBN, NormalizeISBN and ISBN10To13 if
+// an ISBN is invalid, and by Write in strict mode (see SetStrict) if an ISBN
+// identifier of the EPUB is invalid.
+type InvalidISBNError struct {
+	ISBN   string // The invalid ISBN
+	Reason string // Why it's invalid
+}
+
+func (e *InvalidISBNError) Error() string {
+	return fmt.Sprintf("Invalid ISBN %q: %s", e.ISBN, e.Reason)
+}
+
+// ValidateISBN checks the format and the check digit of an ISBN-10 or
+// ISBN-13. Hyphens and spaces are ignored, as well as an "ISBN" or "urn:isbn:"
+// prefix, e.g. ISBN-13: 978-0-306-40615-7. InvalidISBNError is returned if the
+// ISBN is invalid.
+func ValidateISBN(isbn string) error {
+	_, err := isbnDigits(isbn)
+	return err
+}
+
+// NormalizeISBN validates an ISBN as ValidateISBN does and returns it as an
+// ISBN-13 without hyphens, prefix or spaces, converting ISBN-10s, e.g.
+// 0-306-40615-2 becomes 9780306406157. This is the form expected by most
+// distributors; reading systems don't rely on the hyphenation, which depends
+// on the registration group and publisher.
+func NormalizeISBN(isbn string) (string, error) {
+	digits, err := isbnDigits(isbn)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) == 10 {
+		digits = isbn13Prefix + digits[:9]
+		digits += isbn13CheckDigit(digits)
+	}
+
+	return digits, nil
+}
+
+// ISBN10To13 converts an ISBN-10 to an ISBN-13 as NormalizeISBN does.
+// InvalidISBNError is returned if the ISBN isn't a valid ISBN-10.
+func ISBN10To13(isbn string) (string, error) {
+	digits, err := isbnDigits(isbn)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) != 10 {
+		return "", &InvalidISBNError{ISBN: isbn, Reason: "not an ISBN-10"}
+	}
+
+	return NormalizeISBN(digits)
+}
+
+// Get the digits of a valid ISBN, the check digit of an ISBN-10 being either a
+// digit or X
+func isbnDigits(isbn string) (string, error) {
+	digits := isbnCharacters(isbn)
+
+	invalid := func(reason string) (string, error) {
+		return "", &InvalidISBNError{ISBN: isbn, Reason: reason}
+	}
+	if len(digits) != 10 && len(digits) != 13 {
+		return invalid("must have 10 or 13 digits")
+	}
+	for i, r := range digits {
+		if (r < '0' || r > '9') && !(r == 'X' && len(digits) == 10 && i == 9) {
+			return invalid(fmt.Sprintf("invalid character %q", r))
+		}
+	}
+
+	var checkDigit string
+	if len(digits) == 10 {
+		checkDigit = isbn10CheckDigit(digits[:9])
+	} else {
+		if !strings.HasPrefix(digits, isbn13Prefix) && !strings.HasPrefix(digits, isbn13Prefix2) {
+			return invalid("ISBN-13 must start with 978 or 979")
+		}
+		checkDigit = isbn13CheckDigit(digits[:12])
+	}
+	if digits[len(digits)-1:] != checkDigit {
+		return invalid(fmt.Sprintf("wrong check digit, expected %s", checkDigit))
+	}
+
+	return digits, nil
+}
+
+// Get the characters of an ISBN without its prefix and separators, e.g.
+// 0306406152 for urn:isbn:0-306-40615-2, whether it's valid or not
+func isbnCharacters(isbn string) string {
+	s := strings.TrimSpace(isbn)
+	if strings.HasPrefix(strings.ToLower(s), urnISBNPrefix) {
+		s = s[len(urnISBNPrefix):]
+	}
+	// e.g. ISBN-13: 978-0-306-40615-7
+	if strings.HasPrefix(strings.ToUpper(s), isbnLabel) {
+		s = s[len(isbnLabel):]
+		for _, suffix := range []string{"-10", "-13"} {
+			s = strings.TrimPrefix(s, suffix)
+		}
+		s = strings.TrimLeft(s, ": ")
+	}
+
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(isbnSeparators, r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// Compute the check digit of the first 9 digits of an ISBN-10
+func isbn10CheckDigit(digits string) string {
+	sum := 0
+	for i, r := range digits {
+		sum += (10 - i) * int(r-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return "X"
+	}
+
+	return fmt.Sprint(check)
+}
+
+// Compute the check digit of the first 12 digits of an ISBN-13
+func isbn13CheckDigit(digits string) string {
+	sum := 0
+	for i, r := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(r-'0')
+	}
+
+	return fmt.Sprint((10 - sum%10) % 10)
+}
+
+// Check the ISBN identifiers of the EPUB: the identifiers added with the ISBN
+// scheme and the unique identifier if it's an ISBN URN. Invalid ISBNs are
+// rejected in strict mode, otherwise they're reported as build warnings.
+func (e *Epub) checkISBNs() error {
+	isbns := []string{}
+	if strings.HasPrefix(strings.ToLower(e.identifier), urnISBNPrefix) {
+		isbns = append(isbns, e.identifier)
+	}
+	for _, identifier := range e.identifiers {
+		if identifier.Scheme == IdentifierSchemeISBN {
+			isbns = append(isbns, identifier.Value)
+		}
+	}
+
+	for _, isbn := range isbns {
+		err := ValidateISBN(isbn)
+		if err == nil {
+			continue
+		}
+		if e.strict {
+			return err
+		}
+		e.buildWarnings = append(e.buildWarnings, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn     string
+		expected string
+		valid    bool
+	}{
+		{"978-0-306-40615-7", "9780306406157", true},
+		{"ISBN-13: 978 0 306 40615 7", "9780306406157", true},
+		{"urn:isbn:9780306406157", "9780306406157", true},
+		{"0-306-40615-2", "9780306406157", true},
+		{"ISBN 0-8044-2957-x", "9780804429573", true},
+		{"979-10-90636-07-1", "9791090636071", true},
+		{"978-0-306-40615-8", "", false},
+		{"0-306-40615-3", "", false},
+		{"977-0-306-40615-0", "", false},
+		{"0-306-4X615-2", "", false},
+		{"12345", "", false},
+	}
+
+	for _, test := range tests {
+		isbn, err := NormalizeISBN(test.isbn)
+		if test.valid && err != nil {
+			t.Errorf("Unexpected error normalizing ISBN %q: %s", test.isbn, err)
+		}
+		if !test.valid {
+			if _, ok := err.(*InvalidISBNError); !ok {
+				t.Errorf("Expected InvalidISBNError for ISBN %q\nGot: %v", test.isbn, err)
+			}
+		}
+		if isbn != test.expected {
+			t.Errorf("Normalized ISBN doesn't match\nGot: %s\nExpected: %s", isbn, test.expected)
+		}
+		if (ValidateISBN(test.isbn) == nil) != test.valid {
+			t.Errorf("ISBN %q validation doesn't match\nExpected valid: %t", test.isbn, test.valid)
+		}
+	}
+
+	if _, err := ISBN10To13("978-0-306-40615-7"); err == nil {
+		t.Error("Expected error converting an ISBN-13")
+	}
+	if isbn, _ := ISBN10To13("0306406152"); isbn != "9780306406157" {
+		t.Errorf("Converted ISBN doesn't match\nGot: %s\nExpected: %s", isbn, "9780306406157")
+	}
+}
+
+func TestInvalidISBNIdentifier(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddIdentifier("978-0-306-40615-7", IdentifierSchemeISBN)
+	e.AddIdentifier("978-0-306-40615-8", IdentifierSchemeISBN)
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+
+	warnings := e.LastBuildReport().Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "978-0-306-40615-8") {
+		t.Errorf("Build warnings don't match\nGot: %v", warnings)
+	}
+
+	e.SetStrict(true)
+	err := e.Write(testEpubFilename)
+	if _, ok := err.(*InvalidISBNError); !ok {
+		t.Errorf("Expected InvalidISBNError in strict mode\nGot: %v", err)
+	}
+
+	e = NewEpub(testEpubTitle)
+	e.SetStrict(true)
+	e.SetIdentifier("urn:isbn:9780306406158")
+	if _, err := e.Finalize(); err == nil {
+		t.Error("Expected error for an invalid unique identifier in strict mode")
+	}
+}
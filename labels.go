@@ -0,0 +1,256 @@
+package epub
+
+import (
+	"strings"
+)
+
+// Label identifies a string of the navigation documents and generated pages,
+// which are translated according to the language of the EPUB.
+type Label string
+
+// Labels of the navigation documents and generated pages. In the labels
+// containing %s, it's replaced by the value described.
+const (
+	// The heading of the navigation document
+	LabelTableOfContents Label = "tableOfContents"
+	// The title of the page added by GenerateTOCPage
+	LabelContents Label = "contents"
+	// The title of the page added by AddLicensePage
+	LabelLicense Label = "license"
+	// The title of the page added by AddVersionHistoryPage
+	LabelVersionHistory Label = "versionHistory"
+	// The title of the page added by AddAlsoByPage, with the author
+	LabelAlsoBy Label = "alsoBy"
+	// The title of the page added by AddAlsoByPage if there is no author
+	LabelAlsoByThisAuthor Label = "alsoByThisAuthor"
+	// The title of the page added by AddAlsoByPage for a series, with the name
+	// of the series
+	LabelSeries Label = "series"
+	// The title of the page added by AddPraisePage, with the title of the EPUB
+	LabelPraise Label = "praise"
+	// The title of the masthead page of EPUBs created by NewEpubFromIssue
+	LabelMasthead Label = "masthead"
+	// The number of an issue on the masthead page, with the number
+	LabelIssueNumber Label = "issueNumber"
+	// The headings and details of the sections added by AddRecipeSection
+	LabelIngredients  Label = "ingredients"
+	LabelInstructions Label = "instructions"
+	LabelYield        Label = "yield"
+	LabelPrepTime     Label = "prepTime"
+	LabelCookTime     Label = "cookTime"
+	// The title of the page added by AddPlaceIndexPage
+	LabelPlaceIndex Label = "placeIndex"
+	// The title of the page added by CollectEndnotes
+	LabelNotes Label = "notes"
+	// The heading of the page list of the navigation documents
+	LabelPageList Label = "pageList"
+	// The title of EPUBs created by Excerpt, with the title of the EPUB
+	LabelExcerpt Label = "excerpt"
+	// The title of the back page added by Excerpt
+	LabelBuyFullBook Label = "buyFullBook"
+	// The heading of the landmarks of the navigation document
+	LabelLandmarks Label = "landmarks"
+)
+
+// Translations of the labels per language tag, either a full tag (e.g. pt-br)
+// or a primary language subtag. Missing labels are in English.
+var labelTranslations = map[string]map[Label]string{
+	"de": {
+		LabelTableOfContents:  "Inhaltsverzeichnis",
+		LabelContents:         "Inhalt",
+		LabelLicense:          "Lizenz",
+		LabelVersionHistory:   "Versionsverlauf",
+		LabelAlsoBy:           "Weitere Bücher von %s",
+		LabelAlsoByThisAuthor: "Weitere Bücher dieses Autors",
+		LabelSeries:           "Bücher der Reihe %s",
+		LabelPraise:           "Stimmen zu %s",
+		LabelMasthead:         "Impressum",
+		LabelIssueNumber:      "Nr. %s",
+		LabelIngredients:      "Zutaten",
+		LabelInstructions:     "Zubereitung",
+		LabelYield:            "Menge",
+		LabelPrepTime:         "Vorbereitungszeit",
+		LabelCookTime:         "Kochzeit",
+		LabelPlaceIndex:       "Ortsregister",
+		LabelNotes:            "Anmerkungen",
+		LabelPageList:         "Seiten",
+		LabelExcerpt:          "%s (Leseprobe)",
+		LabelBuyFullBook:      "Das ganze Buch kaufen",
+		LabelLandmarks:        "Orientierungspunkte",
+	},
+	"en": {
+		LabelTableOfContents:  "Table of Contents",
+		LabelContents:         "Contents",
+		LabelLicense:          "License",
+		LabelVersionHistory:   "Version history",
+		LabelAlsoBy:           "Also by %s",
+		LabelAlsoByThisAuthor: "Also by this author",
+		LabelSeries:           "Books in the %s series",
+		LabelPraise:           "Praise for %s",
+		LabelMasthead:         "Masthead",
+		LabelIssueNumber:      "No. %s",
+		LabelIngredients:      "Ingredients",
+		LabelInstructions:     "Instructions",
+		LabelYield:            "Yield",
+		LabelPrepTime:         "Prep time",
+		LabelCookTime:         "Cook time",
+		LabelPlaceIndex:       "Index of places",
+		LabelNotes:            "Notes",
+		LabelPageList:         "Pages",
+		LabelExcerpt:          "%s (Excerpt)",
+		LabelBuyFullBook:      "Buy the full book",
+		LabelLandmarks:        "Landmarks",
+	},
+	"es": {
+		LabelTableOfContents:  "Índice",
+		LabelContents:         "Contenido",
+		LabelLicense:          "Licencia",
+		LabelVersionHistory:   "Historial de versiones",
+		LabelAlsoBy:           "Otros libros de %s",
+		LabelAlsoByThisAuthor: "Otros libros del autor",
+		LabelSeries:           "Libros de la serie %s",
+		LabelPraise:           "Elogios para %s",
+		LabelMasthead:         "Créditos",
+		LabelIssueNumber:      "N.º %s",
+		LabelIngredients:      "Ingredientes",
+		LabelInstructions:     "Preparación",
+		LabelYield:            "Raciones",
+		LabelPrepTime:         "Tiempo de preparación",
+		LabelCookTime:         "Tiempo de cocción",
+		LabelPlaceIndex:       "Índice de lugares",
+		LabelNotes:            "Notas",
+		LabelPageList:         "Páginas",
+		LabelExcerpt:          "%s (Fragmento)",
+		LabelBuyFullBook:      "Comprar el libro completo",
+		LabelLandmarks:        "Puntos de referencia",
+	},
+	"fr": {
+		LabelTableOfContents:  "Table des matières",
+		LabelContents:         "Sommaire",
+		LabelLicense:          "Licence",
+		LabelVersionHistory:   "Historique des versions",
+		LabelAlsoBy:           "Du même auteur",
+		LabelAlsoByThisAuthor: "Du même auteur",
+		LabelSeries:           "Dans la série %s",
+		LabelPraise:           "Éloges pour %s",
+		LabelMasthead:         "Ours",
+		LabelIssueNumber:      "N° %s",
+		LabelIngredients:      "Ingrédients",
+		LabelInstructions:     "Préparation",
+		LabelYield:            "Portions",
+		LabelPrepTime:         "Temps de préparation",
+		LabelCookTime:         "Temps de cuisson",
+		LabelPlaceIndex:       "Index des lieux",
+		LabelNotes:            "Notes",
+		LabelPageList:         "Pages",
+		LabelExcerpt:          "%s (Extrait)",
+		LabelBuyFullBook:      "Acheter le livre complet",
+		LabelLandmarks:        "Repères",
+	},
+	"it": {
+		LabelTableOfContents:  "Indice",
+		LabelContents:         "Sommario",
+		LabelLicense:          "Licenza",
+		LabelVersionHistory:   "Cronologia delle versioni",
+		LabelAlsoBy:           "Altri libri di %s",
+		LabelAlsoByThisAuthor: "Altri libri dell'autore",
+		LabelSeries:           "Libri della serie %s",
+		LabelPraise:           "Dicono di %s",
+		LabelMasthead:         "Gerenza",
+		LabelIssueNumber:      "N. %s",
+		LabelIngredients:      "Ingredienti",
+		LabelInstructions:     "Preparazione",
+		LabelYield:            "Dosi",
+		LabelPrepTime:         "Tempo di preparazione",
+		LabelCookTime:         "Tempo di cottura",
+		LabelPlaceIndex:       "Indice dei luoghi",
+		LabelNotes:            "Note",
+		LabelPageList:         "Pagine",
+		LabelExcerpt:          "%s (Estratto)",
+		LabelBuyFullBook:      "Acquista il libro completo",
+		LabelLandmarks:        "Punti di riferimento",
+	},
+	"nl": {
+		LabelTableOfContents:  "Inhoudsopgave",
+		LabelContents:         "Inhoud",
+		LabelLicense:          "Licentie",
+		LabelVersionHistory:   "Versiegeschiedenis",
+		LabelAlsoBy:           "Ook van %s",
+		LabelAlsoByThisAuthor: "Ook van deze auteur",
+		LabelSeries:           "Boeken in de serie %s",
+		LabelPraise:           "Lof voor %s",
+		LabelMasthead:         "Colofon",
+		LabelIssueNumber:      "Nr. %s",
+		LabelIngredients:      "Ingrediënten",
+		LabelInstructions:     "Bereiding",
+		LabelYield:            "Porties",
+		LabelPrepTime:         "Voorbereidingstijd",
+		LabelCookTime:         "Kooktijd",
+		LabelPlaceIndex:       "Plaatsnamenregister",
+		LabelNotes:            "Noten",
+		LabelPageList:         "Pagina's",
+		LabelExcerpt:          "%s (Fragment)",
+		LabelBuyFullBook:      "Koop het volledige boek",
+		LabelLandmarks:        "Oriëntatiepunten",
+	},
+	"pt": {
+		LabelTableOfContents:  "Índice",
+		LabelContents:         "Conteúdo",
+		LabelLicense:          "Licença",
+		LabelVersionHistory:   "Histórico de versões",
+		LabelAlsoBy:           "Outros livros de %s",
+		LabelAlsoByThisAuthor: "Outros livros do autor",
+		LabelSeries:           "Livros da série %s",
+		LabelPraise:           "Elogios a %s",
+		LabelMasthead:         "Expediente",
+		LabelIssueNumber:      "N.º %s",
+		LabelIngredients:      "Ingredientes",
+		LabelInstructions:     "Preparação",
+		LabelYield:            "Rendimento",
+		LabelPrepTime:         "Tempo de preparação",
+		LabelCookTime:         "Tempo de cozedura",
+		LabelPlaceIndex:       "Índice de lugares",
+		LabelNotes:            "Notas",
+		LabelPageList:         "Páginas",
+		LabelExcerpt:          "%s (Excerto)",
+		LabelBuyFullBook:      "Comprar o livro completo",
+		LabelLandmarks:        "Pontos de referência",
+	},
+	"pt-br": {
+		LabelTableOfContents: "Sumário",
+		LabelInstructions:    "Modo de preparo",
+		LabelCookTime:        "Tempo de cozimento",
+	},
+}
+
+// SetLabels overrides the labels of the navigation documents and generated
+// pages, which are otherwise translated according to the language of the EPUB
+// when it's written (English is used for languages without translations).
+// Labels that aren't in the map keep their translation; passing nil removes
+// the overrides.
+func (e *Epub) SetLabels(labels map[Label]string) {
+	e.labels = map[Label]string{}
+	for label, s := range labels {
+		e.labels[label] = s
+	}
+}
+
+// Get a label in the language of the EPUB, replacing %s by the provided value
+func (e *Epub) label(label Label, value string) string {
+	s, ok := e.labels[label]
+	if !ok {
+		lang := strings.ToLower(e.lang)
+		for _, tag := range []string{lang, primaryLanguageSubtag(lang), "en"} {
+			if s, ok = labelTranslations[tag][label]; ok {
+				break
+			}
+		}
+	}
+
+	return strings.Replace(s, "%s", value, 1)
+}
+
+// Get the primary subtag of a language tag, e.g. pt for pt-BR
+func primaryLanguageSubtag(lang string) string {
+	return strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+}
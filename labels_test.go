@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+	tocPage, err := e.GenerateTOCPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error generating TOC page: %s", err)
+	}
+	praisePage, err := e.AddPraisePage([]Praise{{Quote: "Superbe"}}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding praise page: %s", err)
+	}
+	// The language is set after the pages are added
+	e.SetLang("fr-CA")
+	e.SetLabels(map[Label]string{LabelContents: "Table"})
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	for _, test := range []struct {
+		filename string
+		expected string
+	}{
+		{tocNavFilename, "<h1>Table des matières</h1>"},
+		{filepath.Join(xhtmlFolderName, tocPage), "<h1>Table</h1>"},
+		{filepath.Join(xhtmlFolderName, praisePage), "<title>Éloges pour " + testEpubTitle + "</title>"},
+	} {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, test.filename))
+		if err != nil {
+			t.Fatalf("Unexpected error reading %s: %s", test.filename, err)
+		}
+		if !strings.Contains(string(contents), test.expected) {
+			t.Errorf("Label of %s doesn't match\nGot: %s\nExpected to contain: %s", test.filename, contents, test.expected)
+		}
+	}
+}
+
+func TestLabelFallback(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	for _, test := range []struct {
+		lang     string
+		expected string
+	}{
+		{"pt-BR", "Sumário"},
+		{"pt-PT", "Índice"},
+		{"tlh", "Table of Contents"},
+	} {
+		e.SetLang(test.lang)
+		if label := e.label(LabelTableOfContents, ""); label != test.expected {
+			t.Errorf("Label for %s doesn't match\nGot: %s\nExpected: %s", test.lang, label, test.expected)
+		}
+	}
+
+	e.SetLang("fr")
+	if label := e.label(LabelAlsoBy, testEpubAuthor); label != "Du même auteur" {
+		t.Errorf("Label without value doesn't match\nGot: %s\nExpected: %s", label, "Du même auteur")
+	}
+}
@@ -0,0 +1,219 @@
+package epub
+
+import (
+	"encoding/xml"
+	"path"
+)
+
+// Types of landmarks, from the EPUB Structural Semantics Vocabulary
+const (
+	LandmarkAcknowledgements = "acknowledgements"
+	LandmarkBackMatter       = "backmatter"
+	LandmarkBibliography     = "bibliography"
+	LandmarkBodyMatter       = "bodymatter"
+	LandmarkColophon         = "colophon"
+	LandmarkCopyrightPage    = "copyright-page"
+	LandmarkCover            = "cover"
+	LandmarkDedication       = "dedication"
+	LandmarkEpigraph         = "epigraph"
+	LandmarkForeword         = "foreword"
+	LandmarkFrontMatter      = "frontmatter"
+	LandmarkGlossary         = "glossary"
+	LandmarkIndex            = "index"
+	LandmarkLOI              = "loi"
+	LandmarkLOT              = "lot"
+	LandmarkPreface          = "preface"
+	LandmarkTitlePage        = "titlepage"
+	LandmarkTOC              = "toc"
+)
+
+const landmarksEpubType = "landmarks"
+
+// The type of the EPUB 2 guide reference of each type of landmark. The other
+// types are written with the "other." prefix.
+var landmarkGuideTypes = map[string]string{
+	LandmarkAcknowledgements: "acknowledgements",
+	LandmarkBibliography:     "bibliography",
+	LandmarkBodyMatter:       "text",
+	LandmarkColophon:         "colophon",
+	LandmarkCopyrightPage:    "copyright-page",
+	LandmarkCover:            "cover",
+	LandmarkDedication:       "dedication",
+	LandmarkEpigraph:         "epigraph",
+	LandmarkForeword:         "foreword",
+	LandmarkGlossary:         "glossary",
+	LandmarkIndex:            "index",
+	LandmarkLOI:              "loi",
+	LandmarkLOT:              "lot",
+	LandmarkPreface:          "preface",
+	LandmarkTitlePage:        "title-page",
+	LandmarkTOC:              "toc",
+}
+
+// The landmark types known in strict mode
+var knownLandmarks = map[string]bool{
+	LandmarkAcknowledgements: true,
+	LandmarkBackMatter:       true,
+	LandmarkBibliography:     true,
+	LandmarkBodyMatter:       true,
+	LandmarkColophon:         true,
+	LandmarkCopyrightPage:    true,
+	LandmarkCover:            true,
+	LandmarkDedication:       true,
+	LandmarkEpigraph:         true,
+	LandmarkForeword:         true,
+	LandmarkFrontMatter:      true,
+	LandmarkGlossary:         true,
+	LandmarkIndex:            true,
+	LandmarkLOI:              true,
+	LandmarkLOT:              true,
+	LandmarkPreface:          true,
+	LandmarkTitlePage:        true,
+	LandmarkTOC:              true,
+}
+
+// A landmark of the EPUB
+type landmark struct {
+	epubType string
+	// The internal filename of the section, optionally followed by a
+	// fragment, or the filename of the navigation document
+	filename string
+	title    string
+}
+
+// The landmarks of the navigation document
+type tocLandmarksNav struct {
+	XMLName  xml.Name          `xml:"nav"`
+	EpubType string            `xml:"epub:type,attr"`
+	Hidden   string            `xml:"hidden,attr"`
+	H1       string            `xml:"h1"`
+	Links    []tocLandmarkItem `xml:"ol>li"`
+}
+
+type tocLandmarkItem struct {
+	A tocLandmarkLink `xml:"a"`
+}
+
+type tocLandmarkLink struct {
+	EpubType string `xml:"epub:type,attr"`
+	Href     string `xml:"href,attr"`
+	Data     string `xml:",chardata"`
+}
+
+// SetLandmark sets a landmark of the EPUB, which reading systems use to go to
+// the cover, the start of the content, the table of contents, etc. The type is
+// one of the Landmark constants, e.g. LandmarkBodyMatter for the start of the
+// content. The internal filename of the section (as returned by AddSection) is
+// required and can be followed by a fragment, e.g. chapter1.xhtml#start; for
+// LandmarkTOC, it can also be nav.xhtml, the navigation document. The title is
+// shown by the reading system, e.g. "Start of content".
+//
+// Landmarks are written as the landmarks navigation of the EPUB 3 navigation
+// document, in the order they were set, and as the EPUB 2 guide. Setting a
+// landmark of the same type again replaces it, and an empty filename removes
+// it.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned. In strict mode, types that aren't
+// Landmark constants are rejected with UnknownPropertyError.
+func (e *Epub) SetLandmark(epubType string, sectionFilename string, title string) error {
+	if e.strict && !knownLandmarks[epubType] {
+		return &UnknownPropertyError{Property: epubType}
+	}
+	if sectionFilename != "" && e.sectionIndex(tocEntryFilename(sectionFilename)) == -1 &&
+		!(epubType == LandmarkTOC && sectionFilename == tocNavFilename) {
+		return &FilenameNotFoundError{Filename: tocEntryFilename(sectionFilename)}
+	}
+
+	landmarks := []landmark{}
+	replaced := false
+	for _, l := range e.landmarks {
+		if l.epubType != epubType {
+			landmarks = append(landmarks, l)
+			continue
+		}
+		if sectionFilename != "" && !replaced {
+			landmarks = append(landmarks, landmark{epubType: epubType, filename: sectionFilename, title: title})
+			replaced = true
+		}
+	}
+	if sectionFilename != "" && !replaced {
+		landmarks = append(landmarks, landmark{epubType: epubType, filename: sectionFilename, title: title})
+	}
+	e.landmarks = landmarks
+
+	return nil
+}
+
+// Get the path of a landmark relative to the package file, or an empty string
+// if its section was removed
+func (e *Epub) landmarkHref(l landmark) string {
+	if l.filename == tocNavFilename {
+		return tocNavFilename
+	}
+	if e.sectionIndex(tocEntryFilename(l.filename)) == -1 {
+		return ""
+	}
+
+	return path.Join(xhtmlFolderName, l.filename)
+}
+
+// Remove the landmarks pointing to a section
+func (e *Epub) removeLandmarks(filename string) {
+	landmarks := []landmark{}
+	for _, l := range e.landmarks {
+		if tocEntryFilename(l.filename) != filename {
+			landmarks = append(landmarks, l)
+		}
+	}
+	e.landmarks = landmarks
+}
+
+// Add the landmarks to the navigation document
+func (e *Epub) addLandmarks() {
+	for _, l := range e.landmarks {
+		if href := e.landmarkHref(l); href != "" {
+			e.toc.addLandmark(l.epubType, href, l.title)
+		}
+	}
+}
+
+// Add a landmark to the navigation document
+func (t *toc) addLandmark(epubType string, href string, title string) {
+	if t.landmarksNav == nil {
+		t.landmarksNav = &tocLandmarksNav{
+			EpubType: landmarksEpubType,
+			Hidden:   "hidden",
+			H1:       t.landmarksHeading,
+		}
+	}
+	t.landmarksNav.Links = append(t.landmarksNav.Links, tocLandmarkItem{
+		A: tocLandmarkLink{
+			EpubType: epubType,
+			Href:     href,
+			Data:     title,
+		},
+	})
+}
+
+// Set the guide of the package file from the landmarks. EPUB 2 doesn't have a
+// navigation document, so the landmark pointing to it is left out.
+func (e *Epub) setGuide() {
+	references := []pkgReference{}
+	for _, l := range e.landmarks {
+		href := e.landmarkHref(l)
+		if href == "" || (href == tocNavFilename && e.version == EPUBVersion2) {
+			continue
+		}
+		guideType, ok := landmarkGuideTypes[l.epubType]
+		if !ok {
+			guideType = "other." + l.epubType
+		}
+		references = append(references, pkgReference{
+			Type:  guideType,
+			Title: l.title,
+			Href:  href,
+		})
+	}
+	e.pkg.setGuide(references)
+}
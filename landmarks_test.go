@@ -0,0 +1,81 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSetLandmark(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection("<p>For Jane</p>", "Dedication", "dedication.xhtml", "")
+	e.AddSection(`<h1 id="start">Chapter 1</h1>`, "Chapter 1", "one.xhtml", "")
+	if err := e.SetLandmark(LandmarkBodyMatter, "dedication.xhtml", "Start"); err != nil {
+		t.Fatalf("Unexpected error setting landmark: %s", err)
+	}
+	e.SetLandmark(LandmarkTOC, tocNavFilename, "Table of Contents")
+	e.SetLandmark(LandmarkDedication, "dedication.xhtml", "Dedication")
+	e.SetLandmark(LandmarkFrontMatter, "dedication.xhtml", "Front matter")
+	// Replaced and removed landmarks
+	e.SetLandmark(LandmarkBodyMatter, "one.xhtml#start", "Start of content")
+	e.SetLandmark(LandmarkFrontMatter, "", "")
+
+	if _, ok := e.SetLandmark(LandmarkIndex, "missing.xhtml", "Index").(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected FilenameNotFoundError setting landmark of missing section")
+	}
+	e.SetStrict(true)
+	if _, ok := e.SetLandmark("unknown", "one.xhtml", "Unknown").(*UnknownPropertyError); !ok {
+		t.Errorf("Expected UnknownPropertyError setting unknown landmark in strict mode")
+	}
+	e.SetStrict(false)
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	nav, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading nav file: %s", err)
+	}
+	expectedNav := `<nav epub:type="landmarks" hidden="hidden"><h1>Landmarks</h1><ol>` +
+		`<li><a epub:type="bodymatter" href="xhtml/one.xhtml#start">Start of content</a></li>` +
+		`<li><a epub:type="toc" href="nav.xhtml">Table of Contents</a></li>` +
+		`<li><a epub:type="dedication" href="xhtml/dedication.xhtml">Dedication</a></li></ol></nav>`
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(nav), "><"), expectedNav) {
+		t.Errorf("Nav file doesn't match\nGot: %s\nExpected to contain: %s", nav, expectedNav)
+	}
+
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	expectedGuide := `<guide>` +
+		`<reference type="text" title="Start of content" href="xhtml/one.xhtml#start"></reference>` +
+		`<reference type="toc" title="Table of Contents" href="nav.xhtml"></reference>` +
+		`<reference type="dedication" title="Dedication" href="xhtml/dedication.xhtml"></reference></guide>`
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(pkg), "><"), expectedGuide) {
+		t.Errorf("Package file guide doesn't match\nGot: %s\nExpected to contain: %s", pkg, expectedGuide)
+	}
+}
+
+func TestSetLandmarkEPUB2(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetVersion(EPUBVersion2)
+	e.AddSection("<p>Colophon</p>", "Colophon", "colophon.xhtml", "")
+	e.SetLandmark(LandmarkTOC, tocNavFilename, "Table of Contents")
+	e.SetLandmark(LandmarkBackMatter, "colophon.xhtml", "Back matter")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	// The navigation document doesn't exist in EPUB 2
+	expectedGuide := `<guide><reference type="other.backmatter" title="Back matter" href="xhtml/colophon.xhtml"></reference></guide>`
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(pkg), "><"), expectedGuide) {
+		t.Errorf("Package file guide doesn't match\nGot: %s\nExpected to contain: %s", pkg, expectedGuide)
+	}
+}
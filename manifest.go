@@ -0,0 +1,153 @@
+package epub
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// ManifestItem describes a file contained in the EPUB, as listed in the
+// manifest of the package file. It can be marshalled to JSON for consumption
+// by web readers that don't unzip the EPUB.
+type ManifestItem struct {
+	ID string `json:"id"`
+	// Path to the file relative to the package file
+	Href       string   `json:"href"`
+	MediaType  string   `json:"mediaType"`
+	Properties []string `json:"properties,omitempty"`
+	// ID of the manifest item of the media overlay (SMIL file) of the file
+	MediaOverlay string `json:"mediaOverlay,omitempty"`
+	// ID of the manifest item shown by reading systems that can't render the
+	// file
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// SpineItem describes an item of the reading order of the EPUB, as listed in
+// the spine of the package file. It can be marshalled to JSON for consumption
+// by web readers that don't unzip the EPUB.
+type SpineItem struct {
+	// ID of the manifest item
+	IDRef string `json:"idref"`
+	// Path to the file relative to the package file
+	Href       string   `json:"href"`
+	MediaType  string   `json:"mediaType"`
+	Properties []string `json:"properties,omitempty"`
+	Linear     bool     `json:"linear"`
+}
+
+// Manifest returns the list of files the EPUB will contain once written, in
+// the order they will be listed in the package file.
+func (e *Epub) Manifest() []ManifestItem {
+	items := []ManifestItem{}
+	// EPUB 2 doesn't have a navigation document
+	if e.version != EPUBVersion2 {
+		items = append(items, ManifestItem{
+			ID:         tocNavItemID,
+			Href:       tocNavFilename,
+			MediaType:  mediaTypeXhtml,
+			Properties: []string{tocNavItemProperties},
+		})
+	}
+	items = append(items, ManifestItem{
+		ID:        tocNcxItemID,
+		Href:      tocNcxFilename,
+		MediaType: mediaTypeNcx,
+	})
+
+	items = append(items, e.mediaManifestItems(e.audio, AudioFolderName)...)
+	items = append(items, e.mediaManifestItems(e.css, CSSFolderName)...)
+	if e.defaultCSS() != "" {
+		items = append(items, ManifestItem{
+			ID:        defaultCSSFilename,
+			Href:      path.Join(CSSFolderName, defaultCSSFilename),
+			MediaType: mediaTypeCSS,
+		})
+	}
+	items = append(items, e.mediaManifestItems(e.fonts, FontFolderName)...)
+	items = append(items, e.mediaManifestItems(e.images, ImageFolderName)...)
+	items = append(items, e.mediaManifestItems(e.videos, VideoFolderName)...)
+	items = append(items, e.foreignManifestItems()...)
+
+	for _, section := range e.spineSections() {
+		item := ManifestItem{
+			ID:        section.filename,
+			Href:      path.Join(xhtmlFolderName, section.filename),
+			MediaType: mediaTypeXhtml,
+		}
+		if _, ok := e.mediaOverlays[section.filename]; ok {
+			item.MediaOverlay = mediaOverlayID(section.filename)
+		}
+		if e.version != EPUBVersion2 && hasInlineSVG(section.xhtml.xml.Body.XML) {
+			item.Properties = []string{svgItemProperties}
+		}
+		items = append(items, item)
+	}
+	for _, section := range e.spineSections() {
+		if _, ok := e.mediaOverlays[section.filename]; ok {
+			items = append(items, ManifestItem{
+				ID:        mediaOverlayID(section.filename),
+				Href:      path.Join(mediaOverlayFolder, mediaOverlayID(section.filename)),
+				MediaType: mediaTypeSMIL,
+			})
+		}
+	}
+
+	return items
+}
+
+// Spine returns the reading order of the EPUB.
+func (e *Epub) Spine() []SpineItem {
+	items := []SpineItem{}
+
+	for _, section := range e.spineSections() {
+		items = append(items, SpineItem{
+			IDRef:      section.filename,
+			Href:       path.Join(xhtmlFolderName, section.filename),
+			MediaType:  mediaTypeXhtml,
+			Properties: append([]string(nil), section.properties...),
+			Linear:     !section.nonLinear,
+		})
+	}
+
+	return items
+}
+
+// Get manifest items for the files of a media map, sorted by filename
+func (e *Epub) mediaManifestItems(mediaMap map[string]string, mediaFolderName string) []ManifestItem {
+	filenames := make([]string, 0, len(mediaMap))
+	for filename := range mediaMap {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	items := []ManifestItem{}
+	for _, filename := range filenames {
+		item := ManifestItem{
+			ID:        filename,
+			Href:      path.Join(mediaFolderName, filename),
+			MediaType: extensionMediaTypes[strings.ToLower(path.Ext(filename))],
+		}
+		// The cover image has a special value for the properties attribute, which
+		// EPUB 2 doesn't support
+		if filename == e.cover.imageFilename && e.version != EPUBVersion2 {
+			item.Properties = []string{coverImageProperties}
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// Get the sections in reading order. If a cover was set, it comes first.
+func (e *Epub) spineSections() []epubSection {
+	sections := []epubSection{}
+	for _, section := range e.sections {
+		if section.filename == e.cover.xhtmlFilename {
+			sections = append([]epubSection{section}, sections...)
+		} else {
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
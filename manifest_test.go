@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testManifestJSON = `[{"id":"nav","href":"nav.xhtml","mediaType":"application/xhtml+xml","properties":["nav"]},` +
+		`{"id":"ncx","href":"toc.ncx","mediaType":"application/x-dtbncx+xml"},` +
+		`{"id":"cover.css","href":"css/cover.css","mediaType":"text/css"},` +
+		`{"id":"testfromfile.png","href":"images/testfromfile.png","mediaType":"image/png","properties":["cover-image"]},` +
+		`{"id":"cover.xhtml","href":"xhtml/cover.xhtml","mediaType":"application/xhtml+xml"},` +
+		`{"id":"section0001.xhtml","href":"xhtml/section0001.xhtml","mediaType":"application/xhtml+xml"}]`
+	testSpineJSON = `[{"idref":"cover.xhtml","href":"xhtml/cover.xhtml","mediaType":"application/xhtml+xml","linear":true},` +
+		`{"idref":"section0001.xhtml","href":"xhtml/section0001.xhtml","mediaType":"application/xhtml+xml","linear":false}]`
+)
+
+func TestManifestAndSpine(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	cssPath, _ := e.AddCSS(testCoverCSSSource, testCoverCSSFilename)
+	e.SetCover(imagePath, cssPath)
+	e.SetSectionLinear(testSectionFilename, false)
+
+	manifest, err := json.Marshal(e.Manifest())
+	if err != nil {
+		t.Errorf("Unexpected error marshalling manifest: %s", err)
+	}
+	if string(manifest) != testManifestJSON {
+		t.Errorf(
+			"Manifest doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			manifest,
+			testManifestJSON)
+	}
+
+	spine, err := json.Marshal(e.Spine())
+	if err != nil {
+		t.Errorf("Unexpected error marshalling spine: %s", err)
+	}
+	if string(spine) != testSpineJSON {
+		t.Errorf(
+			"Spine doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			spine,
+			testSpineJSON)
+	}
+
+	// Writing twice shouldn't duplicate manifest or spine entries
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	if strings.Count(string(contents), "<item ") != len(e.Manifest()) {
+		t.Errorf("Package file manifest doesn't match: %s", contents)
+	}
+	if !strings.Contains(string(contents), `<itemref idref="section0001.xhtml" linear="no"></itemref>`) {
+		t.Errorf("Package file spine doesn't match: %s", contents)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
@@ -0,0 +1,299 @@
+package epub
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	mapAttributionTemplate = `<small class="attribution">%s</small>`
+	mapCaptionTemplate     = "<figcaption>%s</figcaption>\n"
+	mapFigureTemplate      = `<figure class="map" id="%s">
+<img src="%s" alt="%s" />
+%s</figure>`
+	mapIDFormat = "map-%d"
+	// Default size of a map, in pixels
+	mapDefaultHeight = 400
+	mapDefaultWidth  = 600
+	// Radius of the markers of the places, in pixels
+	mapMarkerRadius = 6
+	// Size of the tiles of web maps, in pixels
+	mapTileSize = 256
+
+	placeIndexBodyTemplate = `<h1>%s</h1>
+<ul class="place-index">
+%s</ul>`
+	placeIndexFilename     = "places.xhtml"
+	placeIndexItemTemplate = "<li>%s: %s</li>\n"
+	placeIndexLinkTemplate = `<a href="%s#%s">%s</a>`
+)
+
+var (
+	mapMarkerColor        = color.RGBA{0xd0, 0x21, 0x2a, 0xff}
+	mapMarkerOutlineColor = color.White
+)
+
+// TileRetrievalError is thrown by AddMap if a tile of the map can't be
+// retrieved.
+type TileRetrievalError struct {
+	Zoom int
+	X    int
+	Y    int
+	Err  error // Underlying error
+}
+
+func (e *TileRetrievalError) Error() string {
+	return fmt.Sprintf("Error retrieving map tile %d/%d/%d: %s", e.Zoom, e.X, e.Y, e.Err)
+}
+
+// TileFetcher retrieves the 256×256 pixel tiles of a web map using the usual
+// XYZ scheme (Web Mercator), e.g. from an OpenStreetMap tile server or a local
+// tile cache.
+type TileFetcher interface {
+	FetchTile(zoom int, x int, y int) (image.Image, error)
+}
+
+// StaticMap is a map rendered to an image by AddMap.
+type StaticMap struct {
+	// The tiles of the map
+	Tiles TileFetcher
+	// The coordinates of the center of the map, in degrees, and the zoom level
+	Latitude  float64
+	Longitude float64
+	Zoom      int
+	// The size of the image in pixels; 600×400 if not set
+	Width  int
+	Height int
+	// The caption of the map, optional. It's also used as the alternative text
+	// of the image.
+	Caption string
+	// The attribution of the map data, e.g. © OpenStreetMap contributors, as
+	// required by most tile providers
+	Attribution string
+	// The places marked on the map
+	Places []MapPlace
+}
+
+// MapPlace is a place marked on a map.
+type MapPlace struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	// Whether the place is listed on the page added by AddPlaceIndexPage
+	Index bool
+}
+
+// A place listed in the place index, along with the ID of the map it's on
+type placeIndexEntry struct {
+	name  string
+	mapID string
+}
+
+// AddMap renders a map from its tiles, marking its places, and adds the image
+// to the EPUB with the provided filename, which works the same way as for
+// AddImage. The returned XHTML is a <figure> element with the class "map" and
+// a unique ID, containing the image and a caption with the attribution (in a
+// <small> element with the class "attribution"), to be used in the body of a
+// section.
+//
+// The tiles are retrieved when the map is added; if one can't be,
+// TileRetrievalError will be returned.
+func (e *Epub) AddMap(m StaticMap, imageFilename string) (string, error) {
+	img, err := renderMap(m)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		panic(fmt.Sprintf("Error encoding map: %s", err))
+	}
+	imagePath, err := e.AddImage(newDataURL("image/png", buf.Bytes()), imageFilename)
+	if err != nil {
+		return "", err
+	}
+
+	e.mapCount++
+	id := fmt.Sprintf(mapIDFormat, e.mapCount)
+	for _, place := range m.Places {
+		if place.Index {
+			e.placeIndex = append(e.placeIndex, placeIndexEntry{name: place.Name, mapID: id})
+		}
+	}
+
+	alt := m.Caption
+	if alt == "" {
+		names := []string{}
+		for _, place := range m.Places {
+			names = append(names, place.Name)
+		}
+		alt = strings.Join(names, ", ")
+	}
+	caption := escapeText(m.Caption)
+	if m.Attribution != "" {
+		if caption != "" {
+			caption += " "
+		}
+		caption += fmt.Sprintf(mapAttributionTemplate, escapeText(m.Attribution))
+	}
+	if caption != "" {
+		caption = fmt.Sprintf(mapCaptionTemplate, caption)
+	}
+
+	return fmt.Sprintf(mapFigureTemplate, id, escapeAttribute(imagePath), escapeAttribute(alt), caption), nil
+}
+
+// AddPlaceIndexPage adds a page listing alphabetically the places of the maps
+// added using AddMap that have Index set, each one followed by links to the
+// sections containing the maps it's on. The page is titled "Index of places",
+// translated according to the language of the EPUB when it's written (see
+// SetLabels), and generated each time the EPUB is written, so that it includes
+// the maps added afterwards. The list is a <ul> element with the class
+// "place-index".
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The relative path to the page is returned, as
+// for AddSection.
+func (e *Epub) AddPlaceIndexPage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelPlaceIndex, ""), placeIndexFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = e.placeIndexGenerator(x)
+
+	return filename, nil
+}
+
+// Get the generator of the place index page
+func (e *Epub) placeIndexGenerator(x *xhtml) *sectionGenerator {
+	return &sectionGenerator{
+		title: func() string {
+			return e.label(LabelPlaceIndex, "")
+		},
+		body: func() string {
+			return fmt.Sprintf(placeIndexBodyTemplate, escapeText(x.Title()), e.placeIndexItems())
+		},
+	}
+}
+
+// Get the items of the place index page, linking to the sections containing
+// the maps of each place
+func (e *Epub) placeIndexItems() string {
+	links := map[string][]string{}
+	linked := map[string]bool{}
+	names := []string{}
+	for _, entry := range e.placeIndex {
+		for _, section := range e.sections {
+			if !strings.Contains(section.xhtml.xml.Body.XML, fmt.Sprintf(`id="%s"`, entry.mapID)) {
+				continue
+			}
+			key := entry.name + "\x00" + section.filename
+			if linked[key] {
+				break
+			}
+			linked[key] = true
+			if _, ok := links[entry.name]; !ok {
+				names = append(names, entry.name)
+			}
+			title := section.xhtml.Title()
+			if title == "" {
+				title = section.filename
+			}
+			links[entry.name] = append(links[entry.name], fmt.Sprintf(placeIndexLinkTemplate, escapeAttribute(section.filename), entry.mapID, escapeText(title)))
+			break
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+
+	items := ""
+	for _, name := range names {
+		items += fmt.Sprintf(placeIndexItemTemplate, escapeText(name), strings.Join(links[name], ", "))
+	}
+	return items
+}
+
+// Render a map from its tiles and draw the markers of its places
+func renderMap(m StaticMap) (*image.RGBA, error) {
+	width, height := m.Width, m.Height
+	if width <= 0 {
+		width = mapDefaultWidth
+	}
+	if height <= 0 {
+		height = mapDefaultHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// The position of the top left corner of the image in the world map
+	centerX, centerY := mercatorPixel(m.Latitude, m.Longitude, m.Zoom)
+	left := int(math.Floor(centerX)) - width/2
+	top := int(math.Floor(centerY)) - height/2
+
+	tileCount := 1 << uint(m.Zoom)
+	for tileY := floorDiv(top, mapTileSize); tileY <= floorDiv(top+height-1, mapTileSize); tileY++ {
+		// There are no tiles above and below the world map
+		if tileY < 0 || tileY >= tileCount {
+			continue
+		}
+		for tileX := floorDiv(left, mapTileSize); tileX <= floorDiv(left+width-1, mapTileSize); tileX++ {
+			// The world map wraps around horizontally
+			x := ((tileX % tileCount) + tileCount) % tileCount
+			tile, err := m.Tiles.FetchTile(m.Zoom, x, tileY)
+			if err != nil {
+				return nil, &TileRetrievalError{Zoom: m.Zoom, X: x, Y: tileY, Err: err}
+			}
+			offset := image.Pt(tileX*mapTileSize-left, tileY*mapTileSize-top)
+			draw.Draw(img, tile.Bounds().Sub(tile.Bounds().Min).Add(offset), tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+
+	for _, place := range m.Places {
+		x, y := mercatorPixel(place.Latitude, place.Longitude, m.Zoom)
+		drawMapMarker(img, int(math.Round(x))-left, int(math.Round(y))-top)
+	}
+
+	return img, nil
+}
+
+// Get the position of coordinates in the world map at a zoom level, in pixels
+func mercatorPixel(latitude float64, longitude float64, zoom int) (float64, float64) {
+	size := float64(mapTileSize) * math.Exp2(float64(zoom))
+	lat := latitude * math.Pi / 180
+	x := (longitude + 180) / 360 * size
+	y := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * size
+
+	return x, y
+}
+
+// Draw a round marker centered on a pixel, with an outline
+func drawMapMarker(img *image.RGBA, cx int, cy int) {
+	outer := mapMarkerRadius + 2
+	for y := cy - outer; y <= cy+outer; y++ {
+		for x := cx - outer; x <= cx+outer; x++ {
+			d := (x-cx)*(x-cx) + (y-cy)*(y-cy)
+			if d <= mapMarkerRadius*mapMarkerRadius {
+				img.Set(x, y, mapMarkerColor)
+			} else if d <= outer*outer {
+				img.Set(x, y, mapMarkerOutlineColor)
+			}
+		}
+	}
+}
+
+// Divide rounding towards negative infinity
+func floorDiv(a int, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,179 @@
+package epub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Returns tiles filled with a color identifying them, or an error for tiles
+// that are missing
+type testTileFetcher struct {
+	fetched []string
+	missing string
+}
+
+func (f *testTileFetcher) FetchTile(zoom int, x int, y int) (image.Image, error) {
+	tile := fmt.Sprintf("%d/%d/%d", zoom, x, y)
+	if tile == f.missing {
+		return nil, errors.New("not found")
+	}
+	f.fetched = append(f.fetched, tile)
+	img := image.NewRGBA(image.Rect(0, 0, mapTileSize, mapTileSize))
+	draw.Draw(img, img.Bounds(), &image.Uniform{testTileColor(x, y)}, image.Point{}, draw.Src)
+	return img, nil
+}
+
+func testTileColor(x int, y int) color.RGBA {
+	return color.RGBA{uint8(x), uint8(y), 0x80, 0xff}
+}
+
+func TestRenderMap(t *testing.T) {
+	tiles := &testTileFetcher{}
+	img, err := renderMap(StaticMap{
+		Tiles:  tiles,
+		Zoom:   1,
+		Width:  100,
+		Height: 100,
+		Places: []MapPlace{{Name: "Null Island"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error rendering map: %s", err)
+	}
+
+	expected := "1/0/0 1/1/0 1/0/1 1/1/1"
+	if fetched := strings.Join(tiles.fetched, " "); fetched != expected {
+		t.Errorf("Fetched tiles don't match\nGot: %s\nExpected: %s", fetched, expected)
+	}
+	for _, test := range []struct {
+		x, y     int
+		expected color.Color
+	}{
+		{0, 0, testTileColor(0, 0)},
+		{99, 0, testTileColor(1, 0)},
+		{0, 99, testTileColor(0, 1)},
+		{99, 99, testTileColor(1, 1)},
+		// The marker of the place at the center
+		{50, 50, mapMarkerColor},
+	} {
+		if c := img.At(test.x, test.y); c != test.expected {
+			t.Errorf("Color of pixel %d,%d doesn't match\nGot: %v\nExpected: %v", test.x, test.y, c, test.expected)
+		}
+	}
+
+	// The map wraps around horizontally, and there are no tiles past the poles
+	tiles = &testTileFetcher{}
+	if _, err := renderMap(StaticMap{Tiles: tiles, Latitude: 85, Longitude: 180, Zoom: 1, Width: 100, Height: 100}); err != nil {
+		t.Fatalf("Unexpected error rendering map: %s", err)
+	}
+	expected = "1/1/0 1/0/0"
+	if fetched := strings.Join(tiles.fetched, " "); fetched != expected {
+		t.Errorf("Fetched tiles don't match\nGot: %s\nExpected: %s", fetched, expected)
+	}
+}
+
+func TestAddMap(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	placeIndex, err := e.AddPlaceIndexPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error adding place index page: %s", err)
+	}
+
+	figure, err := e.AddMap(StaticMap{
+		Tiles:       &testTileFetcher{},
+		Zoom:        2,
+		Caption:     "Old town",
+		Attribution: "© OpenStreetMap contributors",
+		Places: []MapPlace{
+			{Name: "Museum", Index: true},
+			{Name: "Bakery", Index: true},
+			{Name: "Hotel"},
+		},
+	}, "map.png")
+	if err != nil {
+		t.Fatalf("Unexpected error adding map: %s", err)
+	}
+	expected := `<figure class="map" id="map-1">
+<img src="../images/map.png" alt="Old town" />
+<figcaption>Old town <small class="attribution">© OpenStreetMap contributors</small></figcaption>
+</figure>`
+	if figure != expected {
+		t.Errorf("Map figure doesn't match\nGot: %s\nExpected: %s", figure, expected)
+	}
+	e.AddSection(figure, "Walks", "walks.xhtml", "")
+
+	_, err = e.AddMap(StaticMap{Tiles: &testTileFetcher{missing: "0/0/0"}}, "")
+	if _, ok := err.(*TileRetrievalError); !ok {
+		t.Errorf("Expected error TileRetrievalError not returned. Returned instead: %+v", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, placeIndex))
+	if err != nil {
+		t.Fatalf("Unexpected error reading place index page: %s", err)
+	}
+	expected = `<h1>Index of places</h1>
+<ul class="place-index">
+<li>Bakery: <a href="walks.xhtml#map-1">Walks</a></li>
+<li>Museum: <a href="walks.xhtml#map-1">Walks</a></li>
+</ul>`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("Place index page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "map.png")); err != nil {
+		t.Errorf("Unexpected error reading map image: %s", err)
+	}
+}
+
+func TestAddMapSnapshot(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	placeIndex, _ := e.AddPlaceIndexPage("")
+	figure, err := e.AddMap(StaticMap{Tiles: &testTileFetcher{}, Places: []MapPlace{{Name: "Museum", Index: true}}}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding map: %s", err)
+	}
+	e.AddSection(figure, "Walks", "walks.xhtml", "")
+
+	// The map IDs and the place index are kept in snapshots
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+	e = &Epub{}
+	if err := json.Unmarshal(data, e); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+
+	figure, err = e.AddMap(StaticMap{Tiles: &testTileFetcher{}, Places: []MapPlace{{Name: "Bakery", Index: true}}}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding map: %s", err)
+	}
+	if !strings.Contains(figure, `id="map-2"`) {
+		t.Errorf("Map ID doesn't match\nGot: %s\nExpected to contain: %s", figure, `id="map-2"`)
+	}
+	e.AddSection(figure, "Shops", "shops.xhtml", "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, placeIndex))
+	if err != nil {
+		t.Fatalf("Unexpected error reading place index page: %s", err)
+	}
+	expected := `<ul class="place-index">
+<li>Bakery: <a href="shops.xhtml#map-2">Shops</a></li>
+<li>Museum: <a href="walks.xhtml#map-1">Walks</a></li>
+</ul>`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("Place index page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+}
@@ -0,0 +1,290 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownATXHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownCodeSpan       = regexp.MustCompile("`([^`]+)`")
+	markdownEmphasis       = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s](?:[^*_]*[^*_\s])?)[*_]`)
+	markdownFence          = regexp.MustCompile("^(```|~~~)")
+	markdownImage          = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)`)
+	markdownLink           = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)`)
+	markdownOrderedItem    = regexp.MustCompile(`^\s{0,3}\d+[.)]\s+(.*)$`)
+	markdownPlaceholder    = regexp.MustCompile("\x00(\\d+)\x00")
+	markdownRule           = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	markdownStrong         = regexp.MustCompile(`(\*\*|__)([^\s](?:.*?[^\s])?)(\*\*|__)`)
+	markdownTableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	markdownUnorderedItem  = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+)
+
+// AddSectionFromMarkdown adds a section whose body is converted from
+// Markdown: headings, paragraphs, lists, block quotes, fenced code blocks,
+// tables, horizontal rules, emphasis, code spans, links and images are
+// supported. Raw HTML isn't, and is escaped.
+//
+// The images are added to the EPUB using AddImageOnce and their paths are
+// rewritten accordingly, so they can be URLs, data URLs, or paths to local
+// files relative to the working directory. Paths to images already added to
+// the EPUB (as returned by AddImage) are kept. If an image can't be retrieved,
+// FileRetrievalError is returned and the section isn't added; neither are the
+// images added for it.
+//
+// The other parameters work the same way as for AddSection.
+func (e *Epub) AddSectionFromMarkdown(sectionTitle string, markdown string, internalFilename string, internalCSSPath string) (string, error) {
+	var err error
+	// The images added by this call, removed if the section isn't added
+	added := []string{}
+	body := htmlImgRegexp.ReplaceAllStringFunc(markdownToXHTML(markdown), func(tag string) string {
+		src, ok := tagAttribute(tag, "src")
+		if !ok || err != nil {
+			return tag
+		}
+		source := unescapeText(src)
+		if p := path.Join(xhtmlFolderName, source); path.Dir(p) == ImageFolderName {
+			if _, ok := e.images[path.Base(p)]; ok {
+				return tag
+			}
+		}
+
+		imageCount := len(e.images)
+		var imagePath string
+		imagePath, err = e.AddImageOnce(source, "")
+		if err != nil {
+			return tag
+		}
+		if len(e.images) > imageCount {
+			added = append(added, imagePath)
+		}
+		return setTagAttribute(tag, "src", escapeAttribute(filepath.ToSlash(imagePath)))
+	})
+	if err == nil {
+		var filename string
+		filename, err = e.AddSection(body, sectionTitle, internalFilename, internalCSSPath)
+		if err == nil {
+			return filename, nil
+		}
+	}
+
+	for _, imagePath := range added {
+		e.RemoveImage(imagePath)
+	}
+	return "", err
+}
+
+// Convert Markdown to XHTML. The most common block elements (headings,
+// paragraphs, lists, block quotes, fenced code blocks, tables and horizontal
+// rules) and inline elements (emphasis, code, links and images) are supported.
+func markdownToXHTML(markdown string) string {
+	lines := strings.Split(strings.Replace(markdown, "\r\n", "\n", -1), "\n")
+	blocks := []string{}
+	paragraph := []string{}
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			text := strings.TrimRight(strings.Join(paragraph, "\n"), " \t")
+			blocks = append(blocks, "<p>"+markdownInline(text)+"</p>")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+
+		case markdownFence.MatchString(strings.TrimSpace(line)):
+			flushParagraph()
+			fence := markdownFence.FindString(strings.TrimSpace(line))
+			language := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), fence))
+			code := []string{}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if language != "" {
+				class = fmt.Sprintf(` class="language-%s"`, escapeAttribute(language))
+			}
+			blocks = append(blocks, fmt.Sprintf("<pre><code%s>%s</code></pre>", class, escapeText(strings.Join(code, "\n"))))
+
+		case markdownATXHeading.MatchString(line):
+			flushParagraph()
+			m := markdownATXHeading.FindStringSubmatch(line)
+			level := len(m[1])
+			blocks = append(blocks, fmt.Sprintf("<h%d>%s</h%d>", level, markdownInline(m[2]), level))
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && markdownTableDelimiter.MatchString(lines[i+1]):
+			flushParagraph()
+			header := markdownTableCells(line)
+			alignments := []string{}
+			for _, cell := range markdownTableCells(lines[i+1]) {
+				switch {
+				case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+					alignments = append(alignments, "center")
+				case strings.HasSuffix(cell, ":"):
+					alignments = append(alignments, "right")
+				case strings.HasPrefix(cell, ":"):
+					alignments = append(alignments, "left")
+				default:
+					alignments = append(alignments, "")
+				}
+			}
+			row := func(cells []string, tag string) string {
+				r := "<tr>"
+				for j := range header {
+					cell, style := "", ""
+					if j < len(cells) {
+						cell = cells[j]
+					}
+					if j < len(alignments) && alignments[j] != "" {
+						style = fmt.Sprintf(` style="text-align: %s"`, alignments[j])
+					}
+					r += fmt.Sprintf("<%s%s>%s</%s>", tag, style, markdownInline(cell), tag)
+				}
+				return r + "</tr>\n"
+			}
+			table := "<table>\n<thead>\n" + row(header, "th") + "</thead>\n"
+			rows := ""
+			for i += 2; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+				rows += row(markdownTableCells(lines[i]), "td")
+			}
+			i--
+			if rows != "" {
+				table += "<tbody>\n" + rows + "</tbody>\n"
+			}
+			blocks = append(blocks, table+"</table>")
+
+		case markdownRule.MatchString(line):
+			flushParagraph()
+			blocks = append(blocks, "<hr />")
+
+		case strings.HasPrefix(strings.TrimSpace(line), ">"):
+			flushParagraph()
+			quote := []string{}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(text, " "))
+			}
+			i--
+			blocks = append(blocks, "<blockquote>\n"+markdownToXHTML(strings.Join(quote, "\n"))+"\n</blockquote>")
+
+		case markdownUnorderedItem.MatchString(line) || markdownOrderedItem.MatchString(line):
+			flushParagraph()
+			itemRegexp, tag := markdownUnorderedItem, "ul"
+			if !markdownUnorderedItem.MatchString(line) {
+				itemRegexp, tag = markdownOrderedItem, "ol"
+			}
+			items := []string{}
+			for ; i < len(lines); i++ {
+				if m := itemRegexp.FindStringSubmatch(lines[i]); m != nil {
+					items = append(items, m[1])
+				} else if len(items) > 0 && strings.TrimSpace(lines[i]) != "" && strings.HasPrefix(lines[i], " ") {
+					// Continuation of the previous item
+					items[len(items)-1] += "\n" + strings.TrimSpace(lines[i])
+				} else {
+					break
+				}
+			}
+			i--
+			list := "<" + tag + ">\n"
+			for _, item := range items {
+				list += "<li>" + markdownInline(item) + "</li>\n"
+			}
+			blocks = append(blocks, list+"</"+tag+">")
+
+		default:
+			// Trailing spaces are kept, as they can be line breaks
+			paragraph = append(paragraph, strings.TrimLeft(line, " \t"))
+		}
+	}
+	flushParagraph()
+
+	return strings.Join(blocks, "\n")
+}
+
+// Get the cells of a row of a Markdown table. Pipes can be escaped as \|.
+func markdownTableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+
+	cells := []string{}
+	cell := ""
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell += "|"
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell))
+			cell = ""
+		default:
+			cell += string(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell))
+}
+
+// Convert the inline Markdown of a block to XHTML
+func markdownInline(text string) string {
+	// Code spans and URLs must not be processed further, so they're replaced
+	// by placeholders until the end
+	protected := []string{}
+	protect := func(s string) string {
+		protected = append(protected, s)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	text = markdownCodeSpan.ReplaceAllStringFunc(text, func(s string) string {
+		return protect("<code>" + escapeText(markdownCodeSpan.FindStringSubmatch(s)[1]) + "</code>")
+	})
+	text = markdownImage.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownImage.FindStringSubmatch(s)
+		title := ""
+		if m[3] != "" {
+			title = fmt.Sprintf(` title="%s"`, escapeAttribute(m[3]))
+		}
+		return protect(fmt.Sprintf(`<img src="%s" alt="%s"%s />`, escapeAttribute(m[2]), escapeAttribute(m[1]), title))
+	})
+	text = markdownLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := markdownLink.FindStringSubmatch(s)
+		title := ""
+		if m[3] != "" {
+			title = fmt.Sprintf(` title="%s"`, escapeAttribute(m[3]))
+		}
+		return protect(fmt.Sprintf(`<a href="%s"%s>`, escapeAttribute(m[2]), title)) + m[1] + protect("</a>")
+	})
+
+	text = escapeText(text)
+	text = markdownStrong.ReplaceAllString(text, "<strong>$2</strong>")
+	text = markdownEmphasis.ReplaceAllString(text, "$1<em>$2</em>")
+	// Two trailing spaces are a line break
+	text = strings.Replace(text, "  \n", "<br />\n", -1)
+
+	// Placeholders can be nested (e.g. code inside a link)
+	for markdownPlaceholder.MatchString(text) {
+		text = markdownPlaceholder.ReplaceAllStringFunc(text, func(s string) string {
+			var i int
+			fmt.Sscanf(markdownPlaceholder.FindStringSubmatch(s)[1], "%d", &i)
+			return protected[i]
+		})
+	}
+
+	return text
+}
+
+// Escape the characters of a string which can't appear as-is in an XHTML
+// attribute value
+func escapeAttribute(s string) string {
+	return strings.Replace(escapeText(s), `"`, "&quot;", -1)
+}
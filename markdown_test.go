@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testMarkdown = "# Title with `code`\n" +
+		"\n" +
+		"Some *emphasis*, **strong** text & a [link](http://example.com/?a=1&b=2 \"Example\").  \n" +
+		"Next line with ![an image](../images/image.png) and snake_case_words.\n" +
+		"\n" +
+		"- First\n" +
+		"- Second\n" +
+		"  continued\n" +
+		"\n" +
+		"1. One\n" +
+		"2. Two\n" +
+		"\n" +
+		"> Quoted *text*\n" +
+		"\n" +
+		"```go\n" +
+		"if a < b {\n" +
+		"```\n" +
+		"\n" +
+		"---\n"
+	testMarkdownXHTML = "<h1>Title with <code>code</code></h1>\n" +
+		"<p>Some <em>emphasis</em>, <strong>strong</strong> text &amp; a <a href=\"http://example.com/?a=1&amp;b=2\" title=\"Example\">link</a>.<br />\n" +
+		"Next line with <img src=\"../images/image.png\" alt=\"an image\" /> and snake_case_words.</p>\n" +
+		"<ul>\n<li>First</li>\n<li>Second\ncontinued</li>\n</ul>\n" +
+		"<ol>\n<li>One</li>\n<li>Two</li>\n</ol>\n" +
+		"<blockquote>\n<p>Quoted <em>text</em></p>\n</blockquote>\n" +
+		"<pre><code class=\"language-go\">if a &lt; b {</code></pre>\n" +
+		"<hr />"
+)
+
+func TestMarkdownToXHTML(t *testing.T) {
+	output := markdownToXHTML(testMarkdown)
+	if output != testMarkdownXHTML {
+		t.Errorf(
+			"Markdown conversion doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			output,
+			testMarkdownXHTML)
+	}
+}
+
+func TestMarkdownTable(t *testing.T) {
+	markdown := "| Name | Price | Stock |\n" +
+		"|:-----|------:|:-----:|\n" +
+		"| `a\\|b` | *1* | yes |\n" +
+		"| c | 2 |\n" +
+		"\n" +
+		"After"
+	expected := "<table>\n<thead>\n" +
+		"<tr><th style=\"text-align: left\">Name</th><th style=\"text-align: right\">Price</th><th style=\"text-align: center\">Stock</th></tr>\n" +
+		"</thead>\n<tbody>\n" +
+		"<tr><td style=\"text-align: left\"><code>a|b</code></td><td style=\"text-align: right\"><em>1</em></td><td style=\"text-align: center\">yes</td></tr>\n" +
+		"<tr><td style=\"text-align: left\">c</td><td style=\"text-align: right\">2</td><td style=\"text-align: center\"></td></tr>\n" +
+		"</tbody>\n</table>\n" +
+		"<p>After</p>"
+
+	output := markdownToXHTML(markdown)
+	if output != expected {
+		t.Errorf("Markdown table conversion doesn't match\nGot: %s\nExpected: %s", output, expected)
+	}
+}
+
+func TestAddSectionFromMarkdown(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, "existing.png")
+	image, _ := ioutil.ReadFile(testImageFromFileSource)
+	markdown := "# Chapter\n" +
+		"\n" +
+		"![Data](" + newDataURL("image/png", image) + ")\n" +
+		"\n" +
+		"![Local](" + testImageFromFileSource + ") ![Again](" + testImageFromFileSource + ")\n" +
+		"![Existing](" + imagePath + ")\n"
+	filename, err := e.AddSectionFromMarkdown(testSectionTitle, markdown, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	// The images are only added once
+	if len(e.images) != 2 {
+		t.Errorf("Images don't match\nGot: %v", e.images)
+	}
+
+	sectionCount := len(e.sections)
+	_, err = e.AddSectionFromMarkdown(testSectionTitle, "![New]("+newDataURL("image/png", []byte("png"))+") ![Missing](testdata/missing.png)", "", "")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected FileRetrievalError for a missing image\nGot: %v", err)
+	}
+	// The images added before the error are removed along with the section
+	if len(e.images) != 2 || len(e.sections) != sectionCount {
+		t.Errorf("Images or section of the failed call were added\nImages: %v\nSections: %d", e.images, len(e.sections))
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testBody := `<h1>Chapter</h1>
+<p><img src="../images/image0002.png" alt="Data" /></p>
+<p><img src="../images/existing.png" alt="Local" /> <img src="../images/existing.png" alt="Again" />
+<img src="../images/existing.png" alt="Existing" /></p>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf("Section file doesn't match\nGot: %s\nExpected to contain: %s", contents, testBody)
+	}
+	if len(e.sections) != 1 {
+		t.Errorf("Section with a missing image was added")
+	}
+}
@@ -0,0 +1,206 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	mediaDurationProperty = "media:duration"
+	mediaOverlayFolder    = "smil"
+	mediaTypeSMIL         = "application/smil+xml"
+	smilParTemplate       = `    <par id="par%d">
+      <text src="%s"/>
+      <audio src="%s" clipBegin="%s" clipEnd="%s"/>
+    </par>
+`
+	smilTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<smil xmlns="http://www.w3.org/ns/SMIL" xmlns:epub="http://www.idpf.org/2007/ops" version="3.0">
+  <body>
+    <seq id="seq1" epub:textref="%s">
+%s    </seq>
+  </body>
+</smil>
+`
+)
+
+// InvalidMediaOverlayError is thrown by AddMediaOverlay if the clips of a media
+// overlay can't be used, e.g. because a clip ends before it begins.
+type InvalidMediaOverlayError struct {
+	Filename string // Filename of the section
+	Reason   string // Why the clips can't be used
+}
+
+func (e *InvalidMediaOverlayError) Error() string {
+	return fmt.Sprintf("Invalid media overlay for %s: %s", e.Filename, e.Reason)
+}
+
+// Clip is a clip of the audio of a media overlay, played while an element of
+// its section is highlighted.
+type Clip struct {
+	// ID of the element of the section read aloud during the clip, e.g. a
+	// paragraph or a sentence in a <span> element
+	FragmentID string `json:"fragmentID"`
+	// Start and end of the clip in the audio file
+	Begin time.Duration `json:"begin"`
+	End   time.Duration `json:"end"`
+}
+
+// The media overlay of a section
+type epubMediaOverlay struct {
+	// Path to the audio file relative to the EPUB section files
+	audioPath string
+	clips     []Clip
+}
+
+// AddMediaOverlay synchronizes a section with an audio narration, e.g. for
+// read-aloud books: the audio file is added (see AddAudio) and a SMIL file
+// generated, which reading systems use to highlight the elements of the
+// section as their clips are played. The manifest item of the section refers
+// to the SMIL file using the media-overlay attribute, and the duration of
+// each media overlay and their total duration are written as media:duration
+// metadata (EPUB 3 only). The path to the audio file is returned in the
+// format: ../AudioFolderName/internalFilename
+//
+// The internal filename of an already-added section (as returned by
+// AddSection) is required; if it doesn't match a section,
+// FilenameNotFoundError is returned. InvalidMediaOverlayError is returned if
+// there are no clips, a clip has no fragment ID, or a clip doesn't end after
+// it begins. Adding another media overlay to the same section replaces it.
+func (e *Epub) AddMediaOverlay(sectionFilename string, audioSource string, clips []Clip) (string, error) {
+	found := false
+	for _, section := range e.sections {
+		if section.filename == sectionFilename {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", &FilenameNotFoundError{Filename: sectionFilename}
+	}
+
+	reason := ""
+	if len(clips) == 0 {
+		reason = "no clips"
+	}
+	for i, clip := range clips {
+		if clip.FragmentID == "" {
+			reason = fmt.Sprintf("clip %d has no fragment ID", i+1)
+			break
+		}
+		if clip.Begin < 0 || clip.End <= clip.Begin {
+			reason = fmt.Sprintf("clip %d doesn't end after it begins", i+1)
+			break
+		}
+	}
+	if reason != "" {
+		return "", &InvalidMediaOverlayError{Filename: sectionFilename, Reason: reason}
+	}
+
+	audioPath, err := e.AddAudio(audioSource, "")
+	if err != nil {
+		return "", err
+	}
+	if e.mediaOverlays == nil {
+		e.mediaOverlays = map[string]*epubMediaOverlay{}
+	}
+	e.mediaOverlays[sectionFilename] = &epubMediaOverlay{
+		audioPath: audioPath,
+		clips:     append([]Clip(nil), clips...),
+	}
+
+	return audioPath, nil
+}
+
+// Get the ID and filename of the SMIL file of the media overlay of a section
+func mediaOverlayID(sectionFilename string) string {
+	return strings.TrimSuffix(sectionFilename, path.Ext(sectionFilename)) + ".smil"
+}
+
+// Get the duration of a media overlay, from the start of the audio file to the
+// end of its last clip
+func (o *epubMediaOverlay) duration() time.Duration {
+	var d time.Duration
+	for _, clip := range o.clips {
+		if clip.End > d {
+			d = clip.End
+		}
+	}
+	return d
+}
+
+// Format a duration as a SMIL clock value, e.g. 0:01:30.500
+func formatClockValue(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// Write the SMIL files of the media overlays to the temporary directory
+func (e *Epub) writeMediaOverlays(tempDir string) {
+	if len(e.mediaOverlays) == 0 {
+		return
+	}
+
+	folderPath := filepath.Join(tempDir, contentFolderName, mediaOverlayFolder)
+	if err := os.MkdirAll(folderPath, dirPermissions); err != nil {
+		panic(fmt.Sprintf("Unable to create directory: %s", err))
+	}
+
+	for filename, overlay := range e.mediaOverlays {
+		sectionPath := path.Join("..", xhtmlFolderName, filename)
+		audioPath := path.Join("..", AudioFolderName, path.Base(filepath.ToSlash(overlay.audioPath)))
+		pars := ""
+		for i, clip := range overlay.clips {
+			pars += fmt.Sprintf(smilParTemplate,
+				i+1,
+				escapeAttribute(sectionPath+"#"+clip.FragmentID),
+				escapeAttribute(audioPath),
+				formatClockValue(clip.Begin),
+				formatClockValue(clip.End),
+			)
+		}
+		content := fmt.Sprintf(smilTemplate, escapeAttribute(sectionPath), pars)
+
+		if err := ioutil.WriteFile(filepath.Join(folderPath, mediaOverlayID(filename)), []byte(content), filePermissions); err != nil {
+			panic(fmt.Sprintf("Error writing SMIL file: %s", err))
+		}
+	}
+}
+
+// Set the media:duration metadata of the media overlays of the sections, in
+// reading order, and their total duration
+func (p *pkg) setMediaDurations(sections []epubSection, overlays map[string]*epubMediaOverlay) {
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Property != mediaDurationProperty {
+			meta = append(meta, m)
+		}
+	}
+
+	var total time.Duration
+	for _, section := range sections {
+		overlay, ok := overlays[section.filename]
+		if !ok {
+			continue
+		}
+		total += overlay.duration()
+		meta = append(meta, pkgMeta{
+			Data:     formatClockValue(overlay.duration()),
+			Property: mediaDurationProperty,
+			Refines:  "#" + mediaOverlayID(section.filename),
+		})
+	}
+	if len(overlays) > 0 {
+		meta = append(meta, pkgMeta{
+			Data:     formatClockValue(total),
+			Property: mediaDurationProperty,
+		})
+	}
+
+	p.xml.Metadata.Meta = meta
+}
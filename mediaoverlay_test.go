@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddMediaOverlay(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddSection(`<p id="p1">One</p><p id="p2">Two</p>`, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	audioSource := newDataURL("audio/mpeg", []byte("abc"))
+
+	if _, err := e.AddMediaOverlay("missing.xhtml", audioSource, nil); err == nil {
+		t.Errorf("Expected error adding a media overlay to a missing section")
+	}
+	for _, clips := range [][]Clip{
+		nil,
+		{{FragmentID: "", End: time.Second}},
+		{{FragmentID: "p1", Begin: time.Second, End: time.Second}},
+	} {
+		_, err := e.AddMediaOverlay(filename, audioSource, clips)
+		if _, ok := err.(*InvalidMediaOverlayError); !ok {
+			t.Errorf("Expected error InvalidMediaOverlayError not returned for %+v. Returned instead: %+v", clips, err)
+		}
+	}
+
+	audioPath, err := e.AddMediaOverlay(filename, audioSource, []Clip{
+		{FragmentID: "p1", Begin: 0, End: 2500 * time.Millisecond},
+		{FragmentID: "p2", Begin: 2500 * time.Millisecond, End: 65 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error adding media overlay: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`<item id="section0001.xhtml" href="xhtml/section0001.xhtml" media-type="application/xhtml+xml" media-overlay="section0001.smil"></item>`,
+		`<item id="section0001.smil" href="smil/section0001.smil" media-type="application/smil+xml"></item>`,
+		`<meta refines="#section0001.smil" property="media:duration">0:01:05.000</meta>`,
+		`<meta property="media:duration">0:01:05.000</meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf(
+				"Package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				testMetadata)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, "smil", "section0001.smil"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading SMIL file: %s", err)
+	}
+	testPar := `<text src="../xhtml/section0001.xhtml#p2"/>
+      <audio src="../audio/` + filepath.Base(audioPath) + `" clipBegin="0:00:02.500" clipEnd="0:01:05.000"/>`
+	if !strings.Contains(string(contents), testPar) {
+		t.Errorf("SMIL file doesn't match\nGot: %s\nExpected to contain: %s", contents, testPar)
+	}
+}
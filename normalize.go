@@ -0,0 +1,229 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Named character references, which are only defined by HTML
+var namedEntityRegexp = regexp.MustCompile(`&([A-Za-z][A-Za-z0-9]*);`)
+
+// The entities defined by XML, which are left unchanged
+var xmlEntities = map[string]bool{
+	"amp":  true,
+	"apos": true,
+	"gt":   true,
+	"lt":   true,
+	"quot": true,
+}
+
+// The extensions of the text files normalized when the EPUB is written
+var normalizedFileExts = map[string]bool{
+	".ncx":   true,
+	".opf":   true,
+	".xhtml": true,
+}
+
+// PlainPunctuationReplacements replaces typographic punctuation by plain ASCII
+// punctuation, e.g. for reading systems whose fonts lack the glyphs. It can be
+// used as the Replacements of TextNormalization, as is or as the base of a
+// profile of the characters a reading system can't display.
+var PlainPunctuationReplacements = map[rune]string{
+	'‐':      "-",   // Hyphen
+	'‑':      "-",   // Non-breaking hyphen
+	'–':      "-",   // En dash
+	'—':      "--",  // Em dash
+	'‘':      "'",   // Left single quotation mark
+	'’':      "'",   // Right single quotation mark
+	'“':      `"`,   // Left double quotation mark
+	'”':      `"`,   // Right double quotation mark
+	'…':      "...", // Horizontal ellipsis
+	'\u200b': "",    // Zero width space
+}
+
+// TextNormalization sets how the text of the EPUB is normalized when it's
+// written (see SetTextNormalization). The zero value leaves the text
+// unchanged.
+type TextNormalization struct {
+	// Normalizes Unicode text, e.g. norm.NFC.String from the
+	// golang.org/x/text/unicode/norm package for NFC, optional
+	Normalize func(s string) string
+	// Whether control characters are removed, except tab, line feed and
+	// carriage return; C0 control characters aren't allowed in XML and break
+	// the EPUB
+	RemoveControlCharacters bool
+	// Characters replaced by a string, e.g. the characters the fonts of the
+	// target reading system can't display (see PlainPunctuationReplacements)
+	Replacements map[rune]string
+	// Whether the named character references only defined by HTML, such as
+	// &nbsp; or &eacute;, are converted to numeric character references, e.g.
+	// &#160;, which XHTML supports without a DTD
+	NumericEntities bool
+}
+
+// SetTextNormalization sets how the text of the EPUB is normalized when it's
+// written. The normalization is applied uniformly to the content and the
+// metadata: the sections (including generated pages), the navigation
+// documents and the package file. The sections and metadata themselves are
+// left unchanged.
+func (e *Epub) SetTextNormalization(normalization TextNormalization) {
+	e.textNormalization = normalization
+}
+
+// Normalize the metadata, the title of the navigation documents and the
+// section titles as normalizeTextFiles does.
+// They're escaped when they're written, which would replace the control
+// characters, so they're normalized beforehand. The returned function
+// restores them.
+func (e *Epub) normalizeMetadata() func() {
+	n := e.textNormalization
+	if n.Normalize == nil && !n.RemoveControlCharacters {
+		return func() {}
+	}
+	normalize := func(s string) string {
+		if n.Normalize != nil {
+			s = n.Normalize(s)
+		}
+		if n.RemoveControlCharacters {
+			s = removeControlCharacters(s)
+		}
+		return s
+	}
+
+	metadata := e.pkg.xml.Metadata
+	tocTitle := e.toc.title
+	e.toc.setTitle(normalize(tocTitle))
+	m := &e.pkg.xml.Metadata
+	m.Title = normalize(m.Title)
+	m.Description = normalize(m.Description)
+	m.Publisher = normalize(m.Publisher)
+	m.Rights = normalize(m.Rights)
+	if m.Creator != nil {
+		creator := *m.Creator
+		creator.Data = normalize(creator.Data)
+		m.Creator = &creator
+	}
+	m.Subtitle = append([]pkgSubtitle{}, m.Subtitle...)
+	for i := range m.Subtitle {
+		m.Subtitle[i].Data = normalize(m.Subtitle[i].Data)
+	}
+	m.SectionCreator = append([]pkgCreator{}, m.SectionCreator...)
+	for i := range m.SectionCreator {
+		m.SectionCreator[i].Data = normalize(m.SectionCreator[i].Data)
+	}
+	m.Contributor = append([]pkgContributor{}, m.Contributor...)
+	for i := range m.Contributor {
+		m.Contributor[i].Data = normalize(m.Contributor[i].Data)
+	}
+	m.Meta = append([]pkgMeta{}, m.Meta...)
+	for i := range m.Meta {
+		m.Meta[i].Data = normalize(m.Meta[i].Data)
+	}
+
+	titles := map[*xhtml]string{}
+	for _, section := range e.sections {
+		titles[section.xhtml] = section.xhtml.xml.Head.Title
+		section.xhtml.xml.Head.Title = normalize(section.xhtml.xml.Head.Title)
+	}
+
+	return func() {
+		e.pkg.xml.Metadata = metadata
+		e.toc.setTitle(tocTitle)
+		for x, title := range titles {
+			x.xml.Head.Title = title
+		}
+	}
+}
+
+// Normalize the text files of the EPUB in the temporary directory
+func (e *Epub) normalizeTextFiles(tempDir string) {
+	n := e.textNormalization
+	if n.Normalize == nil && !n.RemoveControlCharacters && len(n.Replacements) == 0 && !n.NumericEntities {
+		return
+	}
+
+	var replacer *strings.Replacer
+	if len(n.Replacements) > 0 {
+		// Sort the characters so that the replacer is always the same
+		chars := []string{}
+		for r := range n.Replacements {
+			chars = append(chars, string(r))
+		}
+		sort.Strings(chars)
+		oldnew := []string{}
+		for _, c := range chars {
+			// The files are XML, and the replacement may be in an attribute
+			oldnew = append(oldnew, c, escapeAttribute(n.Replacements[[]rune(c)[0]]))
+		}
+		replacer = strings.NewReplacer(oldnew...)
+	}
+
+	err := filepath.Walk(filepath.Join(tempDir, contentFolderName), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || !normalizedFileExts[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		s := string(contents)
+		if n.Normalize != nil {
+			s = n.Normalize(s)
+		}
+		if n.RemoveControlCharacters {
+			s = removeControlCharacters(s)
+		}
+		if replacer != nil {
+			s = replacer.Replace(s)
+		}
+		if n.NumericEntities {
+			s = numericEntities(s)
+		}
+		if s == string(contents) {
+			return nil
+		}
+
+		return ioutil.WriteFile(path, []byte(s), info.Mode())
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Error normalizing text files: %s", err))
+	}
+}
+
+// Remove the C0 control characters except tab, line feed and carriage return,
+// as well as DEL and the C1 control characters
+func removeControlCharacters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t' && r != '\n' && r != '\r') || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Convert the named character references defined by HTML to numeric character
+// references. Unknown references are left unchanged.
+func numericEntities(s string) string {
+	return namedEntityRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := ref[1 : len(ref)-1]
+		value, ok := xml.HTMLEntity[name]
+		if xmlEntities[name] || !ok {
+			return ref
+		}
+		numeric := ""
+		for _, r := range value {
+			numeric += fmt.Sprintf("&#%d;", r)
+		}
+		return numeric
+	})
+}
@@ -0,0 +1,59 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetTextNormalization(t *testing.T) {
+	e := NewEpub("Café \x01“Gophers”")
+	e.SetDescription("A story\x1b")
+	filename, _ := e.AddSection("<p>Café&nbsp;&amp;&eacute;&unknown;\x02 — “Hi”</p>", "Chapter\x03 1", "", "")
+	e.SetTextNormalization(TextNormalization{
+		// A minimal NFC normalizer
+		Normalize: func(s string) string {
+			return strings.Replace(s, "é", "é", -1)
+		},
+		RemoveControlCharacters: true,
+		Replacements:            PlainPunctuationReplacements,
+		NumericEntities:         true,
+	})
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	files := map[string][]string{
+		filepath.Join(xhtmlFolderName, filename): {
+			`<p>Café&#160;&amp;&#233;&unknown; -- &quot;Hi&quot;</p>`,
+			`<title>Chapter 1</title>`,
+		},
+		pkgFilename: {
+			`<dc:title>Café &quot;Gophers&quot;</dc:title>`,
+			`<dc:description>A story</dc:description>`,
+		},
+		tocNavFilename: {
+			`>Chapter 1</a>`,
+		},
+		tocNcxFilename: {
+			`<text>Café &quot;Gophers&quot;</text>`,
+		},
+	}
+	for file, testContents := range files {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, file))
+		if err != nil {
+			t.Fatalf("Unexpected error reading %s: %s", file, err)
+		}
+		for _, testContent := range testContents {
+			if !strings.Contains(string(contents), testContent) {
+				t.Errorf("%s doesn't match\nGot: %s\nExpected to contain: %s", file, contents, testContent)
+			}
+		}
+	}
+
+	// The metadata and sections are left unchanged
+	if e.pkg.xml.Metadata.Title != "Café \x01“Gophers”" || e.sections[0].xhtml.Title() != "Chapter\x03 1" {
+		t.Errorf("EPUB was changed by the text normalization")
+	}
+}
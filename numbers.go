@@ -0,0 +1,160 @@
+package epub
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A numbering system used by a language
+type numberingSystem struct {
+	// The digits from 0 to 9, if the system uses positional decimal digits
+	digits []rune
+	// The CJK numerals, if the system uses them
+	cjk *cjkNumerals
+	// The CSS list-style-type counter style of ordered lists
+	listStyle string
+}
+
+// CJK numerals, written with the multipliers of the powers of 10
+type cjkNumerals struct {
+	// The digits from 0 to 9
+	digits []rune
+	// The units of 10, 100 and 1000, then of 10^4 and 10^8
+	units   []string
+	myriads []string
+	// Whether zeros within numbers are written, e.g. 一百零五 (105) in
+	// Chinese, and whether 1 is written before 百 and 千
+	zeros bool
+}
+
+var (
+	cjkDigits            = []rune("〇一二三四五六七八九")
+	cjkUnits             = []string{"十", "百", "千"}
+	japaneseNumerals     = &cjkNumerals{digits: cjkDigits, units: cjkUnits, myriads: []string{"万", "億"}}
+	simpChineseNumerals  = &cjkNumerals{digits: []rune("零一二三四五六七八九"), units: cjkUnits, myriads: []string{"万", "亿"}, zeros: true}
+	tradChineseNumerals  = &cjkNumerals{digits: []rune("零一二三四五六七八九"), units: cjkUnits, myriads: []string{"萬", "億"}, zeros: true}
+	tradChineseLanguages = map[string]bool{"zh-hant": true, "zh-hk": true, "zh-mo": true, "zh-tw": true}
+)
+
+// Numbering systems per primary language subtag; other languages use the
+// Western digits
+var numberingSystems = map[string]numberingSystem{
+	"ar": {digits: []rune("٠١٢٣٤٥٦٧٨٩"), listStyle: "arabic-indic"},
+	"bn": {digits: []rune("০১২৩৪৫৬৭৮৯"), listStyle: "bengali"},
+	"fa": {digits: []rune("۰۱۲۳۴۵۶۷۸۹"), listStyle: "persian"},
+	"ja": {cjk: japaneseNumerals, listStyle: "japanese-informal"},
+	"th": {digits: []rune("๐๑๒๓๔๕๖๗๘๙"), listStyle: "thai"},
+	"ur": {digits: []rune("۰۱۲۳۴۵۶۷۸۹"), listStyle: "urdu"},
+	"zh": {cjk: simpChineseNumerals, listStyle: "simp-chinese-informal"},
+}
+
+// FormatNumber formats a number using the numbering system of a language, e.g.
+// ١٢ in Arabic or 十二 in Chinese and Japanese, which can be used to number
+// chapters. Numbers in languages using the Western digits are formatted as-is.
+func FormatNumber(n int, lang string) string {
+	system := languageNumberingSystem(lang)
+	s := strconv.Itoa(n)
+	if system.cjk != nil {
+		s = system.cjk.format(n)
+	} else if system.digits != nil {
+		s = strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return system.digits[r-'0']
+			}
+			return r
+		}, s)
+	}
+
+	return s
+}
+
+// Get the numbering system of a language
+func languageNumberingSystem(lang string) numberingSystem {
+	lang = strings.ToLower(lang)
+	system := numberingSystems[primaryLanguageSubtag(lang)]
+	for tag := range tradChineseLanguages {
+		if lang == tag || strings.HasPrefix(lang, tag+"-") {
+			system = numberingSystem{cjk: tradChineseNumerals, listStyle: "trad-chinese-informal"}
+		}
+	}
+
+	return system
+}
+
+// Get the style attribute setting the counter style of the generated ordered
+// lists for the language of the EPUB, if it doesn't use the Western digits
+func (e *Epub) listStyleAttribute() string {
+	if style := languageNumberingSystem(e.lang).listStyle; style != "" {
+		return fmt.Sprintf(` style="list-style-type: %s"`, style)
+	}
+
+	return ""
+}
+
+// Format a number using CJK numerals
+func (c *cjkNumerals) format(n int) string {
+	if n < 0 {
+		return "-" + c.format(-n)
+	}
+	if n == 0 {
+		return string(c.digits[0])
+	}
+
+	// Split the number into groups of 4 digits, each followed by its myriad
+	groups := []int{}
+	for m := n; m > 0; m /= 10000 {
+		groups = append(groups, m%10000)
+	}
+	if len(groups) > len(c.myriads)+1 {
+		// Too large for the myriads, so write the digits
+		return strings.Map(func(r rune) rune { return c.digits[r-'0'] }, strconv.Itoa(n))
+	}
+
+	s := ""
+	for i := len(groups) - 1; i >= 0; i-- {
+		group := groups[i]
+		if group == 0 {
+			continue
+		}
+		// e.g. 一万零五 (10005)
+		if c.zeros && s != "" && (group < 1000 || groups[i+1] == 0) {
+			s += string(c.digits[0])
+		}
+		s += c.formatGroup(group, s == "")
+		if i > 0 {
+			s += c.myriads[i-1]
+		}
+	}
+
+	return s
+}
+
+// Format a number from 1 to 9999, which starts the number if leading is true
+func (c *cjkNumerals) formatGroup(n int, leading bool) string {
+	s := ""
+	zero := false
+	for i := 3; i >= 0; i-- {
+		power := []int{1, 10, 100, 1000}[i]
+		digit := n / power % 10
+		if digit == 0 {
+			zero = s != ""
+			continue
+		}
+		if zero && c.zeros {
+			s += string(c.digits[0])
+		}
+		zero = false
+
+		// 1 isn't written before the units, except in Chinese where it's only
+		// omitted before 十 at the start of a number, e.g. 十二 but 一百一十
+		if i == 0 || digit != 1 || (c.zeros && (i > 1 || s != "" || !leading)) {
+			s += string(c.digits[digit])
+		}
+		if i > 0 {
+			s += c.units[i-1]
+		}
+	}
+
+	return s
+}
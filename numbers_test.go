@@ -0,0 +1,55 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatNumber(t *testing.T) {
+	for _, test := range []struct {
+		n        int
+		lang     string
+		expected string
+	}{
+		{12, "en", "12"},
+		{2024, "ar-EG", "٢٠٢٤"},
+		{15, "fa", "۱۵"},
+		{12, "ja", "十二"},
+		{105, "ja", "百五"},
+		{12, "zh", "十二"},
+		{105, "zh-Hans", "一百零五"},
+		{110, "zh", "一百一十"},
+		{10012, "zh", "一万零一十二"},
+		{100001000, "zh", "一亿零一千"},
+		{23000, "zh-TW", "二萬三千"},
+		{0, "ja", "〇"},
+	} {
+		if output := FormatNumber(test.n, test.lang); output != test.expected {
+			t.Errorf("Number %d for %s doesn't match\nGot: %s\nExpected: %s", test.n, test.lang, output, test.expected)
+		}
+	}
+}
+
+func TestTOCPageListStyle(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetLang("zh-Hant")
+	filename, err := e.GenerateTOCPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error generating TOC page: %s", err)
+	}
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading TOC page: %s", err)
+	}
+	expected := `<ol class="toc" style="list-style-type: trad-chinese-informal">`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("TOC page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+}
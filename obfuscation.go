@@ -0,0 +1,99 @@
+package epub
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	encryptionFileTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+%s</encryption>
+`
+	encryptionFilename    = "encryption.xml"
+	encryptedDataTemplate = `  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"></enc:EncryptionMethod>
+    <enc:CipherData>
+      <enc:CipherReference URI="%s"></enc:CipherReference>
+    </enc:CipherData>
+  </enc:EncryptedData>
+`
+	// Number of bytes at the start of a font file that are obfuscated
+	obfuscatedLength = 1040
+)
+
+// ResourceTransformObfuscated is the transform applied to fonts obfuscated
+// using SetFontObfuscation.
+const ResourceTransformObfuscated = "obfuscated"
+
+// SetFontObfuscation sets whether the fonts of the EPUB are obfuscated using
+// the IDPF font obfuscation algorithm, as required by the licenses of many
+// commercial fonts to deter their extraction. The fonts are obfuscated when
+// the EPUB is written, using a key derived from its unique identifier, and
+// listed in META-INF/encryption.xml so that reading systems can restore them.
+// Fonts aren't obfuscated by default.
+func (e *Epub) SetFontObfuscation(obfuscate bool) {
+	e.obfuscateFonts = obfuscate
+}
+
+// Obfuscate the fonts of the temporary directory and write the encryption file
+// listing them
+func (e *Epub) writeObfuscatedFonts(tempDir string) {
+	if !e.obfuscateFonts || len(e.fonts) == 0 {
+		return
+	}
+
+	filenames := []string{}
+	for filename := range e.fonts {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	key := fontObfuscationKey(e.identifier)
+	encryptedData := ""
+	for _, filename := range filenames {
+		fontFilePath := filepath.Join(tempDir, contentFolderName, FontFolderName, filename)
+		font, err := ioutil.ReadFile(fontFilePath)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading font file: %s", err))
+		}
+		obfuscateFont(font, key)
+		if err := ioutil.WriteFile(fontFilePath, font, filePermissions); err != nil {
+			panic(fmt.Sprintf("Error writing font file: %s", err))
+		}
+		e.recordTransform(FontFolderName, filename, int64(len(font)), ResourceTransformObfuscated)
+
+		encryptedData += fmt.Sprintf(encryptedDataTemplate, escapeAttribute(path.Join(contentFolderName, FontFolderName, filename)))
+	}
+
+	encryptionFilePath := filepath.Join(tempDir, metaInfFolderName, encryptionFilename)
+	if err := ioutil.WriteFile(encryptionFilePath, []byte(fmt.Sprintf(encryptionFileTemplate, encryptedData)), filePermissions); err != nil {
+		panic(fmt.Sprintf("Error writing encryption file: %s", err))
+	}
+}
+
+// Get the key of the font obfuscation algorithm: the SHA-1 digest of the
+// unique identifier without whitespace
+func fontObfuscationKey(identifier string) [sha1.Size]byte {
+	identifier = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, identifier)
+
+	return sha1.Sum([]byte(identifier))
+}
+
+// Obfuscate the start of a font in place. As it uses XOR, obfuscating an
+// obfuscated font restores it.
+func obfuscateFont(font []byte, key [sha1.Size]byte) {
+	for i := 0; i < len(font) && i < obfuscatedLength; i++ {
+		font[i] ^= key[i%len(key)]
+	}
+}
@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetFontObfuscation(t *testing.T) {
+	font, err := ioutil.ReadFile(testFontFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading font: %s", err)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.SetIdentifier("urn:uuid:0b4bf8a3-3a1a-4d2f-9a4e-1b1c4c5e6f70")
+	if _, err := e.AddFont(testFontFromFileSource, "font.ttf"); err != nil {
+		t.Fatalf("Unexpected error adding font: %s", err)
+	}
+	e.SetFontObfuscation(true)
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	obfuscated, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, FontFolderName, "font.ttf"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading font file: %s", err)
+	}
+	if bytes.Equal(obfuscated, font) {
+		t.Errorf("Font wasn't obfuscated")
+	}
+	if !bytes.Equal(obfuscated[obfuscatedLength:], font[obfuscatedLength:]) {
+		t.Errorf("Font was obfuscated past the first %d bytes", obfuscatedLength)
+	}
+	obfuscateFont(obfuscated, fontObfuscationKey(e.Identifier()))
+	if !bytes.Equal(obfuscated, font) {
+		t.Errorf("Font can't be restored using the unique identifier")
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, metaInfFolderName, encryptionFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading encryption file: %s", err)
+	}
+	expected := `<enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"></enc:EncryptionMethod>
+    <enc:CipherData>
+      <enc:CipherReference URI="EPUB/fonts/font.ttf"></enc:CipherReference>`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("Encryption file contents don't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+
+	for _, r := range e.LastBuildReport().Resources {
+		if r.Path == "EPUB/fonts/font.ttf" && (len(r.Transforms) != 1 || r.Transforms[0] != ResourceTransformObfuscated) {
+			t.Errorf("Font transforms don't match\nGot: %v\nExpected: %v", r.Transforms, []string{ResourceTransformObfuscated})
+		}
+	}
+}
+
+func TestFontObfuscationKey(t *testing.T) {
+	expected := sha1.Sum([]byte("urn:isbn:9780000000002"))
+	if key := fontObfuscationKey(" urn:isbn:\t97800000\r\n00002 "); key != expected {
+		t.Errorf("Font obfuscation key doesn't match\nGot: %x\nExpected: %x", key, expected)
+	}
+}
@@ -0,0 +1,123 @@
+package epub
+
+import "fmt"
+
+// Option configures an EPUB created by NewEpub, e.g.:
+//
+//	e := epub.NewEpub("My title", epub.WithAuthor("Jane Doe"), epub.WithLang("fr"))
+//
+// Each option calls the corresponding setter, which can still be used
+// afterwards.
+type Option func(e *Epub) error
+
+// OptionError is thrown by Write if an option passed to NewEpub failed, e.g.
+// because the cover image couldn't be retrieved. The EPUB is created anyway
+// so that NewEpub doesn't need to return an error, but it can't be written.
+type OptionError struct {
+	Err error // The underlying error that was thrown
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("Error applying EPUB option: %+v", e.Err)
+}
+
+// WithAuthor sets the author of the EPUB (see SetAuthor).
+func WithAuthor(author string) Option {
+	return func(e *Epub) error {
+		e.SetAuthor(author)
+		return nil
+	}
+}
+
+// WithLang sets the language of the EPUB (see SetLang).
+func WithLang(lang string) Option {
+	return func(e *Epub) error {
+		e.SetLang(lang)
+		return nil
+	}
+}
+
+// WithIdentifier sets the unique identifier of the EPUB (see SetIdentifier).
+func WithIdentifier(identifier string) Option {
+	return func(e *Epub) error {
+		e.SetIdentifier(identifier)
+		return nil
+	}
+}
+
+// WithUUID sets the unique identifier of the EPUB to a UUID, e.g.
+// fe93046f-af57-475a-a0cb-a0d4bc99ba6d, which is written with the urn:uuid:
+// prefix. An invalid UUID results in OptionError.
+func WithUUID(id string) Option {
+	return func(e *Epub) error {
+		u, err := parseUUID(id)
+		if err != nil {
+			return err
+		}
+		e.SetIdentifier(urnUUIDPrefix + u.String())
+		return nil
+	}
+}
+
+// WithPublisher sets the publisher of the EPUB (see SetPublisher).
+func WithPublisher(publisher string) Option {
+	return func(e *Epub) error {
+		e.SetPublisher(publisher)
+		return nil
+	}
+}
+
+// WithDescription sets the description of the EPUB (see SetDescription).
+func WithDescription(desc string) Option {
+	return func(e *Epub) error {
+		e.SetDescription(desc)
+		return nil
+	}
+}
+
+// WithCover adds an image to the EPUB (see AddImage) and uses it as the cover
+// with the default cover stylesheet (see SetCover). If the image can't be
+// added, the error is returned by Write as OptionError.
+func WithCover(imageSource string) Option {
+	return func(e *Epub) error {
+		imagePath, err := e.AddImage(imageSource, "")
+		if err != nil {
+			return err
+		}
+		e.SetCover(imagePath, "")
+		return nil
+	}
+}
+
+// WithVersion sets the EPUB version to write (see SetVersion).
+func WithVersion(version string) Option {
+	return func(e *Epub) error {
+		return e.SetVersion(version)
+	}
+}
+
+// WithStrict enables strict mode (see SetStrict).
+func WithStrict() Option {
+	return func(e *Epub) error {
+		e.SetStrict(true)
+		return nil
+	}
+}
+
+// WithDeterministicOutput makes writing the EPUB reproducible, so the same
+// content always results in the same file (see SetReproducible).
+func WithDeterministicOutput() Option {
+	return func(e *Epub) error {
+		e.SetReproducible(true)
+		return nil
+	}
+}
+
+// Apply the options passed to NewEpub, keeping the first error
+func (e *Epub) applyOptions(opts []Option) {
+	for _, opt := range opts {
+		if err := opt(e); err != nil && e.optionErr == nil {
+			e.optionErr = &OptionError{Err: err}
+		}
+	}
+}
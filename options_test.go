@@ -0,0 +1,50 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewEpubOptions(t *testing.T) {
+	e := NewEpub(testEpubTitle,
+		WithAuthor("Jane Doe"),
+		WithLang("fr"),
+		WithUUID("FE93046F-AF57-475A-A0CB-A0D4BC99BA6D"),
+		WithPublisher("Gopher Press"),
+		WithCover(testImageFromFileSource),
+		WithDeterministicOutput(),
+	)
+	if e.Author() != "Jane Doe" || e.Lang() != "fr" || e.Publisher() != "Gopher Press" || !e.Reproducible() {
+		t.Errorf("Options weren't applied")
+	}
+	testIdentifier := "urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d"
+	if e.Identifier() != testIdentifier {
+		t.Errorf("Identifier doesn't match\nGot: %s\nExpected: %s", e.Identifier(), testIdentifier)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	testMetadata := `<meta name="cover" content="gophercolor16x16.png"></meta>`
+	if !strings.Contains(string(contents), testMetadata) {
+		t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+	}
+
+	for _, opt := range []Option{WithUUID("not a UUID"), WithCover("testdata/missing.png"), WithVersion("4.0")} {
+		e := NewEpub(testEpubTitle, WithAuthor("Jane Doe"), opt)
+		if e.Author() != "Jane Doe" {
+			t.Errorf("Options weren't applied")
+		}
+		if err := e.Write(testEpubFilename); err == nil {
+			t.Errorf("Expected OptionError writing EPUB")
+		} else if _, ok := err.(*OptionError); !ok {
+			t.Errorf("Write error type doesn't match\nGot: %T\nExpected: %T", err, &OptionError{})
+		}
+	}
+}
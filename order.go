@@ -0,0 +1,108 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"path/filepath"
+)
+
+// ZipOrder defines the order of the files in the EPUB. The mimetype file always
+// comes first, as required by the EPUB spec.
+type ZipOrder int
+
+// Orders of the files in the EPUB
+const (
+	// The files needed to start reading come first, which allows streaming
+	// reading systems to render the EPUB progressively (the default): the
+	// container file, the package file, the navigation documents, the cover,
+	// then each section of the spine preceded by its stylesheets (and the
+	// fonts and images they use) and followed by the resources it references.
+	// The remaining files come last.
+	ZipOrderReading ZipOrder = iota
+	// The files are sorted by path
+	ZipOrderLexical
+)
+
+// SetZipOrder sets the order of the files in the EPUB.
+func (e *Epub) SetZipOrder(order ZipOrder) {
+	e.zipOrder = order
+}
+
+// Order the paths of the files of the temporary directory to add to the EPUB,
+// which are relative to the temporary directory and in lexical order
+func (e *Epub) orderZipEntries(tempDir string, paths []string) []string {
+	if e.zipOrder == ZipOrderLexical {
+		return paths
+	}
+
+	exists := map[string]bool{}
+	for _, p := range paths {
+		exists[p] = true
+	}
+	ordered := []string{}
+	added := map[string]bool{}
+	var add func(p string, references []string)
+	add = func(p string, references []string) {
+		if !exists[p] || added[p] {
+			return
+		}
+		added[p] = true
+		ordered = append(ordered, p)
+		for _, reference := range references {
+			if resolved, ok := resolveZipReference(p, reference); ok {
+				add(resolved, nil)
+			}
+		}
+	}
+	// Add a stylesheet followed by the fonts and images it uses
+	addCSS := func(href string, sectionPath string) {
+		cssPath, ok := resolveZipReference(sectionPath, href)
+		if !ok || !exists[cssPath] || added[cssPath] {
+			return
+		}
+		css, err := ioutil.ReadFile(filepath.Join(tempDir, filepath.FromSlash(cssPath)))
+		if err != nil {
+			panic(fmt.Sprintf("Error reading CSS file: %s", err))
+		}
+		add(cssPath, findCSSReferences(string(css)))
+	}
+
+	add(path.Join(metaInfFolderName, containerFilename), nil)
+	add(path.Join(metaInfFolderName, encryptionFilename), nil)
+	add(path.Join(contentFolderName, pkgFilename), nil)
+	add(path.Join(contentFolderName, tocNavFilename), nil)
+	add(path.Join(contentFolderName, tocNcxFilename), nil)
+	if e.cover.imageFilename != "" {
+		add(path.Join(contentFolderName, ImageFolderName, e.cover.imageFilename), nil)
+	}
+
+	for _, section := range e.spineSections() {
+		sectionPath := path.Join(contentFolderName, xhtmlFolderName, section.filename)
+		if e.defaultCSS() != "" {
+			addCSS(defaultCSSPath(), sectionPath)
+		}
+		for _, link := range section.xhtml.xml.Head.Link {
+			addCSS(link.Href, sectionPath)
+		}
+		add(sectionPath, findResourceReferences(simplifyResponsiveImages(section.xhtml.xml.Body.XML)))
+	}
+
+	for _, p := range paths {
+		add(p, nil)
+	}
+
+	return ordered
+}
+
+// Resolve a reference from a file of the EPUB to the path of the referenced
+// file relative to the root of the EPUB, if it's a local file
+func resolveZipReference(from string, reference string) (string, bool) {
+	u, err := url.Parse(unescapeText(reference))
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	return path.Join(path.Dir(from), u.Path), true
+}
@@ -0,0 +1,103 @@
+package epub
+
+import (
+	"archive/zip"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Get the names of the files of a zip archive, in order
+func zipEntryNames(t *testing.T, zipPath string) []string {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("Unexpected error opening EPUB: %s", err)
+	}
+	defer r.Close()
+
+	names := []string{}
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+
+	return names
+}
+
+func newTestZipOrderEpub() *Epub {
+	e := NewEpub(testEpubTitle)
+	coverPath, _ := e.AddImage(testImageFromFileSource, "cover.png")
+	cssPath, _ := e.AddCSS(newDataURL(mediaTypeCSS, []byte(`h1 { background: url("../images/b.png"); }`)), "style.css")
+	e.SetCover(coverPath, cssPath)
+	e.AddImage(testImageFromFileSource, "a.png")
+	e.AddImage(testImageFromFileSource, "b.png")
+	e.AddImage(testImageFromFileSource, "unused.png")
+	e.AddSection(`<p>One</p>`, "One", "one.xhtml", cssPath)
+	e.AddSection(`<img src="../images/a.png" alt="" />`, "Two", "two.xhtml", "")
+
+	return e
+}
+
+func TestSetZipOrder(t *testing.T) {
+	e := newTestZipOrderEpub()
+	if err := e.Write(testEpubFilename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	names := zipEntryNames(t, testEpubFilename)
+	os.Remove(testEpubFilename)
+
+	testNames := []string{
+		"mimetype",
+		"META-INF/container.xml",
+		"EPUB/package.opf",
+		"EPUB/nav.xhtml",
+		"EPUB/toc.ncx",
+		"EPUB/images/cover.png",
+		"EPUB/css/style.css",
+		"EPUB/images/b.png",
+		"EPUB/xhtml/cover.xhtml",
+		"EPUB/xhtml/one.xhtml",
+		"EPUB/xhtml/two.xhtml",
+		"EPUB/images/a.png",
+		"EPUB/images/unused.png",
+	}
+	if !reflect.DeepEqual(names, testNames) {
+		t.Errorf(
+			"EPUB files order doesn't match\n"+
+				"Got: %v\n"+
+				"Expected: %v",
+			names,
+			testNames)
+	}
+
+	e = newTestZipOrderEpub()
+	e.SetZipOrder(ZipOrderLexical)
+	if err := e.Write(testEpubFilename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	names = zipEntryNames(t, testEpubFilename)
+	os.Remove(testEpubFilename)
+
+	testNames = []string{
+		"mimetype",
+		"EPUB/css/style.css",
+		"EPUB/images/a.png",
+		"EPUB/images/b.png",
+		"EPUB/images/cover.png",
+		"EPUB/images/unused.png",
+		"EPUB/nav.xhtml",
+		"EPUB/package.opf",
+		"EPUB/toc.ncx",
+		"EPUB/xhtml/cover.xhtml",
+		"EPUB/xhtml/one.xhtml",
+		"EPUB/xhtml/two.xhtml",
+		"META-INF/container.xml",
+	}
+	if !reflect.DeepEqual(names, testNames) {
+		t.Errorf(
+			"EPUB files order doesn't match\n"+
+				"Got: %v\n"+
+				"Expected: %v",
+			names,
+			testNames)
+	}
+}
@@ -0,0 +1,197 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	pageBreakTemplate      = `<span epub:type="pagebreak" role="doc-pagebreak" id="%s" title="%s"></span>`
+	pageBreakEPUB2Template = `<span id="%s" title="%s"></span>`
+	pageListEpubType       = "page-list"
+)
+
+// Page labels that are roman numerals, used for front matter
+var romanNumeralRegexp = regexp.MustCompile(`^(?i)[ivxlcdm]+$`)
+
+// InvalidPageBreakError is thrown by AddPageBreak if a page break can't be
+// added, e.g. because its label is already used.
+type InvalidPageBreakError struct {
+	Filename string // Filename of the section
+	Label    string // Label of the page
+	Reason   string // Why it can't be added
+}
+
+func (e *InvalidPageBreakError) Error() string {
+	return fmt.Sprintf("Invalid page break %q in %s: %s", e.Label, e.Filename, e.Reason)
+}
+
+// A page break added using AddPageBreak
+type epubPageBreak struct {
+	filename string
+	label    string
+	// ID of the element the page starts at, empty for the start of the
+	// section
+	anchorID string
+	// ID of the page break element
+	id string
+}
+
+// The page list of the navigation document
+type tocPageListNav struct {
+	XMLName  xml.Name      `xml:"nav"`
+	EpubType string        `xml:"epub:type,attr"`
+	Hidden   string        `xml:"hidden,attr"`
+	H1       string        `xml:"h1"`
+	Links    []*tocNavItem `xml:"ol>li"`
+}
+
+// The page list of the NCX
+type tocNcxPageList struct {
+	Text    string             `xml:"navLabel>text"`
+	Targets []tocNcxPageTarget `xml:"pageTarget"`
+}
+
+type tocNcxPageTarget struct {
+	ID      string        `xml:"id,attr"`
+	Type    string        `xml:"type,attr"`
+	Value   string        `xml:"value,attr,omitempty"`
+	Text    string        `xml:"navLabel>text"`
+	Content tocNcxContent `xml:"content"`
+}
+
+// AddPageBreak marks where a page of the print edition starts in a section,
+// so that readers can find the page numbers referenced elsewhere (e.g. in a
+// class). When the EPUB is written, an empty <span epub:type="pagebreak">
+// element is inserted before the element of the section with the provided ID,
+// or at the start of the section if the ID is empty, and the page is listed in
+// the page list of the navigation document and the NCX.
+//
+// The internal filename of an already-added section (as returned by
+// AddSection) is required; if it doesn't match a section,
+// FilenameNotFoundError is returned. InvalidPageBreakError is returned if the
+// label is empty or already used, or if the section has no element with the
+// provided ID.
+func (e *Epub) AddPageBreak(sectionFilename string, pageLabel string, anchorID string) error {
+	i := e.sectionIndex(sectionFilename)
+	if i == -1 {
+		return &FilenameNotFoundError{Filename: sectionFilename}
+	}
+
+	reason := ""
+	switch {
+	case strings.TrimSpace(pageLabel) == "":
+		reason = "empty label"
+	case anchorID != "" && !idAttrRegexp(anchorID).MatchString(e.sections[i].xhtml.xml.Body.XML):
+		reason = "no element with the ID " + anchorID
+	}
+	for _, pageBreak := range e.pageBreaks {
+		if pageBreak.label == pageLabel {
+			reason = "label already used"
+		}
+	}
+	if reason != "" {
+		return &InvalidPageBreakError{Filename: sectionFilename, Label: pageLabel, Reason: reason}
+	}
+
+	id := "page-" + pageLabel
+	if !footnoteIDRegexp.MatchString(id) {
+		id = "pagebreak-" + strconv.Itoa(len(e.pageBreaks)+1)
+	}
+	e.pageBreaks = append(e.pageBreaks, epubPageBreak{
+		filename: sectionFilename,
+		label:    pageLabel,
+		anchorID: anchorID,
+		id:       id,
+	})
+
+	return nil
+}
+
+// Match the start tag of the element with the provided ID
+func idAttrRegexp(id string) *regexp.Regexp {
+	return regexp.MustCompile(`<[a-zA-Z][^>]*\sid\s*=\s*["']` + regexp.QuoteMeta(id) + `["'][^>]*>`)
+}
+
+// Insert the page breaks of a section. The body is returned unchanged if the
+// section has no page breaks.
+func (e *Epub) applyPageBreaks(filename string, body string) string {
+	start := ""
+	for _, pageBreak := range e.pageBreaks {
+		if pageBreak.filename != filename {
+			continue
+		}
+		template := pageBreakTemplate
+		if e.version == EPUBVersion2 {
+			template = pageBreakEPUB2Template
+		}
+		span := fmt.Sprintf(template, pageBreak.id, escapeAttribute(pageBreak.label))
+		if pageBreak.anchorID == "" {
+			start += span
+			continue
+		}
+		if loc := idAttrRegexp(pageBreak.anchorID).FindStringIndex(body); loc != nil {
+			body = body[:loc[0]] + span + body[loc[0]:]
+		}
+	}
+
+	return start + body
+}
+
+// Whether page breaks were added to a section
+func (e *Epub) hasPageBreaks(filename string) bool {
+	for _, pageBreak := range e.pageBreaks {
+		if pageBreak.filename == filename {
+			return true
+		}
+	}
+	return false
+}
+
+// Add the page breaks to the page lists of the TOC, in reading order
+func (e *Epub) addPageList() {
+	for _, section := range e.spineSections() {
+		for _, pageBreak := range e.pageBreaks {
+			if pageBreak.filename == section.filename {
+				e.toc.addPage(pageBreak.label, xhtmlFolderName+"/"+section.filename+"#"+pageBreak.id)
+			}
+		}
+	}
+}
+
+// Add a page to the page lists
+func (t *toc) addPage(label string, href string) {
+	if t.pageListNav == nil {
+		t.pageListNav = &tocPageListNav{
+			EpubType: pageListEpubType,
+			Hidden:   "hidden",
+			H1:       t.pageListHeading,
+		}
+		t.ncxXML.PageList = &tocNcxPageList{Text: t.pageListHeading}
+	}
+	t.pageListNav.Links = append(t.pageListNav.Links, &tocNavItem{
+		A: tocNavLink{
+			Href: href,
+			Data: label,
+		},
+	})
+
+	target := tocNcxPageTarget{
+		ID:   "pageTarget-" + strconv.Itoa(len(t.ncxXML.PageList.Targets)+1),
+		Type: "special",
+		Text: label,
+		Content: tocNcxContent{
+			Src: href,
+		},
+	}
+	if _, err := strconv.Atoi(label); err == nil {
+		target.Type = "normal"
+		target.Value = label
+	} else if romanNumeralRegexp.MatchString(label) {
+		target.Type = "front"
+	}
+	t.ncxXML.PageList.Targets = append(t.ncxXML.PageList.Targets, target)
+}
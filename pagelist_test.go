@@ -0,0 +1,87 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddPageBreak(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	first, _ := e.AddSection(`<p>Preface</p>`, "Preface", "", "")
+	second, _ := e.AddSection(`<p>Start</p><p id="p2">Middle</p>`, testSectionTitle, "", "")
+
+	if err := e.AddPageBreak("missing.xhtml", "1", ""); err == nil {
+		t.Errorf("Expected error adding a page break to a missing section")
+	}
+	for _, testCase := range [][2]string{{"", ""}, {"1", "missing"}} {
+		err := e.AddPageBreak(second, testCase[0], testCase[1])
+		if _, ok := err.(*InvalidPageBreakError); !ok {
+			t.Errorf("Expected error InvalidPageBreakError not returned for %v. Returned instead: %+v", testCase, err)
+		}
+	}
+
+	// Page breaks are listed in reading order
+	for _, testCase := range [][3]string{{second, "1", ""}, {second, "2", "p2"}, {first, "iv", ""}} {
+		if err := e.AddPageBreak(testCase[0], testCase[1], testCase[2]); err != nil {
+			t.Fatalf("Unexpected error adding page break: %s", err)
+		}
+	}
+	if err := e.AddPageBreak(first, "2", ""); err == nil {
+		t.Errorf("Expected error adding a page break with a label already used")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, second))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testBody := `<span epub:type="pagebreak" role="doc-pagebreak" id="page-1" title="1"></span>` + "\n" + `<p>Start</p>` +
+		`<span epub:type="pagebreak" role="doc-pagebreak" id="page-2" title="2"></span><p id="p2">Middle</p>`
+	if !strings.Contains(string(contents), testBody) || !strings.Contains(string(contents), `xmlns:epub=`) {
+		t.Errorf("Section file doesn't match\nGot: %s\nExpected to contain: %s", contents, testBody)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading navigation document: %s", err)
+	}
+	testNav := `<nav epub:type="page-list" hidden="hidden">
+      <h1>Pages</h1>
+      <ol>
+        <li>
+          <a href="xhtml/section0001.xhtml#page-iv">iv</a>
+        </li>
+        <li>
+          <a href="xhtml/section0002.xhtml#page-1">1</a>
+        </li>
+        <li>
+          <a href="xhtml/section0002.xhtml#page-2">2</a>
+        </li>
+      </ol>
+    </nav>`
+	if !strings.Contains(string(contents), testNav) {
+		t.Errorf("Navigation document doesn't match\nGot: %s\nExpected to contain: %s", contents, testNav)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading NCX: %s", err)
+	}
+	for _, testTarget := range []string{
+		`<pageTarget id="pageTarget-1" type="front">`,
+		`<pageTarget id="pageTarget-3" type="normal" value="2">
+      <navLabel>
+        <text>2</text>
+      </navLabel>
+      <content src="xhtml/section0002.xhtml#page-2"></content>
+    </pageTarget>`,
+	} {
+		if !strings.Contains(string(contents), testTarget) {
+			t.Errorf("NCX doesn't match\nGot: %s\nExpected to contain: %s", contents, testTarget)
+		}
+	}
+}
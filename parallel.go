@@ -0,0 +1,122 @@
+package epub
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ParallelLayout defines how the paragraphs of a parallel text are laid out.
+type ParallelLayout int
+
+// Parallel text layouts
+const (
+	// Each paragraph of the original is shown side by side with its
+	// translation (the default)
+	ParallelLayoutSideBySide ParallelLayout = iota
+	// Each paragraph of the original is followed by its translation
+	ParallelLayoutInterleaved
+)
+
+const (
+	parallelCSSContent = `div.parallel-side-by-side div.parallel-row {
+  display: table;
+  table-layout: fixed;
+  width: 100%;
+}
+div.parallel-side-by-side p.original,
+div.parallel-side-by-side p.translation {
+  display: table-cell;
+  padding: 0 0.5em;
+  vertical-align: top;
+  width: 50%;
+}
+div.parallel-interleaved p.translation {
+  font-style: italic;
+  margin-bottom: 1em;
+}
+`
+	parallelCSSFilename       = "parallel.css"
+	parallelParagraphTemplate = `<p class="%s"%s>%s</p>
+`
+	parallelRowTemplate = `<div class="parallel-row">
+%s</div>
+`
+	parallelTemplate = `<div class="parallel %s">
+%s</div>`
+)
+
+// ParallelText is a text along with its translation, aligned paragraph by
+// paragraph, as used by AddParallelSection.
+type ParallelText struct {
+	// The content of each paragraph of the original, which must be valid
+	// XHTML that can go inside a <p> element
+	Original []string
+	// The content of each paragraph of the translation
+	Translation []string
+	// The languages of the original and the translation, e.g. fr and en;
+	// optional
+	OriginalLang    string
+	TranslationLang string
+}
+
+// AddParallelSection adds a section showing a text and its translation
+// paragraph by paragraph, as is common in bilingual books. The paragraphs of
+// the original and of the translation are aligned by index; if one of them has
+// fewer paragraphs, the missing paragraphs are left empty.
+//
+// The paragraphs have the class "original" or "translation" and are in a
+// <div> element with the class "parallel" and the class of the layout
+// ("parallel-side-by-side" or "parallel-interleaved"). A default stylesheet
+// laying them out is added to the EPUB and linked from the section, followed
+// by the optional internal path to an already-added CSS file (as returned by
+// AddCSS), which can override it, e.g. to hide the translation:
+//
+//	div.parallel p.translation { display: none; }
+//
+// The title and internal filename work the same way as for AddSection.
+func (e *Epub) AddParallelSection(text ParallelText, layout ParallelLayout, sectionTitle string, internalFilename string, internalCSSPath string) (string, error) {
+	paragraph := func(class string, paragraphs []string, i int, lang string) string {
+		content := ""
+		if i < len(paragraphs) {
+			content = paragraphs[i]
+		}
+		attributes := ""
+		if lang != "" {
+			attributes = fmt.Sprintf(` lang="%s" xml:lang="%s"`, escapeAttribute(lang), escapeAttribute(lang))
+		}
+		return fmt.Sprintf(parallelParagraphTemplate, class, attributes, content)
+	}
+
+	rows := ""
+	for i := 0; i < len(text.Original) || i < len(text.Translation); i++ {
+		row := paragraph("original", text.Original, i, text.OriginalLang) +
+			paragraph("translation", text.Translation, i, text.TranslationLang)
+		if layout == ParallelLayoutSideBySide {
+			row = fmt.Sprintf(parallelRowTemplate, row)
+		}
+		rows += row
+	}
+	layoutClass := "parallel-side-by-side"
+	if layout == ParallelLayoutInterleaved {
+		layoutClass = "parallel-interleaved"
+	}
+
+	parallelCSSPath := filepath.Join("..", CSSFolderName, parallelCSSFilename)
+	if _, ok := e.css[parallelCSSFilename]; !ok {
+		var err error
+		parallelCSSPath, err = e.AddCSS(newDataURL(mediaTypeCSS, []byte(parallelCSSContent)), parallelCSSFilename)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	filename, err := e.AddSection(fmt.Sprintf(parallelTemplate, layoutClass, rows), sectionTitle, internalFilename, parallelCSSPath)
+	if err != nil {
+		return "", err
+	}
+	if internalCSSPath != "" {
+		e.sections[len(e.sections)-1].xhtml.addCSS(internalCSSPath)
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,77 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddParallelSection(t *testing.T) {
+	text := ParallelText{
+		Original:        []string{"Bonjour.", "Au revoir."},
+		Translation:     []string{"Hello."},
+		OriginalLang:    "fr",
+		TranslationLang: "en",
+	}
+	tests := []struct {
+		layout   ParallelLayout
+		testBody string
+	}{
+		{
+			layout: ParallelLayoutSideBySide,
+			testBody: `<div class="parallel parallel-side-by-side">
+<div class="parallel-row">
+<p class="original" lang="fr" xml:lang="fr">Bonjour.</p>
+<p class="translation" lang="en" xml:lang="en">Hello.</p>
+</div>
+<div class="parallel-row">
+<p class="original" lang="fr" xml:lang="fr">Au revoir.</p>
+<p class="translation" lang="en" xml:lang="en"></p>
+</div>
+</div>`,
+		},
+		{
+			layout: ParallelLayoutInterleaved,
+			testBody: `<div class="parallel parallel-interleaved">
+<p class="original" lang="fr" xml:lang="fr">Bonjour.</p>
+<p class="translation" lang="en" xml:lang="en">Hello.</p>
+<p class="original" lang="fr" xml:lang="fr">Au revoir.</p>
+<p class="translation" lang="en" xml:lang="en"></p>
+</div>`,
+		},
+	}
+
+	e := NewEpub(testEpubTitle)
+	cssPath, _ := e.AddCSS(testCoverCSSSource, "custom.css")
+	for _, test := range tests {
+		filename, err := e.AddParallelSection(text, test.layout, testSectionTitle, "", cssPath)
+		if err != nil {
+			t.Errorf("Unexpected error adding parallel section: %s", err)
+		}
+
+		if body := e.sections[len(e.sections)-1].xhtml.xml.Body.XML; !strings.Contains(body, test.testBody) {
+			t.Errorf(
+				"Parallel section body doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				body,
+				test.testBody)
+		}
+		links := e.sections[len(e.sections)-1].xhtml.xml.Head.Link
+		if len(links) != 2 || links[0].Href != "../css/parallel.css" || links[1].Href != cssPath {
+			t.Errorf("Stylesheets of %s don't match\nGot: %+v", filename, links)
+		}
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, parallelCSSFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading parallel CSS file: %s", err)
+	}
+	if !strings.Contains(string(contents), "div.parallel-side-by-side") {
+		t.Errorf("Parallel CSS file doesn't match\nGot: %s", contents)
+	}
+}
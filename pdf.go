@@ -0,0 +1,140 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// NoPDFPagesError is thrown by NewEpubFromPDF if no pages were provided, either
+// as page images or using a rasterizer.
+type NoPDFPagesError struct{}
+
+func (e *NoPDFPagesError) Error() string {
+	return "No PDF pages provided"
+}
+
+// PDFRasterizer renders a page of a PDF as an image. The page number starts at
+// 1. The image must be encoded as GIF, JPEG, or PNG, and its file extension
+// (e.g. ".png") must be returned along with it.
+type PDFRasterizer func(page int) (data []byte, ext string, err error)
+
+// PDFBookmark is an entry of the outline (bookmarks) of a PDF.
+type PDFBookmark struct {
+	Title string
+	// Page the bookmark points to, starting at 1
+	Page     int
+	Children []PDFBookmark
+}
+
+// PDFOptions configures the conversion of a PDF by NewEpubFromPDF. The pages
+// can either be rendered by a rasterizer, or provided as pre-rendered page
+// images.
+type PDFOptions struct {
+	// Title of the EPUB
+	Title string
+	// Renders each page of the PDF; used along with PageCount
+	Rasterizer PDFRasterizer
+	// Number of pages of the PDF; used along with Rasterizer
+	PageCount int
+	// Sources of pre-rendered page images, in page order; only used if
+	// Rasterizer is nil. Each source should either be a URL, a data URL, or a
+	// path to a local file.
+	PageImages []string
+	// Outline of the PDF, used to build the TOC of the EPUB
+	Outline []PDFBookmark
+}
+
+// NewEpubFromPDF creates a fixed-layout EPUB from the pages of a PDF, one page
+// image per section. As there is no PDF parser in the standard library, the
+// pages must either be rendered by the provided rasterizer or provided as
+// pre-rendered images.
+//
+// The TOC is derived from the outline of the PDF: each page that a bookmark
+// points to is titled using the first bookmark pointing to it, in outline
+// order (depth first). Bookmarks pointing to pages that don't exist are
+// ignored. If no bookmark points to the first page, it's titled using the
+// title of the EPUB. The first page is used as the cover image.
+//
+// If no pages are provided, NoPDFPagesError will be returned.
+func NewEpubFromPDF(opts PDFOptions) (*Epub, error) {
+	pageCount := len(opts.PageImages)
+	if opts.Rasterizer != nil {
+		pageCount = opts.PageCount
+	}
+	if pageCount <= 0 {
+		return nil, &NoPDFPagesError{}
+	}
+
+	e, cssPath, err := newImagePagesEpub(opts.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := map[int]string{}
+	flattenPDFOutline(opts.Outline, titles)
+	if _, ok := titles[1]; !ok {
+		titles[1] = opts.Title
+	}
+
+	for page := 1; page <= pageCount; page++ {
+		data, ext, err := renderPDFPage(opts, page)
+		if err != nil {
+			return nil, err
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, &FileRetrievalError{Source: fmt.Sprintf("PDF page %d", page), Err: err}
+		}
+
+		if err := e.addImagePage(page, titles[page], ext, data, config.Width, config.Height, cssPath, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// Get the image of a page, either from the rasterizer or from the
+// pre-rendered page images
+func renderPDFPage(opts PDFOptions, page int) ([]byte, string, error) {
+	if opts.Rasterizer != nil {
+		data, ext, err := opts.Rasterizer(page)
+		if err != nil {
+			return nil, "", &FileRetrievalError{Source: fmt.Sprintf("PDF page %d", page), Err: err}
+		}
+		return data, strings.ToLower(ext), nil
+	}
+
+	source := opts.PageImages[page-1]
+	r, err := openMediaSource(context.Background(), http.DefaultClient, source)
+	if err != nil {
+		return nil, "", &FileRetrievalError{Source: source, Err: err}
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			panic(err)
+		}
+	}()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, "", &FileRetrievalError{Source: source, Err: err}
+	}
+
+	return data, mediaSourceExt(source), nil
+}
+
+// Map page numbers to the title of the first bookmark pointing to them
+func flattenPDFOutline(bookmarks []PDFBookmark, titles map[int]string) {
+	for _, bookmark := range bookmarks {
+		if _, ok := titles[bookmark.Page]; !ok && bookmark.Title != "" {
+			titles[bookmark.Page] = bookmark.Title
+		}
+		flattenPDFOutline(bookmark.Children, titles)
+	}
+}
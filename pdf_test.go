@@ -0,0 +1,97 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testPDFChapterTitle = "Chapter 1"
+	testPDFTitle        = "My document"
+)
+
+func TestNewEpubFromPDF(t *testing.T) {
+	e, err := NewEpubFromPDF(PDFOptions{
+		Title: testPDFTitle,
+		Rasterizer: func(page int) ([]byte, string, error) {
+			buf := &bytes.Buffer{}
+			err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, 60, 80)))
+			return buf.Bytes(), ".png", err
+		},
+		PageCount: 3,
+		Outline: []PDFBookmark{
+			{
+				Title: "Part 1",
+				Page:  2,
+				Children: []PDFBookmark{
+					{Title: "Subsection", Page: 2},
+					{Title: testPDFChapterTitle, Page: 3},
+				},
+			},
+			{Title: "Missing", Page: 10},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error converting PDF: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	output, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, "nav.xhtml"))
+	if err != nil {
+		t.Errorf("Unexpected error reading nav file: %s", err)
+	}
+	for _, expected := range []string{
+		`<a href="xhtml/page0001.xhtml">` + testPDFTitle + `</a>`,
+		`<a href="xhtml/page0002.xhtml">Part 1</a>`,
+		`<a href="xhtml/page0003.xhtml">` + testPDFChapterTitle + `</a>`,
+	} {
+		if !strings.Contains(string(output), expected) {
+			t.Errorf(
+				"Nav file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				output,
+				expected)
+		}
+	}
+	if strings.Contains(string(output), "Missing") {
+		t.Errorf("Unexpected bookmark in nav file: %s", output)
+	}
+
+	output, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "page0003.xhtml"))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testViewport := `<meta name="viewport" content="width=60, height=80"></meta>`
+	if !strings.Contains(string(output), testViewport) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			output,
+			testViewport)
+	}
+
+	_, err = NewEpubFromPDF(PDFOptions{Title: testPDFTitle})
+	if _, ok := err.(*NoPDFPagesError); !ok {
+		t.Errorf("Expected error *NoPDFPagesError, got %#v", err)
+	}
+
+	_, err = NewEpubFromPDF(PDFOptions{
+		Rasterizer: func(page int) ([]byte, string, error) {
+			return nil, "", errors.New("Unable to render page")
+		},
+		PageCount: 1,
+	})
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error *FileRetrievalError, got %#v", err)
+	}
+}
@@ -0,0 +1,283 @@
+package epub
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	articleBodyTemplate = `<article>
+<h1>%s</h1>
+%s%s
+</article>`
+	articleBylineTemplate = "<p class=\"byline\">%s</p>\n"
+	articleDateTemplate   = `<time datetime="%s">%s</time>`
+	categoryBodyTemplate  = `<h1 class="category">%s</h1>`
+	mastheadBodyTemplate  = `<div class="masthead">
+<h1>%s</h1>
+%s%s</div>`
+	mastheadFilename      = "masthead.xhtml"
+	mastheadIssueTemplate = "<p class=\"issue\">%s</p>\n"
+	mastheadStaffTemplate = "<dl class=\"staff\">\n%s</dl>\n"
+	mastheadEntryTemplate = "<dt>%s</dt><dd>%s</dd>\n"
+	periodicalCSSContent  = `div.masthead {
+  text-align: center;
+}
+dl.staff dt {
+  font-weight: bold;
+  margin-top: 0.5em;
+}
+dl.staff dd {
+  margin: 0;
+}
+p.byline {
+  font-style: italic;
+}
+`
+	periodicalCSSFilename = "periodical.css"
+
+	pkgCollectionID       = "collection"
+	pkgCollectionProperty = "belongs-to-collection"
+	pkgCollectionType     = "series"
+	pkgCollectionTypeProp = "collection-type"
+	pkgGroupPositionProp  = "group-position"
+)
+
+// NoArticlesError is thrown by NewEpubFromIssue if the issue doesn't have any
+// articles.
+type NoArticlesError struct {
+	Name string // The name of the periodical
+}
+
+func (e *NoArticlesError) Error() string {
+	return fmt.Sprintf("No articles in issue of %s", e.Name)
+}
+
+// Issue is an issue of a periodical, such as a newspaper or a magazine, which
+// can be converted to an EPUB using NewEpubFromIssue.
+type Issue struct {
+	// The name of the periodical, e.g. The Daily Gopher
+	Name string
+	// The number of the issue, e.g. 42, optional
+	Number string
+	// The date of the issue, optional
+	Date time.Time
+	// The language of the issue, e.g. en, which is also used to translate
+	// the generated content and format the dates. Defaults to en.
+	Lang string
+	// The staff and other information shown on the masthead page, in order
+	Masthead []MastheadEntry
+	// The order of the categories in the TOC. The categories of the articles
+	// that aren't listed come afterwards, in order of first appearance.
+	Categories []string
+	// The articles, in order
+	Articles []Article
+	// The source of a stylesheet (a URL, a data URL, or a path to a local
+	// file) used by all the pages, optional. If none is provided, a default
+	// stylesheet is used.
+	CSS string
+}
+
+// MastheadEntry is an entry of the masthead page of an issue, e.g. the
+// editor-in-chief.
+type MastheadEntry struct {
+	// e.g. Editor-in-chief
+	Role string
+	// e.g. Jane Doe
+	Name string
+}
+
+// Article is an article of an issue of a periodical.
+type Article struct {
+	Title string
+	// The author of the article, optional
+	Author string
+	// The date of the article, optional
+	Date time.Time
+	// The category (section) of the article, e.g. Sports, optional
+	Category string
+	// The body of the article, which must be valid XHTML as for AddSection
+	Body string
+}
+
+// NewEpubFromIssue creates an EPUB from an issue of a periodical. The EPUB is
+// titled using the name of the periodical and the date of the issue, and
+// belongs to a collection (the periodical) whose position is the number of the
+// issue, if it's numeric.
+//
+// The EPUB starts with a generated masthead page showing the name, number and
+// date of the issue and its masthead entries. It's followed by the articles
+// without category, then each category: a page titled by the category,
+// followed by its articles as sub-sections, so that the TOC is grouped by
+// category. Each article starts with its title and a byline (the element with
+// the class "byline") containing its author and date; the author is also set
+// using SetSectionAuthor.
+//
+// If the issue doesn't have any articles, NoArticlesError will be returned.
+func NewEpubFromIssue(issue Issue) (*Epub, error) {
+	if len(issue.Articles) == 0 {
+		return nil, &NoArticlesError{Name: issue.Name}
+	}
+
+	e := NewEpub(issue.Name)
+	if issue.Lang != "" {
+		e.SetLang(issue.Lang)
+	}
+	if !issue.Date.IsZero() {
+		e.SetTitle(issue.Name + ", " + e.formatDate(issue.Date))
+	}
+	e.pkg.setCollection(issue.Name, issue.Number)
+
+	css := issue.CSS
+	cssFilename := ""
+	if css == "" {
+		css = newDataURL(mediaTypeCSS, []byte(periodicalCSSContent))
+		cssFilename = periodicalCSSFilename
+	}
+	cssPath, err := e.AddCSS(css, cssFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := e.AddSection(e.issueMastheadBody(issue), e.label(LabelMasthead, ""), mastheadFilename, cssPath); err != nil {
+		return nil, err
+	}
+
+	// Group the articles by category
+	categories := append([]string{}, issue.Categories...)
+	known := map[string]bool{"": true}
+	for _, category := range categories {
+		known[category] = true
+	}
+	articles := map[string][]Article{}
+	for _, article := range issue.Articles {
+		if !known[article.Category] {
+			known[article.Category] = true
+			categories = append(categories, article.Category)
+		}
+		articles[article.Category] = append(articles[article.Category], article)
+	}
+
+	for _, article := range articles[""] {
+		if _, err := e.addArticle("", article, cssPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, category := range categories {
+		if len(articles[category]) == 0 {
+			continue
+		}
+		categoryFilename, err := e.AddSection(fmt.Sprintf(categoryBodyTemplate, escapeText(category)), category, "", cssPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, article := range articles[category] {
+			if _, err := e.addArticle(categoryFilename, article, cssPath); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// Get the body of the masthead page of an issue
+func (e *Epub) issueMastheadBody(issue Issue) string {
+	details := ""
+	if issue.Number != "" {
+		details = e.label(LabelIssueNumber, issue.Number)
+	}
+	if !issue.Date.IsZero() {
+		if details != "" {
+			details += " — "
+		}
+		details += e.formatDate(issue.Date)
+	}
+	if details != "" {
+		details = fmt.Sprintf(mastheadIssueTemplate, escapeText(details))
+	}
+
+	staff := ""
+	for _, entry := range issue.Masthead {
+		staff += fmt.Sprintf(mastheadEntryTemplate, escapeText(entry.Role), escapeText(entry.Name))
+	}
+	if staff != "" {
+		staff = fmt.Sprintf(mastheadStaffTemplate, staff)
+	}
+
+	return fmt.Sprintf(mastheadBodyTemplate, escapeText(issue.Name), details, staff)
+}
+
+// Add an article, as a sub-section of the provided category page if any
+func (e *Epub) addArticle(categoryFilename string, article Article, cssPath string) (string, error) {
+	byline := ""
+	if article.Author != "" {
+		byline = escapeText(article.Author)
+	}
+	if !article.Date.IsZero() {
+		if byline != "" {
+			byline += ", "
+		}
+		byline += fmt.Sprintf(articleDateTemplate, article.Date.Format(isoDateFormat), escapeText(e.formatDate(article.Date)))
+	}
+	if byline != "" {
+		byline = fmt.Sprintf(articleBylineTemplate, byline)
+	}
+	body := fmt.Sprintf(articleBodyTemplate, escapeText(article.Title), byline, article.Body)
+
+	var filename string
+	var err error
+	if categoryFilename == "" {
+		filename, err = e.AddSection(body, article.Title, "", cssPath)
+	} else {
+		filename, err = e.AddSubSection(categoryFilename, body, article.Title, "", cssPath)
+	}
+	if err != nil {
+		return "", err
+	}
+	if article.Author != "" {
+		if err := e.SetSectionAuthor(filename, article.Author); err != nil {
+			return "", err
+		}
+	}
+
+	return filename, nil
+}
+
+// Set the collection the EPUB belongs to, e.g. a periodical, and the position
+// of the EPUB in it if it's numeric
+func (p *pkg) setCollection(name string, position string) {
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.ID != pkgCollectionID && m.Refines != "#"+pkgCollectionID {
+			meta = append(meta, m)
+		}
+	}
+	// An empty name removes the collection
+	if name == "" {
+		p.xml.Metadata.Meta = meta
+		return
+	}
+	meta = append(meta,
+		pkgMeta{
+			Data:     name,
+			ID:       pkgCollectionID,
+			Property: pkgCollectionProperty,
+		},
+		pkgMeta{
+			Data:     pkgCollectionType,
+			Property: pkgCollectionTypeProp,
+			Refines:  "#" + pkgCollectionID,
+		},
+	)
+	if _, err := strconv.ParseFloat(position, 64); err == nil {
+		meta = append(meta, pkgMeta{
+			Data:     position,
+			Property: pkgGroupPositionProp,
+			Refines:  "#" + pkgCollectionID,
+		})
+	}
+
+	p.xml.Metadata.Meta = meta
+}
@@ -0,0 +1,83 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewEpubFromIssue(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	e, err := NewEpubFromIssue(Issue{
+		Name:       "The Daily Gopher",
+		Number:     "42",
+		Date:       date,
+		Masthead:   []MastheadEntry{{Role: "Editor", Name: "Jane Doe"}},
+		Categories: []string{"World", "Sports"},
+		Articles: []Article{
+			{Title: "Goal!", Category: "Sports", Body: "<p>Goal</p>"},
+			{Title: "Summit", Author: "Ann Smith", Date: date, Category: "World", Body: "<p>Summit</p>"},
+			{Title: "Editorial", Body: "<p>Editorial</p>"},
+			{Title: "Recipe", Category: "Food", Body: "<p>Recipe</p>"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating EPUB from issue: %s", err)
+	}
+	if e.Title() != "The Daily Gopher, March 5, 2024" {
+		t.Errorf("Title doesn't match\nGot: %s\nExpected: %s", e.Title(), "The Daily Gopher, March 5, 2024")
+	}
+
+	titles := []string{}
+	for _, section := range e.sections {
+		titles = append(titles, section.xhtml.Title())
+	}
+	expectedTitles := "Masthead|Editorial|World|Summit|Sports|Goal!|Food|Recipe"
+	if strings.Join(titles, "|") != expectedTitles {
+		t.Errorf("Sections don't match\nGot: %s\nExpected: %s", strings.Join(titles, "|"), expectedTitles)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	masthead, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, mastheadFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading masthead page: %s", err)
+	}
+	expectedMasthead := `<h1>The Daily Gopher</h1>
+<p class="issue">No. 42 — March 5, 2024</p>
+<dl class="staff">
+<dt>Editor</dt><dd>Jane Doe</dd>
+</dl>`
+	if !strings.Contains(string(masthead), expectedMasthead) {
+		t.Errorf("Masthead page doesn't match\nGot: %s\nExpected to contain: %s", masthead, expectedMasthead)
+	}
+
+	article, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, e.sections[3].filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading article: %s", err)
+	}
+	expectedByline := `<p class="byline">Ann Smith, <time datetime="2024-03-05">March 5, 2024</time></p>`
+	if !strings.Contains(string(article), expectedByline) {
+		t.Errorf("Article doesn't match\nGot: %s\nExpected to contain: %s", article, expectedByline)
+	}
+
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<meta property="belongs-to-collection" id="collection">The Daily Gopher</meta>`,
+		`<meta refines="#collection" property="group-position">42</meta>`,
+	} {
+		if !strings.Contains(string(pkg), expected) {
+			t.Errorf("Package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+		}
+	}
+
+	if _, err := NewEpubFromIssue(Issue{Name: "Empty"}); err == nil {
+		t.Errorf("Expected error creating EPUB from issue without articles")
+	}
+}
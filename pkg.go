@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
-	"time"
+	"strings"
 )
 
 const (
@@ -29,10 +29,16 @@ const (
   </spine>
 </package>
 `
-	pkgModifiedProperty = "dcterms:modified"
-	pkgUniqueIdentifier = "pub-id"
-
-	xmlnsDc = "http://purl.org/dc/elements/1.1/"
+	pkgIdentifierIDFormat     = "identifier%d"
+	pkgIdentifierTypeProperty = "identifier-type"
+	pkgIdentifierTypeScheme   = "onix:codelist5"
+	pkgModifiedProperty       = "dcterms:modified"
+	pkgSubtitleID             = "subtitle"
+	pkgTitleTypeProperty      = "title-type"
+	pkgUniqueIdentifier       = "pub-id"
+
+	xmlnsDc  = "http://purl.org/dc/elements/1.1/"
+	xmlnsOpf = "http://www.idpf.org/2007/opf"
 )
 
 // pkg implements the package document file (package.opf), which contains
@@ -45,30 +51,62 @@ type pkg struct {
 	xml          *pkgRoot
 	authorMeta   *pkgMeta
 	modifiedMeta *pkgMeta
+	// The key is the ID of an identifier, the value is its scheme (EPUB 2 only)
+	identifierSchemes map[string]string
+	// Identifiers of related works (EPUB 2 only)
+	epub2Sources   []string
+	epub2Relations []string
 }
 
 // This holds the actual XML for the package file
 type pkgRoot struct {
-	XMLName          xml.Name    `xml:"http://www.idpf.org/2007/opf package"`
-	UniqueIdentifier string      `xml:"unique-identifier,attr"`
-	Version          string      `xml:"version,attr"`
-	Metadata         pkgMetadata `xml:"metadata"`
-	ManifestItems    []pkgItem   `xml:"manifest>item"`
-	Spine            pkgSpine    `xml:"spine"`
+	XMLName          xml.Name `xml:"http://www.idpf.org/2007/opf package"`
+	UniqueIdentifier string   `xml:"unique-identifier,attr"`
+	Version          string   `xml:"version,attr"`
+	// Ex: prefix="ibooks: http://vocabulary.itunes.apple.com/rdf/ibooks/vocabulary-extensions-1.0/"
+	Prefix        string      `xml:"prefix,attr,omitempty"`
+	Metadata      pkgMetadata `xml:"metadata"`
+	ManifestItems []pkgItem   `xml:"manifest>item"`
+	Spine         pkgSpine    `xml:"spine"`
+	// The landmarks of the EPUB, nil if there are none
+	Guide *pkgGuide `xml:"guide,omitempty"`
 }
 
 // <dc:creator>, e.g. the author
 type pkgCreator struct {
 	XMLName xml.Name `xml:"dc:creator"`
 	ID      string   `xml:"id,attr"`
+	// Only used by EPUB 2, which doesn't support refines
+	Role string `xml:"opf:role,attr,omitempty"`
+	Data string `xml:",chardata"`
+}
+
+// The second <dc:title>, refined as a subtitle
+// Ex: <dc:title id="subtitle">A novel</dc:title>
+type pkgSubtitle struct {
+	XMLName xml.Name `xml:"dc:title"`
+	ID      string   `xml:"id,attr"`
 	Data    string   `xml:",chardata"`
 }
 
+// <dc:contributor>, e.g. an illustrator
+type pkgContributor struct {
+	XMLName xml.Name `xml:"dc:contributor"`
+	ID      string   `xml:"id,attr"`
+	// Only used by EPUB 2, which doesn't support refines
+	Role string `xml:"opf:role,attr,omitempty"`
+	Data string `xml:",chardata"`
+	// The MARC relator code of the role
+	role string
+}
+
 // <dc:identifier>, where the unique identifier is stored
 // Ex: <dc:identifier id="pub-id">urn:uuid:fe93046f-af57-475a-a0cb-a0d4bc99ba6d</dc:identifier>
 type pkgIdentifier struct {
-	ID   string `xml:"id,attr"`
-	Data string `xml:",chardata"`
+	ID string `xml:"id,attr"`
+	// Only used by EPUB 2, which doesn't support refines
+	Scheme string `xml:"opf:scheme,attr,omitempty"`
+	Data   string `xml:",chardata"`
 }
 
 // <item> elements, one per each file stored in the EPUB
@@ -76,41 +114,89 @@ type pkgIdentifier struct {
 //     <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml" />
 //     <item id="section0001.xhtml" href="xhtml/section0001.xhtml" media-type="application/xhtml+xml" />
 type pkgItem struct {
-	ID         string `xml:"id,attr"`
-	Href       string `xml:"href,attr"`
-	MediaType  string `xml:"media-type,attr"`
-	Properties string `xml:"properties,attr,omitempty"`
+	ID           string `xml:"id,attr"`
+	Href         string `xml:"href,attr"`
+	MediaType    string `xml:"media-type,attr"`
+	Properties   string `xml:"properties,attr,omitempty"`
+	MediaOverlay string `xml:"media-overlay,attr,omitempty"`
+	Fallback     string `xml:"fallback,attr,omitempty"`
 }
 
 // <itemref> elements, which define the reading order
 // Ex: <itemref idref="section0001.xhtml" />
+//     <itemref idref="section0002.xhtml" properties="page-spread-left" />
+//     <itemref idref="section0003.xhtml" linear="no" />
 type pkgItemref struct {
-	Idref string `xml:"idref,attr"`
+	Idref      string `xml:"idref,attr"`
+	Linear     string `xml:"linear,attr,omitempty"`
+	Properties string `xml:"properties,attr,omitempty"`
 }
 
 // The <meta> element, which contains modified date, role of the creator (e.g.
 // author), etc
 // Ex: <meta refines="#creator" property="role" scheme="marc:relators" id="role">aut</meta>
 //     <meta property="dcterms:modified">2011-01-01T12:00:00Z</meta>
+//     <meta name="cover" content="cover.png" /> (EPUB 2, also used by EPUB 3
+//     reading systems that don't support the cover-image property)
 type pkgMeta struct {
-	Refines  string `xml:"refines,attr,omitempty"`
-	Property string `xml:"property,attr"`
-	Scheme   string `xml:"scheme,attr,omitempty"`
-	ID       string `xml:"id,attr,omitempty"`
-	Data     string `xml:",chardata"`
+	Refines  string `xml:"refines,attr,omitempty" json:"refines,omitempty"`
+	Property string `xml:"property,attr,omitempty" json:"property,omitempty"`
+	Scheme   string `xml:"scheme,attr,omitempty" json:"scheme,omitempty"`
+	ID       string `xml:"id,attr,omitempty" json:"id,omitempty"`
+	Name     string `xml:"name,attr,omitempty" json:"name,omitempty"`
+	Content  string `xml:"content,attr,omitempty" json:"content,omitempty"`
+	Data     string `xml:",chardata" json:"data,omitempty"`
 }
 
 // The <metadata> element
 type pkgMetadata struct {
-	XmlnsDc    string        `xml:"xmlns:dc,attr"`
-	Identifier pkgIdentifier `xml:"dc:identifier"`
+	XmlnsDc  string `xml:"xmlns:dc,attr"`
+	XmlnsOpf string `xml:"xmlns:opf,attr,omitempty"`
+	// The first identifier is the unique identifier
+	Identifier []pkgIdentifier `xml:"dc:identifier"`
 	// Ex: <dc:title>Your title here</dc:title>
 	Title string `xml:"dc:title"`
+	// A slice so that it doesn't conflict with Title, but there's at most one
+	Subtitle []pkgSubtitle
 	// Ex: <dc:language>en</dc:language>
 	Language    string `xml:"dc:language"`
 	Description string `xml:"dc:description,omitempty"`
-	Creator     *pkgCreator
-	Meta        []pkgMeta `xml:"meta"`
+	// Ex: <dc:publisher>Gopher Press</dc:publisher>
+	Publisher string `xml:"dc:publisher,omitempty"`
+	// Ex: <dc:date>2024-01-15</dc:date>
+	Date    string `xml:"dc:date,omitempty"`
+	Creator *pkgCreator
+	// The other authors, added using AddAuthor, and the authors of sections,
+	// set using SetSectionAuthor
+	SectionCreator []pkgCreator
+	Contributor    []pkgContributor
+	// Ex: <dc:rights>All rights reserved</dc:rights>
+	Rights string `xml:"dc:rights,omitempty"`
+	// Only used by EPUB 2, which doesn't support relation types
+	Source   []string  `xml:"dc:source"`
+	Relation []string  `xml:"dc:relation"`
+	Meta     []pkgMeta `xml:"meta"`
+	Link     []pkgLink `xml:"link"`
+}
+
+// The <link> element of the metadata, which links to a resource related to the
+// EPUB (EPUB 3 only)
+// Ex: <link rel="cc:license" href="https://creativecommons.org/licenses/by/4.0/" />
+type pkgLink struct {
+	Rel  string `xml:"rel,attr" json:"rel"`
+	Href string `xml:"href,attr" json:"href"`
+}
+
+// The <guide> element, which lists the landmarks of the EPUB
+type pkgGuide struct {
+	References []pkgReference `xml:"reference"`
+}
+
+// Ex: <reference type="text" title="Start" href="xhtml/chapter1.xhtml" />
+type pkgReference struct {
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr"`
+	Href  string `xml:"href,attr"`
 }
 
 // The <spine> element
@@ -126,8 +212,10 @@ func newPackage() *pkg {
 		xml: &pkgRoot{
 			Metadata: pkgMetadata{
 				XmlnsDc: xmlnsDc,
-				Identifier: pkgIdentifier{
-					ID: pkgUniqueIdentifier,
+				Identifier: []pkgIdentifier{
+					{
+						ID: pkgUniqueIdentifier,
+					},
 				},
 			},
 		},
@@ -147,25 +235,46 @@ func newPackage() *pkg {
 	return p
 }
 
-func (p *pkg) addToManifest(id string, href string, mediaType string, properties string) {
+func (p *pkg) addToManifest(id string, href string, mediaType string, properties string, mediaOverlay string, fallback string) {
 	href = filepath.ToSlash(href)
 	i := &pkgItem{
-		ID:         id,
-		Href:       href,
-		MediaType:  mediaType,
-		Properties: properties,
+		ID:           id,
+		Href:         href,
+		MediaType:    mediaType,
+		Properties:   properties,
+		MediaOverlay: mediaOverlay,
+		Fallback:     fallback,
 	}
 	p.xml.ManifestItems = append(p.xml.ManifestItems, *i)
 }
 
-func (p *pkg) addToSpine(id string) {
+func (p *pkg) addToSpine(id string, properties string, linear bool) {
 	i := &pkgItemref{
-		Idref: id,
+		Idref:      id,
+		Properties: properties,
+	}
+	if !linear {
+		i.Linear = "no"
 	}
 
 	p.xml.Spine.Items = append(p.xml.Spine.Items, *i)
 }
 
+// Remove all items from the manifest and spine
+func (p *pkg) resetManifestAndSpine() {
+	p.xml.ManifestItems = nil
+	p.xml.Spine.Items = nil
+}
+
+// Set the references of the guide, removing the guide if there are none
+func (p *pkg) setGuide(references []pkgReference) {
+	if len(references) == 0 {
+		p.xml.Guide = nil
+		return
+	}
+	p.xml.Guide = &pkgGuide{References: references}
+}
+
 func (p *pkg) setAuthor(author string) {
 	p.xml.Metadata.Creator = &pkgCreator{
 		Data: author,
@@ -183,7 +292,7 @@ func (p *pkg) setAuthor(author string) {
 }
 
 func (p *pkg) setIdentifier(identifier string) {
-	p.xml.Metadata.Identifier.Data = identifier
+	p.xml.Metadata.Identifier[0].Data = identifier
 }
 
 func (p *pkg) setLang(lang string) {
@@ -194,6 +303,78 @@ func (p *pkg) setDescription(desc string) {
 	p.xml.Metadata.Description = desc
 }
 
+func (p *pkg) setPublisher(publisher string) {
+	p.xml.Metadata.Publisher = publisher
+}
+
+func (p *pkg) setDate(date string) {
+	p.xml.Metadata.Date = date
+}
+
+// Set the identifiers other than the unique identifier. The schemes of the
+// identifiers, including the unique identifier if it's one of them, are set as
+// identifier-type refinements using ONIX codes, or as opf:scheme attributes for
+// EPUB 2.
+func (p *pkg) setOtherIdentifiers(identifiers []Identifier) {
+	unique := p.xml.Metadata.Identifier[0]
+	p.xml.Metadata.Identifier = []pkgIdentifier{unique}
+	p.identifierSchemes = map[string]string{}
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Property != pkgIdentifierTypeProperty {
+			meta = append(meta, m)
+		}
+	}
+
+	for i, identifier := range identifiers {
+		id := unique.ID
+		if identifier.Value != unique.Data {
+			id = fmt.Sprintf(pkgIdentifierIDFormat, i+1)
+			p.xml.Metadata.Identifier = append(p.xml.Metadata.Identifier, pkgIdentifier{
+				ID:   id,
+				Data: identifier.Value,
+			})
+		}
+		if identifier.Scheme == "" {
+			continue
+		}
+		p.identifierSchemes[id] = string(identifier.Scheme)
+		if code := identifier.onixCode(); code != "" {
+			meta = append(meta, pkgMeta{
+				Data:     code,
+				Property: pkgIdentifierTypeProperty,
+				Refines:  "#" + id,
+				Scheme:   pkgIdentifierTypeScheme,
+			})
+		}
+	}
+
+	p.xml.Metadata.Meta = meta
+}
+
+// Set a <link> element identified by its rel attribute. An empty href removes
+// the element.
+func (p *pkg) setLink(rel string, href string) {
+	links := []pkgLink{}
+	for _, l := range p.xml.Metadata.Link {
+		if l.Rel != rel {
+			links = append(links, l)
+		}
+	}
+	if href != "" {
+		links = append(links, pkgLink{
+			Rel:  rel,
+			Href: href,
+		})
+	}
+
+	p.xml.Metadata.Link = links
+}
+
+func (p *pkg) setRights(rights string) {
+	p.xml.Metadata.Rights = rights
+}
+
 func (p *pkg) setPpd(direction string) {
 	p.xml.Spine.Ppd = direction
 }
@@ -207,10 +388,80 @@ func (p *pkg) setModified(timestamp string) {
 	p.xml.Metadata.Meta = updateMeta(p.xml.Metadata.Meta, p.modifiedMeta)
 }
 
+// Declare a metadata vocabulary prefix, which is needed to use properties
+// (e.g. ibooks:specified-fonts) that aren't part of the reserved vocabularies
+func (p *pkg) addPrefix(prefix string, uri string) {
+	declaration := prefix + ": " + uri
+	for _, existing := range strings.Split(p.xml.Prefix, " ") {
+		if existing == prefix+":" {
+			return
+		}
+	}
+	if p.xml.Prefix != "" {
+		declaration = p.xml.Prefix + " " + declaration
+	}
+	p.xml.Prefix = declaration
+}
+
+// Set a <meta> element identified by its property, e.g. rendition:layout. An
+// empty value removes the element.
+func (p *pkg) setPropertyMeta(property string, value string) {
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Property != property || m.Refines != "" {
+			meta = append(meta, m)
+		}
+	}
+	if value != "" {
+		meta = append(meta, pkgMeta{
+			Data:     value,
+			Property: property,
+		})
+	}
+
+	p.xml.Metadata.Meta = meta
+}
+
+// Set the <meta> elements with a property, one per value, e.g.
+// schema:accessMode. Empty values remove the elements.
+func (p *pkg) setPropertyMetas(property string, values []string) {
+	p.setPropertyMeta(property, "")
+	for _, value := range values {
+		p.xml.Metadata.Meta = append(p.xml.Metadata.Meta, pkgMeta{
+			Data:     value,
+			Property: property,
+		})
+	}
+}
+
 func (p *pkg) setTitle(title string) {
 	p.xml.Metadata.Title = title
 }
 
+// Set the subtitle, refined with its title type. An empty subtitle removes it.
+func (p *pkg) setSubtitle(subtitle string) {
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Refines != "#"+pkgSubtitleID {
+			meta = append(meta, m)
+		}
+	}
+	p.xml.Metadata.Subtitle = nil
+	if subtitle != "" {
+		p.xml.Metadata.Subtitle = []pkgSubtitle{{
+			ID:   pkgSubtitleID,
+			Data: subtitle,
+		}}
+		meta = append(meta, pkgMeta{
+			Refines:  "#" + pkgSubtitleID,
+			Property: pkgTitleTypeProperty,
+			Data:     pkgSubtitleID,
+		})
+	}
+
+	p.xml.Metadata.Meta = meta
+}
+
 // Update the <meta> element
 func updateMeta(a []pkgMeta, m *pkgMeta) []pkgMeta {
 	indexToReplace := -1
@@ -239,20 +490,85 @@ func updateMeta(a []pkgMeta, m *pkgMeta) []pkgMeta {
 	return a
 }
 
-// Write the package file to the temporary directory
-func (p *pkg) write(tempDir string) {
-	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
-	p.setModified(now)
+// Write the package file to the temporary directory. The cover image is also
+// identified using the EPUB 2 meta element, as many reading systems only look
+// for it.
+func (p *pkg) write(tempDir string, coverImageID string) {
+	if coverImageID == "" {
+		p.writeXML(tempDir, p.xml)
+		return
+	}
+	root := *p.xml
+	root.Metadata.Meta = append(append([]pkgMeta{}, p.xml.Metadata.Meta...), coverMeta(coverImageID))
+	p.writeXML(tempDir, &root)
+}
+
+// Get the EPUB 2 meta element identifying the cover image
+func coverMeta(coverImageID string) pkgMeta {
+	return pkgMeta{
+		Name:    "cover",
+		Content: coverImageID,
+	}
+}
+
+// Write the package file to the temporary directory using the EPUB 2 (OPF 2.0)
+// grammar. The package is converted on the fly so that the same EPUB can still
+// be written as EPUB 3 afterwards.
+func (p *pkg) writeEPUB2(tempDir string, coverImageID string) {
+	root := *p.xml
+	root.Version = EPUBVersion2
+	root.Metadata.XmlnsOpf = xmlnsOpf
+	root.Prefix = ""
+	root.Spine.Ppd = ""
+	root.Metadata.Link = nil
+	root.Metadata.Source = p.epub2Sources
+	root.Metadata.Relation = p.epub2Relations
+	root.Metadata.Identifier = []pkgIdentifier{}
+	for _, identifier := range p.xml.Metadata.Identifier {
+		identifier.Scheme = p.identifierSchemes[identifier.ID]
+		root.Metadata.Identifier = append(root.Metadata.Identifier, identifier)
+	}
+
+	// EPUB 2 doesn't support property meta elements
+	root.Metadata.Meta = []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Property == "" {
+			root.Metadata.Meta = append(root.Metadata.Meta, m)
+		}
+	}
+	if p.xml.Metadata.Creator != nil {
+		creator := *p.xml.Metadata.Creator
+		creator.Role = pkgAuthorData
+		root.Metadata.Creator = &creator
+	}
+	root.Metadata.SectionCreator = []pkgCreator{}
+	for _, creator := range p.xml.Metadata.SectionCreator {
+		creator.Role = pkgAuthorData
+		root.Metadata.SectionCreator = append(root.Metadata.SectionCreator, creator)
+	}
+	root.Metadata.Contributor = []pkgContributor{}
+	for _, contributor := range p.xml.Metadata.Contributor {
+		contributor.Role = contributor.role
+		root.Metadata.Contributor = append(root.Metadata.Contributor, contributor)
+	}
+	if coverImageID != "" {
+		root.Metadata.Meta = append(root.Metadata.Meta, coverMeta(coverImageID))
+	}
+
+	p.writeXML(tempDir, &root)
+}
 
+// Marshal the package file XML and write it to the temporary directory
+func (p *pkg) writeXML(tempDir string, root *pkgRoot) {
 	pkgFilePath := filepath.Join(tempDir, contentFolderName, pkgFilename)
 
-	output, err := xml.MarshalIndent(p.xml, "", "  ")
+	output, err := xml.MarshalIndent(root, "", "  ")
 	if err != nil {
 		panic(fmt.Sprintf(
 			"Error marshalling XML for package file: %s\n"+
 				"\tXML=%#v",
 			err,
-			p.xml))
+			root))
 	}
 	// Add the xml header to the output
 	pkgFileContent := append([]byte(xml.Header), output...)
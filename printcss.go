@@ -0,0 +1,310 @@
+package epub
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Absolute lengths, e.g. 12pt, which don't scale with the font size chosen
+	// by the reader
+	cssAbsoluteLengthRegexp = regexp.MustCompile(`(?i)(^|[\s,(/])(-?(?:\d+\.?\d*|\.\d+))(pt|pc|in|cm|mm|px)\b`)
+	// Selectors of elements that can keep their floats and fixed sizes
+	cssMediaSelectorRegexp = regexp.MustCompile(`(?i)\b(img|svg|figure|picture|video)\b`)
+)
+
+// Points per absolute length unit
+var cssPointsPerUnit = map[string]float64{
+	"pt": 1,
+	"pc": 12,
+	"in": 72,
+	"cm": 72 / 2.54,
+	"mm": 72 / 25.4,
+	"px": 0.75,
+}
+
+// Properties whose absolute lengths are converted to em, based on a 12pt font
+// size
+var cssRelativeLengthProperties = map[string]bool{
+	"font-size":      true,
+	"letter-spacing": true,
+	"line-height":    true,
+	"margin":         true,
+	"margin-bottom":  true,
+	"margin-left":    true,
+	"margin-right":   true,
+	"margin-top":     true,
+	"padding":        true,
+	"padding-bottom": true,
+	"padding-left":   true,
+	"padding-right":  true,
+	"padding-top":    true,
+	"text-indent":    true,
+	"word-spacing":   true,
+}
+
+// Properties removed from positioned elements along with the position
+var cssOffsetProperties = map[string]bool{
+	"bottom":  true,
+	"left":    true,
+	"right":   true,
+	"top":     true,
+	"z-index": true,
+}
+
+// CSSWarning describes a print-oriented CSS declaration removed by
+// ConvertPrintCSS, which may change the rendering of the content.
+type CSSWarning struct {
+	// Filename of the CSS file, empty if ConvertPrintCSS was called directly
+	Filename string
+	// Selector of the rule containing the declaration, e.g. .sidebar
+	Selector string
+	// The declaration as it was, e.g. position: absolute
+	Declaration string
+	// Why it was removed
+	Message string
+}
+
+func (w CSSWarning) String() string {
+	return fmt.Sprintf("%s: %s { %s }: %s", w.Filename, w.Selector, w.Declaration, w.Message)
+}
+
+// ConvertPrintCSS rewrites print-oriented CSS, e.g. exported from InDesign,
+// into CSS suited to reflowable EPUBs. The absolute lengths (pt, pc, in, cm,
+// mm, px) of font sizes, margins, paddings, indents, and spacings are
+// converted to em, assuming a 12pt font size, so that they scale with the
+// font size chosen by the reader. The following declarations are removed,
+// each with a warning:
+//
+//   - absolute and fixed positioning, along with the offsets of the element
+//   - floats, except for images and figures
+//   - fixed widths and heights, except for images and figures
+//   - multiple columns
+//   - the page size, marks, and bleed of @page rules
+//
+// The rules of @media and @supports blocks are converted as well; other
+// at-rules, e.g. @font-face, are left as is.
+func ConvertPrintCSS(css string) (string, []CSSWarning) {
+	c := &printCSSConverter{}
+	return c.convertBlocks(css), c.warnings
+}
+
+// SetConvertPrintCSS sets whether the CSS files added using AddCSS are
+// converted by ConvertPrintCSS when the EPUB is written. The warnings are
+// reported by LastBuildReport, and the converted files have the transform
+// ResourceTransformReflowed.
+func (e *Epub) SetConvertPrintCSS(convert bool) {
+	e.convertPrintCSS = convert
+}
+
+type printCSSConverter struct {
+	warnings []CSSWarning
+}
+
+// Convert the rules of a stylesheet or of the block of a conditional at-rule
+func (c *printCSSConverter) convertBlocks(css string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(css); i++ {
+		switch {
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end == -1 {
+				i = len(css)
+			} else {
+				i += end + 3
+			}
+		case css[i] == '"' || css[i] == '\'':
+			i = cssStringEnd(css, i)
+		case css[i] == ';':
+			// The end of an at-rule without a block, e.g. @import
+			b.WriteString(css[start : i+1])
+			start = i + 1
+		case css[i] == '{':
+			prelude := css[start:i]
+			end := cssBlockEnd(css, i)
+			b.WriteString(prelude)
+			b.WriteString("{")
+			b.WriteString(c.convertRule(strings.TrimSpace(prelude), css[i+1:end]))
+			if end < len(css) {
+				b.WriteString("}")
+			}
+			i = end
+			start = end + 1
+		}
+	}
+	if start < len(css) {
+		b.WriteString(css[start:])
+	}
+
+	return b.String()
+}
+
+// Convert the block of a rule
+func (c *printCSSConverter) convertRule(prelude string, block string) string {
+	lower := strings.ToLower(prelude)
+	switch {
+	case strings.HasPrefix(lower, "@media"), strings.HasPrefix(lower, "@supports"):
+		return c.convertBlocks(block)
+	case strings.HasPrefix(lower, "@page"):
+		return c.convertDeclarations(prelude, block, true)
+	case strings.HasPrefix(lower, "@"):
+		return block
+	}
+
+	return c.convertDeclarations(prelude, block, false)
+}
+
+// Convert the declarations of a rule, keeping their formatting
+func (c *printCSSConverter) convertDeclarations(selector string, block string, page bool) string {
+	declarations := splitCSSDeclarations(block)
+	media := cssMediaSelectorRegexp.MatchString(selector)
+
+	positioned := false
+	for _, d := range declarations {
+		prop, value := parseCSSDeclaration(d)
+		if prop == "position" && (value == "absolute" || value == "fixed") {
+			positioned = true
+		}
+	}
+
+	kept := []string{}
+	for _, d := range declarations {
+		prop, value := parseCSSDeclaration(d)
+		message := ""
+		switch {
+		case prop == "":
+		case page && (prop == "size" || prop == "marks" || prop == "bleed"):
+			message = "the page size is set by the reading system"
+		case prop == "position" && positioned:
+			message = "positioned elements overlap the content once it's reflowed"
+		case positioned && cssOffsetProperties[prop]:
+			// Removed along with the position
+			continue
+		case prop == "float" && (value == "left" || value == "right") && !media:
+			message = "floating text blocks break reflowable layouts"
+		case (prop == "width" || prop == "height" || prop == "min-width" || prop == "min-height") && !media && cssAbsoluteLengthRegexp.MatchString(value):
+			message = "fixed sizes don't fit every screen"
+		case prop == "columns" || prop == "column-count" || prop == "column-width":
+			message = "multiple columns don't fit small screens"
+		case cssRelativeLengthProperties[prop]:
+			colon := strings.Index(d, ":")
+			d = d[:colon+1] + convertCSSLengths(d[colon+1:])
+		}
+		if message != "" {
+			c.warnings = append(c.warnings, CSSWarning{
+				Selector:    selector,
+				Declaration: strings.TrimSpace(d),
+				Message:     message,
+			})
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	result := strings.Join(kept, ";")
+	// Keep the whitespace before the end of the block if the last
+	// declaration was removed
+	if len(kept) < len(declarations) && strings.TrimSpace(result) == "" {
+		return "\n"
+	}
+	return result
+}
+
+// Split the declarations of a block on the semicolons that aren't inside
+// parentheses or quotes, e.g. in data URLs
+func splitCSSDeclarations(block string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(block); i++ {
+		switch c := block[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			parts = append(parts, block[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, block[start:])
+}
+
+// Get the lowercase property and value of a declaration, without !important
+func parseCSSDeclaration(declaration string) (string, string) {
+	colon := strings.Index(declaration, ":")
+	if colon == -1 {
+		return "", ""
+	}
+	prop := strings.ToLower(strings.TrimSpace(declaration[:colon]))
+	value := strings.ToLower(strings.TrimSpace(declaration[colon+1:]))
+	value = strings.TrimSpace(strings.TrimSuffix(value, "!important"))
+
+	return prop, value
+}
+
+// Convert the absolute lengths of a value to em, assuming a 12pt font size
+func convertCSSLengths(value string) string {
+	return cssAbsoluteLengthRegexp.ReplaceAllStringFunc(value, func(s string) string {
+		m := cssAbsoluteLengthRegexp.FindStringSubmatch(s)
+		n, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return s
+		}
+		em := math.Round(n*cssPointsPerUnit[strings.ToLower(m[3])]/12*1000) / 1000
+		if em == 0 {
+			return m[1] + "0"
+		}
+		return m[1] + strconv.FormatFloat(em, 'f', -1, 64) + "em"
+	})
+}
+
+// Get the index of the closing quote of a CSS string starting at i
+func cssStringEnd(css string, i int) int {
+	quote := css[i]
+	for i++; i < len(css); i++ {
+		if css[i] == '\\' {
+			i++
+		} else if css[i] == quote {
+			break
+		}
+	}
+	return i
+}
+
+// Get the index of the closing brace of a block starting at i, or the length
+// of the CSS if it isn't closed
+func cssBlockEnd(css string, i int) int {
+	depth := 0
+	for ; i < len(css); i++ {
+		switch {
+		case strings.HasPrefix(css[i:], "/*"):
+			end := strings.Index(css[i+2:], "*/")
+			if end == -1 {
+				return len(css)
+			}
+			i += end + 3
+		case css[i] == '"' || css[i] == '\'':
+			i = cssStringEnd(css, i)
+		case css[i] == '{':
+			depth++
+		case css[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
@@ -0,0 +1,110 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testPrintCSS = `@page {
+  size: A5;
+  margin: 1em;
+}
+p {
+  font-size: 10.5pt;
+  margin: 0 0 6pt;
+  text-indent: 12pt !important;
+}
+.sidebar {
+  position: absolute;
+  top: 2in;
+  float: right;
+  width: 200pt;
+  background: url("data:image/png;base64,AAAA");
+}
+img.left {
+  float: left;
+  width: 120px;
+}
+@media screen {
+  .cols { column-count: 2; color: red }
+}
+@font-face {
+  font-family: "Serif";
+  src: url(serif.ttf);
+}
+`
+	testReflowedCSS = `@page {
+  margin: 1em;
+}
+p {
+  font-size: 0.875em;
+  margin: 0 0 0.5em;
+  text-indent: 1em !important;
+}
+.sidebar {
+  background: url("data:image/png;base64,AAAA");
+}
+img.left {
+  float: left;
+  width: 120px;
+}
+@media screen {
+  .cols { color: red }
+}
+@font-face {
+  font-family: "Serif";
+  src: url(serif.ttf);
+}
+`
+)
+
+func TestConvertPrintCSS(t *testing.T) {
+	css, warnings := ConvertPrintCSS(testPrintCSS)
+	if css != testReflowedCSS {
+		t.Errorf("Converted CSS doesn't match\nGot: %s\nExpected: %s", css, testReflowedCSS)
+	}
+
+	expected := []string{"size: A5", "position: absolute", "float: right", "width: 200pt", "column-count: 2"}
+	if len(warnings) != len(expected) {
+		t.Fatalf("Warnings don't match\nGot: %+v\nExpected: %d warnings", warnings, len(expected))
+	}
+	for i, w := range warnings {
+		if w.Declaration != expected[i] {
+			t.Errorf("Warning declaration doesn't match\nGot: %s\nExpected: %s", w.Declaration, expected[i])
+		}
+	}
+	if warnings[1].Selector != ".sidebar" {
+		t.Errorf("Warning selector doesn't match\nGot: %s\nExpected: .sidebar", warnings[1].Selector)
+	}
+}
+
+func TestSetConvertPrintCSS(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetConvertPrintCSS(true)
+	if _, err := e.AddCSS(newDataURL(mediaTypeCSS, []byte(testPrintCSS)), "print.css"); err != nil {
+		t.Fatalf("Unexpected error adding CSS: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, "print.css"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading CSS file: %s", err)
+	}
+	if string(contents) != testReflowedCSS {
+		t.Errorf("CSS file doesn't match\nGot: %s\nExpected: %s", contents, testReflowedCSS)
+	}
+
+	report := e.LastBuildReport()
+	if len(report.CSSWarnings) != 5 || report.CSSWarnings[0].Filename != "print.css" {
+		t.Errorf("Build report warnings don't match\nGot: %+v", report.CSSWarnings)
+	}
+	for _, r := range report.Resources {
+		if r.Path == "EPUB/css/print.css" && (len(r.Transforms) != 1 || r.Transforms[0] != ResourceTransformReflowed) {
+			t.Errorf("CSS file transforms don't match\nGot: %v\nExpected: [%s]", r.Transforms, ResourceTransformReflowed)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package epub
+
+// RelationType is the type of relation between the EPUB and a related work,
+// expressed as a DCMI Metadata Terms property.
+type RelationType string
+
+// Relation types
+const (
+	// A related work, when no more specific type applies
+	RelationRelation RelationType = "dcterms:relation"
+	// A work the EPUB is derived from, e.g. the original of a translation
+	RelationSource RelationType = "dcterms:source"
+	// A work the EPUB is a version of, e.g. the unabridged edition of an
+	// abridged edition or the original of an adaptation
+	RelationIsVersionOf RelationType = "dcterms:isVersionOf"
+	// A version of the EPUB, e.g. its abridged edition
+	RelationHasVersion RelationType = "dcterms:hasVersion"
+	// The same work in another format, e.g. the print edition
+	RelationIsFormatOf RelationType = "dcterms:isFormatOf"
+	// The same work in another format derived from the EPUB
+	RelationHasFormat RelationType = "dcterms:hasFormat"
+)
+
+// Relation is a relation between the EPUB and a related work.
+type Relation struct {
+	Type RelationType `json:"type"`
+	// The identifier of the related work, e.g. urn:isbn:9780000000002
+	Identifier string `json:"identifier"`
+}
+
+// AddRelation adds a relation between the EPUB and a related work, identified
+// by its identifier (e.g. an ISBN URN or a URL), such as the original of a
+// translation (RelationSource), the unabridged edition (RelationIsVersionOf)
+// or the print edition (RelationIsFormatOf).
+//
+// Relations are written as dcterms metadata. EPUB 2 doesn't support their
+// types, so they're written as dc:source elements for RelationSource and
+// dc:relation elements otherwise.
+func (e *Epub) AddRelation(relType RelationType, identifier string) {
+	e.relations = append(e.relations, Relation{
+		Type:       relType,
+		Identifier: identifier,
+	})
+	e.pkg.addRelation(relType, identifier)
+}
+
+// Relations returns the relations added using AddRelation.
+func (e *Epub) Relations() []Relation {
+	return append([]Relation{}, e.relations...)
+}
+
+// Add a relation as a <meta> element, or as a dc:source or dc:relation element
+// for EPUB 2
+func (p *pkg) addRelation(relType RelationType, identifier string) {
+	p.xml.Metadata.Meta = append(p.xml.Metadata.Meta, pkgMeta{
+		Data:     identifier,
+		Property: string(relType),
+	})
+	if relType == RelationSource {
+		p.epub2Sources = append(p.epub2Sources, identifier)
+	} else {
+		p.epub2Relations = append(p.epub2Relations, identifier)
+	}
+}
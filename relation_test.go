@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddRelation(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddRelation(RelationSource, "urn:isbn:9780000000002")
+	e.AddRelation(RelationIsFormatOf, "urn:isbn:9780000000019")
+	e.AddRelation(RelationIsVersionOf, "https://example.com/unabridged")
+
+	if len(e.Relations()) != 3 || e.Relations()[1].Type != RelationIsFormatOf {
+		t.Errorf("Relations don't match\nGot: %+v", e.Relations())
+	}
+
+	tests := []struct {
+		version      string
+		testMetadata []string
+	}{
+		{
+			version: EPUBVersion3,
+			testMetadata: []string{
+				`<meta property="dcterms:source">urn:isbn:9780000000002</meta>`,
+				`<meta property="dcterms:isFormatOf">urn:isbn:9780000000019</meta>`,
+				`<meta property="dcterms:isVersionOf">https://example.com/unabridged</meta>`,
+			},
+		},
+		{
+			version: EPUBVersion2,
+			testMetadata: []string{
+				`<dc:source>urn:isbn:9780000000002</dc:source>`,
+				`<dc:relation>urn:isbn:9780000000019</dc:relation>`,
+				`<dc:relation>https://example.com/unabridged</dc:relation>`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		e.SetVersion(test.version)
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+		if err != nil {
+			t.Errorf("Unexpected error reading package file: %s", err)
+		}
+		for _, testMetadata := range test.testMetadata {
+			if !strings.Contains(string(contents), testMetadata) {
+				t.Errorf(
+					"Package file metadata doesn't match\n"+
+						"Got: %s\n"+
+						"Expected to contain: %s",
+					contents,
+					testMetadata)
+			}
+		}
+		if test.version == EPUBVersion3 && strings.Contains(string(contents), "<dc:relation>") {
+			t.Errorf("Unexpected dc:relation element in EPUB 3\nGot: %s", contents)
+		}
+
+		cleanup(testEpubFilename, tempDir)
+	}
+}
@@ -0,0 +1,190 @@
+package epub
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// FallbackInUseError is thrown by RemoveSection if the section is the fallback
+// of a foreign resource, as foreign resources must have one.
+type FallbackInUseError struct {
+	Filename string // Filename of the section
+	Foreign  string // Internal filename of the foreign resource
+}
+
+func (e *FallbackInUseError) Error() string {
+	return fmt.Sprintf("Section %s is the fallback of foreign resource %s", e.Filename, e.Foreign)
+}
+
+// RemoveSection removes a section from the EPUB, e.g. to drop a chapter of an
+// EPUB assembled incrementally. The manifest, spine and table of contents are
+// built when the EPUB is written, so they won't reference the section. Its
+// sub-sections are moved up a level, and its notes, page breaks, media overlay,
+// table of contents entries (see AddTOCEntry), landmarks and the aliases set
+// for it are removed as well. If the section is the cover page, the cover is
+// removed but the cover image is kept.
+//
+// Links to the section from other sections aren't updated.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned. If the section is the fallback of a
+// foreign resource (see AddForeignResource), FallbackInUseError will be
+// returned and the section is kept.
+func (e *Epub) RemoveSection(filename string) error {
+	i := e.sectionIndex(filename)
+	if i == -1 {
+		return &FilenameNotFoundError{Filename: filename}
+	}
+	foreignFilenames := make([]string, 0, len(e.foreign))
+	for foreignFilename := range e.foreign {
+		foreignFilenames = append(foreignFilenames, foreignFilename)
+	}
+	sort.Strings(foreignFilenames)
+	for _, foreignFilename := range foreignFilenames {
+		if e.foreign[foreignFilename].fallback == filename {
+			return &FallbackInUseError{Filename: filename, Foreign: foreignFilename}
+		}
+	}
+
+	removed := e.sections[i]
+	e.sections = append(e.sections[:i], e.sections[i+1:]...)
+	for i := range e.sections {
+		if e.sections[i].parent == filename {
+			e.sections[i].parent = removed.parent
+		}
+	}
+
+	if filename == e.cover.xhtmlFilename {
+		e.removeCover()
+	}
+	footnotes := []epubFootnote{}
+	for _, note := range e.footnotes {
+		if note.filename != filename {
+			footnotes = append(footnotes, note)
+		}
+	}
+	e.footnotes = footnotes
+	pageBreaks := []epubPageBreak{}
+	for _, pageBreak := range e.pageBreaks {
+		if pageBreak.filename != filename {
+			pageBreaks = append(pageBreaks, pageBreak)
+		}
+	}
+	e.pageBreaks = pageBreaks
+	delete(e.mediaOverlays, filename)
+	e.removeAliases(path.Join(xhtmlFolderName, filename))
+	e.removeTOCEntries(filename)
+	e.removeLandmarks(filename)
+
+	if e.endnotesFilename == filename {
+		e.endnotesFilename = ""
+	}
+	if e.autoCoverSection == filename {
+		e.autoCoverSection = ""
+	}
+
+	return nil
+}
+
+// RemoveImage removes an image from the EPUB. The internal filename or path
+// of the image (as returned by AddImage) is required. The aliases set for the
+// image are removed, and if it's the cover image or the poster of a video, the
+// cover or the poster is removed as well.
+//
+// Sections showing the image aren't updated.
+//
+// If the filename doesn't match an image that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) RemoveImage(internalFilename string) error {
+	filename := filepath.Base(internalFilename)
+	if _, ok := e.images[filename]; !ok {
+		return &FilenameNotFoundError{Filename: filename}
+	}
+
+	if filename == e.cover.imageFilename {
+		if i := e.sectionIndex(e.cover.xhtmlFilename); i != -1 {
+			e.sections = append(e.sections[:i], e.sections[i+1:]...)
+		}
+		e.removeCover()
+	}
+	delete(e.images, filename)
+	for source, f := range e.imageSources {
+		if f == filename {
+			delete(e.imageSources, source)
+		}
+	}
+	delete(e.transcodedMedia, path.Join(ImageFolderName, filename))
+	e.removeAliases(path.Join(ImageFolderName, filename))
+	for _, video := range e.videoInfo {
+		if filepath.Base(video.posterPath) == filename {
+			video.posterPath = ""
+		}
+	}
+
+	return nil
+}
+
+// ReplaceSection replaces the title, body and CSS of a section, e.g. to
+// regenerate a chapter of an EPUB assembled incrementally. The section keeps
+// its position in the reading order and the table of contents, and its other
+// settings, such as its sub-sections, spine properties and whether it's in the
+// table of contents.
+//
+// The internal filename of the section (as returned by AddSection) is required.
+// If it doesn't match a section that has been added, FilenameNotFoundError
+// will be returned. The other parameters work the same way as for AddSection.
+func (e *Epub) ReplaceSection(filename string, sectionTitle string, body string, internalCSSPath string) error {
+	i := e.sectionIndex(filename)
+	if i == -1 {
+		return &FilenameNotFoundError{Filename: filename}
+	}
+
+	// Add the section again so that it's handled the same way, then move it
+	// (and its embedded documents) back to its position
+	old := e.sections[i]
+	e.sections = append(e.sections[:i], e.sections[i+1:]...)
+	count := len(e.sections)
+	if _, err := e.AddSection(body, sectionTitle, filename, internalCSSPath); err != nil {
+		e.sections = append(e.sections[:i], append([]epubSection{old}, e.sections[i:]...)...)
+		return err
+	}
+
+	added := append([]epubSection{}, e.sections[count:]...)
+	for j := range added {
+		added[j].parent = old.parent
+	}
+	added[0].nonLinear = old.nonLinear
+	added[0].hiddenFromTOC = old.hiddenFromTOC
+	added[0].properties = old.properties
+	// Keep the metadata, e.g. the section authors and viewport
+	added[0].xhtml.xml.Head.Meta = old.xhtml.xml.Head.Meta
+
+	sections := append([]epubSection{}, e.sections[:i]...)
+	sections = append(sections, added...)
+	e.sections = append(sections, e.sections[i:count]...)
+
+	return nil
+}
+
+// Remove the cover, keeping the cover image and the CSS passed to SetCover if
+// any
+func (e *Epub) removeCover() {
+	if e.cover.cssTempFile != "" {
+		delete(e.css, e.cover.cssFilename)
+		os.Remove(e.cover.cssTempFile)
+	}
+	*e.cover = epubCover{}
+}
+
+// Remove the aliases set for a file, whose path is relative to the content
+// folder
+func (e *Epub) removeAliases(p string) {
+	for alias, target := range e.aliases {
+		if target == p {
+			delete(e.aliases, alias)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package epub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemoveSection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	first, _ := e.AddSection(testSectionBody, "First", "", "")
+	second, _ := e.AddSection(`<p><a href="#n1">1</a></p>`, "Second", "", "")
+	sub, _ := e.AddSubSection(second, testSectionBody, "Sub", "", "")
+	e.AddFootnote(second, "n1", "Note")
+	e.SetAlias("second", second)
+
+	if err := e.RemoveSection("missing.xhtml"); err == nil {
+		t.Errorf("Expected error removing a missing section")
+	}
+	if err := e.RemoveSection(second); err != nil {
+		t.Fatalf("Unexpected error removing section: %s", err)
+	}
+	if len(e.footnotes) != 0 || len(e.aliases) != 0 {
+		t.Errorf("Notes and aliases of the removed section weren't removed\nNotes: %v\nAliases: %v", e.footnotes, e.aliases)
+	}
+	if e.sections[e.sectionIndex(sub)].parent != "" {
+		t.Errorf("Sub-section wasn't moved up a level")
+	}
+
+	// The generated filename of a new section doesn't clash with the others
+	third, err := e.AddSection(testSectionBody, "Third", "", "")
+	if err != nil {
+		t.Errorf("Unexpected error adding section: %s", err)
+	}
+	if third == first || third == sub {
+		t.Errorf("Generated section filename %s is already used", third)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	if _, err := os.Stat(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, second)); !os.IsNotExist(err) {
+		t.Errorf("Removed section file was written")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	if strings.Contains(string(contents), second) {
+		t.Errorf("Package file references removed section %s\nGot: %s", second, contents)
+	}
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading navigation document: %s", err)
+	}
+	if strings.Contains(string(contents), "Second") {
+		t.Errorf("Navigation document references removed section\nGot: %s", contents)
+	}
+}
+
+func TestRemoveSectionForeignFallback(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	fallback, _ := e.AddSection(`<p>A 3D model of a cube</p>`, "Cube", "", "")
+	if _, err := e.AddForeignResource(newDataURL("model/gltf-binary", []byte("glTF")), "cube.glb", "model/gltf-binary", fallback); err != nil {
+		t.Fatalf("Unexpected error adding foreign resource: %s", err)
+	}
+
+	err := e.RemoveSection(fallback)
+	if fallbackErr, ok := err.(*FallbackInUseError); !ok || fallbackErr.Foreign != "cube.glb" {
+		t.Errorf("Expected error FallbackInUseError not returned. Returned instead: %+v", err)
+	}
+	if e.sectionIndex(fallback) == -1 || e.foreign["cube.glb"].fallback != fallback {
+		t.Errorf("Fallback section was removed")
+	}
+}
+
+func TestRemoveImage(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, "")
+	e.SetCover(imagePath, "")
+	e.SetAlias("cover", imagePath)
+
+	if err := e.RemoveImage("missing.png"); err == nil {
+		t.Errorf("Expected error removing a missing image")
+	}
+	if err := e.RemoveImage(imagePath); err != nil {
+		t.Fatalf("Unexpected error removing image: %s", err)
+	}
+	if len(e.images) != 0 || len(e.imageSources) != 0 || len(e.aliases) != 0 || len(e.css) != 0 || len(e.sections) != 0 {
+		t.Errorf("Image, its cover and aliases weren't removed\nImages: %v\nCSS: %v\nSections: %d", e.images, e.css, len(e.sections))
+	}
+
+	// The same image can be added again
+	if _, err := e.AddImage(testImageFromFileSource, ""); err != nil {
+		t.Errorf("Unexpected error adding image: %s", err)
+	}
+	if len(e.images) != 1 {
+		t.Errorf("Number of images doesn't match\nGot: %d\nExpected: %d", len(e.images), 1)
+	}
+}
+
+func TestReplaceSection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, "First", "", "")
+	filename, _ := e.AddSection(testSectionBody, "Old", "", "")
+	e.AddSection(testSectionBody, "Last", "", "")
+	e.SetSectionLinear(filename, false)
+	e.SetSectionAuthor(filename, "Author")
+
+	if err := e.ReplaceSection("missing.xhtml", "New", testSectionBody, ""); err == nil {
+		t.Errorf("Expected error replacing a missing section")
+	}
+	if err := e.ReplaceSection(filename, "New", "<p>New body</p>", ""); err != nil {
+		t.Fatalf("Unexpected error replacing section: %s", err)
+	}
+
+	section := e.sections[1]
+	if section.filename != filename || section.xhtml.Title() != "New" || strings.TrimSpace(section.xhtml.xml.Body.XML) != "<p>New body</p>" {
+		t.Errorf("Replaced section doesn't match\nGot: %s %s %s", section.filename, section.xhtml.Title(), section.xhtml.xml.Body.XML)
+	}
+	if !section.nonLinear || section.xhtml.meta(xhtmlMetaAuthor) != "Author" {
+		t.Errorf("Settings of the replaced section weren't kept")
+	}
+	if len(e.sections) != 3 {
+		t.Errorf("Number of sections doesn't match\nGot: %d\nExpected: %d", len(e.sections), 3)
+	}
+}
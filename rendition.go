@@ -0,0 +1,126 @@
+package epub
+
+const (
+	renditionLayoutProperty      = "rendition:layout"
+	renditionOrientationProperty = "rendition:orientation"
+	renditionSpreadProperty      = "rendition:spread"
+)
+
+// Layout is the layout of the content of an EPUB, set using SetLayout.
+type Layout string
+
+// Layouts of the content of an EPUB
+const (
+	// The content is reflowed to fit the screen, as in most books
+	ReflowableLayout Layout = "reflowable"
+	// Each section is a page with fixed dimensions (see SetViewport), as in
+	// picture books and comics
+	FixedLayout Layout = "pre-paginated"
+)
+
+// Orientation is the orientation in which an EPUB is meant to be read, set
+// using SetOrientation.
+type Orientation string
+
+// Orientations in which an EPUB can be read
+const (
+	OrientationAuto      Orientation = "auto"
+	OrientationLandscape Orientation = "landscape"
+	OrientationPortrait  Orientation = "portrait"
+)
+
+// Spread is when two pages of an EPUB are displayed side by side, set using
+// SetSpread.
+type Spread string
+
+// When two pages are displayed side by side
+const (
+	SpreadAuto      Spread = "auto"
+	SpreadBoth      Spread = "both"
+	SpreadLandscape Spread = "landscape"
+	SpreadNone      Spread = "none"
+)
+
+// PageSpread is the side of a spread a page is displayed on, set using
+// SetPageSpread.
+type PageSpread string
+
+// Sides of a spread
+const (
+	PageSpreadLeft   PageSpread = "page-spread-left"
+	PageSpreadRight  PageSpread = "page-spread-right"
+	PageSpreadCenter PageSpread = "rendition:page-spread-center"
+)
+
+// SetLayout sets the layout of the EPUB, written as rendition:layout metadata
+// (EPUB 3 only). An empty layout removes the metadata, which is the same as
+// ReflowableLayout for reading systems.
+func (e *Epub) SetLayout(layout Layout) {
+	e.pkg.setPropertyMeta(renditionLayoutProperty, string(layout))
+}
+
+// SetOrientation sets the orientation in which the EPUB is meant to be read,
+// written as rendition:orientation metadata (EPUB 3 only). An empty
+// orientation removes the metadata.
+func (e *Epub) SetOrientation(orientation Orientation) {
+	e.pkg.setPropertyMeta(renditionOrientationProperty, string(orientation))
+}
+
+// SetSpread sets when two pages of the EPUB are displayed side by side,
+// written as rendition:spread metadata (EPUB 3 only). An empty value removes
+// the metadata.
+func (e *Epub) SetSpread(spread Spread) {
+	e.pkg.setPropertyMeta(renditionSpreadProperty, string(spread))
+}
+
+// SetViewport sets the dimensions in CSS pixels of the page of a section of a
+// fixed-layout EPUB (see SetLayout), written as a viewport <meta> element of
+// the section. Dimensions of 0 remove the viewport.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetViewport(sectionFilename string, width int, height int) error {
+	for _, section := range e.sections {
+		if section.filename == sectionFilename {
+			if width <= 0 && height <= 0 {
+				section.xhtml.setMeta(xhtmlMetaViewport, "")
+			} else {
+				section.xhtml.setViewport(width, height)
+			}
+			return nil
+		}
+	}
+
+	return &FilenameNotFoundError{Filename: sectionFilename}
+}
+
+// SetPageSpread sets the side of a spread on which the page of a section is
+// displayed, written as a property of its spine item (EPUB 3 only). The other
+// properties of the spine item are kept. An empty value removes the page
+// spread.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetPageSpread(sectionFilename string, spread PageSpread) error {
+	for i, section := range e.sections {
+		if section.filename != sectionFilename {
+			continue
+		}
+
+		properties := []string{}
+		for _, property := range section.properties {
+			switch PageSpread(property) {
+			case PageSpreadLeft, PageSpreadRight, PageSpreadCenter:
+			default:
+				properties = append(properties, property)
+			}
+		}
+		if spread != "" {
+			properties = append(properties, string(spread))
+		}
+		e.sections[i].properties = properties
+		return nil
+	}
+
+	return &FilenameNotFoundError{Filename: sectionFilename}
+}
@@ -0,0 +1,69 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixedLayout(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetLayout(FixedLayout)
+	e.SetOrientation(OrientationLandscape)
+	e.SetSpread(SpreadBoth)
+	filename, err := e.AddSection(testSectionBody, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	if err := e.SetViewport(filename, 1200, 800); err != nil {
+		t.Errorf("Unexpected error setting viewport: %s", err)
+	}
+	if err := e.SetSpineItemProperties(filename, "custom"); err != nil {
+		t.Errorf("Unexpected error setting spine item properties: %s", err)
+	}
+	if err := e.SetPageSpread(filename, PageSpreadLeft); err != nil {
+		t.Errorf("Unexpected error setting page spread: %s", err)
+	}
+	if err := e.SetPageSpread(filename, PageSpreadRight); err != nil {
+		t.Errorf("Unexpected error setting page spread: %s", err)
+	}
+	if _, ok := e.SetViewport("missing.xhtml", 1, 1).(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error FilenameNotFoundError not returned for a missing section")
+	}
+	if _, ok := e.SetPageSpread("missing.xhtml", PageSpreadLeft).(*FilenameNotFoundError); !ok {
+		t.Errorf("Expected error FilenameNotFoundError not returned for a missing section")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`<meta property="rendition:layout">pre-paginated</meta>`,
+		`<meta property="rendition:orientation">landscape</meta>`,
+		`<meta property="rendition:spread">both</meta>`,
+		`properties="custom page-spread-right"`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf(
+				"Package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				testMetadata)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testViewport := `<meta name="viewport" content="width=1200, height=800"></meta>`
+	if !strings.Contains(string(contents), testViewport) {
+		t.Errorf("Section viewport doesn't match\nGot: %s\nExpected to contain: %s", contents, testViewport)
+	}
+}
@@ -0,0 +1,137 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"text/tabwriter"
+)
+
+// Transforms applied to resources when the EPUB is written
+const (
+	ResourceTransformReflowed   = "reflowed"
+	ResourceTransformResized    = "resized"
+	ResourceTransformSubsetted  = "subsetted"
+	ResourceTransformTranscoded = "transcoded"
+)
+
+// Compression methods of the files of an EPUB
+const (
+	CompressionMethodDeflate = "deflate"
+	CompressionMethodStore   = "store"
+)
+
+// BuildReport describes the files of the last EPUB written, which allows size
+// regressions between builds to be diagnosed.
+type BuildReport struct {
+	// The files of the EPUB, in the order they're stored
+	Resources []ResourceReport
+	// The declarations removed from the CSS files, if SetConvertPrintCSS is
+	// enabled
+	CSSWarnings []CSSWarning
+	// Other issues found while writing, e.g. that a cover was set by
+	// SetAutoCover
+	Warnings []string
+}
+
+// ResourceReport describes a file of an EPUB.
+type ResourceReport struct {
+	// Path of the file inside the EPUB, e.g. EPUB/images/cover.png
+	Path string
+	// Size of the file before any transform, e.g. the size of the source of a
+	// transcoded audio file
+	OriginalSize int64
+	// Size of the file once uncompressed
+	Size int64
+	// Size of the file in the EPUB, once compressed
+	StoredSize int64
+	// CompressionMethodDeflate or CompressionMethodStore
+	CompressionMethod string
+	// The transforms applied to the file, e.g. ResourceTransformTranscoded
+	Transforms []string
+}
+
+// Transforms applied to a file, keyed by path inside the EPUB
+type resourceTransform struct {
+	originalSize int64
+	transforms   []string
+}
+
+// LastBuildReport returns the report of the last EPUB written using Write, or
+// nil if no EPUB has been written.
+func (e *Epub) LastBuildReport() *BuildReport {
+	return e.buildReport
+}
+
+// String formats the report as a table, followed by the total sizes.
+func (r *BuildReport) String() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Original\tSize\tStored\tMethod\tPath\tTransforms")
+
+	var original, size, stored int64
+	for _, resource := range r.Resources {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%s\t%s\t%s\n",
+			resource.OriginalSize, resource.Size, resource.StoredSize, resource.CompressionMethod, resource.Path, strings.Join(resource.Transforms, ","))
+		original += resource.OriginalSize
+		size += resource.Size
+		stored += resource.StoredSize
+	}
+	fmt.Fprintf(w, "%d\t%d\t%d\t\tTotal\t\n", original, size, stored)
+	w.Flush()
+
+	return b.String()
+}
+
+// Record a transform applied to a file while the EPUB is being written. The
+// original size is only kept for the first transform.
+func (e *Epub) recordTransform(mediaFolderName string, filename string, originalSize int64, transform string) {
+	p := path.Join(contentFolderName, mediaFolderName, filename)
+	t, ok := e.resourceTransforms[p]
+	if !ok {
+		t = &resourceTransform{originalSize: originalSize}
+		e.resourceTransforms[p] = t
+	}
+	t.transforms = append(t.transforms, transform)
+}
+
+// Counts the bytes written to the EPUB for the current file
+type countingWriter struct {
+	w     io.Writer
+	count *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.count += int64(n)
+	return n, err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Register compressors that count the bytes stored for each file added to the
+// zip archive in the report resources, which must be added in the same order
+// as the files
+func registerCountingCompressors(z *zip.Writer, resources *[]*ResourceReport) {
+	next := 0
+	current := func() *int64 {
+		r := (*resources)[next]
+		next++
+		return &r.StoredSize
+	}
+
+	z.RegisterCompressor(zip.Store, func(w io.Writer) (io.WriteCloser, error) {
+		return nopWriteCloser{&countingWriter{w: w, count: current()}}, nil
+	})
+	z.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(&countingWriter{w: w, count: current()}, flate.DefaultCompression)
+	})
+}
@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLastBuildReport(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	if e.LastBuildReport() != nil {
+		t.Error("Expected no build report before the EPUB is written")
+	}
+
+	e.AddSection(strings.Repeat("<p>Compressible</p>\n", 100), testSectionTitle, "", "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+
+	report := e.LastBuildReport()
+	if report == nil || len(report.Resources) == 0 {
+		t.Fatal("Expected a build report after the EPUB is written")
+	}
+
+	mimetype := report.Resources[0]
+	testMimetype := ResourceReport{
+		Path:              mimetypeFilename,
+		OriginalSize:      int64(len(mediaTypeEpub)),
+		Size:              int64(len(mediaTypeEpub)),
+		StoredSize:        int64(len(mediaTypeEpub)),
+		CompressionMethod: CompressionMethodStore,
+	}
+	if !reflect.DeepEqual(mimetype, testMimetype) {
+		t.Errorf(
+			"Mimetype report doesn't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			mimetype,
+			testMimetype)
+	}
+
+	for _, r := range report.Resources {
+		if r.Path == contentFolderName+"/"+xhtmlFolderName+"/section0001.xhtml" {
+			if r.CompressionMethod != CompressionMethodDeflate || r.StoredSize <= 0 || r.StoredSize >= r.Size {
+				t.Errorf("Section report doesn't match\nGot: %+v", r)
+			}
+		}
+	}
+
+	if !strings.Contains(report.String(), "Total") {
+		t.Errorf("Report table doesn't contain the total\nGot: %s", report)
+	}
+}
+
+func TestLastBuildReportTransforms(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetMediaTranscoder(&doublingTranscoder{})
+	e.AddAudio(newDataURL("audio/mpeg", []byte("abc")), "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+
+	for _, r := range e.LastBuildReport().Resources {
+		if r.Path != contentFolderName+"/"+AudioFolderName+"/audio0001.m4a" {
+			continue
+		}
+		if r.OriginalSize != 3 || r.Size != 6 || !reflect.DeepEqual(r.Transforms, []string{ResourceTransformTranscoded}) {
+			t.Errorf("Transcoded audio report doesn't match\nGot: %+v", r)
+		}
+		return
+	}
+	t.Errorf("Transcoded audio not found in build report\nGot: %s", e.LastBuildReport())
+}
+
+// Transcodes MP3 to M4A by doubling the data
+type doublingTranscoder struct{}
+
+func (t *doublingTranscoder) OutputExt(ext string) string {
+	if ext == ".mp3" {
+		return ".m4a"
+	}
+	return ""
+}
+
+func (t *doublingTranscoder) Transcode(w io.Writer, r io.Reader, ext string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, data...))
+	return err
+}
@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// Format of the modification date (dcterms:modified)
+	modifiedDateFormat = "2006-01-02T15:04:05Z"
+	// Environment variable pinning the dates of reproducible builds
+	// (https://reproducible-builds.org/specs/source-date-epoch/)
+	sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+)
+
+// Default date of reproducible builds, the earliest date of zip archives
+var reproducibleDefaultDate = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// SetReproducible sets whether the EPUB is written reproducibly, so that
+// writing the same content twice produces byte-identical files, e.g. to diff
+// builds. In this mode:
+//
+//   - The modification date (dcterms:modified), the dates of undated changelog
+//     entries, and the timestamps of the files in the archive are set to the
+//     date set using SetModified. If none was set, the SOURCE_DATE_EPOCH
+//     environment variable is used, or 1980-01-01 if it isn't set either.
+//   - An identifier generated automatically by NewEpub is replaced by
+//     ContentHashIdentifier.
+//
+// The files are always added to the archive in the same order (see
+// SetZipOrder).
+func (e *Epub) SetReproducible(reproducible bool) {
+	e.reproducible = reproducible
+	if !reproducible && e.identifierGenerated && e.identifierStrategy == nil {
+		e.setIdentifier(RandomIdentifier(e))
+	}
+	e.resolveIdentifier()
+}
+
+// Reproducible returns whether the EPUB is written reproducibly.
+func (e *Epub) Reproducible() bool {
+	return e.reproducible
+}
+
+// SetModified sets the modification date of the EPUB (dcterms:modified), which
+// is otherwise the time it's written. The zero time restores the default.
+func (e *Epub) SetModified(modified time.Time) {
+	e.modified = modified
+}
+
+// Get the modification date of the EPUB
+func (e *Epub) modifiedTime() time.Time {
+	if !e.modified.IsZero() {
+		return e.modified.UTC()
+	}
+	if !e.reproducible {
+		return time.Now().UTC()
+	}
+	if epoch, err := strconv.ParseInt(os.Getenv(sourceDateEpochEnv), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return reproducibleDefaultDate
+}
+
+// Get the timestamp of the files added to the archive. The files don't have
+// any unless the EPUB is written reproducibly.
+func (e *Epub) zipTimestamp() time.Time {
+	if !e.reproducible {
+		return time.Time{}
+	}
+	return e.modifiedTime()
+}
@@ -0,0 +1,83 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetReproducible(t *testing.T) {
+	newTestEpub := func() *Epub {
+		e := NewEpub(testEpubTitle)
+		e.SetReproducible(true)
+		e.AddCSS(testCoverCSSSource, "")
+		for _, filename := range []string{"a.png", "b.png", "c.png"} {
+			e.AddImage(testImageFromFileSource, filename)
+		}
+		e.AddChangelogEntry(ChangelogEntry{Changes: []string{"First edition"}})
+		e.AddVersionHistoryPage("")
+		e.AddSection(testSectionBody, testSectionTitle, "", "")
+		return e
+	}
+
+	write := func(e *Epub) []byte {
+		buf := &bytes.Buffer{}
+		if _, err := e.WriteTo(buf); err != nil {
+			t.Fatalf("Unexpected error writing EPUB: %s", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := write(newTestEpub())
+	// The current time isn't used
+	time.Sleep(time.Second)
+	second := write(newTestEpub())
+	if !bytes.Equal(first, second) {
+		t.Errorf("Reproducible EPUBs aren't identical")
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatalf("Unexpected error reading EPUB: %s", err)
+	}
+	for _, f := range z.File {
+		if !f.Modified.Equal(reproducibleDefaultDate) {
+			t.Errorf("Timestamp of %s doesn't match\nGot: %s\nExpected: %s", f.Name, f.Modified, reproducibleDefaultDate)
+		}
+	}
+
+	// The identifier is derived from the content
+	e := newTestEpub()
+	if e.Identifier() != ContentHashIdentifier(e) {
+		t.Errorf("Identifier doesn't match\nGot: %s\nExpected: %s", e.Identifier(), ContentHashIdentifier(e))
+	}
+	e.SetReproducible(false)
+	if e.Identifier() == ContentHashIdentifier(e) {
+		t.Errorf("Random identifier wasn't restored")
+	}
+}
+
+func TestSetModified(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetReproducible(true)
+	modified := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+	os.Setenv(sourceDateEpochEnv, "1700000000")
+	defer os.Unsetenv(sourceDateEpochEnv)
+	if m := e.modifiedTime(); !m.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Modification date doesn't match\nGot: %s\nExpected: %s", m, time.Unix(1700000000, 0).UTC())
+	}
+
+	e.SetModified(modified)
+	f, err := e.Finalize()
+	if err != nil {
+		t.Fatalf("Unexpected error finalizing EPUB: %s", err)
+	}
+	expected := `<meta property="dcterms:modified">2024-03-01T12:30:00Z</meta>`
+	if pkg := string(f.Files[contentFolderName+"/"+pkgFilename]); !strings.Contains(pkg, expected) {
+		t.Errorf("Package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+	}
+}
@@ -0,0 +1,71 @@
+package epub
+
+// ResetCSS is the stylesheet applied by SetResetCSS, which normalizes the
+// rendering differences between reading systems. It can also be embedded in
+// a stylesheet added using AddCSS.
+const ResetCSS = `html, body {
+  margin: 0;
+  padding: 0;
+}
+body {
+  widows: 2;
+  orphans: 2;
+  -webkit-hyphens: auto;
+  -epub-hyphens: auto;
+  -adobe-hyphenate: auto;
+  hyphens: auto;
+}
+p {
+  margin: 0;
+  text-indent: 1.5em;
+}
+h1, h2, h3, h4, h5, h6 {
+  text-indent: 0;
+  -webkit-hyphens: manual;
+  -epub-hyphens: manual;
+  -adobe-hyphenate: none;
+  hyphens: manual;
+  page-break-after: avoid;
+  break-after: avoid;
+}
+h1 + p, h2 + p, h3 + p, h4 + p, h5 + p, h6 + p, hr + p {
+  text-indent: 0;
+}
+img, svg, video {
+  max-width: 100%;
+  height: auto;
+}
+img, svg, video, figure, table {
+  page-break-inside: avoid;
+  break-inside: avoid;
+}
+pre {
+  white-space: pre-wrap;
+  -webkit-hyphens: none;
+  -epub-hyphens: none;
+  -adobe-hyphenate: none;
+  hyphens: none;
+}
+table {
+  border-collapse: collapse;
+}
+/* Keep superscripts (e.g. note references) from spacing out lines */
+sup, sub {
+  line-height: 0;
+}
+/* Adobe RMSDK based reading systems use the page margins rather than the
+   body margins */
+@page {
+  margin: 0.5em 0.75em;
+}
+`
+
+// SetResetCSS sets whether ResetCSS is applied to every section using the
+// default stylesheet, before the other default styles (e.g. the default font)
+// and the section stylesheets, which can still override it. It resets the
+// margins, indents the paragraphs that don't follow a heading, keeps images
+// within the page, enables hyphenation except in headings and preformatted
+// text, and sets the page margins used by Adobe RMSDK based reading systems.
+func (e *Epub) SetResetCSS(enabled bool) {
+	e.resetCSS = enabled
+}
@@ -0,0 +1,28 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetResetCSS(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetResetCSS(true)
+	if _, err := e.EmbedDefaultFont(testFontFromFileSource, testDefaultFontFamily); err != nil {
+		t.Fatalf("Unexpected error embedding default font: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, defaultCSSFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading default CSS file: %s", err)
+	}
+	// The reset comes first so that the other styles override it
+	if !strings.HasPrefix(string(contents), ResetCSS) || !strings.Contains(string(contents), "@font-face") {
+		t.Errorf("Default CSS file contents don't match\nGot: %s\nExpected to start with: %s", contents, ResetCSS)
+	}
+}
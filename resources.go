@@ -0,0 +1,439 @@
+package epub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	cssImageSetRegexp = regexp.MustCompile(`(?i)(?:-webkit-)?image-set\(`)
+	cssURLRegexp      = regexp.MustCompile(`(?i)url\(\s*("[^"]*"|'[^']*'|[^)\s]*)\s*\)`)
+	htmlAttrRegexp    = regexp.MustCompile(`(?s)\s([\w:-]+)\s*=\s*("[^"]*"|'[^']*')`)
+	htmlImgRegexp     = regexp.MustCompile(`(?is)<img\b[^>]*>`)
+	htmlPictureRegexp = regexp.MustCompile(`(?is)<picture\b[^>]*>(.*?)</picture>`)
+	htmlSourceRegexp  = regexp.MustCompile(`(?is)<source\b[^>]*>`)
+	htmlTagRegexp     = regexp.MustCompile(`(?s)<[a-zA-Z][^>]*>`)
+)
+
+// UnresolvedResource is a reference from a section or CSS file to a resource
+// that isn't part of the EPUB.
+type UnresolvedResource struct {
+	Filename  string // The path of the referencing file, e.g. xhtml/section0001.xhtml
+	Reference string // The reference as it appears in the file
+}
+
+// UnresolvedResources checks the resources referenced by the sections and CSS
+// files: images (including srcset candidates and <picture> sources), audio,
+// video, and CSS url() values such as background images. The relative
+// references that don't point to a file of the EPUB are returned; references
+// with a scheme (e.g. http:) and fragment-only references are ignored.
+//
+// As responsive images are simplified when the EPUB is written (see Write),
+// only the sources that are actually used are checked.
+func (e *Epub) UnresolvedResources() ([]UnresolvedResource, error) {
+	files := e.files()
+
+	unresolved := []UnresolvedResource{}
+	check := func(filename string, references []string) {
+		for _, reference := range references {
+			if !resolvesTo(files, path.Dir(filename), reference) {
+				unresolved = append(unresolved, UnresolvedResource{
+					Filename:  filename,
+					Reference: reference,
+				})
+			}
+		}
+	}
+
+	for _, section := range e.sections {
+		body := simplifyResponsiveImages(section.xhtml.xml.Body.XML)
+		check(path.Join(xhtmlFolderName, section.filename), findResourceReferences(body))
+	}
+
+	cssFilenames := []string{}
+	for filename := range e.css {
+		cssFilenames = append(cssFilenames, filename)
+	}
+	sort.Strings(cssFilenames)
+	for _, filename := range cssFilenames {
+		css, err := readMediaSource(e.context(), e.httpClient(), e.css[filename])
+		if err != nil {
+			return nil, err
+		}
+		check(path.Join(CSSFolderName, filename), findCSSReferences(simplifyImageSets(string(css))))
+	}
+	check(path.Join(CSSFolderName, defaultCSSFilename), findCSSReferences(e.defaultCSS()))
+
+	return unresolved, nil
+}
+
+// Get the paths of the files of the EPUB, relative to the content folder
+func (e *Epub) files() map[string]bool {
+	files := map[string]bool{}
+	for folder, media := range map[string]map[string]string{
+		AudioFolderName: e.audio,
+		CSSFolderName:   e.css,
+		FontFolderName:  e.fonts,
+		ImageFolderName: e.images,
+		VideoFolderName: e.videos,
+	} {
+		for filename := range media {
+			files[path.Join(folder, filename)] = true
+		}
+	}
+	for _, section := range e.sections {
+		files[path.Join(xhtmlFolderName, section.filename)] = true
+	}
+	if e.defaultCSS() != "" {
+		files[path.Join(CSSFolderName, defaultCSSFilename)] = true
+	}
+
+	return files
+}
+
+// Read the content of a media source
+func readMediaSource(ctx context.Context, client *http.Client, source string) ([]byte, error) {
+	r, err := openMediaSource(ctx, client, source)
+	if err != nil {
+		return nil, &FileRetrievalError{Source: source, Err: err}
+	}
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, &FileRetrievalError{Source: source, Err: err}
+	}
+
+	return content, nil
+}
+
+// Whether a reference from a file in the provided folder points to one of the
+// files, or isn't a reference to a local file
+func resolvesTo(files map[string]bool, folder string, reference string) bool {
+	u, err := url.Parse(unescapeText(reference))
+	if err != nil {
+		return false
+	}
+	if u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return true
+	}
+
+	return files[path.Join(folder, u.Path)]
+}
+
+// Find the resources referenced by a section body
+func findResourceReferences(body string) []string {
+	references := []string{}
+	for _, tag := range htmlTagRegexp.FindAllString(body, -1) {
+		for _, name := range []string{"src", "poster"} {
+			if value, ok := tagAttribute(tag, name); ok && value != "" {
+				references = append(references, value)
+			}
+		}
+		if value, ok := tagAttribute(tag, "srcset"); ok {
+			for _, candidate := range parseSrcset(value) {
+				references = append(references, candidate.url)
+			}
+		}
+	}
+
+	// Inline styles and <style> elements
+	return append(references, findCSSReferences(body)...)
+}
+
+// Find the resources referenced by CSS
+func findCSSReferences(css string) []string {
+	references := []string{}
+	for _, m := range cssURLRegexp.FindAllStringSubmatch(css, -1) {
+		if reference := strings.Trim(m[1], `"'`); reference != "" {
+			references = append(references, reference)
+		}
+	}
+
+	return references
+}
+
+// A candidate of a srcset attribute or image-set() function
+type imageCandidate struct {
+	url string
+	// The width descriptor (e.g. 800w), 0 if there is none
+	width float64
+	// The pixel density descriptor (e.g. 2x), 1 if there is none
+	density float64
+}
+
+// Choose the candidate to use when only one source is possible. Reading
+// systems often have high density screens, so the largest image is used.
+func bestImageCandidate(candidates []imageCandidate) (imageCandidate, bool) {
+	if len(candidates) == 0 {
+		return imageCandidate{}, false
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		// Width descriptors take precedence over density descriptors, as they
+		// can't be compared
+		if c.width > best.width || (c.width == best.width && c.density > best.density) {
+			best = c
+		}
+	}
+
+	return best, true
+}
+
+// Parse the candidates of a srcset attribute, e.g. "a.png 1x, b.png 2x"
+//
+// Spec: https://html.spec.whatwg.org/multipage/images.html#srcset-attributes
+func parseSrcset(srcset string) []imageCandidate {
+	candidates := []imageCandidate{}
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return candidates
+		}
+
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end == -1 {
+			end = len(s)
+		}
+		c := imageCandidate{url: s[:end], density: 1}
+		s = s[end:]
+
+		// A URL ending with a comma has no descriptor
+		if strings.HasSuffix(c.url, ",") {
+			c.url = strings.TrimRight(c.url, ",")
+		} else {
+			end = strings.Index(s, ",")
+			if end == -1 {
+				end = len(s)
+			}
+			setImageDescriptor(&c, strings.TrimSpace(s[:end]))
+			s = s[end:]
+		}
+		candidates = append(candidates, c)
+	}
+}
+
+// Set the descriptor of an image candidate, e.g. 800w or 2x
+func setImageDescriptor(c *imageCandidate, descriptor string) {
+	if len(descriptor) < 2 {
+		return
+	}
+	value, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64)
+	if err != nil {
+		return
+	}
+
+	switch strings.ToLower(descriptor[len(descriptor)-1:]) {
+	case "w":
+		c.width = value
+	case "x":
+		c.density = value
+	}
+}
+
+// Replace the responsive images of a section body, which many reading systems
+// don't support, by images with a single source: <picture> elements are
+// replaced by their <img> element and srcset attributes are removed, the best
+// candidate becoming the source of the image.
+func simplifyResponsiveImages(body string) string {
+	body = htmlPictureRegexp.ReplaceAllStringFunc(body, func(picture string) string {
+		content := htmlPictureRegexp.FindStringSubmatch(picture)[1]
+		img := htmlImgRegexp.FindString(content)
+		if img == "" {
+			return picture
+		}
+
+		// Use the first source that applies regardless of the media and that
+		// has a type supported by EPUB, as a browser would
+		for _, source := range htmlSourceRegexp.FindAllString(content, -1) {
+			if _, ok := tagAttribute(source, "media"); ok {
+				continue
+			}
+			if mediaType, ok := tagAttribute(source, "type"); ok && !isImageMediaType(mediaType) {
+				continue
+			}
+			srcset, _ := tagAttribute(source, "srcset")
+			if best, ok := bestImageCandidate(parseSrcset(srcset)); ok {
+				return removeTagAttribute(removeTagAttribute(setTagAttribute(img, "src", best.url), "srcset"), "sizes")
+			}
+		}
+
+		return simplifyImg(img)
+	})
+
+	return htmlImgRegexp.ReplaceAllStringFunc(body, simplifyImg)
+}
+
+// Replace the srcset attribute of an <img> element by the best candidate
+func simplifyImg(img string) string {
+	srcset, ok := tagAttribute(img, "srcset")
+	if !ok {
+		return img
+	}
+
+	candidates := parseSrcset(srcset)
+	// The source is a candidate with a density of 1
+	if src, ok := tagAttribute(img, "src"); ok && src != "" {
+		candidates = append([]imageCandidate{{url: src, density: 1}}, candidates...)
+	}
+	if best, ok := bestImageCandidate(candidates); ok {
+		img = setTagAttribute(img, "src", best.url)
+	}
+
+	return removeTagAttribute(removeTagAttribute(img, "srcset"), "sizes")
+}
+
+// Whether a media type is an image type supported by EPUB
+func isImageMediaType(mediaType string) bool {
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, t := range extensionMediaTypes {
+		if t == mediaType && strings.HasPrefix(t, "image/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Replace the CSS image-set() functions, which many reading systems don't
+// support, by the url() of their best candidate
+func simplifyImageSets(css string) string {
+	b := strings.Builder{}
+	for {
+		loc := cssImageSetRegexp.FindStringIndex(css)
+		if loc == nil {
+			b.WriteString(css)
+			return b.String()
+		}
+
+		// Find the matching closing parenthesis
+		depth, end := 1, loc[1]
+		for ; end < len(css) && depth > 0; end++ {
+			switch css[end] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+		}
+		if depth > 0 {
+			b.WriteString(css)
+			return b.String()
+		}
+
+		b.WriteString(css[:loc[0]])
+		if best, ok := bestImageCandidate(parseImageSet(css[loc[1] : end-1])); ok {
+			b.WriteString(`url("` + best.url + `")`)
+		} else {
+			b.WriteString(css[loc[0]:end])
+		}
+		css = css[end:]
+	}
+}
+
+// Parse the arguments of an image-set() function, e.g.
+// url(a.png) 1x, "b.png" 2x
+func parseImageSet(arguments string) []imageCandidate {
+	candidates := []imageCandidate{}
+	for _, argument := range splitCSSArguments(arguments) {
+		argument = strings.TrimSpace(argument)
+		c := imageCandidate{density: 1}
+
+		if m := cssURLRegexp.FindStringSubmatchIndex(argument); m != nil && m[0] == 0 {
+			c.url = strings.Trim(argument[m[2]:m[3]], `"'`)
+			argument = argument[m[1]:]
+		} else if len(argument) > 0 && (argument[0] == '"' || argument[0] == '\'') {
+			end := strings.IndexByte(argument[1:], argument[0])
+			if end == -1 {
+				continue
+			}
+			c.url = argument[1 : end+1]
+			argument = argument[end+2:]
+		} else {
+			continue
+		}
+
+		// Other arguments such as type() are ignored
+		for _, descriptor := range strings.Fields(argument) {
+			setImageDescriptor(&c, strings.Replace(descriptor, "dppx", "x", 1))
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates
+}
+
+// Split CSS function arguments on the commas that aren't inside parentheses
+// or quotes
+func splitCSSArguments(arguments string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(arguments); i++ {
+		switch c := arguments[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, arguments[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, arguments[start:])
+}
+
+// Get the value of an attribute of an HTML start tag
+func tagAttribute(tag string, name string) (string, bool) {
+	for _, m := range htmlAttrRegexp.FindAllStringSubmatch(tag, -1) {
+		if strings.EqualFold(m[1], name) {
+			return m[2][1 : len(m[2])-1], true
+		}
+	}
+
+	return "", false
+}
+
+// Set the value of an attribute of an HTML start tag, adding the attribute if
+// needed. The value must already be escaped.
+func setTagAttribute(tag string, name string, value string) string {
+	attribute := " " + name + `="` + strings.Replace(value, `"`, "&quot;", -1) + `"`
+	for _, loc := range htmlAttrRegexp.FindAllStringSubmatchIndex(tag, -1) {
+		if strings.EqualFold(tag[loc[2]:loc[3]], name) {
+			return tag[:loc[0]] + attribute + tag[loc[1]:]
+		}
+	}
+
+	if strings.HasSuffix(tag, "/>") {
+		return strings.TrimRight(tag[:len(tag)-2], " \t\n\r") + attribute + " />"
+	}
+
+	return strings.TrimRight(tag[:len(tag)-1], " \t\n\r") + attribute + ">"
+}
+
+// Remove an attribute of an HTML start tag
+func removeTagAttribute(tag string, name string) string {
+	for _, loc := range htmlAttrRegexp.FindAllStringSubmatchIndex(tag, -1) {
+		if strings.EqualFold(tag[loc[2]:loc[3]], name) {
+			return tag[:loc[0]] + tag[loc[1]:]
+		}
+	}
+
+	return tag
+}
@@ -0,0 +1,129 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimplifyResponsiveImages(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{
+			`<img src="a.png" srcset="b.png 2x, c.png 3x" alt="A" />`,
+			`<img src="c.png" alt="A" />`,
+		},
+		{
+			`<img srcset="small.jpg 480w, large.jpg 1200w" sizes="50vw" alt="">`,
+			`<img alt="" src="large.jpg">`,
+		},
+		{
+			`<picture><source media="(min-width: 800px)" srcset="wide.png" /><source type="image/avif" srcset="a.avif" /><source srcset="b.png 1x, b2.png 2x" /><img src="fallback.png" alt="B" /></picture>`,
+			`<img src="b2.png" alt="B" />`,
+		},
+		{
+			`<picture><source type="image/avif" srcset="a.avif" /><img src="fallback.png" alt="C" /></picture>`,
+			`<img src="fallback.png" alt="C" />`,
+		},
+		{
+			`<img src="unchanged.png" alt="D" />`,
+			`<img src="unchanged.png" alt="D" />`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := simplifyResponsiveImages(test.body); got != test.expected {
+			t.Errorf(
+				"Simplified body doesn't match\n"+
+					"Got: %s\n"+
+					"Expected: %s",
+				got,
+				test.expected)
+		}
+	}
+}
+
+func TestSimplifyImageSets(t *testing.T) {
+	css := `.hero { background-image: -webkit-image-set(url(a.png) 1x, url("a@2x.png") 2x); }
+.logo { background-image: image-set("b.png" 1x, "b@3x.png" 3dppx); }
+`
+	expected := `.hero { background-image: url("a@2x.png"); }
+.logo { background-image: url("b@3x.png"); }
+`
+	if got := simplifyImageSets(css); got != expected {
+		t.Errorf(
+			"Simplified CSS doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			got,
+			expected)
+	}
+}
+
+func TestUnresolvedResources(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	cssPath, _ := e.AddCSS(newDataURL(mediaTypeCSS, []byte(`body { background: url("../images/missing.png"); }`)), "style.css")
+	e.AddSection(
+		`<img src="`+imagePath+`" srcset="../images/large.png 2x" alt="" />`+
+			`<video src="../video/clip.mp4" poster="`+imagePath+`"></video>`+
+			`<p style="background-image: url('`+imagePath+`')"><a href="#top">Top</a></p>`+
+			`<img src="https://example.com/remote.png" alt="" />`,
+		"Section",
+		"",
+		cssPath)
+
+	unresolved, err := e.UnresolvedResources()
+	if err != nil {
+		t.Errorf("Unexpected error checking resources: %s", err)
+	}
+	expected := []UnresolvedResource{
+		{Filename: "xhtml/section0001.xhtml", Reference: "../images/large.png"},
+		{Filename: "xhtml/section0001.xhtml", Reference: "../video/clip.mp4"},
+		{Filename: "css/style.css", Reference: "../images/missing.png"},
+	}
+	if !reflect.DeepEqual(unresolved, expected) {
+		t.Errorf(
+			"Unresolved resources don't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			unresolved,
+			expected)
+	}
+}
+
+func TestWriteResponsiveImages(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	cssPath, _ := e.AddCSS(newDataURL(mediaTypeCSS, []byte(`body { background: image-set("a.png" 1x, "b.png" 2x); }`)), "style.css")
+	filename, _ := e.AddSection(`<img src="a.png" srcset="b.png 2x" alt="" />`, "Section", "", cssPath)
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	if !strings.Contains(string(contents), `<img src="b.png" alt="" />`) || strings.Contains(string(contents), "srcset") {
+		t.Errorf("Section file doesn't contain the simplified image\nGot: %s", contents)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, "style.css"))
+	if err != nil {
+		t.Errorf("Unexpected error reading CSS file: %s", err)
+	}
+	testCSS := `body { background: url("b.png"); }`
+	if string(contents) != testCSS {
+		t.Errorf(
+			"CSS file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testCSS)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
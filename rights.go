@@ -0,0 +1,100 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmaupin/go-epub/cc"
+)
+
+const (
+	ccLicenseRel            = "cc:license"
+	ccPrefix                = "cc"
+	ccPrefixURI             = "http://creativecommons.org/ns#"
+	licensePageBodyTemplate = `<h1>%s</h1>
+<p class="license">%s</p>`
+	licensePageFilename = "license.xhtml"
+	licensePageLink     = `<a href="%s">%s</a>`
+)
+
+// UnsupportedLicenseError is thrown by SetCreativeCommons if the license
+// doesn't exist in the provided version.
+type UnsupportedLicenseError struct {
+	License cc.License // The license
+	Version string     // The version of the license
+}
+
+func (e *UnsupportedLicenseError) Error() string {
+	return fmt.Sprintf("License %s doesn't exist in version %q", e.License, e.Version)
+}
+
+// Rights returns the rights statement of the EPUB.
+func (e *Epub) Rights() string {
+	return e.rights
+}
+
+// SetRights sets the rights statement of the EPUB (dc:rights), a
+// human-readable statement about the rights held in and over the EPUB, e.g.
+// Copyright © 2024 Jane Doe. All rights reserved.
+func (e *Epub) SetRights(rights string) {
+	e.rights = rights
+	e.pkg.setRights(rights)
+}
+
+// SetCreativeCommons licenses the EPUB under a Creative Commons license in the
+// provided version (e.g. cc.LatestVersion), replacing the rights statement by
+// the notice of the license. The license is also linked from the metadata
+// (link rel="cc:license"), which allows it to be processed by machines; EPUB 2
+// doesn't support this link, so only the notice is written.
+//
+// If the license doesn't exist in the version, UnsupportedLicenseError will be
+// returned. See AddLicensePage to add the notice to the content of the EPUB.
+func (e *Epub) SetCreativeCommons(license cc.License, version string) error {
+	if !license.Valid(version) {
+		return &UnsupportedLicenseError{
+			License: license,
+			Version: version,
+		}
+	}
+
+	e.SetRights(license.Notice(version))
+	e.licenseURL = license.URL(version)
+	e.pkg.addPrefix(ccPrefix, ccPrefixURI)
+	e.pkg.setLink(ccLicenseRel, e.licenseURL)
+
+	return nil
+}
+
+// AddLicensePage adds a page to the EPUB showing its rights statement, as set
+// when the EPUB is written. If the EPUB is licensed using SetCreativeCommons,
+// the URL of the license is a link to it.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The statement is a <p> element with the class
+// "license", which can be used to style it.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddLicensePage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelLicense, ""), licensePageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		title: func() string {
+			return e.label(LabelLicense, "")
+		},
+		body: func() string {
+			rights := escapeText(e.rights)
+			if e.licenseURL != "" {
+				url := escapeText(e.licenseURL)
+				rights = strings.Replace(rights, url, fmt.Sprintf(licensePageLink, escapeAttribute(e.licenseURL), url), 1)
+			}
+
+			return fmt.Sprintf(licensePageBodyTemplate, escapeText(x.Title()), rights)
+		},
+	}
+
+	return filename, nil
+}
@@ -0,0 +1,104 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmaupin/go-epub/cc"
+)
+
+func TestSetRights(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	testRights := "Copyright © 2024 Jane Doe & Co. All rights reserved."
+	e.SetRights(testRights)
+	if e.Rights() != testRights {
+		t.Errorf("Rights doesn't match\nGot: %s\nExpected: %s", e.Rights(), testRights)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	testMetadata := "<dc:rights>Copyright © 2024 Jane Doe &amp; Co. All rights reserved.</dc:rights>"
+	if !strings.Contains(string(contents), testMetadata) {
+		t.Errorf(
+			"Package file metadata doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testMetadata)
+	}
+}
+
+func TestSetCreativeCommons(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	err := e.SetCreativeCommons(cc.BY_NC_SA, "4.0")
+	if err != nil {
+		t.Errorf("Unexpected error setting license: %s", err)
+	}
+	filename, err := e.AddLicensePage("")
+	if err != nil {
+		t.Errorf("Unexpected error adding license page: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`prefix="cc: http://creativecommons.org/ns#"`,
+		"<dc:rights>This work is licensed under the Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International License. To view a copy of this license, visit https://creativecommons.org/licenses/by-nc-sa/4.0/</dc:rights>",
+		`<link rel="cc:license" href="https://creativecommons.org/licenses/by-nc-sa/4.0/"></link>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf(
+				"Package file metadata doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				testMetadata)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading license page: %s", err)
+	}
+	testBody := `<h1>License</h1>
+<p class="license">This work is licensed under the Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International License. To view a copy of this license, visit <a href="https://creativecommons.org/licenses/by-nc-sa/4.0/">https://creativecommons.org/licenses/by-nc-sa/4.0/</a></p>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"License page doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	// EPUB 2 doesn't support the link
+	e.SetVersion(EPUBVersion2)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	if strings.Contains(string(contents), "<link") || !strings.Contains(string(contents), "<dc:rights>") {
+		t.Errorf("EPUB 2 package file metadata doesn't match\nGot: %s", contents)
+	}
+
+	err = e.SetCreativeCommons(cc.CC0, "4.0")
+	if _, ok := err.(*UnsupportedLicenseError); !ok {
+		t.Errorf("Expected error UnsupportedLicenseError not returned. Returned instead: %+v", err)
+	}
+}
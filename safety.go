@@ -0,0 +1,103 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Maximum length of an internal filename in bytes, the usual limit of
+	// file systems
+	maxFilenameLength = 255
+	// Maximum number of pixels of the images that are decoded, e.g. to be
+	// degraded or split, so that small images with huge dimensions can't
+	// exhaust the memory
+	maxDecodedImagePixels = 128 << 20
+	// Maximum uncompressed sizes of a file and of all the files read from an
+	// archive, e.g. a CBZ comic, so that zip bombs can't exhaust the memory
+	maxArchiveFileSize  = 512 << 20
+	maxArchiveTotalSize = 2 << 30
+)
+
+// InvalidFilenameError is thrown by AddSection, the functions adding media
+// files (e.g. AddImage), and UnmarshalJSON if an internal filename can't be
+// used, e.g. because it contains a path separator.
+type InvalidFilenameError struct {
+	Filename string // Filename that caused the error
+	Reason   string // Why it can't be used
+}
+
+func (e *InvalidFilenameError) Error() string {
+	return fmt.Sprintf("Invalid filename %q: %s", e.Filename, e.Reason)
+}
+
+// Check that an internal filename can be used as the name of a file of the
+// EPUB, in particular that it stays in its folder
+func validateFilename(filename string) error {
+	reason := ""
+	switch {
+	case filename == "" || filename == "." || filename == "..":
+		reason = "not a filename"
+	case len(filename) > maxFilenameLength:
+		reason = fmt.Sprintf("longer than %d bytes", maxFilenameLength)
+	case !utf8.ValidString(filename):
+		reason = "not valid UTF-8"
+	case strings.ContainsAny(filename, `/\`):
+		reason = "contains a path separator"
+	case strings.IndexFunc(filename, func(r rune) bool { return r < 0x20 || r == 0x7f }) != -1:
+		reason = "contains a control character"
+	default:
+		return nil
+	}
+
+	return &InvalidFilenameError{Filename: filename, Reason: reason}
+}
+
+// Check that an internal filename can be used for a media file, whose media
+// type is derived from its extension
+func validateMediaFilename(filename string) error {
+	if err := validateFilename(filename); err != nil {
+		return err
+	}
+	if _, ok := extensionMediaTypes[strings.ToLower(path.Ext(filename))]; !ok {
+		return &InvalidFilenameError{Filename: filename, Reason: "unknown media type"}
+	}
+
+	return nil
+}
+
+// Decode an image, unless its dimensions are too large
+func decodeImage(data []byte) (image.Image, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > maxDecodedImagePixels {
+		return nil, "", fmt.Errorf("image too large to decode: %d×%d", config.Width, config.Height)
+	}
+
+	return image.Decode(bytes.NewReader(data))
+}
+
+var errArchiveTooLarge = errors.New("archive too large")
+
+// Read a file of an archive, counting its size in the total size read so far
+func readArchiveFile(r io.Reader, total *int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxArchiveFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	*total += int64(len(data))
+	if len(data) > maxArchiveFileSize || *total > maxArchiveTotalSize {
+		return nil, errArchiveTooLarge
+	}
+
+	return data, nil
+}
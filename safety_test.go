@@ -0,0 +1,67 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFilename(t *testing.T) {
+	tests := map[string]bool{
+		"section0001.xhtml":                 true,
+		"café.xhtml":                        true,
+		"":                                  false,
+		"..":                                false,
+		"../../etc/passwd":                  false,
+		`..\evil.xhtml`:                     false,
+		"sub/section.xhtml":                 false,
+		"section\x00.xhtml":                 false,
+		"\xff.xhtml":                        false,
+		strings.Repeat("a", 256):            false,
+		strings.Repeat("a", 249) + ".xhtml": true,
+	}
+	for filename, valid := range tests {
+		err := validateFilename(filename)
+		if valid && err != nil {
+			t.Errorf("Unexpected error validating %q: %s", filename, err)
+		}
+		if !valid {
+			if _, ok := err.(*InvalidFilenameError); !ok {
+				t.Errorf("Expected error InvalidFilenameError not returned for %q. Returned instead: %+v", filename, err)
+			}
+		}
+	}
+}
+
+func TestAddInvalidFilename(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+
+	_, err := e.AddSection(testSectionBody, testSectionTitle, "../section.xhtml", "")
+	if _, ok := err.(*InvalidFilenameError); !ok {
+		t.Errorf("Expected error InvalidFilenameError not returned. Returned instead: %+v", err)
+	}
+	_, err = e.AddImage(testImageFromFileSource, "../../image.png")
+	if _, ok := err.(*InvalidFilenameError); !ok {
+		t.Errorf("Expected error InvalidFilenameError not returned. Returned instead: %+v", err)
+	}
+	_, err = e.AddImage(testImageFromFileSource, "image.exe")
+	if _, ok := err.(*InvalidFilenameError); !ok {
+		t.Errorf("Expected error InvalidFilenameError not returned. Returned instead: %+v", err)
+	}
+}
+
+func TestUnmarshalJSONInvalidFilename(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	if _, err := e.AddImage(testImageFromFileSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	data, err := e.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling: %s", err)
+	}
+	data = []byte(strings.Replace(string(data), `"gophercolor16x16.png"`, `"../gophercolor16x16.png"`, 1))
+
+	err = NewEpub("").UnmarshalJSON(data)
+	if _, ok := err.(*InvalidFilenameError); !ok {
+		t.Errorf("Expected error InvalidFilenameError not returned. Returned instead: %+v", err)
+	}
+}
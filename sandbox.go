@@ -0,0 +1,290 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Elements removed from the body of sections in sandbox mode, as they run
+// scripts or load external content
+var sandboxRemovedElements = map[string]bool{
+	"applet": true, "embed": true, "form": true, "iframe": true, "object": true, "script": true,
+}
+
+// Local names of the attributes containing URLs, whatever their prefix (e.g.
+// xlink:href), which are removed in sandbox mode if they use a scripting
+// scheme
+var sandboxURLAttributes = map[string]bool{
+	"action": true, "formaction": true, "href": true, "poster": true, "src": true,
+}
+
+var (
+	scriptURLSchemeRegexp = regexp.MustCompile(`^(?i)(javascript|vbscript):`)
+	urlIgnoredCharsRegexp = regexp.MustCompile(`[\x00-\x20]`)
+)
+
+// SandboxViolationError is thrown by AddSection, AddSectionWriter, the
+// functions adding media files (e.g. AddImage), and Write if something isn't
+// allowed in sandbox mode.
+type SandboxViolationError struct {
+	Reason string // Why it isn't allowed
+}
+
+func (e *SandboxViolationError) Error() string {
+	return fmt.Sprintf("Not allowed in sandbox mode: %s", e.Reason)
+}
+
+// SandboxOptions are the restrictions of sandbox mode. Limits of 0 disable
+// them.
+type SandboxOptions struct {
+	// The files that media sources which aren't URLs are read from; media
+	// sources are paths relative to its root, e.g. images/cover.png. If nil,
+	// only data URLs can be used.
+	FS fs.FS
+	// Maximum size of a media file, in bytes
+	MaxFileSize int64
+	// Maximum number of sections
+	MaxSections int
+	// Maximum size of the body of a section, in bytes
+	MaxSectionSize int
+}
+
+// SetSandbox enables sandbox mode, which allows untrusted users to drive the
+// creation of EPUBs, e.g. in a multi-tenant service. Passing nil disables it.
+//
+// In sandbox mode:
+//   - Nothing is retrieved over the network: URL media sources are rejected,
+//     and the embedded content of sections is removed instead of inlined.
+//   - Local files, including foreign resources, are only read from the
+//     provided fs.FS. They're read when
+//     they're added and kept in memory.
+//   - Scripts, embedded content, forms, event handler attributes and
+//     javascript: URLs are removed from the body of sections, including the
+//     ones rendered from templates. The body is parsed as XML to do so; the
+//     markup that can't be parsed is escaped.
+//   - Sections can't be streamed using AddSectionWriter.
+//   - The size limits are enforced.
+//
+// Anything that isn't allowed returns SandboxViolationError. Sandbox mode
+// should be enabled before anything is added to the EPUB, as it doesn't apply
+// retroactively, except for the sources of the files and the notes added using
+// AddFootnote (e.g. of an EPUB restored from a snapshot), which are all checked
+// again by Write.
+func (e *Epub) SetSandbox(options *SandboxOptions) {
+	e.sandbox = options
+}
+
+// Check a media source in sandbox mode, reading it into a data URL if it's a
+// file of the sandbox file system. The filename to use for the media file if
+// none was provided is also returned.
+func (e *Epub) sandboxMediaSource(source string, internalFilename string) (string, string, error) {
+	if strings.HasPrefix(source, dataURLPrefix) {
+		data, _, err := decodeDataURL(source)
+		if err != nil {
+			return "", "", &FileRetrievalError{Source: source, Err: err}
+		}
+		if err := e.sandboxCheckFileSize(source, int64(len(data))); err != nil {
+			return "", "", err
+		}
+		return source, internalFilename, nil
+	}
+
+	if isRemoteSource(source) || blobStoreFor(source) != nil {
+		return "", "", &SandboxViolationError{Reason: fmt.Sprintf("retrieving %s", source)}
+	}
+	if e.sandbox.FS == nil || !fs.ValidPath(source) {
+		return "", "", &SandboxViolationError{Reason: fmt.Sprintf("reading %s", source)}
+	}
+
+	f, err := e.sandbox.FS.Open(source)
+	if err != nil {
+		return "", "", &FileRetrievalError{Source: source, Err: err}
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if e.sandbox.MaxFileSize > 0 {
+		// Read one byte more than the limit to tell if it's exceeded
+		r = io.LimitReader(f, e.sandbox.MaxFileSize+1)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", "", &FileRetrievalError{Source: source, Err: err}
+	}
+	if err := e.sandboxCheckFileSize(source, int64(len(data))); err != nil {
+		return "", "", err
+	}
+
+	if internalFilename == "" {
+		internalFilename = path.Base(source)
+	}
+	mediaType := extensionMediaTypes[strings.ToLower(path.Ext(source))]
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	return newDataURL(mediaType, data), internalFilename, nil
+}
+
+// Check the sources of all the files in sandbox mode, including the ones added
+// before it was enabled, e.g. restored from a snapshot
+func (e *Epub) sandboxSources() error {
+	return e.mapSources(func(source string) (string, error) {
+		if source == e.cover.cssTempFile {
+			return source, nil
+		}
+		source, _, err := e.sandboxMediaSource(source, "")
+		return source, err
+	})
+}
+
+// Sanitize the notes in sandbox mode, as they may have been added before it was
+// enabled
+func (e *Epub) sandboxFootnotes() error {
+	for i, note := range e.footnotes {
+		xhtml, err := e.sandboxBody(note.xhtml)
+		if err != nil {
+			return err
+		}
+		e.footnotes[i].xhtml = xhtml
+	}
+
+	return nil
+}
+
+// Check the size of a media file against the limit of sandbox mode
+func (e *Epub) sandboxCheckFileSize(source string, size int64) error {
+	if e.sandbox.MaxFileSize > 0 && size > e.sandbox.MaxFileSize {
+		if strings.HasPrefix(source, dataURLPrefix) {
+			source = "data URL"
+		}
+		return &SandboxViolationError{Reason: fmt.Sprintf("%s is larger than %d bytes", source, e.sandbox.MaxFileSize)}
+	}
+
+	return nil
+}
+
+// Check that a section body can be added in sandbox mode and sanitize it
+func (e *Epub) sandboxSection(body string) (string, error) {
+	if e.sandbox.MaxSections > 0 && len(e.sections) >= e.sandbox.MaxSections {
+		return "", &SandboxViolationError{Reason: fmt.Sprintf("more than %d sections", e.sandbox.MaxSections)}
+	}
+
+	return e.sandboxBody(body)
+}
+
+// Check the size of a section body and sanitize it
+func (e *Epub) sandboxBody(body string) (string, error) {
+	if e.sandbox.MaxSectionSize > 0 && len(body) > e.sandbox.MaxSectionSize {
+		return "", &SandboxViolationError{Reason: fmt.Sprintf("section larger than %d bytes", e.sandbox.MaxSectionSize)}
+	}
+
+	return sanitizeBody(body), nil
+}
+
+// Remove scripts, embedded content, forms, event handler attributes and
+// scripting URLs from XHTML. The markup is tokenized as an XML parser reads
+// it, and the start tags are written again from their tokens so that only the
+// attributes that were checked are kept. The markup that can't be tokenized,
+// e.g. <img/onerror=...>, is escaped along with everything after it.
+func sanitizeBody(body string) string {
+	d := xml.NewDecoder(strings.NewReader(body))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	b := &strings.Builder{}
+	// The element being removed and how deeply it's nested in itself
+	removed := ""
+	depth := 0
+	for {
+		offset := d.InputOffset()
+		// Raw tokens keep the prefixes of the names and aren't auto-closed
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if removed == "" {
+				b.WriteString(escapeText(body[offset:]))
+			}
+			break
+		}
+		// Empty for the end of a self-closing element
+		raw := body[offset:d.InputOffset()]
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if removed == "" && sandboxRemovedElements[name] {
+				removed = name
+			}
+			if removed != "" {
+				if name == removed {
+					depth++
+				}
+				continue
+			}
+			// Keep how the tag was closed, e.g. <br />
+			end := ">"
+			if strings.HasSuffix(raw, "/>") {
+				end = raw[len(strings.TrimRight(raw[:len(raw)-2], " \t\r\n")):]
+			}
+			b.WriteString(sanitizeStartTag(t, end))
+		case xml.EndElement:
+			if removed != "" {
+				if strings.ToLower(t.Name.Local) == removed {
+					depth--
+				}
+				if depth == 0 {
+					removed = ""
+				}
+				continue
+			}
+			b.WriteString(raw)
+		case xml.CharData, xml.Comment:
+			if removed == "" {
+				b.WriteString(raw)
+			}
+		}
+		// Processing instructions and directives are dropped
+	}
+
+	return b.String()
+}
+
+// Write a start tag without its event handler attributes and scripting URLs,
+// ending it with the provided end, e.g. />
+func sanitizeStartTag(t xml.StartElement, end string) string {
+	b := &strings.Builder{}
+	b.WriteString("<" + xmlQualifiedName(t.Name))
+	for _, attr := range t.Attr {
+		local := strings.ToLower(attr.Name.Local)
+		if strings.HasPrefix(local, "on") {
+			continue
+		}
+		// Reading systems ignore whitespace and control characters in URL
+		// schemes
+		if sandboxURLAttributes[local] && scriptURLSchemeRegexp.MatchString(urlIgnoredCharsRegexp.ReplaceAllString(attr.Value, "")) {
+			continue
+		}
+		fmt.Fprintf(b, ` %s="%s"`, xmlQualifiedName(attr.Name), escapeAttribute(attr.Value))
+	}
+	b.WriteString(end)
+
+	return b.String()
+}
+
+// Get the name of a raw token with its prefix, e.g. xlink:href
+func xmlQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,162 @@
+package epub
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSandboxMediaSources(t *testing.T) {
+	image, err := ioutil.ReadFile(testImageFromFileSource)
+	if err != nil {
+		t.Fatalf("Unexpected error reading image: %s", err)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.SetSandbox(&SandboxOptions{
+		FS:          fstest.MapFS{"images/gopher.png": {Data: image}},
+		MaxFileSize: int64(len(image)),
+	})
+
+	imagePath, err := e.AddImage("images/gopher.png", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image from the sandbox file system: %s", err)
+	}
+	if imagePath != filepath.Join("..", ImageFolderName, "gopher.png") {
+		t.Errorf("Image path doesn't match\nGot: %s\nExpected: %s", imagePath, filepath.Join("..", ImageFolderName, "gopher.png"))
+	}
+	if _, err := e.AddImage(newDataURL("image/png", image), ""); err != nil {
+		t.Errorf("Unexpected error adding image from a data URL: %s", err)
+	}
+
+	for _, source := range []string{
+		testImageFromURLSource,
+		"../images/gopher.png",
+		"/images/gopher.png",
+		newDataURL("image/png", append(image, 0)),
+	} {
+		_, err := e.AddImage(source, "")
+		if _, ok := err.(*SandboxViolationError); !ok {
+			t.Errorf("Expected error adding image %.50s\nGot: %v\nExpected: SandboxViolationError", source, err)
+		}
+	}
+
+	// Local files outside of the sandbox file system can't be read
+	_, err = e.AddImage(testImageFromFileSource, "")
+	if _, ok := err.(*FileRetrievalError); !ok {
+		t.Errorf("Expected error adding image %s\nGot: %v\nExpected: FileRetrievalError", testImageFromFileSource, err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, ImageFolderName, "gopher.png"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading image file from EPUB: %s", err)
+	}
+	if !bytes.Equal(contents, image) {
+		t.Errorf("Image file contents don't match")
+	}
+}
+
+func TestSandboxSections(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetSandbox(&SandboxOptions{MaxSections: 2, MaxSectionSize: 200})
+
+	body := `<p onclick="steal()">One</p><script>steal()</script><iframe src="https://example.com"></iframe><a href=" jav&#x61;script:steal()">Two</a><img src="x.png" onerror='steal()' />`
+	filename, err := e.AddSection(body, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	expected := `<p>One</p><a>Two</a><img src="x.png" />`
+	if output := e.sections[0].xhtml.xml.Body.XML; trimAllSpace(output) != trimAllSpace(expected) {
+		t.Errorf("Sanitized section body doesn't match\nGot: %s\nExpected: %s", output, expected)
+	}
+
+	if _, err := e.AddSection(strings.Repeat("a", 201), testSectionTitle, "", ""); err == nil {
+		t.Errorf("Expected error adding section larger than the limit")
+	}
+	if _, err := e.AddSectionWriter(testSectionTitle, "", func(w io.Writer) error { return nil }); err == nil {
+		t.Errorf("Expected error adding streamed section")
+	}
+
+	tmpl := template.Must(template.New("").Parse(`<p>{{.}}</p><script>steal()</script>`))
+	if _, err := e.AddSectionTemplate(testSectionTitle, tmpl, "Three", ""); err != nil {
+		t.Fatalf("Unexpected error adding section template: %s", err)
+	}
+	if _, err := e.AddSection(testSectionBody, testSectionTitle, "", ""); err == nil {
+		t.Errorf("Expected error adding more sections than the limit")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "section0002.xhtml"))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	if strings.Contains(string(contents), "script") || !strings.Contains(string(contents), "<p>Three</p>") {
+		t.Errorf("Rendered template wasn't sanitized\nGot: %s", contents)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename)); err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+}
+
+func TestSandboxFootnotes(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddSection(`<p>Text<a href="#note1">1</a><a href="#note2">2</a></p>`, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	// Notes added before sandbox mode is enabled, e.g. restored from a
+	// snapshot, are sanitized when the EPUB is written
+	if err := e.AddFootnote(filename, "note1", `<p>One</p><script>alert(1)</script>`); err != nil {
+		t.Fatalf("Unexpected error adding note: %s", err)
+	}
+	e.SetSandbox(&SandboxOptions{})
+	if err := e.AddFootnote(filename, "note2", `<p>Two</p><script>alert(2)</script><img src="x" onerror="alert(3)"/>`); err != nil {
+		t.Fatalf("Unexpected error adding note: %s", err)
+	}
+	expected := `<p>Two</p><img src="x"/>`
+	if output := e.footnotes[1].xhtml; output != expected {
+		t.Errorf("Sanitized note doesn't match\nGot: %s\nExpected: %s", output, expected)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	if strings.Contains(string(contents), "alert") || !strings.Contains(string(contents), "<p>One</p>") {
+		t.Errorf("Notes weren't sanitized\nGot: %s", contents)
+	}
+}
+
+func TestSanitizeBody(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{`<p onclick=steal>One</p>`, `<p>One</p>`},
+		// Markup that can't be tokenized is escaped
+		{`<p>One</p><img/onerror=steal() src=x>`, `<p>One</p>&lt;img/onerror=steal() src=x&gt;`},
+		{`<img src="x.png"/><br />`, `<img src="x.png"/><br />`},
+		{`<SCRIPT>steal()</SCRIPT><svg:script>steal()</svg:script><p>Two</p>`, `<p>Two</p>`},
+		{`<object><object></object>fallback</object>Three`, `Three`},
+		{`<use xlink:href="java&#x09;script:steal()"/><a href="#x" title="a&amp;b">x</a>`, `<use/><a href="#x" title="a&amp;b">x</a>`},
+	}
+	for _, test := range tests {
+		if output := sanitizeBody(test.body); output != test.expected {
+			t.Errorf("Sanitized body doesn't match\nGot: %s\nExpected: %s", output, test.expected)
+		}
+	}
+}
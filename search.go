@@ -0,0 +1,165 @@
+package epub
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// SearchIndex is a full-text inverted index of the sections of an EPUB. It is
+// meant to be shipped alongside the EPUB so reading apps can search the book
+// without parsing the XHTML content at runtime.
+//
+// Terms are lowercased words; each term maps to the sections (and the closest
+// preceding element ID within the section, if any) where it occurs.
+type SearchIndex struct {
+	Sections []SearchIndexSection        `json:"sections"`
+	Terms    map[string][]SearchIndexHit `json:"terms"`
+}
+
+// SearchIndexSection describes a section referenced by the search index.
+type SearchIndexSection struct {
+	// Path to the section relative to the package file, e.g. xhtml/section0001.xhtml
+	Href  string `json:"href"`
+	Title string `json:"title,omitempty"`
+}
+
+// SearchIndexHit is a single location of a term in the EPUB.
+type SearchIndexHit struct {
+	Section int    `json:"section"`          // Index in SearchIndex.Sections
+	Anchor  string `json:"anchor,omitempty"` // ID of the closest preceding element
+	Count   int    `json:"count"`            // Number of occurrences at this location
+}
+
+// Elements whose text content shouldn't be indexed
+var searchIndexSkippedElements = map[string]bool{
+	"script": true,
+	"style":  true,
+}
+
+// SearchIndex builds a full-text search index of the EPUB sections.
+func (e *Epub) SearchIndex() (*SearchIndex, error) {
+	index := &SearchIndex{
+		Terms: make(map[string][]SearchIndexHit),
+	}
+
+	for _, section := range e.sections {
+		sectionIndex := len(index.Sections)
+		index.Sections = append(index.Sections, SearchIndexSection{
+			Href:  filepath.ToSlash(filepath.Join(xhtmlFolderName, section.filename)),
+			Title: section.xhtml.Title(),
+		})
+
+		err := walkSectionText(section.xhtml.xml.Body.XML, func(anchor string, text string) {
+			for _, term := range searchTerms(text) {
+				index.addHit(term, sectionIndex, anchor)
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error indexing section %q: %s", section.filename, err)
+		}
+	}
+
+	return index, nil
+}
+
+// WriteSearchIndex builds a full-text search index of the EPUB sections and
+// writes it as JSON to the provided path, which should usually be next to the
+// EPUB file (e.g. "My EPUB.epub.search.json").
+func (e *Epub) WriteSearchIndex(destFilePath string) error {
+	index, err := e.SearchIndex()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(destFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return json.NewEncoder(f).Encode(index)
+}
+
+func (index *SearchIndex) addHit(term string, section int, anchor string) {
+	hits := index.Terms[term]
+	// Hits are added in document order, so only the last one can match
+	if len(hits) > 0 {
+		last := &hits[len(hits)-1]
+		if last.Section == section && last.Anchor == anchor {
+			last.Count++
+			return
+		}
+	}
+	index.Terms[term] = append(hits, SearchIndexHit{
+		Section: section,
+		Anchor:  anchor,
+		Count:   1,
+	})
+}
+
+// Split text into lowercase search terms
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Walk the text content of a section body, calling fn for each piece of text
+// along with the ID of the closest preceding element that has one
+func walkSectionText(body string, fn func(anchor string, text string)) error {
+	d := newSectionDecoder(body)
+
+	anchor := ""
+	skipDepth := 0
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "id" && attr.Value != "" {
+					anchor = attr.Value
+				}
+			}
+			if skipDepth > 0 || searchIndexSkippedElements[t.Name.Local] {
+				skipDepth++
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+			}
+		case xml.CharData:
+			if skipDepth == 0 {
+				fn(anchor, string(t))
+			}
+		}
+	}
+}
+
+// Create a lenient XML decoder for the content of a section body. Section
+// content isn't validated, so this tolerates HTML entities and unclosed void
+// elements.
+func newSectionDecoder(body string) *xml.Decoder {
+	d := xml.NewDecoder(strings.NewReader("<body>" + body + "</body>"))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	return d
+}
@@ -0,0 +1,68 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const (
+	testSearchIndexFilename = "My EPUB.epub.search.json"
+	testSearchSectionBody   = `    <h1 id="start">Section 1</h1>
+    <p>This is a paragraph.</p>
+    <p id="second">Another paragraph &amp; more.</p>
+    <script>var ignored = true;</script>`
+)
+
+func TestSearchIndex(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSearchSectionBody, testSectionTitle, testSectionFilename, "")
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	index, err := e.SearchIndex()
+	if err != nil {
+		t.Fatalf("Unexpected error building search index: %s", err)
+	}
+
+	if len(index.Sections) != 2 || index.Sections[0].Href != "xhtml/"+testSectionFilename {
+		t.Errorf("Unexpected search index sections: %+v", index.Sections)
+	}
+
+	testHits := []SearchIndexHit{
+		{Section: 0, Anchor: "start", Count: 1},
+		{Section: 0, Anchor: "second", Count: 1},
+		{Section: 1, Anchor: "", Count: 1},
+	}
+	if !reflect.DeepEqual(index.Terms["paragraph"], testHits) {
+		t.Errorf(
+			"Search index hits don't match\n"+
+				"Got: %+v\n"+
+				"Expected: %+v",
+			index.Terms["paragraph"],
+			testHits)
+	}
+
+	if _, ok := index.Terms["ignored"]; ok {
+		t.Errorf("Script content was added to the search index")
+	}
+
+	err = e.WriteSearchIndex(testSearchIndexFilename)
+	if err != nil {
+		t.Errorf("Unexpected error writing search index: %s", err)
+	}
+	defer os.Remove(testSearchIndexFilename)
+
+	contents, err := ioutil.ReadFile(testSearchIndexFilename)
+	if err != nil {
+		t.Errorf("Unexpected error reading search index: %s", err)
+	}
+	written := &SearchIndex{}
+	if err := json.Unmarshal(contents, written); err != nil {
+		t.Errorf("Unexpected error unmarshalling search index: %s", err)
+	}
+	if !reflect.DeepEqual(written, index) {
+		t.Errorf("Written search index doesn't match")
+	}
+}
@@ -0,0 +1,91 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+const pkgSectionCreatorIDFormat = "creator%d"
+
+// SetSectionAuthor sets the author of a section, e.g. of a story in an
+// anthology, which can differ from the author of the EPUB. An empty name
+// removes the author of the section.
+//
+// The author is set in the metadata of the section file, listed as a creator
+// of the EPUB in the package metadata along with the other authors, and shown
+// after the title of the section on the page added by GenerateTOCPage, e.g.
+// "Story Title — Author Name".
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SetSectionAuthor(filename string, author string) error {
+	for _, section := range e.sections {
+		if section.filename == filename {
+			section.xhtml.setMeta(xhtmlMetaAuthor, author)
+			return nil
+		}
+	}
+
+	return &FilenameNotFoundError{Filename: filename}
+}
+
+// SectionAuthor returns the author of the section with the provided internal
+// filename, or an empty string if it has none.
+//
+// If the filename doesn't match a section that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) SectionAuthor(filename string) (string, error) {
+	for _, section := range e.sections {
+		if section.filename == filename {
+			return section.xhtml.meta(xhtmlMetaAuthor), nil
+		}
+	}
+
+	return "", &FilenameNotFoundError{Filename: filename}
+}
+
+// Get the authors of the sections other than the author of the EPUB, without
+// duplicates, in reading order
+func (e *Epub) sectionAuthors() []string {
+	authors := []string{}
+	found := map[string]bool{e.author: true}
+	for _, section := range e.sections {
+		author := section.xhtml.meta(xhtmlMetaAuthor)
+		if author != "" && !found[author] {
+			found[author] = true
+			authors = append(authors, author)
+		}
+	}
+
+	return authors
+}
+
+// Set the creators of the package other than the author of the EPUB, replacing
+// the ones previously set
+func (p *pkg) setSectionCreators(authors []string) {
+	p.xml.Metadata.SectionCreator = nil
+	for i, author := range authors {
+		// The author of the EPUB is the first creator
+		id := fmt.Sprintf(pkgSectionCreatorIDFormat, i+2)
+		p.xml.Metadata.SectionCreator = append(p.xml.Metadata.SectionCreator, pkgCreator{
+			ID:   id,
+			Data: author,
+		})
+	}
+
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Refines == pkgAuthorRefines || !strings.HasPrefix(m.Refines, pkgAuthorRefines) {
+			meta = append(meta, m)
+		}
+	}
+	for _, creator := range p.xml.Metadata.SectionCreator {
+		meta = append(meta, pkgMeta{
+			Data:     pkgAuthorData,
+			Property: pkgAuthorProperty,
+			Refines:  "#" + creator.ID,
+			Scheme:   pkgAuthorScheme,
+		})
+	}
+	p.xml.Metadata.Meta = meta
+}
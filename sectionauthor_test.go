@@ -0,0 +1,69 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetSectionAuthor(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+	tocPage, err := e.GenerateTOCPage("")
+	if err != nil {
+		t.Fatalf("Unexpected error generating TOC page: %s", err)
+	}
+	one, _ := e.AddSection("<p>One</p>", "Story One", "one.xhtml", "")
+	two, _ := e.AddSection("<p>Two</p>", "Story Two", "two.xhtml", "")
+	three, _ := e.AddSection("<p>Three</p>", "Story Three", "three.xhtml", "")
+	for filename, author := range map[string]string{one: "Ann Smith", two: "Bo <Lee>", three: "Ann Smith"} {
+		if err := e.SetSectionAuthor(filename, author); err != nil {
+			t.Fatalf("Unexpected error setting section author: %s", err)
+		}
+	}
+	if err := e.SetSectionAuthor("missing.xhtml", "Ann Smith"); err == nil {
+		t.Errorf("Expected error setting author of missing section")
+	}
+	if author, _ := e.SectionAuthor(two); author != "Bo <Lee>" {
+		t.Errorf("Section author doesn't match\nGot: %s\nExpected: %s", author, "Bo <Lee>")
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	pkg, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`<dc:creator id="creator">` + testEpubAuthor + `</dc:creator>`,
+		`<dc:creator id="creator2">Ann Smith</dc:creator>`,
+		`<dc:creator id="creator3">Bo &lt;Lee&gt;</dc:creator>`,
+		`<meta refines="#creator3" property="role" scheme="marc:relators">aut</meta>`,
+	} {
+		if !strings.Contains(string(pkg), expected) {
+			t.Errorf("Package file doesn't match\nGot: %s\nExpected to contain: %s", pkg, expected)
+		}
+	}
+	if strings.Contains(string(pkg), "creator4") {
+		t.Errorf("Duplicate section author in package file\nGot: %s", pkg)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, tocPage))
+	if err != nil {
+		t.Fatalf("Unexpected error reading TOC page: %s", err)
+	}
+	expected := `<li><a href="two.xhtml">Story Two</a> — <span class="author">Bo &lt;Lee&gt;</span></li>`
+	if !strings.Contains(string(contents), expected) {
+		t.Errorf("TOC page doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, one))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	if !strings.Contains(string(contents), `<meta name="author" content="Ann Smith"></meta>`) {
+		t.Errorf("Section file doesn't match\nGot: %s", contents)
+	}
+}
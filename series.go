@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"strconv"
+)
+
+const (
+	calibreSeriesIndexName = "calibre:series_index"
+	calibreSeriesName      = "calibre:series"
+)
+
+// Series returns the name of the series the EPUB belongs to and its position
+// in the series.
+func (e *Epub) Series() (string, float64) {
+	return e.series, e.seriesPosition
+}
+
+// SetSeries sets the series the EPUB belongs to and its position in the
+// series, e.g. 2 for the second book or 2.5 for a novella set between the
+// second and third books, so that reading systems can group the books of the
+// series. The series is written as belongs-to-collection metadata with the
+// collection type series (EPUB 3 only), as well as calibre:series and
+// calibre:series_index metadata, used by Calibre and Kobo. An empty name
+// removes the series.
+func (e *Epub) SetSeries(name string, position float64) {
+	e.series = name
+	e.seriesPosition = position
+	if name == "" {
+		e.seriesPosition = 0
+	}
+
+	index := strconv.FormatFloat(e.seriesPosition, 'f', -1, 64)
+	e.pkg.setCollection(name, index)
+	e.pkg.setNameMeta(calibreSeriesName, name)
+	if name == "" {
+		index = ""
+	}
+	e.pkg.setNameMeta(calibreSeriesIndexName, index)
+}
+
+// Set the value of a <meta> element with a name attribute, used by EPUB 2 and
+// reading systems, replacing the existing one. An empty value removes it.
+func (p *pkg) setNameMeta(name string, value string) {
+	meta := []pkgMeta{}
+	for _, m := range p.xml.Metadata.Meta {
+		if m.Name != name {
+			meta = append(meta, m)
+		}
+	}
+	if value != "" {
+		meta = append(meta, pkgMeta{
+			Name:    name,
+			Content: value,
+		})
+	}
+
+	p.xml.Metadata.Meta = meta
+}
@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetSeries(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetSeries("The Gopher Saga", 2.5)
+	if name, position := e.Series(); name != "The Gopher Saga" || position != 2.5 {
+		t.Errorf("Series doesn't match\nGot: %s, %v\nExpected: The Gopher Saga, 2.5", name, position)
+	}
+
+	for _, version := range []string{EPUBVersion3, EPUBVersion2} {
+		if err := e.SetVersion(version); err != nil {
+			t.Fatalf("Unexpected error setting version: %s", err)
+		}
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+		if err != nil {
+			t.Errorf("Unexpected error reading package file: %s", err)
+		}
+		cleanup(testEpubFilename, tempDir)
+
+		expected := []string{
+			`<meta name="calibre:series" content="The Gopher Saga"></meta>`,
+			`<meta name="calibre:series_index" content="2.5"></meta>`,
+		}
+		if version == EPUBVersion3 {
+			expected = append(expected,
+				`<meta property="belongs-to-collection" id="collection">The Gopher Saga</meta>`,
+				`<meta refines="#collection" property="collection-type">series</meta>`,
+				`<meta refines="#collection" property="group-position">2.5</meta>`,
+			)
+		}
+		for _, testMetadata := range expected {
+			if !strings.Contains(string(contents), testMetadata) {
+				t.Errorf(
+					"Package file metadata doesn't match\n"+
+						"Got: %s\n"+
+						"Expected to contain: %s",
+					contents,
+					testMetadata)
+			}
+		}
+	}
+
+	// An empty name removes the metadata
+	e.SetSeries("", 0)
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.ID == pkgCollectionID || m.Refines != "" || m.Name != "" {
+			t.Errorf("Series metadata wasn't removed\nGot: %+v", m)
+		}
+	}
+}
@@ -0,0 +1,647 @@
+package epub
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Version of the snapshot format, increased when it changes incompatibly
+const snapshotFormat = 1
+
+// UnsupportedSnapshotError is thrown by UnmarshalJSON if the snapshot was
+// created using an unsupported version of the snapshot format.
+type UnsupportedSnapshotError struct {
+	Format int // The version of the snapshot format
+}
+
+func (e *UnsupportedSnapshotError) Error() string {
+	return fmt.Sprintf("Unsupported snapshot format: %d", e.Format)
+}
+
+// The JSON snapshot of an EPUB
+type epubSnapshot struct {
+	Format int `json:"format"`
+
+	Author              string           `json:"author,omitempty"`
+	Authors             []string         `json:"authors,omitempty"`
+	Contributors        []Contributor    `json:"contributors,omitempty"`
+	Changelog           []ChangelogEntry `json:"changelog,omitempty"`
+	Description         string           `json:"description,omitempty"`
+	PubDate             *time.Time       `json:"pubDate,omitempty"`
+	OnSaleDate          *time.Time       `json:"onSaleDate,omitempty"`
+	Modified            *time.Time       `json:"modified,omitempty"`
+	Publisher           string           `json:"publisher,omitempty"`
+	Edition             string           `json:"edition,omitempty"`
+	Identifier          string           `json:"identifier"`
+	IdentifierGenerated bool             `json:"identifierGenerated,omitempty"`
+	IdentifierAdded     bool             `json:"identifierAdded,omitempty"`
+	Identifiers         []Identifier     `json:"identifiers,omitempty"`
+	Lang                string           `json:"lang"`
+	LicenseURL          string           `json:"licenseURL,omitempty"`
+	Market              string           `json:"market,omitempty"`
+	Ppd                 string           `json:"ppd,omitempty"`
+	PublicationVersion  string           `json:"publicationVersion,omitempty"`
+	Relations           []Relation       `json:"relations,omitempty"`
+	Rights              string           `json:"rights,omitempty"`
+	Series              string           `json:"series,omitempty"`
+	SeriesPosition      float64          `json:"seriesPosition,omitempty"`
+	Subtitle            string           `json:"subtitle,omitempty"`
+	Title               string           `json:"title"`
+	Version             string           `json:"version"`
+	// The package metadata, including the metadata not covered by the fields
+	// above, e.g. rendition properties
+	Prefix string    `json:"prefix,omitempty"`
+	Meta   []pkgMeta `json:"meta,omitempty"`
+	Links  []pkgLink `json:"links,omitempty"`
+
+	AutoCover        bool             `json:"autoCover,omitempty"`
+	AutoCoverSection string           `json:"autoCoverSection,omitempty"`
+	ConvertPrintCSS  bool             `json:"convertPrintCSS,omitempty"`
+	Direction        string           `json:"direction,omitempty"`
+	EmbedPolicy      EmbedPolicy      `json:"embedPolicy,omitempty"`
+	FetchConcurrency int              `json:"fetchConcurrency,omitempty"`
+	Labels           map[Label]string `json:"labels,omitempty"`
+	ObfuscateFonts   bool             `json:"obfuscateFonts,omitempty"`
+	Reproducible     bool             `json:"reproducible,omitempty"`
+	ResetCSS         bool             `json:"resetCSS,omitempty"`
+	SizeBudget       int64            `json:"sizeBudget,omitempty"`
+	Strict           bool             `json:"strict,omitempty"`
+	StripTracking    bool             `json:"stripTracking,omitempty"`
+	ValidateOnWrite  bool             `json:"validateOnWrite,omitempty"`
+	ZipOrder         ZipOrder         `json:"zipOrder,omitempty"`
+
+	// The key is the filename, the value is the source
+	Audio  map[string]string `json:"audio,omitempty"`
+	CSS    map[string]string `json:"css,omitempty"`
+	Fonts  map[string]string `json:"fonts,omitempty"`
+	Images map[string]string `json:"images,omitempty"`
+	// The key is the source of an image as it was added, the value is its
+	// filename
+	ImageSources map[string]string `json:"imageSources,omitempty"`
+	// The key is an alias, the value is the path of its file
+	Aliases map[string]string `json:"aliases,omitempty"`
+	Videos  map[string]string `json:"videos,omitempty"`
+	// The key is the path of a file to transcode, the value is the extension
+	// of its source
+	TranscodedMedia map[string]string `json:"transcodedMedia,omitempty"`
+
+	AudiobookChapters []snapshotAudiobookChapter `json:"audiobookChapters,omitempty"`
+	Cover             snapshotCover              `json:"cover"`
+	DefaultFont       *snapshotDefaultFont       `json:"defaultFont,omitempty"`
+	EndnotesFilename  string                     `json:"endnotesFilename,omitempty"`
+	FontFeatureCSS    []string                   `json:"fontFeatureCSS,omitempty"`
+	Footnotes         []snapshotFootnote         `json:"footnotes,omitempty"`
+	Foreign           map[string]snapshotForeign `json:"foreign,omitempty"`
+	MediaOverlays     map[string]snapshotOverlay `json:"mediaOverlays,omitempty"`
+	PageBreaks        []snapshotPageBreak        `json:"pageBreaks,omitempty"`
+	Sections          []snapshotSection          `json:"sections,omitempty"`
+	TOCEntries        []snapshotTOCEntry         `json:"tocEntries,omitempty"`
+	Landmarks         []snapshotLandmark         `json:"landmarks,omitempty"`
+	MapCount          int                        `json:"mapCount,omitempty"`
+	PlaceIndex        []snapshotPlace            `json:"placeIndex,omitempty"`
+	PlaceIndexPage    bool                       `json:"placeIndexPage,omitempty"`
+	VideoInfo         map[string]snapshotVideo   `json:"videoInfo,omitempty"`
+}
+
+type snapshotAudiobookChapter struct {
+	Title    string        `json:"title"`
+	Filename string        `json:"filename"`
+	Duration time.Duration `json:"duration"`
+}
+
+type snapshotCover struct {
+	CSSFilename   string `json:"cssFilename,omitempty"`
+	ImageFilename string `json:"imageFilename,omitempty"`
+	XHTMLFilename string `json:"xhtmlFilename,omitempty"`
+}
+
+type snapshotDefaultFont struct {
+	Family       string `json:"family"`
+	FontFilename string `json:"fontFilename"`
+}
+
+type snapshotFootnote struct {
+	Filename string `json:"filename"`
+	ID       string `json:"id"`
+	XHTML    string `json:"xhtml"`
+}
+
+type snapshotPageBreak struct {
+	Filename string `json:"filename"`
+	Label    string `json:"label"`
+	AnchorID string `json:"anchorID,omitempty"`
+	ID       string `json:"id"`
+}
+
+type snapshotOverlay struct {
+	AudioPath string `json:"audioPath"`
+	Clips     []Clip `json:"clips"`
+}
+
+type snapshotSection struct {
+	Filename   string            `json:"filename"`
+	Parent     string            `json:"parent,omitempty"`
+	Title      string            `json:"title,omitempty"`
+	Body       string            `json:"body"`
+	CSS        []string          `json:"css,omitempty"`
+	Meta       map[string]string `json:"meta,omitempty"`
+	XmlnsEpub  string            `json:"xmlnsEpub,omitempty"`
+	NonLinear  bool              `json:"nonLinear,omitempty"`
+	HiddenTOC  bool              `json:"hiddenTOC,omitempty"`
+	Properties []string          `json:"properties,omitempty"`
+}
+
+type snapshotForeign struct {
+	Source    string `json:"source"`
+	MediaType string `json:"mediaType"`
+	Fallback  string `json:"fallback,omitempty"`
+}
+
+type snapshotTOCEntry struct {
+	Title  string `json:"title"`
+	Href   string `json:"href"`
+	Parent string `json:"parent,omitempty"`
+}
+
+type snapshotLandmark struct {
+	Type     string `json:"type"`
+	Filename string `json:"filename"`
+	Title    string `json:"title"`
+}
+
+type snapshotPlace struct {
+	Name  string `json:"name"`
+	MapID string `json:"mapID"`
+}
+
+type snapshotVideo struct {
+	PosterPath string          `json:"posterPath,omitempty"`
+	Tracks     []snapshotTrack `json:"tracks,omitempty"`
+}
+
+type snapshotTrack struct {
+	VideoTrack
+	Path string `json:"path"`
+}
+
+// MarshalJSON saves the EPUB as JSON, including its metadata, sections,
+// options and the sources of its files, so that a partially built EPUB can be
+// persisted and resumed later or in another process using UnmarshalJSON. The
+// files aren't retrieved, so local sources must still be available when the
+// EPUB is restored.
+//
+// Functions and templates can't be saved: the sections rendered or generated
+// when the EPUB is written (e.g. by AddSectionTemplate, GenerateTOCPage or
+// AddSectionWriter) are saved with the body they had when the EPUB was last
+// written, and the back matter, embed screenshot source, emoji fallback,
+// identifier strategy, ID generator, media transcoder and text normalization
+// must be set again.
+func (e *Epub) MarshalJSON() ([]byte, error) {
+	s := epubSnapshot{
+		Format:              snapshotFormat,
+		Author:              e.author,
+		Changelog:           e.changelog,
+		Authors:             e.authors,
+		Contributors:        e.contributors,
+		Description:         e.desc,
+		Publisher:           e.publisher,
+		Edition:             e.edition,
+		Identifier:          e.Identifier(),
+		IdentifierGenerated: e.identifierGenerated,
+		IdentifierAdded:     e.uniqueIdentifierAdded,
+		Identifiers:         e.identifiers,
+		Lang:                e.lang,
+		LicenseURL:          e.licenseURL,
+		Market:              e.market,
+		Ppd:                 e.ppd,
+		PublicationVersion:  e.publicationVersion,
+		Relations:           e.relations,
+		Rights:              e.rights,
+		Series:              e.series,
+		SeriesPosition:      e.seriesPosition,
+		Subtitle:            e.subtitle,
+		Title:               e.title,
+		Version:             e.version,
+		Prefix:              e.pkg.xml.Prefix,
+		Links:               e.pkg.xml.Metadata.Link,
+		AutoCover:           e.autoCover,
+		AutoCoverSection:    e.autoCoverSection,
+		ConvertPrintCSS:     e.convertPrintCSS,
+		Direction:           e.direction,
+		EmbedPolicy:         e.embedPolicy,
+		FetchConcurrency:    e.fetchConcurrency,
+		Labels:              e.labels,
+		ObfuscateFonts:      e.obfuscateFonts,
+		ResetCSS:            e.resetCSS,
+		Reproducible:        e.reproducible,
+		SizeBudget:          e.sizeBudget,
+		Strict:              e.strict,
+		StripTracking:       e.stripTracking,
+		ValidateOnWrite:     e.validateOnWrite,
+		ZipOrder:            e.zipOrder,
+		Audio:               e.audio,
+		CSS:                 map[string]string{},
+		Fonts:               e.fonts,
+		Images:              e.images,
+		ImageSources:        e.imageSources,
+		Aliases:             e.aliases,
+		Videos:              e.videos,
+		TranscodedMedia:     e.transcodedMedia,
+		Cover: snapshotCover{
+			CSSFilename:   e.cover.cssFilename,
+			ImageFilename: e.cover.imageFilename,
+			XHTMLFilename: e.cover.xhtmlFilename,
+		},
+		EndnotesFilename: e.endnotesFilename,
+		FontFeatureCSS:   e.fontFeatureCSS,
+		MapCount:         e.mapCount,
+		VideoInfo:        map[string]snapshotVideo{},
+	}
+
+	// The modification date is set when the EPUB is written
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.Property != pkgModifiedProperty {
+			s.Meta = append(s.Meta, m)
+		}
+	}
+
+	for filename, source := range e.css {
+		// The default cover CSS is stored in a temp file, which may not exist
+		// when the EPUB is restored
+		if source == e.cover.cssTempFile {
+			source = newDataURL(mediaTypeCSS, []byte(defaultCoverCSSContent))
+		}
+		s.CSS[filename] = source
+	}
+
+	for _, chapter := range e.audiobookChapters {
+		s.AudiobookChapters = append(s.AudiobookChapters, snapshotAudiobookChapter{
+			Title:    chapter.title,
+			Filename: chapter.filename,
+			Duration: chapter.duration,
+		})
+	}
+	if !e.pubDate.IsZero() {
+		pubDate := e.pubDate
+		s.PubDate = &pubDate
+	}
+	if !e.onSaleDate.IsZero() {
+		onSaleDate := e.onSaleDate
+		s.OnSaleDate = &onSaleDate
+	}
+	if !e.modified.IsZero() {
+		modified := e.modified
+		s.Modified = &modified
+	}
+	if e.defaultFont != nil {
+		s.DefaultFont = &snapshotDefaultFont{
+			Family:       e.defaultFont.family,
+			FontFilename: e.defaultFont.fontFilename,
+		}
+	}
+	for filename, overlay := range e.mediaOverlays {
+		if s.MediaOverlays == nil {
+			s.MediaOverlays = map[string]snapshotOverlay{}
+		}
+		s.MediaOverlays[filename] = snapshotOverlay{
+			AudioPath: overlay.audioPath,
+			Clips:     overlay.clips,
+		}
+	}
+	for _, pageBreak := range e.pageBreaks {
+		s.PageBreaks = append(s.PageBreaks, snapshotPageBreak{
+			Filename: pageBreak.filename,
+			Label:    pageBreak.label,
+			AnchorID: pageBreak.anchorID,
+			ID:       pageBreak.id,
+		})
+	}
+	for _, note := range e.footnotes {
+		s.Footnotes = append(s.Footnotes, snapshotFootnote{
+			Filename: note.filename,
+			ID:       note.id,
+			XHTML:    note.xhtml,
+		})
+	}
+	for filename, foreign := range e.foreign {
+		if s.Foreign == nil {
+			s.Foreign = map[string]snapshotForeign{}
+		}
+		s.Foreign[filename] = snapshotForeign{
+			Source:    foreign.source,
+			MediaType: foreign.mediaType,
+			Fallback:  foreign.fallback,
+		}
+	}
+	for _, entry := range e.placeIndex {
+		s.PlaceIndex = append(s.PlaceIndex, snapshotPlace{
+			Name:  entry.name,
+			MapID: entry.mapID,
+		})
+	}
+	for filename, video := range e.videoInfo {
+		v := snapshotVideo{PosterPath: video.posterPath}
+		for _, track := range video.tracks {
+			v.Tracks = append(v.Tracks, snapshotTrack{
+				VideoTrack: track.VideoTrack,
+				Path:       track.path,
+			})
+		}
+		s.VideoInfo[filename] = v
+	}
+
+	for _, section := range e.sections {
+		x := section.xhtml.xml
+		ss := snapshotSection{
+			Filename:   section.filename,
+			Parent:     section.parent,
+			Title:      x.Head.Title,
+			Body:       x.Body.XML,
+			XmlnsEpub:  x.XmlnsEpub,
+			NonLinear:  section.nonLinear,
+			HiddenTOC:  section.hiddenFromTOC,
+			Properties: section.properties,
+		}
+		if section.filename == placeIndexFilename && section.generator != nil {
+			s.PlaceIndexPage = true
+		}
+		for _, link := range x.Head.Link {
+			ss.CSS = append(ss.CSS, link.Href)
+		}
+		for _, meta := range x.Head.Meta {
+			if ss.Meta == nil {
+				ss.Meta = map[string]string{}
+			}
+			ss.Meta[meta.Name] = meta.Content
+		}
+		s.Sections = append(s.Sections, ss)
+	}
+	for _, l := range e.landmarks {
+		s.Landmarks = append(s.Landmarks, snapshotLandmark{
+			Type:     l.epubType,
+			Filename: l.filename,
+			Title:    l.title,
+		})
+	}
+	for _, entry := range e.tocEntries {
+		s.TOCEntries = append(s.TOCEntries, snapshotTOCEntry{
+			Title:  entry.title,
+			Href:   entry.href,
+			Parent: entry.parent,
+		})
+	}
+
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON restores an EPUB saved using MarshalJSON, replacing the
+// content of the EPUB, e.g.:
+//
+//	e := &epub.Epub{}
+//	err := json.Unmarshal(data, e)
+//
+// If the snapshot was created using an unsupported version of the snapshot
+// format, UnsupportedSnapshotError will be returned, and if its EPUB version
+// isn't supported, UnsupportedVersionError. The defaults of NewEpub are kept
+// for the identifier, language and EPUB version if they're missing.
+func (e *Epub) UnmarshalJSON(data []byte) error {
+	s := epubSnapshot{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s.Format != snapshotFormat {
+		return &UnsupportedSnapshotError{Format: s.Format}
+	}
+	if s.Version != "" {
+		if err := validateVersion(s.Version); err != nil {
+			return err
+		}
+	}
+	// Snapshots may come from untrusted sources, e.g. NewHandler
+	for _, media := range []map[string]string{s.Audio, s.CSS, s.Fonts, s.Images, s.Videos} {
+		for filename := range media {
+			if err := validateMediaFilename(filename); err != nil {
+				return err
+			}
+		}
+	}
+	for filename, foreign := range s.Foreign {
+		if err := validateFilename(filename); err != nil {
+			return err
+		}
+		if err := validateForeignMediaType(foreign.Source, foreign.MediaType); err != nil {
+			return err
+		}
+	}
+	for _, ss := range s.Sections {
+		if err := validateFilename(ss.Filename); err != nil {
+			return err
+		}
+	}
+
+	*e = *NewEpub(s.Title)
+	e.pkg.xml.Prefix = s.Prefix
+	e.pkg.xml.Metadata.Meta = s.Meta
+	e.pkg.xml.Metadata.Link = s.Links
+
+	if s.Author != "" {
+		e.SetAuthor(s.Author)
+	}
+	e.authors = s.Authors
+	e.contributors = s.Contributors
+	e.SetDescription(s.Description)
+	if s.PubDate != nil {
+		e.SetPubDate(*s.PubDate)
+	}
+	if s.OnSaleDate != nil {
+		e.SetOnSaleDate(*s.OnSaleDate)
+	}
+	if s.Modified != nil {
+		e.modified = *s.Modified
+	}
+	e.SetPublisher(s.Publisher)
+	// The subtitle metadata is already restored, SetSubtitle replaces it
+	e.SetSubtitle(s.Subtitle)
+	if s.Identifier != "" {
+		e.SetIdentifier(s.Identifier)
+		e.identifierGenerated = s.IdentifierGenerated
+	}
+	if s.Lang != "" {
+		e.SetLang(s.Lang)
+	}
+	e.SetRights(s.Rights)
+	e.changelog = s.Changelog
+	e.edition = s.Edition
+	// The series metadata is already restored
+	e.series = s.Series
+	e.seriesPosition = s.SeriesPosition
+	e.identifiers = s.Identifiers
+	e.uniqueIdentifierAdded = s.IdentifierAdded
+	e.licenseURL = s.LicenseURL
+	e.market = s.Market
+	e.ppd = s.Ppd
+	e.pkg.setPpd(s.Ppd)
+	e.publicationVersion = s.PublicationVersion
+	// The relation metadata is already restored
+	e.relations = s.Relations
+	for _, relation := range s.Relations {
+		if relation.Type == RelationSource {
+			e.pkg.epub2Sources = append(e.pkg.epub2Sources, relation.Identifier)
+		} else {
+			e.pkg.epub2Relations = append(e.pkg.epub2Relations, relation.Identifier)
+		}
+	}
+	if s.Version != "" {
+		e.SetVersion(s.Version)
+	}
+
+	e.autoCover = s.AutoCover
+	e.autoCoverSection = s.AutoCoverSection
+	e.convertPrintCSS = s.ConvertPrintCSS
+	e.direction = s.Direction
+	e.embedPolicy = s.EmbedPolicy
+	e.SetLabels(s.Labels)
+	e.obfuscateFonts = s.ObfuscateFonts
+	e.reproducible = s.Reproducible
+	e.resetCSS = s.ResetCSS
+	e.sizeBudget = s.SizeBudget
+	e.fetchConcurrency = s.FetchConcurrency
+	e.strict = s.Strict
+	e.stripTracking = s.StripTracking
+	e.validateOnWrite = s.ValidateOnWrite
+	e.zipOrder = s.ZipOrder
+
+	for _, m := range []struct {
+		dest   map[string]string
+		source map[string]string
+	}{
+		{e.audio, s.Audio},
+		{e.css, s.CSS},
+		{e.fonts, s.Fonts},
+		{e.images, s.Images},
+		{e.imageSources, s.ImageSources},
+		{e.aliases, s.Aliases},
+		{e.videos, s.Videos},
+		{e.transcodedMedia, s.TranscodedMedia},
+	} {
+		for k, v := range m.source {
+			m.dest[k] = v
+		}
+	}
+
+	for _, chapter := range s.AudiobookChapters {
+		e.audiobookChapters = append(e.audiobookChapters, audiobookChapter{
+			title:    chapter.Title,
+			filename: chapter.Filename,
+			duration: chapter.Duration,
+		})
+	}
+	e.cover.cssFilename = s.Cover.CSSFilename
+	e.cover.imageFilename = s.Cover.ImageFilename
+	e.cover.xhtmlFilename = s.Cover.XHTMLFilename
+	if s.DefaultFont != nil {
+		e.defaultFont = &epubDefaultFont{
+			family:       s.DefaultFont.Family,
+			fontFilename: s.DefaultFont.FontFilename,
+		}
+	}
+	e.fontFeatureCSS = s.FontFeatureCSS
+	for filename, overlay := range s.MediaOverlays {
+		if e.mediaOverlays == nil {
+			e.mediaOverlays = map[string]*epubMediaOverlay{}
+		}
+		e.mediaOverlays[filename] = &epubMediaOverlay{
+			audioPath: overlay.AudioPath,
+			clips:     overlay.Clips,
+		}
+	}
+	for _, pageBreak := range s.PageBreaks {
+		e.pageBreaks = append(e.pageBreaks, epubPageBreak{
+			filename: pageBreak.Filename,
+			label:    pageBreak.Label,
+			anchorID: pageBreak.AnchorID,
+			id:       pageBreak.ID,
+		})
+	}
+	for _, note := range s.Footnotes {
+		e.footnotes = append(e.footnotes, epubFootnote{
+			filename: note.Filename,
+			id:       note.ID,
+			xhtml:    note.XHTML,
+		})
+	}
+	for filename, foreign := range s.Foreign {
+		e.foreign[filename] = &epubForeign{
+			source:    foreign.Source,
+			mediaType: foreign.MediaType,
+			fallback:  foreign.Fallback,
+		}
+	}
+	e.mapCount = s.MapCount
+	for _, entry := range s.PlaceIndex {
+		e.placeIndex = append(e.placeIndex, placeIndexEntry{
+			name:  entry.Name,
+			mapID: entry.MapID,
+		})
+	}
+	for filename, video := range s.VideoInfo {
+		v := &epubVideo{posterPath: video.PosterPath}
+		for _, track := range video.Tracks {
+			v.tracks = append(v.tracks, videoTrack{
+				VideoTrack: track.VideoTrack,
+				path:       track.Path,
+			})
+		}
+		e.videoInfo[filename] = v
+	}
+
+	for _, ss := range s.Sections {
+		x := newXhtml("")
+		x.xml.Body.XML = ss.Body
+		x.setTitle(ss.Title)
+		x.setXmlnsEpub(ss.XmlnsEpub)
+		for _, css := range ss.CSS {
+			x.addCSS(css)
+		}
+		for name, content := range ss.Meta {
+			x.xml.Head.Meta = append(x.xml.Head.Meta, xhtmlMeta{
+				Name:    name,
+				Content: content,
+			})
+		}
+		e.sections = append(e.sections, epubSection{
+			filename:      ss.Filename,
+			parent:        ss.Parent,
+			nonLinear:     ss.NonLinear,
+			hiddenFromTOC: ss.HiddenTOC,
+			properties:    ss.Properties,
+			xhtml:         x,
+		})
+		// The notes page is generated again when the EPUB is written
+		if ss.Filename == s.EndnotesFilename {
+			e.endnotesFilename = s.EndnotesFilename
+			e.sections[len(e.sections)-1].generator = e.endnotesGenerator(x)
+		}
+		// So is the place index page
+		if ss.Filename == placeIndexFilename && s.PlaceIndexPage {
+			e.sections[len(e.sections)-1].generator = e.placeIndexGenerator(x)
+		}
+	}
+	for _, l := range s.Landmarks {
+		e.landmarks = append(e.landmarks, landmark{
+			epubType: l.Type,
+			filename: l.Filename,
+			title:    l.Title,
+		})
+	}
+	for _, entry := range s.TOCEntries {
+		e.tocEntries = append(e.tocEntries, tocEntry{
+			title:  entry.Title,
+			href:   entry.Href,
+			parent: entry.Parent,
+		})
+	}
+
+	return nil
+}
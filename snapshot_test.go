@@ -0,0 +1,93 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+	e.SetLang("fr")
+	e.SetEdition("Second edition")
+	e.SetPublisher("Gopher Press")
+	e.SetPubDate(time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC))
+	e.SetPublicationVersion("2.0.0")
+	e.AddIdentifier("9780000000002", IdentifierSchemeISBN)
+	e.AddRelation(RelationIsFormatOf, "urn:isbn:9780000000019")
+	e.SetSizeBudget(1 << 20)
+	e.SetCover(testImageFromFileSource, "")
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e.AddSection("<p><img src=\""+imagePath+"\" alt=\"\" /></p>", testSectionTitle, "", "")
+	e.SetSpineItemProperties("section0002.xhtml", "page-spread-left")
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling EPUB: %s", err)
+	}
+
+	restored := &Epub{}
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+
+	restoredData, err := json.Marshal(restored)
+	if err != nil {
+		t.Fatalf("Unexpected error marshalling restored EPUB: %s", err)
+	}
+	if string(restoredData) != string(data) {
+		t.Errorf(
+			"Restored EPUB doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			restoredData,
+			data)
+	}
+
+	// The restored EPUB is written identically, except for the modification
+	// date
+	modified := regexp.MustCompile(`<meta property="dcterms:modified">[^<]*</meta>`)
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	expected, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	cleanup(testEpubFilename, tempDir)
+
+	tempDir = writeAndExtractEpub(t, restored, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+	got, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	if modified.ReplaceAllString(string(got), "") != modified.ReplaceAllString(string(expected), "") {
+		t.Errorf(
+			"Package file of the restored EPUB doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			got,
+			expected)
+	}
+
+	err = json.Unmarshal([]byte(`{"format": 99}`), restored)
+	if _, ok := err.(*UnsupportedSnapshotError); !ok {
+		t.Errorf("Expected error UnsupportedSnapshotError not returned. Returned instead: %+v", err)
+	}
+
+	err = json.Unmarshal([]byte(`{"format": 1, "version": "9"}`), restored)
+	if _, ok := err.(*UnsupportedVersionError); !ok {
+		t.Errorf("Expected error UnsupportedVersionError not returned. Returned instead: %+v", err)
+	}
+
+	// The defaults are kept for missing fields
+	if err := json.Unmarshal([]byte(`{"format": 1, "title": "t"}`), restored); err != nil {
+		t.Fatalf("Unexpected error unmarshalling EPUB: %s", err)
+	}
+	if restored.Lang() != defaultEpubLang || restored.Version() != EPUBVersion3 || restored.Identifier() == "" {
+		t.Errorf("Defaults weren't kept\nGot: %q %q %q", restored.Lang(), restored.Version(), restored.Identifier())
+	}
+}
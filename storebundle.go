@@ -0,0 +1,207 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	storeBundleCoverFilename      = "cover.jpg"
+	storeBundleCoverQuality       = 90
+	storeBundleMetadataFilename   = "metadata.csv"
+	storeBundleValidationFilename = "validation.txt"
+)
+
+// The columns of the metadata file of a store bundle
+var storeBundleMetadataColumns = []string{
+	"title", "subtitle", "authors", "publisher", "language", "identifier",
+	"isbn", "publication_date", "on_sale_date", "description", "series",
+	"series_position", "edition", "publication_version",
+}
+
+// The parts of the package file needed to find the cover image
+type storeBundlePkg struct {
+	Meta []struct {
+		Name    string `xml:"name,attr"`
+		Content string `xml:"content,attr"`
+	} `xml:"metadata>meta"`
+	Items []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+}
+
+// WriteStoreBundle writes a ZIP archive of the files that stores want along
+// with the EPUB, which upload tools can use as-is:
+//   - The EPUB, named after the archive, e.g. book.epub for book.zip
+//   - cover.jpg, the cover image as a JPEG, scaled down so that its longest
+//     side is at most coverSize pixels if coverSize is greater than 0; it's
+//     omitted if the EPUB has no cover or the cover is an SVG image
+//   - metadata.csv, a header row and a row with the metadata of the EPUB, the
+//     authors and the ISBNs being separated by semicolons
+//   - validation.txt, the problems found by Validate and the warnings of the
+//     build report, one per line
+//
+// The destination path must be the full path to the archive, including
+// filename and extension. The same errors as Write are returned, except that
+// the problems found by Validate are reported in the archive rather than
+// returned, unless validation is enabled using SetValidateOnWrite.
+//
+// Unlike Write, the EPUB and the archive are built in memory, so the size of
+// the EPUB, including its media files, must fit in memory.
+func (e *Epub) WriteStoreBundle(destFilePath string, coverSize int) error {
+	var epubBuf bytes.Buffer
+	if _, err := e.WriteTo(&epubBuf); err != nil {
+		return err
+	}
+	warnings := e.LastBuildReport().Warnings
+
+	report := ""
+	for _, err := range e.Validate() {
+		report += err.Error() + "\n"
+	}
+	for _, warning := range warnings {
+		report += warning + "\n"
+	}
+
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	add := func(name string, data []byte) {
+		w, err := z.Create(name)
+		if err != nil {
+			panic(fmt.Sprintf("Error creating store bundle file: %s", err))
+		}
+		if _, err := w.Write(data); err != nil {
+			panic(fmt.Sprintf("Error writing store bundle file: %s", err))
+		}
+	}
+
+	add(strings.TrimSuffix(filepath.Base(destFilePath), filepath.Ext(destFilePath))+".epub", epubBuf.Bytes())
+	if cover := storeBundleCover(epubBuf.Bytes(), coverSize); cover != nil {
+		add(storeBundleCoverFilename, cover)
+	}
+	add(storeBundleMetadataFilename, e.storeBundleMetadata())
+	add(storeBundleValidationFilename, []byte(report))
+	if err := z.Close(); err != nil {
+		panic(fmt.Sprintf("Error closing store bundle: %s", err))
+	}
+
+	if err := ioutil.WriteFile(destFilePath, buf.Bytes(), filePermissions); err != nil {
+		return &UnableToCreateEpubError{
+			Path: destFilePath,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// Get the metadata file of a store bundle
+func (e *Epub) storeBundleMetadata() []byte {
+	isbns := []string{}
+	for _, identifier := range e.identifiers {
+		if identifier.Scheme == IdentifierSchemeISBN {
+			isbns = append(isbns, identifier.Value)
+		}
+	}
+	pubDate, onSaleDate := "", ""
+	if !e.pubDate.IsZero() {
+		pubDate = formatW3CDate(e.pubDate)
+	}
+	if !e.onSaleDate.IsZero() {
+		onSaleDate = formatW3CDate(e.onSaleDate)
+	}
+	seriesPosition := ""
+	if e.series != "" {
+		seriesPosition = strconv.FormatFloat(e.seriesPosition, 'f', -1, 64)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(storeBundleMetadataColumns)
+	w.Write([]string{
+		e.title, e.subtitle, strings.Join(e.Authors(), "; "), e.publisher, e.lang, e.Identifier(),
+		strings.Join(isbns, "; "), pubDate, onSaleDate, e.desc, e.series,
+		seriesPosition, e.edition, e.publicationVersion,
+	})
+	w.Flush()
+
+	return buf.Bytes()
+}
+
+// Get the cover image of an EPUB as a JPEG, scaled down to the provided size,
+// or nil if it has none
+func storeBundleCover(epubData []byte, size int) []byte {
+	r, err := zip.NewReader(bytes.NewReader(epubData), int64(len(epubData)))
+	if err != nil {
+		panic(fmt.Sprintf("Error reading EPUB: %s", err))
+	}
+	read := func(name string) []byte {
+		for _, f := range r.File {
+			if f.Name == name {
+				rc, err := f.Open()
+				if err != nil {
+					panic(fmt.Sprintf("Error reading EPUB file: %s", err))
+				}
+				defer rc.Close()
+				data, err := ioutil.ReadAll(rc)
+				if err != nil {
+					panic(fmt.Sprintf("Error reading EPUB file: %s", err))
+				}
+				return data
+			}
+		}
+		return nil
+	}
+
+	var p storeBundlePkg
+	if err := xml.Unmarshal(read(path.Join(contentFolderName, pkgFilename)), &p); err != nil {
+		panic(fmt.Sprintf("Error reading package file: %s", err))
+	}
+	coverID := ""
+	for _, m := range p.Meta {
+		if m.Name == "cover" {
+			coverID = m.Content
+		}
+	}
+	var data []byte
+	for _, item := range p.Items {
+		if coverID != "" && item.ID == coverID {
+			data = read(path.Join(contentFolderName, item.Href))
+		}
+	}
+	if data == nil {
+		return nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// The cover may be an SVG image
+		return nil
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if size > 0 && (width > size || height > size) {
+		if width > height {
+			img = scaleImage(img, size, height*size/width)
+		} else {
+			img = scaleImage(img, width*size/height, size)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: storeBundleCoverQuality}); err != nil {
+		panic(fmt.Sprintf("Error encoding cover image: %s", err))
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,118 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"image/jpeg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteStoreBundle(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+	e.AddAuthor("Second author")
+	e.AddIdentifier("978-3-16-148410-0", IdentifierSchemeISBN)
+	imagePath, err := e.AddImage(testImageFromFileSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	e.SetCover(imagePath, "")
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+	bundlePath := filepath.Join(tempDir, "bundle.zip")
+	if err := e.WriteStoreBundle(bundlePath, 8); err != nil {
+		t.Fatalf("Unexpected error writing store bundle: %s", err)
+	}
+
+	r, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store bundle: %s", err)
+	}
+	defer r.Close()
+	files := map[string][]byte{}
+	names := []string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Unexpected error opening store bundle file: %s", err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Unexpected error reading store bundle file: %s", err)
+		}
+		files[f.Name] = data
+		names = append(names, f.Name)
+	}
+
+	testNames := []string{"bundle.epub", storeBundleCoverFilename, storeBundleMetadataFilename, storeBundleValidationFilename}
+	if !reflect.DeepEqual(names, testNames) {
+		t.Errorf("Store bundle files don't match\nGot: %v\nExpected: %v", names, testNames)
+	}
+
+	cover, err := jpeg.DecodeConfig(bytes.NewReader(files[storeBundleCoverFilename]))
+	if err != nil {
+		t.Errorf("Unexpected error decoding cover image: %s", err)
+	}
+	if cover.Width != 8 || cover.Height != 7 {
+		t.Errorf("Cover image size doesn't match\nGot: %dx%d\nExpected: 8x7", cover.Width, cover.Height)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(files[storeBundleMetadataFilename])).ReadAll()
+	if err != nil {
+		t.Fatalf("Unexpected error reading metadata: %s", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Metadata rows don't match\nGot: %d\nExpected: 2", len(records))
+	}
+	metadata := map[string]string{}
+	for i, column := range records[0] {
+		metadata[column] = records[1][i]
+	}
+	testMetadata := map[string]string{
+		"title":   testEpubTitle,
+		"authors": testEpubAuthor + "; Second author",
+		"isbn":    "9783161484100",
+	}
+	for column, testValue := range testMetadata {
+		if metadata[column] != testValue {
+			t.Errorf("Metadata %s doesn't match\nGot: %s\nExpected: %s", column, metadata[column], testValue)
+		}
+	}
+}
+
+func TestWriteStoreBundleNoCover(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+	bundlePath := filepath.Join(tempDir, "bundle.zip")
+	if err := e.WriteStoreBundle(bundlePath, 0); err != nil {
+		t.Fatalf("Unexpected error writing store bundle: %s", err)
+	}
+
+	r, err := zip.OpenReader(bundlePath)
+	if err != nil {
+		t.Fatalf("Unexpected error opening store bundle: %s", err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name == storeBundleCoverFilename {
+			t.Errorf("Store bundle contains a cover image but the EPUB has none")
+		}
+	}
+}
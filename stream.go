@@ -0,0 +1,48 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+)
+
+// SectionWriterError is thrown by Write if the function streaming the body of
+// a section added using AddSectionWriter returns an error.
+type SectionWriterError struct {
+	Filename string // The filename of the section
+	Err      error  // The underlying error that was thrown
+}
+
+func (e *SectionWriterError) Error() string {
+	return fmt.Sprintf("Error writing body of section %s: %+v", e.Filename, e.Err)
+}
+
+// SectionWriter writes the body of a section, i.e. the XHTML that goes between
+// the <body> tags of the section XHTML file. The content will not be
+// validated.
+type SectionWriter func(w io.Writer) error
+
+// AddSectionWriter adds a section whose body is streamed directly to the EPUB
+// by the provided function each time the EPUB is written, so that very large
+// generated sections (logs, database dumps, etc) never have to be held in
+// memory as a single string. If the function returns an error, Write will
+// return SectionWriterError.
+//
+// As the body only exists while the EPUB is written, features that work on the
+// body of sections (emoji fallback, annotations, search index, EPUB 2
+// compatibility checks, etc) ignore streamed sections.
+//
+// The title and internal filename work the same way as for AddSection. In
+// sandbox mode, SandboxViolationError is returned.
+func (e *Epub) AddSectionWriter(sectionTitle string, internalFilename string, fn SectionWriter) (string, error) {
+	if e.sandbox != nil {
+		return "", &SandboxViolationError{Reason: "streaming sections"}
+	}
+	filename, err := e.AddSection("", sectionTitle, internalFilename, "")
+	if err != nil {
+		return "", err
+	}
+
+	e.sections[len(e.sections)-1].writer = fn
+
+	return filename, nil
+}
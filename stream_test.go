@@ -0,0 +1,70 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddSectionWriter(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddSectionWriter("Log", "", func(w io.Writer) error {
+		for i := 1; i <= 3; i++ {
+			if _, err := fmt.Fprintf(w, "<p>Line %d</p>\n", i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error adding section writer: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testSectionContents := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+  <head>
+    <title>Log</title>
+  </head>
+  <body>
+<p>Line 1</p>
+<p>Line 2</p>
+<p>Line 3</p>
+
+</body>
+</html>
+`
+	if trimAllSpace(string(contents)) != trimAllSpace(testSectionContents) {
+		t.Errorf(
+			"Section file contents don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testSectionContents)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	testErr := errors.New("database unavailable")
+	e.AddSectionWriter("Broken", "", func(w io.Writer) error {
+		return testErr
+	})
+	err = e.Write(testEpubFilename)
+	if sectionErr, ok := err.(*SectionWriterError); !ok || sectionErr.Err != testErr {
+		t.Errorf("Expected error SectionWriterError not returned. Returned instead: %+v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "database unavailable") {
+		t.Errorf("SectionWriterError doesn't contain the underlying error: %s", err)
+	}
+	cleanup(testEpubFilename, tempDir)
+}
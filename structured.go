@@ -0,0 +1,273 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	faqItemTemplate = `<div class="question" itemprop="mainEntity" itemscope="itemscope" itemtype="https://schema.org/Question">
+<h2 itemprop="name">%s</h2>
+<div class="answer" itemprop="acceptedAnswer" itemscope="itemscope" itemtype="https://schema.org/Answer">
+<div itemprop="text">%s</div>
+</div>
+</div>
+`
+	faqTemplate = `<div class="faq" itemscope="itemscope" itemtype="https://schema.org/FAQPage">
+%s</div>`
+	productBrandTemplate    = "<p class=\"brand\" itemprop=\"brand\" itemscope=\"itemscope\" itemtype=\"https://schema.org/Brand\"><span itemprop=\"name\">%s</span></p>\n"
+	productCurrencyTemplate = ` <span itemprop="priceCurrency" content="%s">%s</span>`
+	productOfferTemplate    = "<p class=\"price\" itemprop=\"offers\" itemscope=\"itemscope\" itemtype=\"https://schema.org/Offer\"><span itemprop=\"price\" content=\"%s\">%s</span>%s</p>\n"
+	productSKUTemplate      = "<meta itemprop=\"sku\" content=\"%s\" />\n"
+	productTemplate         = `<div class="product" itemscope="itemscope" itemtype="https://schema.org/Product">
+<h2 itemprop="name">%s</h2>
+%s%s%s%s</div>
+`
+	productsTemplate = `<div class="products">
+%s</div>`
+	recipeDetailTemplate     = "<dt>%s</dt><dd>%s</dd>\n"
+	recipeDetailsTemplate    = "<dl class=\"details\">\n%s</dl>\n"
+	recipeDurationTemplate   = `<time itemprop="%s" datetime="%s">%s</time>`
+	recipeIngredientTemplate = "<li itemprop=\"recipeIngredient\">%s</li>\n"
+	recipeTemplate           = `<div class="recipe" itemscope="itemscope" itemtype="https://schema.org/Recipe">
+<h1 itemprop="name">%s</h1>
+%s%s%s<h2>%s</h2>
+<ul class="ingredients">
+%s</ul>
+<h2>%s</h2>
+<ol class="instructions">
+%s</ol>
+</div>`
+	recipeStepTemplate            = "<li itemprop=\"recipeInstructions\" itemscope=\"itemscope\" itemtype=\"https://schema.org/HowToStep\"><div itemprop=\"text\">%s</div></li>\n"
+	recipeYieldTemplate           = `<span itemprop="recipeYield">%s</span>`
+	structuredImageTemplate       = "<img itemprop=\"image\" src=\"%s\" alt=\"%s\" />\n"
+	structuredDescriptionTemplate = "<div class=\"description\" itemprop=\"description\">%s</div>\n"
+)
+
+// Recipe is a recipe, as used by AddRecipeSection.
+type Recipe struct {
+	Name string
+	// A description of the recipe, which must be valid XHTML, optional
+	Description string
+	// The internal path to an already-added image (as returned by AddImage),
+	// optional
+	Image string
+	// The quantity produced, e.g. 4 servings, optional
+	Yield string
+	// The preparation and cooking times, optional
+	PrepTime time.Duration
+	CookTime time.Duration
+	// The content of each ingredient and step, which must be valid XHTML that
+	// can go inside a <li> element
+	Ingredients []string
+	Steps       []string
+}
+
+// Product is a product of a listing, as used by AddProductListing.
+type Product struct {
+	Name string
+	// A description of the product, which must be valid XHTML, optional
+	Description string
+	// The internal path to an already-added image (as returned by AddImage),
+	// optional
+	Image string
+	// The brand and stock keeping unit of the product, optional
+	Brand string
+	SKU   string
+	// The price as a decimal number, e.g. 19.99, and its currency as an ISO
+	// 4217 code, e.g. USD; optional
+	Price    string
+	Currency string
+}
+
+// FAQ is a question and its answer, as used by AddFAQSection.
+type FAQ struct {
+	Question string
+	// The answer, which must be valid XHTML
+	Answer string
+}
+
+// AddRecipeSection adds a section titled by the name of a recipe, listing its
+// ingredients and its steps. The recipe is marked up using schema.org
+// microdata (https://schema.org/Recipe), so that it's kept when the content is
+// processed by other tools, and the headings of the lists and the details are
+// translated according to the language of the EPUB when it's written (see
+// SetLabels).
+//
+// The recipe is in a <div> element with the class "recipe". The details
+// (yield and times) are in a <dl> element with the class "details", the
+// ingredients in a <ul> element with the class "ingredients", and the steps in
+// an <ol> element with the class "instructions".
+//
+// The internal filename and CSS path work the same way as for AddSection.
+func (e *Epub) AddRecipeSection(recipe Recipe, internalFilename string, internalCSSPath string) (string, error) {
+	recipe.Description = e.structuredXHTML(recipe.Description)
+	recipe.Ingredients = e.structuredXHTMLList(recipe.Ingredients)
+	recipe.Steps = e.structuredXHTMLList(recipe.Steps)
+
+	return e.addStructuredSection(recipe.Name, internalFilename, internalCSSPath, func() string {
+		details := ""
+		if recipe.Yield != "" {
+			details += fmt.Sprintf(recipeDetailTemplate, escapeText(e.label(LabelYield, "")), fmt.Sprintf(recipeYieldTemplate, escapeText(recipe.Yield)))
+		}
+		for _, d := range []struct {
+			label    Label
+			property string
+			duration time.Duration
+		}{
+			{LabelPrepTime, "prepTime", recipe.PrepTime},
+			{LabelCookTime, "cookTime", recipe.CookTime},
+		} {
+			if d.duration > 0 {
+				details += fmt.Sprintf(recipeDetailTemplate, escapeText(e.label(d.label, "")), fmt.Sprintf(recipeDurationTemplate, d.property, formatISO8601Duration(d.duration), formatRecipeDuration(d.duration)))
+			}
+		}
+		if details != "" {
+			details = fmt.Sprintf(recipeDetailsTemplate, details)
+		}
+
+		ingredients := ""
+		for _, ingredient := range recipe.Ingredients {
+			ingredients += fmt.Sprintf(recipeIngredientTemplate, ingredient)
+		}
+		steps := ""
+		for _, step := range recipe.Steps {
+			steps += fmt.Sprintf(recipeStepTemplate, step)
+		}
+
+		return fmt.Sprintf(
+			recipeTemplate,
+			escapeText(recipe.Name),
+			structuredImage(recipe.Image, recipe.Name),
+			structuredDescription(recipe.Description),
+			details,
+			escapeText(e.label(LabelIngredients, "")),
+			ingredients,
+			escapeText(e.label(LabelInstructions, "")),
+			steps,
+		)
+	})
+}
+
+// AddProductListing adds a section listing products, such as a catalog. Each
+// product is marked up using schema.org microdata
+// (https://schema.org/Product) in a <div> element with the class "product",
+// its price being in a <p> element with the class "price".
+//
+// The title, internal filename and CSS path work the same way as for
+// AddSection.
+func (e *Epub) AddProductListing(products []Product, sectionTitle string, internalFilename string, internalCSSPath string) (string, error) {
+	products = append([]Product{}, products...)
+	for i := range products {
+		products[i].Description = e.structuredXHTML(products[i].Description)
+	}
+
+	return e.addStructuredSection(sectionTitle, internalFilename, internalCSSPath, func() string {
+		items := ""
+		for _, p := range products {
+			brand := ""
+			if p.Brand != "" {
+				brand = fmt.Sprintf(productBrandTemplate, escapeText(p.Brand))
+			}
+			sku := ""
+			if p.SKU != "" {
+				sku = fmt.Sprintf(productSKUTemplate, escapeAttribute(p.SKU))
+			}
+			offer := ""
+			if p.Price != "" {
+				currency := ""
+				if p.Currency != "" {
+					currency = fmt.Sprintf(productCurrencyTemplate, escapeAttribute(p.Currency), escapeText(p.Currency))
+				}
+				offer = fmt.Sprintf(productOfferTemplate, escapeAttribute(p.Price), escapeText(p.Price), currency)
+			}
+			items += fmt.Sprintf(productTemplate, escapeText(p.Name), structuredImage(p.Image, p.Name), structuredDescription(p.Description), brand+sku, offer)
+		}
+
+		return fmt.Sprintf(productsTemplate, items)
+	})
+}
+
+// AddFAQSection adds a section of frequently asked questions. The questions
+// are marked up using schema.org microdata (https://schema.org/FAQPage) in a
+// <div> element with the class "faq"; each question is a heading followed by
+// its answer, in a <div> element with the class "answer".
+//
+// The title, internal filename and CSS path work the same way as for
+// AddSection.
+func (e *Epub) AddFAQSection(questions []FAQ, sectionTitle string, internalFilename string, internalCSSPath string) (string, error) {
+	questions = append([]FAQ{}, questions...)
+	for i := range questions {
+		questions[i].Answer = e.structuredXHTML(questions[i].Answer)
+	}
+
+	return e.addStructuredSection(sectionTitle, internalFilename, internalCSSPath, func() string {
+		items := ""
+		for _, q := range questions {
+			items += fmt.Sprintf(faqItemTemplate, escapeText(q.Question), q.Answer)
+		}
+
+		return fmt.Sprintf(faqTemplate, items)
+	})
+}
+
+// Add a section whose body is generated when the EPUB is written, so that its
+// labels are translated according to the language of the EPUB
+func (e *Epub) addStructuredSection(sectionTitle string, internalFilename string, internalCSSPath string, body func() string) (string, error) {
+	filename, err := e.AddSection("", sectionTitle, internalFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		body: body,
+	}
+
+	return filename, nil
+}
+
+// Sanitize XHTML provided for a structured section in sandbox mode, as the
+// bodies of generated sections aren't
+func (e *Epub) structuredXHTML(s string) string {
+	if e.sandbox == nil {
+		return s
+	}
+	return sanitizeBody(s)
+}
+
+func (e *Epub) structuredXHTMLList(list []string) []string {
+	sanitized := make([]string, len(list))
+	for i, s := range list {
+		sanitized[i] = e.structuredXHTML(s)
+	}
+	return sanitized
+}
+
+// Get the image of a structured item, if any, using its name as the
+// alternative text
+func structuredImage(image string, name string) string {
+	if image == "" {
+		return ""
+	}
+	return fmt.Sprintf(structuredImageTemplate, escapeAttribute(image), escapeAttribute(name))
+}
+
+func structuredDescription(description string) string {
+	if description == "" {
+		return ""
+	}
+	return fmt.Sprintf(structuredDescriptionTemplate, description)
+}
+
+// Format the duration of a recipe for display, e.g. 1 h 30 min
+func formatRecipeDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	parts := []string{}
+	if h := int(d.Hours()); h > 0 {
+		parts = append(parts, fmt.Sprintf("%d h", h))
+	}
+	if m := int(d.Minutes()) % 60; m > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d min", m))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,130 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddRecipeSection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	filename, err := e.AddRecipeSection(Recipe{
+		Name:        "Crêpes",
+		Yield:       "4 portions",
+		PrepTime:    10 * time.Minute,
+		CookTime:    90 * time.Minute,
+		Ingredients: []string{"250 g de farine", "<em>3</em> œufs"},
+		Steps:       []string{"Mélanger."},
+	}, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding recipe section: %s", err)
+	}
+	// The labels are translated when the EPUB is written
+	e.SetLang("fr")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section file: %s", err)
+	}
+	for _, expected := range []string{
+		"<title>Crêpes</title>",
+		`<div class="recipe" itemscope="itemscope" itemtype="https://schema.org/Recipe">
+<h1 itemprop="name">Crêpes</h1>
+<dl class="details">
+<dt>Portions</dt><dd><span itemprop="recipeYield">4 portions</span></dd>
+<dt>Temps de préparation</dt><dd><time itemprop="prepTime" datetime="PT600S">10 min</time></dd>
+<dt>Temps de cuisson</dt><dd><time itemprop="cookTime" datetime="PT5400S">1 h 30 min</time></dd>
+</dl>
+<h2>Ingrédients</h2>
+<ul class="ingredients">
+<li itemprop="recipeIngredient">250 g de farine</li>
+<li itemprop="recipeIngredient"><em>3</em> œufs</li>
+</ul>
+<h2>Préparation</h2>
+<ol class="instructions">
+<li itemprop="recipeInstructions" itemscope="itemscope" itemtype="https://schema.org/HowToStep"><div itemprop="text">Mélanger.</div></li>
+</ol>
+</div>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf("Recipe section doesn't match\nGot: %s\nExpected to contain: %s", contents, expected)
+		}
+	}
+}
+
+func TestAddProductListing(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	_, err := e.AddProductListing([]Product{
+		{Name: "Gopher plush", Image: imagePath, Brand: "Go & Co", SKU: "GP-1", Price: "19.99", Currency: "USD"},
+		{Name: "Sticker"},
+	}, "Catalog", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding product listing: %s", err)
+	}
+	e.renderGeneratedSections()
+
+	expected := `<div class="products">
+<div class="product" itemscope="itemscope" itemtype="https://schema.org/Product">
+<h2 itemprop="name">Gopher plush</h2>
+<img itemprop="image" src="` + imagePath + `" alt="Gopher plush" />
+<p class="brand" itemprop="brand" itemscope="itemscope" itemtype="https://schema.org/Brand"><span itemprop="name">Go &amp; Co</span></p>
+<meta itemprop="sku" content="GP-1" />
+<p class="price" itemprop="offers" itemscope="itemscope" itemtype="https://schema.org/Offer"><span itemprop="price" content="19.99">19.99</span> <span itemprop="priceCurrency" content="USD">USD</span></p>
+</div>
+<div class="product" itemscope="itemscope" itemtype="https://schema.org/Product">
+<h2 itemprop="name">Sticker</h2>
+</div>
+</div>`
+	if body := e.sections[0].xhtml.xml.Body.XML; !strings.Contains(body, expected) {
+		t.Errorf("Product listing doesn't match\nGot: %s\nExpected to contain: %s", body, expected)
+	}
+}
+
+func TestAddFAQSection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetSandbox(&SandboxOptions{})
+	_, err := e.AddFAQSection([]FAQ{
+		{Question: "Is it free?", Answer: `<p onclick="steal()">Yes.</p>`},
+	}, "FAQ", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding FAQ section: %s", err)
+	}
+	e.renderGeneratedSections()
+
+	expected := `<div class="faq" itemscope="itemscope" itemtype="https://schema.org/FAQPage">
+<div class="question" itemprop="mainEntity" itemscope="itemscope" itemtype="https://schema.org/Question">
+<h2 itemprop="name">Is it free?</h2>
+<div class="answer" itemprop="acceptedAnswer" itemscope="itemscope" itemtype="https://schema.org/Answer">
+<div itemprop="text"><p>Yes.</p></div>
+</div>
+</div>
+</div>`
+	if body := e.sections[0].xhtml.xml.Body.XML; !strings.Contains(body, expected) {
+		t.Errorf("FAQ section doesn't match\nGot: %s\nExpected to contain: %s", body, expected)
+	}
+	if title := e.sections[0].xhtml.Title(); title != "FAQ" {
+		t.Errorf("FAQ section title doesn't match\nGot: %s\nExpected: %s", title, "FAQ")
+	}
+}
+
+func TestFormatRecipeDuration(t *testing.T) {
+	for _, test := range []struct {
+		duration time.Duration
+		expected string
+	}{
+		{20 * time.Second, "0 min"},
+		{45 * time.Minute, "45 min"},
+		{2 * time.Hour, "2 h"},
+		{2*time.Hour + 5*time.Minute, "2 h 5 min"},
+	} {
+		if output := formatRecipeDuration(test.duration); output != test.expected {
+			t.Errorf("Duration %s doesn't match\nGot: %s\nExpected: %s", test.duration, output, test.expected)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultCSSFilename = "default.css"
+	fontFaceTemplate   = `@font-face {
+  font-family: "%s";
+  src: url("%s");
+}
+`
+	fontFamilyTemplate = `body {
+  font-family: "%s", serif;
+}
+`
+
+	ibooksPrefix          = "ibooks"
+	ibooksPrefixURI       = "http://vocabulary.itunes.apple.com/rdf/ibooks/vocabulary-extensions-1.0/"
+	ibooksSpecifiedFonts  = "ibooks:specified-fonts"
+	specifiedFontsEnabled = "true"
+)
+
+// The default font of the EPUB, set by EmbedDefaultFont
+type epubDefaultFont struct {
+	family       string
+	fontFilename string
+}
+
+// EmbedDefaultFont embeds a font and uses it as the default font of the EPUB.
+// It adds the font file (see AddFont), declares it in the default stylesheet
+// using @font-face, applies the font family to the body of every section, and
+// sets the vendor metadata that tells reading systems (e.g. Apple Books) to
+// honor embedded fonts. The default stylesheet is linked before any section
+// stylesheet, so styles set in section stylesheets take precedence.
+//
+// The path to the font file is returned in the format:
+// ../FontFolderName/internalFilename
+//
+// The default stylesheet is stored as CSSFolderName/default.css; if a CSS file
+// with that filename has already been added, FilenameAlreadyUsedError will be
+// returned.
+func (e *Epub) EmbedDefaultFont(fontSource string, familyName string) (string, error) {
+	// The default stylesheet uses a reserved filename
+	if _, ok := e.css[defaultCSSFilename]; ok {
+		return "", &FilenameAlreadyUsedError{Filename: defaultCSSFilename}
+	}
+
+	fontPath, err := e.AddFont(fontSource, "")
+	if err != nil {
+		return "", err
+	}
+
+	e.defaultFont = &epubDefaultFont{
+		family:       familyName,
+		fontFilename: filepath.Base(fontPath),
+	}
+	e.pkg.addPrefix(ibooksPrefix, ibooksPrefixURI)
+	e.pkg.setPropertyMeta(ibooksSpecifiedFonts, specifiedFontsEnabled)
+
+	return fontPath, nil
+}
+
+// Get the content of the default stylesheet, which is generated from the
+// settings of the EPUB. If empty, no default stylesheet is written.
+func (e *Epub) defaultCSS() string {
+	css := []string{}
+
+	// Must be first so that the other styles override it
+	if e.resetCSS {
+		css = append(css, ResetCSS)
+	}
+	if e.defaultFont != nil {
+		family := strings.Replace(e.defaultFont.family, `"`, `\"`, -1)
+		css = append(css,
+			fmt.Sprintf(fontFaceTemplate, family, path.Join("..", FontFolderName, e.defaultFont.fontFilename)),
+			fmt.Sprintf(fontFamilyTemplate, family),
+		)
+	}
+
+	css = append(css, e.emojiCSS())
+	css = append(css, e.fontFeatureCSS...)
+
+	return strings.Join(css, "")
+}
+
+// Get the path to the default stylesheet relative to the EPUB section files
+func defaultCSSPath() string {
+	return path.Join("..", CSSFolderName, defaultCSSFilename)
+}
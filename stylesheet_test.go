@@ -0,0 +1,85 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testDefaultFontFamily = "Redacted Script"
+	testDefaultCSS        = `@font-face {
+  font-family: "Redacted Script";
+  src: url("../fonts/redacted-script-regular.ttf");
+}
+body {
+  font-family: "Redacted Script", serif;
+}
+`
+)
+
+func TestEmbedDefaultFont(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	testCSSPath, _ := e.AddCSS(testFontCSSSource, testFontCSSFilename)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, testCSSPath)
+
+	fontPath, err := e.EmbedDefaultFont(testFontFromFileSource, testDefaultFontFamily)
+	if err != nil {
+		t.Errorf("Unexpected error embedding default font: %s", err)
+	}
+	if fontPath != filepath.Join("..", FontFolderName, "redacted-script-regular.ttf") {
+		t.Errorf("Unexpected font path: %s", fontPath)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, defaultCSSFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading default CSS file: %s", err)
+	}
+	if string(contents) != testDefaultCSS {
+		t.Errorf(
+			"Default CSS file contents don't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			contents,
+			testDefaultCSS)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, testSectionFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	// The default stylesheet comes first so the section stylesheet takes
+	// precedence
+	defaultIndex := strings.Index(string(contents), `href="../css/default.css"`)
+	sectionIndex := strings.Index(string(contents), `href="../css/font.css"`)
+	if defaultIndex == -1 || sectionIndex == -1 || defaultIndex > sectionIndex {
+		t.Errorf(
+			"Section file stylesheets don't match\n"+
+				"Got: %s\n"+
+				"Expected: default.css linked before font.css",
+			contents)
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, expected := range []string{
+		`prefix="ibooks: http://vocabulary.itunes.apple.com/rdf/ibooks/vocabulary-extensions-1.0/"`,
+		`<meta property="ibooks:specified-fonts">true</meta>`,
+		`<item id="default.css" href="css/default.css" media-type="text/css"></item>`,
+	} {
+		if !strings.Contains(string(contents), expected) {
+			t.Errorf(
+				"Package file doesn't match\n"+
+					"Got: %s\n"+
+					"Expected to contain: %s",
+				contents,
+				expected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package epub
+
+// AddSubSection adds a section nested under an already-added section (e.g. a
+// sub-chapter of a chapter) and returns a relative path to it, as AddSection
+// does. Sub-sections can themselves have sub-sections, to any depth.
+//
+// The sub-section is nested under its parent in the table of contents (both
+// the EPUB 3 navigation document and the EPUB 2 NCX), and follows the parent
+// and its previous sub-sections in the reading order. If the parent has no
+// title, the sub-section is nested under the closest ancestor in the table of
+// contents instead.
+//
+// The internal filename of the parent section (as returned by AddSection or
+// AddSubSection) is required. If it doesn't match a section that has been
+// added, FilenameNotFoundError will be returned. The other parameters work the
+// same way as for AddSection.
+func (e *Epub) AddSubSection(parentFilename string, body string, sectionTitle string, internalFilename string, internalCSSPath string) (string, error) {
+	parentIndex := e.sectionIndex(parentFilename)
+	if parentIndex == -1 {
+		return "", &FilenameNotFoundError{Filename: parentFilename}
+	}
+
+	count := len(e.sections)
+	filename, err := e.AddSection(body, sectionTitle, internalFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	// The sections just added, including the embedded documents
+	added := append([]epubSection{}, e.sections[count:]...)
+	for i := range added {
+		added[i].parent = parentFilename
+	}
+
+	// Insert them after the parent and its descendants
+	i := parentIndex + 1
+	for i < count && e.isDescendant(e.sections[i], parentFilename) {
+		i++
+	}
+	sections := append([]epubSection{}, e.sections[:i]...)
+	sections = append(sections, added...)
+	e.sections = append(sections, e.sections[i:count]...)
+
+	return filename, nil
+}
+
+// Get the index of the section with the provided filename, or -1 if there is
+// none
+func (e *Epub) sectionIndex(filename string) int {
+	for i, section := range e.sections {
+		if section.filename == filename {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Whether a section is nested under the section with the provided filename
+func (e *Epub) isDescendant(section epubSection, ancestorFilename string) bool {
+	for section.parent != "" {
+		if section.parent == ancestorFilename {
+			return true
+		}
+		i := e.sectionIndex(section.parent)
+		if i == -1 {
+			return false
+		}
+		section = e.sections[i]
+	}
+
+	return false
+}
+
+// Get the filename of the closest ancestor of a section that is in the table
+// of contents, or an empty string if there is none
+func (e *Epub) tocParent(section epubSection) string {
+	for section.parent != "" {
+		i := e.sectionIndex(section.parent)
+		if i == -1 {
+			return ""
+		}
+		section = e.sections[i]
+		if e.inTOC(section) {
+			return section.filename
+		}
+	}
+
+	return ""
+}
+
+// Whether a section is in the table of contents
+func (e *Epub) inTOC(section epubSection) bool {
+	// Pages without titles, the cover, and the sections excluded using
+	// SetSectionInTOC aren't in the TOC
+	return section.xhtml.Title() != "" && section.filename != e.cover.xhtmlFilename && !section.hiddenFromTOC
+}
@@ -0,0 +1,77 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAddSubSection(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	one, _ := e.AddSection("<p>One</p>", "Chapter 1", "one.xhtml", "")
+	two, _ := e.AddSection("<p>Two</p>", "Chapter 2", "two.xhtml", "")
+	oneOne, err := e.AddSubSection(one, "<p>1.1</p>", "Section 1.1", "one-one.xhtml", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding sub-section: %s", err)
+	}
+	if _, err := e.AddSubSection(oneOne, "<p>1.1.1</p>", "Section 1.1.1", "one-one-one.xhtml", ""); err != nil {
+		t.Fatalf("Unexpected error adding sub-section: %s", err)
+	}
+	e.AddSubSection(one, "<p>1.2</p>", "Section 1.2", "one-two.xhtml", "")
+	e.AddSubSection(two, "<p>2.1</p>", "Section 2.1", "two-one.xhtml", "")
+	if _, err := e.AddSubSection("missing.xhtml", "<p>Missing</p>", "Missing", "", ""); err == nil {
+		t.Errorf("Expected error adding sub-section of missing section")
+	}
+
+	// Sub-sections follow their parent in the reading order
+	filenames := []string{}
+	for _, section := range e.sections {
+		filenames = append(filenames, section.filename)
+	}
+	expectedOrder := "one.xhtml one-one.xhtml one-one-one.xhtml one-two.xhtml two.xhtml two-one.xhtml"
+	if strings.Join(filenames, " ") != expectedOrder {
+		t.Errorf("Section order doesn't match\nGot: %s\nExpected: %s", strings.Join(filenames, " "), expectedOrder)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	nav, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading nav file: %s", err)
+	}
+	expectedNav := `<ol><li><a href="xhtml/one.xhtml">Chapter 1</a><ol>` +
+		`<li><a href="xhtml/one-one.xhtml">Section 1.1</a><ol><li><a href="xhtml/one-one-one.xhtml">Section 1.1.1</a></li></ol></li>` +
+		`<li><a href="xhtml/one-two.xhtml">Section 1.2</a></li></ol></li>` +
+		`<li><a href="xhtml/two.xhtml">Chapter 2</a><ol><li><a href="xhtml/two-one.xhtml">Section 2.1</a></li></ol></li></ol>`
+	// Remove the indentation
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(nav), "><"), expectedNav) {
+		t.Errorf("Nav file doesn't match\nGot: %s\nExpected to contain: %s", nav, expectedNav)
+	}
+
+	ncx, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading NCX file: %s", err)
+	}
+	// Keep only the structure of the nav points
+	structure := regexp.MustCompile(`<navPoint id="[^"]*">\s*<navLabel>\s*<text>([^<]*)</text>\s*</navLabel>\s*<content[^>]*>(?:</content>)?`).ReplaceAllString(string(ncx), "[$1")
+	structure = regexp.MustCompile(`\s*</navPoint>\s*`).ReplaceAllString(structure, "]")
+	structure = regexp.MustCompile(`\s+\[`).ReplaceAllString(structure, "[")
+	expectedNcx := "[Chapter 1[Section 1.1[Section 1.1.1]][Section 1.2]][Chapter 2[Section 2.1]]"
+	if !strings.Contains(structure, expectedNcx) {
+		t.Errorf("NCX file doesn't match\nGot: %s\nExpected to contain: %s", ncx, expectedNcx)
+	}
+
+	// Writing again doesn't duplicate the TOC
+	tempDir2 := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir2)
+	nav, err = ioutil.ReadFile(filepath.Join(tempDir2, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading nav file: %s", err)
+	}
+	if count := strings.Count(string(nav), "<li>"); count != 6 {
+		t.Errorf("Number of nav entries doesn't match\nGot: %d\nExpected: %d", count, 6)
+	}
+}
@@ -0,0 +1,87 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	svgItemProperties = "svg"
+	svgNamespace      = "http://www.w3.org/2000/svg"
+)
+
+var (
+	inlineSVGRegexp = regexp.MustCompile(`(?is)<svg\b[^>]*?(?:/>|>.*?</svg\s*>)`)
+	// foreignObject embeds arbitrary XHTML, which most reading systems don't
+	// render and which often doesn't validate
+	svgForeignObjectRegexp    = regexp.MustCompile(`(?is)<foreignObject\b[^>]*?(?:/>|>.*?</foreignObject\s*>)`)
+	svgForeignObjectTagRegexp = regexp.MustCompile(`(?i)</?foreignObject\b[^>]*>`)
+	svgURLRefRegexp           = regexp.MustCompile(`url\(\s*(['"]?)#([^'")\s]+)(['"]?)\s*\)`)
+)
+
+// Check if the body of a section contains inline SVG
+func hasInlineSVG(body string) bool {
+	return inlineSVGRegexp.MatchString(body)
+}
+
+// Sanitize the inline SVG of a section so that it validates: scripts,
+// foreignObject elements, event handler attributes and scripting URLs are
+// removed, the SVG namespace is added if missing, and the IDs of the elements
+// inside each SVG are prefixed with the section and the position of the SVG,
+// as SVG exported from the same tool usually use the same IDs. The ID of the
+// svg element itself is kept so that links to it still work.
+func processInlineSVG(sectionFilename string, body string) string {
+	prefix := strings.TrimSuffix(sectionFilename, path.Ext(sectionFilename))
+	n := 0
+
+	return inlineSVGRegexp.ReplaceAllStringFunc(body, func(svg string) string {
+		n++
+		svg = svgForeignObjectRegexp.ReplaceAllString(svg, "")
+		svg = svgForeignObjectTagRegexp.ReplaceAllString(svg, "")
+		svg = sanitizeBody(svg)
+
+		ids := map[string]string{}
+		root := true
+		svg = htmlTagRegexp.ReplaceAllStringFunc(svg, func(tag string) string {
+			if root {
+				root = false
+				if _, ok := tagAttribute(tag, "xmlns"); !ok {
+					tag = setTagAttribute(tag, "xmlns", svgNamespace)
+				}
+				return tag
+			}
+			id, ok := tagAttribute(tag, "id")
+			if !ok || id == "" {
+				return tag
+			}
+			ids[id] = fmt.Sprintf("%s-svg%d-%s", prefix, n, id)
+			return setTagAttribute(tag, "id", ids[id])
+		})
+		if len(ids) == 0 {
+			return svg
+		}
+
+		// Update the references to the renamed IDs
+		svg = svgURLRefRegexp.ReplaceAllStringFunc(svg, func(ref string) string {
+			m := svgURLRefRegexp.FindStringSubmatch(ref)
+			if id, ok := ids[m[2]]; ok {
+				return "url(" + m[1] + "#" + id + m[3] + ")"
+			}
+			return ref
+		})
+		return htmlTagRegexp.ReplaceAllStringFunc(svg, func(tag string) string {
+			for _, name := range []string{"href", "xlink:href"} {
+				value, ok := tagAttribute(tag, name)
+				if !ok || !strings.HasPrefix(value, "#") {
+					continue
+				}
+				if id, ok := ids[value[1:]]; ok {
+					tag = setTagAttribute(tag, name, "#"+id)
+				}
+			}
+			return tag
+		})
+	})
+}
@@ -0,0 +1,67 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessInlineSVG(t *testing.T) {
+	testBody := `<p>Chart</p>
+<svg id="chart"><defs><clipPath id="clip"><rect width="10" height="10"/></clipPath></defs>` +
+		`<script>alert(1)</script><foreignObject><p>HTML</p></foreignObject>` +
+		`<g clip-path="url(#clip)" onclick="alert(1)"><use xlink:href="#clip"/></g></svg>
+<svg xmlns="http://www.w3.org/2000/svg"><rect id="clip" fill="url('#clip')"/><a href="javascript:alert(1)">x</a></svg>`
+	expected := `<p>Chart</p>
+<svg id="chart" xmlns="http://www.w3.org/2000/svg"><defs><clipPath id="section0001-svg1-clip"><rect width="10" height="10"/></clipPath></defs>` +
+		`<g clip-path="url(#section0001-svg1-clip)"><use xlink:href="#section0001-svg1-clip"/></g></svg>
+<svg xmlns="http://www.w3.org/2000/svg"><rect id="section0001-svg2-clip" fill="url('#section0001-svg2-clip')"/><a>x</a></svg>`
+
+	body := processInlineSVG("section0001.xhtml", testBody)
+	if body != expected {
+		t.Errorf("Inline SVG doesn't match\nGot: %s\nExpected: %s", body, expected)
+	}
+
+	testBody = `<p>No SVG</p>`
+	if body := processInlineSVG("section0001.xhtml", testBody); body != testBody {
+		t.Errorf("Body without SVG doesn't match\nGot: %s\nExpected: %s", body, testBody)
+	}
+}
+
+func TestInlineSVGProperty(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	_, err := e.AddSection(`<svg><circle r="5"/></svg>`, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+	_, err = e.AddSection(testSectionBody, testSectionTitle, "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding section: %s", err)
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testItem := range []string{
+		`<item id="section0001.xhtml" href="xhtml/section0001.xhtml" media-type="application/xhtml+xml" properties="svg"></item>`,
+		`<item id="section0002.xhtml" href="xhtml/section0002.xhtml" media-type="application/xhtml+xml"></item>`,
+	} {
+		if !strings.Contains(string(contents), testItem) {
+			t.Errorf("Package file manifest doesn't match\nGot: %s\nExpected to contain: %s", contents, testItem)
+		}
+	}
+
+	contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, "section0001.xhtml"))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testSVG := `<svg xmlns="http://www.w3.org/2000/svg"><circle r="5"/></svg>`
+	if !strings.Contains(string(contents), testSVG) {
+		t.Errorf("Section file doesn't match\nGot: %s\nExpected to contain: %s", contents, testSVG)
+	}
+}
@@ -0,0 +1,152 @@
+package epub
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Target is a distributor that WriteFor prepares the EPUB for.
+type Target int
+
+// Distributors
+const (
+	// Apple Books
+	TargetAppleBooks Target = iota
+	// Google Play Books
+	TargetGooglePlay
+	// Kobo Writing Life
+	TargetKoboWritingLife
+)
+
+// The format of publication versions required by Apple Books, e.g. 1.0.1
+var appleBooksVersionRegexp = regexp.MustCompile(`^\d+\.\d+(\.\d+)?$`)
+
+// The settings of a target
+type targetPreset struct {
+	name string
+	// Minimum size of the shortest side of the cover image, in pixels
+	minCoverSize int
+	// Whether the vendor metadata telling the reading system to honor
+	// embedded fonts is set
+	specifiedFonts bool
+	// Whether the publication version must be in the major.minor.patch format
+	checkPublicationVersion bool
+}
+
+// The settings of each target, as required or recommended by the
+// distributors' guidelines
+var targetPresets = map[Target]targetPreset{
+	TargetAppleBooks: {
+		name:                    "Apple Books",
+		minCoverSize:            1400,
+		specifiedFonts:          true,
+		checkPublicationVersion: true,
+	},
+	TargetGooglePlay: {
+		name:         "Google Play Books",
+		minCoverSize: 640,
+	},
+	TargetKoboWritingLife: {
+		name:         "Kobo Writing Life",
+		minCoverSize: 1400,
+	},
+}
+
+// UnsupportedTargetError is thrown by WriteFor if the target isn't one of the
+// Target constants.
+type UnsupportedTargetError struct {
+	Target Target // The target that was given
+}
+
+func (e *UnsupportedTargetError) Error() string {
+	return fmt.Sprintf("Unsupported target: %d", e.Target)
+}
+
+// WriteFor writes the EPUB file as Write does, with the settings that the
+// target distributor needs, so that they're maintained here rather than in the
+// code of every application. The settings of the EPUB are restored afterwards.
+//
+// For all targets, the EPUB is written as EPUB 3 in strict mode (see
+// SetStrict), it's validated (see SetValidateOnWrite), and an image is used as
+// the cover if none was set (see SetAutoCover). A warning is added to the
+// build report (see LastBuildReport) if the cover image is smaller than the
+// target recommends: 1400 pixels on the shortest side for Apple Books and Kobo
+// Writing Life, and 640 pixels for Google Play Books.
+//
+// For Apple Books, the vendor metadata telling it to honor embedded fonts is
+// set if the EPUB has fonts, and a warning is added if the publication version
+// (see SetPublicationVersion) isn't in the major.minor.patch format.
+func (e *Epub) WriteFor(target Target, destFilePath string) error {
+	preset, ok := targetPresets[target]
+	if !ok {
+		return &UnsupportedTargetError{Target: target}
+	}
+
+	version := e.version
+	strict := e.strict
+	validateOnWrite := e.validateOnWrite
+	autoCover := e.autoCover
+	prefix := e.pkg.xml.Prefix
+	meta := append([]pkgMeta{}, e.pkg.xml.Metadata.Meta...)
+	defer func() {
+		e.version = version
+		e.strict = strict
+		e.validateOnWrite = validateOnWrite
+		e.autoCover = autoCover
+		e.pkg.xml.Prefix = prefix
+		e.pkg.xml.Metadata.Meta = meta
+		e.target = nil
+	}()
+
+	e.version = EPUBVersion3
+	e.strict = true
+	e.validateOnWrite = true
+	e.autoCover = true
+	if preset.specifiedFonts && len(e.fonts) > 0 {
+		e.pkg.addPrefix(ibooksPrefix, ibooksPrefixURI)
+		e.pkg.setPropertyMeta(ibooksSpecifiedFonts, specifiedFontsEnabled)
+	}
+	e.target = &preset
+
+	return e.Write(destFilePath)
+}
+
+// Check the EPUB against the recommendations of the target WriteFor is
+// writing it for, adding build warnings
+func (e *Epub) checkTarget(tempDir string) {
+	if e.target == nil {
+		return
+	}
+
+	if e.target.checkPublicationVersion && e.publicationVersion != "" && !appleBooksVersionRegexp.MatchString(e.publicationVersion) {
+		e.buildWarnings = append(e.buildWarnings, fmt.Sprintf(
+			"Publication version %s isn't in the major.minor.patch format required by %s",
+			e.publicationVersion, e.target.name))
+	}
+
+	if e.cover.imageFilename == "" {
+		return
+	}
+	f, err := os.Open(filepath.Join(tempDir, contentFolderName, ImageFolderName, e.cover.imageFilename))
+	if err != nil {
+		panic(fmt.Sprintf("Error opening cover image: %s", err))
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		// The cover may be an SVG image
+		return
+	}
+	size := config.Width
+	if config.Height < size {
+		size = config.Height
+	}
+	if size < e.target.minCoverSize {
+		e.buildWarnings = append(e.buildWarnings, fmt.Sprintf(
+			"Cover image %s is %dx%d, %s recommends at least %d pixels on the shortest side",
+			e.cover.imageFilename, config.Width, config.Height, e.target.name, e.target.minCoverSize))
+	}
+}
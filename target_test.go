@@ -0,0 +1,71 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFor(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetVersion(EPUBVersion2)
+	e.SetPublicationVersion("2")
+	if _, err := e.AddFont(testFontFromFileSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding font: %s", err)
+	}
+	if _, err := e.AddImage(testImageFromFileSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	e.AddSection(testSectionBody, testSectionTitle, "", "")
+
+	if err := e.WriteFor(TargetAppleBooks, testEpubFilename); err != nil {
+		t.Fatalf("Unexpected error writing EPUB: %s", err)
+	}
+	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Errorf("Unexpected error creating temp dir: %s", err)
+	}
+	defer cleanup(testEpubFilename, tempDir)
+	if err := unzipFile(testEpubFilename, tempDir); err != nil {
+		t.Errorf("Unexpected error extracting EPUB: %s", err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`version="3.0"`,
+		`<meta property="ibooks:specified-fonts">true</meta>`,
+		`<meta name="cover" content="gophercolor16x16.png"></meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+		}
+	}
+
+	testWarnings := []string{
+		"Publication version 2 isn't in the major.minor.patch format required by Apple Books",
+		"Cover image gophercolor16x16.png is 16x15, Apple Books recommends at least 1400 pixels on the shortest side",
+	}
+	for _, testWarning := range testWarnings {
+		if !strings.Contains(strings.Join(e.LastBuildReport().Warnings, "\n"), testWarning) {
+			t.Errorf("Build warnings don't match\nGot: %v\nExpected to contain: %s", e.LastBuildReport().Warnings, testWarning)
+		}
+	}
+
+	// The settings are restored
+	if e.Version() != EPUBVersion2 || e.Strict() || e.validateOnWrite || e.autoCover || e.cover.xhtmlFilename != "" {
+		t.Errorf("Settings weren't restored after writing")
+	}
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.Property == ibooksSpecifiedFonts {
+			t.Errorf("Vendor metadata wasn't restored after writing")
+		}
+	}
+
+	if err := e.WriteFor(Target(42), testEpubFilename); err == nil {
+		t.Error("Expected error writing for an unsupported target")
+	}
+}
@@ -0,0 +1,114 @@
+package epub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DiskUsageExceededError is thrown by Write, WriteTo, and Finalize if the files
+// prepared in the temp directory take more space than the limit set using
+// SetMaxDiskUsage. Nothing is written.
+type DiskUsageExceededError struct {
+	Limit int64 // The disk usage limit, in bytes
+	Usage int64 // The size of the files when the limit was exceeded, in bytes
+}
+
+func (e *DiskUsageExceededError) Error() string {
+	return fmt.Sprintf("Disk usage of %d bytes exceeds limit of %d bytes", e.Usage, e.Limit)
+}
+
+// SetTempDir sets the directory in which the temporary files are created: the
+// files of the EPUB are prepared in a temp directory while it's written, and
+// SetCover stores the default cover stylesheet in a temp file. An empty
+// directory (the default) uses os.TempDir. If the temp directory can't be
+// created in it, e.g. because it doesn't exist, the error is returned by
+// Write, WriteTo, and Finalize.
+//
+// The temp directory of a write is always removed before Write, WriteTo, or
+// Finalize return, including when they return an error or panic. The temp
+// files kept until the EPUB is written are removed by Close.
+func (e *Epub) SetTempDir(dir string) {
+	e.tempDir = dir
+}
+
+// SetMaxDiskUsage sets the maximum size in bytes of the files prepared in the
+// temp directory while the EPUB is written. The size is checked as each media
+// file is copied, so that large video or audio files can't fill the disk. A
+// limit of 0 (the default) disables it.
+func (e *Epub) SetMaxDiskUsage(maxBytes int64) {
+	e.maxDiskUsage = maxBytes
+}
+
+// Close removes the temp files kept by the EPUB until it's written, e.g. the
+// default cover stylesheet created by SetCover. It should be called once the
+// EPUB has been written, or if it won't be written, e.g. because building it
+// failed. Close can be called more than once, and the EPUB can still be
+// written afterwards: the default cover stylesheet is then kept in memory.
+func (e *Epub) Close() error {
+	if e.cover.cssTempFile == "" {
+		return nil
+	}
+	if err := os.Remove(e.cover.cssTempFile); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for filename, source := range e.css {
+		if source == e.cover.cssTempFile {
+			e.css[filename] = newDataURL(mediaTypeCSS, []byte(defaultCoverCSSContent))
+		}
+	}
+	e.cover.cssTempFile = ""
+
+	return nil
+}
+
+// Create a temp directory in the configured location
+func (e *Epub) newTempDir() (string, error) {
+	return ioutil.TempDir(e.tempDir, tempDirPrefix)
+}
+
+// Create a temp file in the configured location
+func (e *Epub) newTempFile() (*os.File, error) {
+	return ioutil.TempFile(e.tempDir, tempDirPrefix)
+}
+
+// Count a file written to the temp directory in the disk usage of the write in
+// progress
+func (e *Epub) useDisk(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	return e.setDiskUsage(e.diskUsage + info.Size())
+}
+
+// Set the disk usage of the write in progress, checking it against the limit
+func (e *Epub) setDiskUsage(usage int64) error {
+	e.diskUsage = usage
+	if e.maxDiskUsage > 0 && usage > e.maxDiskUsage {
+		return &DiskUsageExceededError{Limit: e.maxDiskUsage, Usage: usage}
+	}
+
+	return nil
+}
+
+// Check the size of all the files of the temp directory against the limit
+func (e *Epub) checkDiskUsage(tempDir string) error {
+	var usage int64
+	err := filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			usage += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Error walking temp directory: %s", err))
+	}
+
+	return e.setDiskUsage(usage)
+}
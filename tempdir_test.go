@@ -0,0 +1,90 @@
+package epub
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTempDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temporary directory: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	e := NewEpub(testEpubTitle)
+	e.SetTempDir(dir)
+	imagePath, err := e.AddImage(testImageFromFileSource, "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	e.SetCover(imagePath, "")
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Cover CSS temp file wasn't created in the temp directory\nGot: %d files\nExpected: 1 file", len(files))
+	}
+
+	if err := e.Close(); err != nil {
+		t.Errorf("Unexpected error closing EPUB: %s", err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Temp files weren't removed by Close\nGot: %d files\nExpected: 0 files", len(files))
+	}
+	// Close can be called more than once
+	if err := e.Close(); err != nil {
+		t.Errorf("Unexpected error closing EPUB again: %s", err)
+	}
+	// The EPUB can still be written once closed
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+
+	// A temp directory that can't be used is reported as an error
+	missingDir := filepath.Join(dir, "missing")
+	e = NewEpub(testEpubTitle)
+	e.SetTempDir(missingDir)
+	imagePath, _ = e.AddImage(testImageFromFileSource, "")
+	e.SetCover(imagePath, "")
+	if err := e.Write(testEpubFilename); err == nil {
+		os.Remove(testEpubFilename)
+		t.Errorf("Expected error writing EPUB with a missing temp directory")
+	}
+	if _, err := e.Finalize(); err == nil {
+		t.Errorf("Expected error finalizing EPUB with a missing temp directory")
+	}
+	// The default cover CSS is kept in memory instead
+	e.SetTempDir("")
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+
+	e = NewEpub(testEpubTitle)
+	e.SetTempDir(dir)
+	tempDir = writeAndExtractEpub(t, e, testEpubFilename)
+	cleanup(testEpubFilename, tempDir)
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Temp directory of the write wasn't removed\nGot: %d files\nExpected: 0 files", len(files))
+	}
+}
+
+func TestSetMaxDiskUsage(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	if _, err := e.AddImage(testImageFromFileSource, ""); err != nil {
+		t.Fatalf("Unexpected error adding image: %s", err)
+	}
+	e.SetMaxDiskUsage(100)
+
+	err := e.Write(testEpubFilename)
+	if _, ok := err.(*DiskUsageExceededError); !ok {
+		t.Errorf("Expected error DiskUsageExceededError not returned. Returned instead: %+v", err)
+	}
+	if _, err := os.Stat(testEpubFilename); !os.IsNotExist(err) {
+		t.Errorf("EPUB was created despite the error")
+		os.Remove(testEpubFilename)
+	}
+
+	e.SetMaxDiskUsage(1 << 20)
+	if err := e.Write(testEpubFilename); err != nil {
+		t.Errorf("Unexpected error writing EPUB: %s", err)
+	}
+	os.Remove(testEpubFilename)
+}
@@ -0,0 +1,145 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// SectionTemplateError is thrown by AddSectionFromTemplate, and by Write if the
+// template of a section added using AddSectionTemplate or of back matter can't
+// be rendered.
+type SectionTemplateError struct {
+	Filename string // The filename of the section
+	Err      error  // The underlying error that was thrown
+}
+
+func (e *SectionTemplateError) Error() string {
+	return fmt.Sprintf("Error rendering template of section %s: %+v", e.Filename, e.Err)
+}
+
+// MalformedXHTMLError is thrown by AddSectionFromTemplate if the rendered
+// template isn't well-formed XHTML.
+type MalformedXHTMLError struct {
+	Filename string // The filename of the section
+	Err      error  // The underlying error that was thrown
+}
+
+func (e *MalformedXHTMLError) Error() string {
+	return fmt.Sprintf("Malformed XHTML in section %s: %+v", e.Filename, e.Err)
+}
+
+// The template of a section, rendered when the EPUB is written
+type sectionTemplate struct {
+	tmpl *template.Template
+	data interface{}
+	// Function returning the data when the EPUB is written, used instead of
+	// data if set
+	dataFunc func() interface{}
+	// Function wrapping the rendered body, if any
+	wrap func(string) string
+}
+
+// AddSectionTemplate adds a section whose body is rendered from a template
+// each time the EPUB is written, which allows data-driven sections (catalogs,
+// reports, directories, etc) to be generated without a separate rendering
+// step. Until the EPUB is written, the body of the section is empty.
+//
+// The template must render valid XHTML that will go between the <body> tags of
+// the section XHTML file. The content will not be validated. If the template
+// can't be rendered, Write will return SectionTemplateError.
+//
+// The title and internal filename work the same way as for AddSection.
+func (e *Epub) AddSectionTemplate(sectionTitle string, tmpl *template.Template, data interface{}, internalFilename string) (string, error) {
+	filename, err := e.AddSection("", sectionTitle, internalFilename, "")
+	if err != nil {
+		return "", err
+	}
+
+	e.sections[len(e.sections)-1].template = &sectionTemplate{
+		tmpl: tmpl,
+		data: data,
+	}
+
+	return filename, nil
+}
+
+// AddSectionFromTemplate adds a section whose body is rendered from a template
+// right away, which avoids building the XHTML with fmt.Sprintf: html/template
+// escapes the data according to its context.
+//
+// The template must render the content that will go between the <body> tags
+// of the section XHTML file. SectionTemplateError is returned if the template
+// can't be rendered, and MalformedXHTMLError if the result isn't well-formed
+// XML, e.g. because of an unclosed tag or an HTML entity such as &nbsp;.
+//
+// The other parameters work the same way as for AddSection.
+func (e *Epub) AddSectionFromTemplate(sectionTitle string, tmpl *template.Template, data interface{}, internalFilename string, internalCSSPath string) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", &SectionTemplateError{
+			Filename: internalFilename,
+			Err:      err,
+		}
+	}
+	if err := checkWellFormed(b.String()); err != nil {
+		return "", &MalformedXHTMLError{
+			Filename: internalFilename,
+			Err:      err,
+		}
+	}
+
+	return e.AddSection(b.String(), sectionTitle, internalFilename, internalCSSPath)
+}
+
+// Check that the body of a section is well-formed XML
+func checkWellFormed(body string) error {
+	// The body may have more than one root element
+	d := xml.NewDecoder(strings.NewReader("<body>" + body + "</body>"))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Render the templates of the sections that have one, replacing their body
+func (e *Epub) renderSectionTemplates() error {
+	for _, section := range e.sections {
+		if section.template == nil {
+			continue
+		}
+
+		data := section.template.data
+		if section.template.dataFunc != nil {
+			data = section.template.dataFunc()
+		}
+		var b bytes.Buffer
+		if err := section.template.tmpl.Execute(&b, data); err != nil {
+			return &SectionTemplateError{
+				Filename: section.filename,
+				Err:      err,
+			}
+		}
+		body := b.String()
+		if section.template.wrap != nil {
+			body = section.template.wrap(body)
+		}
+		if e.sandbox != nil {
+			var err error
+			if body, err = e.sandboxBody(body); err != nil {
+				return err
+			}
+		}
+		section.xhtml.setBody(body)
+	}
+
+	return nil
+}
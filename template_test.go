@@ -0,0 +1,113 @@
+package epub
+
+import (
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSectionTemplate = `<h1>{{.Title}}</h1>
+<ul>
+{{range .Items}}<li>{{.}}</li>
+{{end}}</ul>`
+
+func TestAddSectionTemplate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	tmpl := template.Must(template.New("catalog").Parse(testSectionTemplate))
+	data := &struct {
+		Title string
+		Items []string
+	}{
+		Title: "Catalog",
+		Items: []string{"Apples & pears"},
+	}
+
+	filename, err := e.AddSectionTemplate("Catalog", tmpl, data, "")
+	if err != nil {
+		t.Errorf("Unexpected error adding section template: %s", err)
+	}
+
+	// The template is rendered when the EPUB is written
+	data.Items = append(data.Items, "<Oranges>")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testBody := "<h1>Catalog</h1>\n<ul>\n<li>Apples &amp; pears</li>\n<li>&lt;Oranges&gt;</li>\n</ul>"
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	e.AddSectionTemplate("Broken", template.Must(template.New("broken").Parse("{{.Missing}}")), data, "")
+	err = e.Write(testEpubFilename)
+	if _, ok := err.(*SectionTemplateError); !ok {
+		t.Errorf("Expected error SectionTemplateError not returned. Returned instead: %+v", err)
+	}
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestAddSectionFromTemplate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	tmpl := template.Must(template.New("catalog").Parse(testSectionTemplate))
+	data := &struct {
+		Title string
+		Items []string
+	}{
+		Title: "Catalog",
+		Items: []string{"Apples & pears", "<Oranges>"},
+	}
+
+	filename, err := e.AddSectionFromTemplate("Catalog", tmpl, data, "", "")
+	if err != nil {
+		t.Errorf("Unexpected error adding section from template: %s", err)
+	}
+
+	// The template is rendered when the section is added
+	data.Items = nil
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading section file: %s", err)
+	}
+	testBody := "<h1>Catalog</h1>\n<ul>\n<li>Apples &amp; pears</li>\n<li>&lt;Oranges&gt;</li>\n</ul>"
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"Section file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+
+	_, err = e.AddSectionFromTemplate("Broken", template.Must(template.New("broken").Parse("{{.Missing}}")), data, "", "")
+	if _, ok := err.(*SectionTemplateError); !ok {
+		t.Errorf("Expected error SectionTemplateError not returned. Returned instead: %+v", err)
+	}
+	for _, testTemplate := range []string{
+		"<p>{{.Title}}",
+		"<p>{{.Title}}&nbsp;</p>",
+	} {
+		_, err = e.AddSectionFromTemplate("Malformed", template.Must(template.New("malformed").Parse(testTemplate)), data, "", "")
+		if _, ok := err.(*MalformedXHTMLError); !ok {
+			t.Errorf("Expected error MalformedXHTMLError not returned for %s. Returned instead: %+v", testTemplate, err)
+		}
+	}
+	if len(e.sections) != 1 {
+		t.Errorf("Number of sections doesn't match\nGot: %d\nExpected: %d", len(e.sections), 1)
+	}
+}
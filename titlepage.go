@@ -0,0 +1,208 @@
+package epub
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// TitlePageLayout defines how the title page added by AddTitlePage is laid
+// out.
+type TitlePageLayout int
+
+// Title page layouts
+const (
+	// The title, subtitle, authors and publisher, centered (the default)
+	TitlePageLayoutCentered TitlePageLayout = iota
+	// The title and subtitle set apart from the authors and the publisher by
+	// a rule, in small capitals
+	TitlePageLayoutClassic
+	// Only the title and the authors, aligned to the left
+	TitlePageLayoutMinimal
+)
+
+const (
+	titlePageCSSContent = `.title-page {
+  margin-top: 20%;
+}
+.title-page h1.title {
+  font-size: 2em;
+  margin: 0 0 0.5em;
+}
+.title-page p.subtitle {
+  font-size: 1.3em;
+  margin: 0 0 2em;
+}
+.title-page p.author {
+  font-size: 1.2em;
+  margin: 0;
+}
+.title-page p.publisher {
+  margin-top: 4em;
+}
+.title-page-centered {
+  text-align: center;
+}
+.title-page-classic {
+  font-variant: small-caps;
+  text-align: center;
+}
+.title-page-classic hr {
+  margin: 1.5em 30%;
+}
+.title-page-minimal {
+  margin-left: 10%;
+}
+`
+	titlePageCSSFilename  = "titlepage.css"
+	titlePageFilename     = "titlepage.xhtml"
+	titlePageItemTemplate = `<p class="%s">%s</p>
+`
+	titlePageSectionTemplate = `<%s%s class="title-page%s">
+%s</%s>`
+	titlePageTitleTemplate = `<h1 class="title">%s</h1>
+`
+	titlePageEpubType = ` epub:type="titlepage"`
+	titlePageRule     = "<hr />\n"
+)
+
+// The classes of the title page layouts
+var titlePageLayoutClasses = map[TitlePageLayout]string{
+	TitlePageLayoutCentered: "title-page-centered",
+	TitlePageLayoutClassic:  "title-page-classic",
+	TitlePageLayoutMinimal:  "title-page-minimal",
+}
+
+// TitlePage is the data passed to the template of AddTitlePageTemplate, as set
+// when the EPUB is written.
+type TitlePage struct {
+	Title     string
+	Subtitle  string
+	Authors   []string
+	Publisher string
+}
+
+// AddTitlePage adds a title page to the EPUB, showing its title, subtitle
+// (see SetSubtitle), authors and publisher as set when the EPUB is written. The
+// page is placed at the start of the EPUB, after the cover and the half-title
+// page if any, and isn't in the table of contents.
+//
+// The page is a <section epub:type="titlepage"> element (a <div> for EPUB 2)
+// with the class
+// "title-page" and the class of the layout ("title-page-centered",
+// "title-page-classic" or "title-page-minimal"), containing an <h1> element
+// with the class "title" and <p> elements with the classes "subtitle",
+// "author" and "publisher". The internal path to an already-added CSS file (as
+// returned by AddCSS) to be used for the page is optional; if none is
+// provided, a default stylesheet is added to the EPUB and used.
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) AddTitlePage(layout TitlePageLayout, internalCSSPath string) (string, error) {
+	class, ok := titlePageLayoutClasses[layout]
+	if !ok {
+		class = titlePageLayoutClasses[TitlePageLayoutCentered]
+	}
+	if internalCSSPath == "" && e.sectionIndex(titlePageFilename) == -1 {
+		var err error
+		internalCSSPath, err = e.AddCSS(newDataURL(mediaTypeCSS, []byte(titlePageCSSContent)), titlePageCSSFilename)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	filename, err := e.addTitlePage(internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	e.sections[e.sectionIndex(filename)].generator = &sectionGenerator{
+		body: func() string {
+			page := e.titlePage()
+			content := fmt.Sprintf(titlePageTitleTemplate, escapeText(page.Title))
+			if page.Subtitle != "" && layout != TitlePageLayoutMinimal {
+				content += fmt.Sprintf(titlePageItemTemplate, "subtitle", escapeText(page.Subtitle))
+			}
+			if layout == TitlePageLayoutClassic {
+				content += titlePageRule
+			}
+			for _, author := range page.Authors {
+				content += fmt.Sprintf(titlePageItemTemplate, "author", escapeText(author))
+			}
+			if page.Publisher != "" && layout != TitlePageLayoutMinimal {
+				content += fmt.Sprintf(titlePageItemTemplate, "publisher", escapeText(page.Publisher))
+			}
+
+			return e.titlePageSection(" "+class, content)
+		},
+	}
+
+	return filename, nil
+}
+
+// AddTitlePageTemplate adds a title page to the EPUB like AddTitlePage, but
+// whose content is rendered from a template each time the EPUB is written,
+// with a TitlePage as data. The template must render valid XHTML, which is put
+// in a <section epub:type="titlepage"> element with the class "title-page".
+// If the template can't be rendered, Write will return SectionTemplateError.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The relative path to the page is returned, as
+// for AddSection.
+func (e *Epub) AddTitlePageTemplate(tmpl *template.Template, internalCSSPath string) (string, error) {
+	filename, err := e.addTitlePage(internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	e.sections[e.sectionIndex(filename)].template = &sectionTemplate{
+		tmpl: tmpl,
+		dataFunc: func() interface{} {
+			return e.titlePage()
+		},
+		wrap: func(body string) string {
+			return e.titlePageSection("", strings.TrimSpace(body)+"\n")
+		},
+	}
+
+	return filename, nil
+}
+
+// Add the section of the title page and move it after the half-title page, or
+// else to the start of the EPUB
+func (e *Epub) addTitlePage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", "", titlePageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+	e.sections[len(e.sections)-1].xhtml.setXmlnsEpub(xmlnsEpub)
+
+	i := e.sectionIndex(halfTitlePageFilename) + 1
+	section := e.sections[len(e.sections)-1]
+	copy(e.sections[i+1:], e.sections[i:len(e.sections)-1])
+	e.sections[i] = section
+
+	return filename, nil
+}
+
+// Get the data shown on the title page
+func (e *Epub) titlePage() TitlePage {
+	return TitlePage{
+		Title:     e.Title(),
+		Subtitle:  e.Subtitle(),
+		Authors:   e.Authors(),
+		Publisher: e.Publisher(),
+	}
+}
+
+// Put the content of the title page in its section element. EPUB 2 supports
+// neither <section> nor epub:type, so a <div> is used instead.
+func (e *Epub) titlePageSection(class string, content string) string {
+	element := "section"
+	epubType := titlePageEpubType
+	if e.version == EPUBVersion2 {
+		element = "div"
+		epubType = ""
+	}
+
+	return fmt.Sprintf(titlePageSectionTemplate, element, epubType, class, content, element)
+}
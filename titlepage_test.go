@@ -0,0 +1,176 @@
+package epub
+
+import (
+	"html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddTitlePage(t *testing.T) {
+	tests := []struct {
+		layout   TitlePageLayout
+		version  string
+		testBody string
+	}{
+		{
+			layout: TitlePageLayoutCentered,
+			testBody: `<section epub:type="titlepage" class="title-page title-page-centered">
+<h1 class="title">Gophers &amp; Co</h1>
+<p class="subtitle">A novel</p>
+<p class="author">Jane Doe</p>
+<p class="author">John Doe</p>
+<p class="publisher">Gopher Press</p>
+</section>`,
+		},
+		{
+			layout: TitlePageLayoutClassic,
+			testBody: `<section epub:type="titlepage" class="title-page title-page-classic">
+<h1 class="title">Gophers &amp; Co</h1>
+<p class="subtitle">A novel</p>
+<hr />
+<p class="author">Jane Doe</p>
+<p class="author">John Doe</p>
+<p class="publisher">Gopher Press</p>
+</section>`,
+		},
+		{
+			layout: TitlePageLayoutMinimal,
+			testBody: `<section epub:type="titlepage" class="title-page title-page-minimal">
+<h1 class="title">Gophers &amp; Co</h1>
+<p class="author">Jane Doe</p>
+<p class="author">John Doe</p>
+</section>`,
+		},
+		{
+			layout:  TitlePageLayoutMinimal,
+			version: EPUBVersion2,
+			testBody: `<div class="title-page title-page-minimal">
+<h1 class="title">Gophers &amp; Co</h1>
+<p class="author">Jane Doe</p>
+<p class="author">John Doe</p>
+</div>`,
+		},
+	}
+
+	for _, test := range tests {
+		e := NewEpub(testEpubTitle)
+		if test.version != "" {
+			e.SetVersion(test.version)
+		}
+		e.AddHalfTitlePage("")
+		_, err := e.AddSection(testSectionBody, testSectionTitle, "chapter1.xhtml", "")
+		if err != nil {
+			t.Errorf("Unexpected error adding section: %s", err)
+		}
+		filename, err := e.AddTitlePage(test.layout, "")
+		if err != nil {
+			t.Errorf("Unexpected error adding title page: %s", err)
+		}
+		e.SetTitle("Gophers & Co")
+		e.SetSubtitle("A novel")
+		e.SetAuthor("Jane Doe")
+		e.AddAuthor("John Doe")
+		e.SetPublisher("Gopher Press")
+
+		if _, err := e.AddTitlePage(test.layout, ""); err == nil {
+			t.Error("Expected error adding a second title page")
+		}
+
+		tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+		if err != nil {
+			t.Errorf("Unexpected error reading title page: %s", err)
+		}
+		if !strings.Contains(string(contents), test.testBody) {
+			t.Errorf("Title page doesn't match\nGot: %s\nExpected to contain: %s", contents, test.testBody)
+		}
+
+		contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+		if err != nil {
+			t.Errorf("Unexpected error reading package file: %s", err)
+		}
+		testSpine := `<itemref idref="halftitle.xhtml"></itemref>
+    <itemref idref="titlepage.xhtml"></itemref>
+    <itemref idref="chapter1.xhtml"></itemref>`
+		if !strings.Contains(string(contents), testSpine) {
+			t.Errorf("Package file spine doesn't match\nGot: %s\nExpected to contain: %s", contents, testSpine)
+		}
+
+		contents, err = ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+		if err != nil {
+			t.Errorf("Unexpected error reading NCX file: %s", err)
+		}
+		if strings.Contains(string(contents), filename) {
+			t.Errorf("Title page is in the table of contents\nGot: %s", contents)
+		}
+
+		cleanup(testEpubFilename, tempDir)
+	}
+}
+
+func TestAddTitlePageTemplate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor("Jane Doe")
+	tmpl := template.Must(template.New("title").Parse(`
+<h1>{{.Title}}</h1>
+{{range .Authors}}<p>{{.}}</p>{{end}}`))
+	filename, err := e.AddTitlePageTemplate(tmpl, "")
+	if err != nil {
+		t.Errorf("Unexpected error adding title page: %s", err)
+	}
+	e.SetTitle("Gophers & Co")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading title page: %s", err)
+	}
+	testBody := `<section epub:type="titlepage" class="title-page">
+<h1>Gophers &amp; Co</h1>
+<p>Jane Doe</p>
+</section>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf("Title page doesn't match\nGot: %s\nExpected to contain: %s", contents, testBody)
+	}
+}
+
+func TestSetSubtitle(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetSubtitle("Old subtitle")
+	e.SetSubtitle("A novel")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, testMetadata := range []string{
+		`<dc:title>` + testEpubTitle + `</dc:title>
+    <dc:title id="subtitle">A novel</dc:title>`,
+		`<meta refines="#subtitle" property="title-type">subtitle</meta>`,
+	} {
+		if !strings.Contains(string(contents), testMetadata) {
+			t.Errorf("Package file metadata doesn't match\nGot: %s\nExpected to contain: %s", contents, testMetadata)
+		}
+	}
+	if strings.Contains(string(contents), "Old subtitle") || strings.Count(string(contents), `refines="#subtitle"`) != 1 {
+		t.Errorf("Package file metadata contains the old subtitle\nGot: %s", contents)
+	}
+
+	e.SetSubtitle("")
+	if len(e.pkg.xml.Metadata.Subtitle) != 0 {
+		t.Error("Subtitle wasn't removed")
+	}
+	for _, m := range e.pkg.xml.Metadata.Meta {
+		if m.Refines == "#subtitle" {
+			t.Errorf("Subtitle metadata wasn't removed\nGot: %+v", m)
+		}
+	}
+}
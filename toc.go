@@ -55,17 +55,38 @@ type toc struct {
 	ncxXML *tocNcxRoot
 
 	title string // EPUB title
+	dir   string // Text direction of the navigation document
+
+	// The entries of the navigation document and the NCX, by relative path to
+	// their section, so that sub-sections can be nested under them
+	navItems     map[string]*tocNavItem
+	ncxNavPoints map[string]*tocNcxNavPoint
+
+	// The page list of the navigation document, nil if there are no pages.
+	// The page list of the NCX is part of ncxXML.
+	pageListNav     *tocPageListNav
+	pageListHeading string
+
+	// The landmarks of the navigation document, nil if there are none
+	landmarksNav     *tocLandmarksNav
+	landmarksHeading string
 }
 
 type tocNavBody struct {
-	XMLName  xml.Name     `xml:"nav"`
-	EpubType string       `xml:"epub:type,attr"`
-	H1       string       `xml:"h1"`
-	Links    []tocNavItem `xml:"ol>li"`
+	XMLName  xml.Name      `xml:"nav"`
+	EpubType string        `xml:"epub:type,attr"`
+	H1       string        `xml:"h1"`
+	Links    []*tocNavItem `xml:"ol>li"`
 }
 
 type tocNavItem struct {
 	A tocNavLink `xml:"a"`
+	// Sub-sections, nil if there are none as empty lists aren't allowed
+	Children *tocNavList `xml:"ol,omitempty"`
+}
+
+type tocNavList struct {
+	Items []*tocNavItem `xml:"li"`
 }
 
 type tocNavLink struct {
@@ -75,11 +96,13 @@ type tocNavLink struct {
 }
 
 type tocNcxRoot struct {
-	XMLName xml.Name         `xml:"http://www.daisy.org/z3986/2005/ncx/ ncx"`
-	Version string           `xml:"version,attr"`
-	Meta    tocNcxMeta       `xml:"head>meta"`
-	Title   string           `xml:"docTitle>text"`
-	NavMap  []tocNcxNavPoint `xml:"navMap>navPoint"`
+	XMLName xml.Name          `xml:"http://www.daisy.org/z3986/2005/ncx/ ncx"`
+	Version string            `xml:"version,attr"`
+	Meta    tocNcxMeta        `xml:"head>meta"`
+	Title   string            `xml:"docTitle>text"`
+	NavMap  []*tocNcxNavPoint `xml:"navMap>navPoint"`
+	// Pages of the print edition, nil if there are none
+	PageList *tocNcxPageList `xml:"pageList,omitempty"`
 }
 
 type tocNcxContent struct {
@@ -96,6 +119,8 @@ type tocNcxNavPoint struct {
 	ID      string        `xml:"id,attr"`
 	Text    string        `xml:"navLabel>text"`
 	Content tocNcxContent `xml:"content"`
+	// Sub-sections
+	Children []*tocNcxNavPoint `xml:"navPoint,omitempty"`
 }
 
 // Constructor for toc
@@ -106,6 +131,8 @@ func newToc() *toc {
 
 	t.ncxXML = newTocNcxXML()
 
+	t.reset()
+
 	return t
 }
 
@@ -146,31 +173,82 @@ func newTocNcxXML() *tocNcxRoot {
 	return n
 }
 
-// Add a section to the TOC (navXML as well as ncxXML)
-func (t *toc) addSection(index int, title string, relativePath string) {
+// Add a section to the TOC (navXML as well as ncxXML). If the relative path
+// to a parent section already in the TOC is provided, the section is nested
+// under it.
+func (t *toc) addSection(index int, title string, relativePath string, parentPath string) {
+	t.addEntry("navPoint-"+strconv.Itoa(index), title, relativePath, parentPath)
+}
+
+// Add an entry to the TOC as addSection does, with the provided NCX navPoint
+// ID. The relative path may point to a fragment of a section.
+func (t *toc) addEntry(id string, title string, relativePath string, parentPath string) {
 	relativePath = filepath.ToSlash(relativePath)
+	parentPath = filepath.ToSlash(parentPath)
 	l := &tocNavItem{
 		A: tocNavLink{
 			Href: relativePath,
 			Data: title,
 		},
 	}
-	t.navXML.Links = append(t.navXML.Links, *l)
+	if parent, ok := t.navItems[parentPath]; ok {
+		if parent.Children == nil {
+			parent.Children = &tocNavList{}
+		}
+		parent.Children.Items = append(parent.Children.Items, l)
+	} else {
+		t.navXML.Links = append(t.navXML.Links, l)
+	}
+	t.navItems[relativePath] = l
 
 	np := &tocNcxNavPoint{
-		ID:   "navPoint-" + strconv.Itoa(index),
+		ID:   id,
 		Text: title,
 		Content: tocNcxContent{
 			Src: relativePath,
 		},
 	}
-	t.ncxXML.NavMap = append(t.ncxXML.NavMap, *np)
+	if parent, ok := t.ncxNavPoints[parentPath]; ok {
+		parent.Children = append(parent.Children, np)
+	} else {
+		t.ncxXML.NavMap = append(t.ncxXML.NavMap, np)
+	}
+	t.ncxNavPoints[relativePath] = np
+}
+
+// Remove all the sections from the TOC
+func (t *toc) reset() {
+	t.navXML.Links = nil
+	t.ncxXML.NavMap = nil
+	t.navItems = map[string]*tocNavItem{}
+	t.ncxNavPoints = map[string]*tocNcxNavPoint{}
+	t.pageListNav = nil
+	t.ncxXML.PageList = nil
+	t.landmarksNav = nil
 }
 
 func (t *toc) setIdentifier(identifier string) {
 	t.ncxXML.Meta.Content = identifier
 }
 
+// Set the heading of the navigation document
+func (t *toc) setHeading(heading string) {
+	t.navXML.H1 = heading
+}
+
+// Set the heading of the page lists
+func (t *toc) setLandmarksHeading(heading string) {
+	t.landmarksHeading = heading
+}
+
+func (t *toc) setPageListHeading(heading string) {
+	t.pageListHeading = heading
+}
+
+func (t *toc) setDir(dir string) {
+	t.dir = dir
+}
+
 func (t *toc) setTitle(title string) {
 	t.title = title
 }
@@ -191,10 +269,33 @@ func (t *toc) writeNavDoc(tempDir string) {
 			err,
 			t.navXML))
 	}
+	if t.pageListNav != nil {
+		pageListContent, err := xml.MarshalIndent(t.pageListNav, "    ", "  ")
+		if err != nil {
+			panic(fmt.Sprintf(
+				"Error marshalling XML for EPUB v3 page list: %s\n"+
+					"\tXML=%#v",
+				err,
+				t.pageListNav))
+		}
+		navBodyContent = append(append(navBodyContent, "\n    "...), pageListContent...)
+	}
+	if t.landmarksNav != nil {
+		landmarksContent, err := xml.MarshalIndent(t.landmarksNav, "    ", "  ")
+		if err != nil {
+			panic(fmt.Sprintf(
+				"Error marshalling XML for EPUB v3 landmarks: %s\n"+
+					"\tXML=%#v",
+				err,
+				t.landmarksNav))
+		}
+		navBodyContent = append(append(navBodyContent, "\n    "...), landmarksContent...)
+	}
 
 	n := newXhtml(string(navBodyContent))
 	n.setXmlnsEpub(xmlnsEpub)
 	n.setTitle(t.title)
+	n.xml.Dir = t.dir
 
 	navFilePath := filepath.Join(tempDir, contentFolderName, tocNavFilename)
 	n.write(navFilePath)
@@ -0,0 +1,113 @@
+package epub
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// An entry of the table of contents pointing to a fragment of a section
+type tocEntry struct {
+	title string
+	// The internal filename of the section followed by the fragment, e.g.
+	// chapter3.xhtml#part2
+	href string
+	// The href of the parent entry or the filename of the parent section,
+	// empty to nest the entry under its section
+	parent string
+}
+
+// AddTOCEntry adds an entry to the table of contents (both the EPUB 3
+// navigation document and the EPUB 2 NCX) pointing to a fragment of an
+// already-added section, e.g. "Chapter 3 — Part II" at chapter3.xhtml#part2,
+// so that long sections can have more than one entry. The href is the internal
+// filename of the section (as returned by AddSection) followed by the
+// fragment. The element with the ID of the fragment must be in the section.
+//
+// The entry is nested under its section by default, or under the closest
+// ancestor in the table of contents if the section has no title. Otherwise,
+// the parent is the filename of a section or the href of another entry, which
+// must precede the entry in the table of contents, e.g. to nest entries under
+// each other. The entries of a section are listed after it, in the order they
+// were added.
+//
+// If the href or the parent don't match a section or an entry that has been
+// added, FilenameNotFoundError will be returned.
+func (e *Epub) AddTOCEntry(title string, href string, parent ...string) error {
+	filename := tocEntryFilename(href)
+	if e.sectionIndex(filename) == -1 {
+		return &FilenameNotFoundError{Filename: filename}
+	}
+
+	entry := tocEntry{
+		title: title,
+		href:  href,
+	}
+	if len(parent) > 0 && parent[0] != "" {
+		if e.sectionIndex(parent[0]) == -1 && e.tocEntryIndex(parent[0]) == -1 {
+			return &FilenameNotFoundError{Filename: parent[0]}
+		}
+		entry.parent = parent[0]
+	}
+	e.tocEntries = append(e.tocEntries, entry)
+
+	return nil
+}
+
+// Get the filename of the section an entry points to
+func tocEntryFilename(href string) string {
+	return strings.SplitN(href, "#", 2)[0]
+}
+
+// Get the index of the entry with the provided href, or -1 if there is none
+func (e *Epub) tocEntryIndex(href string) int {
+	for i, entry := range e.tocEntries {
+		if entry.href == href {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Add the entries pointing to a section to the TOC, once the section was added
+func (e *Epub) addTOCEntries(index int, section epubSection) {
+	n := 0
+	for _, entry := range e.tocEntries {
+		if tocEntryFilename(entry.href) != section.filename {
+			continue
+		}
+
+		parent := entry.parent
+		if parent == "" {
+			parent = section.filename
+			if !e.inTOC(section) {
+				parent = e.tocParent(section)
+			}
+		}
+		parentPath := ""
+		if parent != "" {
+			parentPath = filepath.Join(xhtmlFolderName, parent)
+		}
+
+		n++
+		e.toc.addEntry(fmt.Sprintf("navPoint-%d-%d", index, n), entry.title, filepath.Join(xhtmlFolderName, entry.href), parentPath)
+	}
+}
+
+// Remove the entries pointing to a section, moving the entries nested under
+// them or under the section back under their own section
+func (e *Epub) removeTOCEntries(filename string) {
+	entries := []tocEntry{}
+	for _, entry := range e.tocEntries {
+		if tocEntryFilename(entry.href) != filename {
+			entries = append(entries, entry)
+		}
+	}
+	for i := range entries {
+		if entries[i].parent == filename || tocEntryFilename(entries[i].parent) == filename {
+			entries[i].parent = ""
+		}
+	}
+	e.tocEntries = entries
+}
@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAddTOCEntry(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection(`<h1>Chapter 1</h1><h2 id="part1">Part I</h2><h2 id="part2">Part II</h2><h3 id="scene">Scene</h3>`, "Chapter 1", "one.xhtml", "")
+	e.AddSection(`<h2 id="intro">Introduction</h2>`, "", "two.xhtml", "")
+	if err := e.AddTOCEntry("Part I", "one.xhtml#part1"); err != nil {
+		t.Fatalf("Unexpected error adding TOC entry: %s", err)
+	}
+	e.AddTOCEntry("Part II", "one.xhtml#part2")
+	if err := e.AddTOCEntry("Scene", "one.xhtml#scene", "one.xhtml#part2"); err != nil {
+		t.Fatalf("Unexpected error adding TOC entry: %s", err)
+	}
+	// The section has no title, so the entry is at the top level
+	e.AddTOCEntry("Introduction", "two.xhtml#intro")
+
+	for _, test := range []struct {
+		href   string
+		parent string
+	}{
+		{"missing.xhtml#part1", ""},
+		{"one.xhtml#part1", "missing.xhtml"},
+		{"one.xhtml#part1", "one.xhtml#missing"},
+	} {
+		if _, ok := e.AddTOCEntry("Missing", test.href, test.parent).(*FilenameNotFoundError); !ok {
+			t.Errorf("Expected FilenameNotFoundError adding TOC entry %s under %s", test.href, test.parent)
+		}
+	}
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	nav, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNavFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading nav file: %s", err)
+	}
+	expectedNav := `<ol><li><a href="xhtml/one.xhtml">Chapter 1</a><ol>` +
+		`<li><a href="xhtml/one.xhtml#part1">Part I</a></li>` +
+		`<li><a href="xhtml/one.xhtml#part2">Part II</a><ol><li><a href="xhtml/one.xhtml#scene">Scene</a></li></ol></li></ol></li>` +
+		`<li><a href="xhtml/two.xhtml#intro">Introduction</a></li></ol>`
+	if !strings.Contains(regexp.MustCompile(`>\s+<`).ReplaceAllString(string(nav), "><"), expectedNav) {
+		t.Errorf("Nav file doesn't match\nGot: %s\nExpected to contain: %s", nav, expectedNav)
+	}
+
+	ncx, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, tocNcxFilename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading NCX file: %s", err)
+	}
+	for _, testNavPoint := range []string{
+		`<navPoint id="navPoint-0-2">`,
+		`<content src="xhtml/one.xhtml#scene"></content>`,
+		`<content src="xhtml/two.xhtml#intro"></content>`,
+	} {
+		if !strings.Contains(string(ncx), testNavPoint) {
+			t.Errorf("NCX file doesn't match\nGot: %s\nExpected to contain: %s", ncx, testNavPoint)
+		}
+	}
+
+	// The entries of a removed section are removed as well
+	e.RemoveSection("one.xhtml")
+	if len(e.tocEntries) != 1 || e.tocEntries[0].href != "two.xhtml#intro" {
+		t.Errorf("TOC entries don't match after removing section\nGot: %+v", e.tocEntries)
+	}
+}
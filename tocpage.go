@@ -0,0 +1,84 @@
+package epub
+
+import (
+	"fmt"
+)
+
+const (
+	tocPageBodyTemplate = `<h1>%s</h1>
+<ol class="toc"%s>
+%s</ol>`
+	tocPageAuthorTemplate = ` — <span class="author">%s</span>`
+	tocPageFilename       = "contents.xhtml"
+	tocPageItemTemplate   = `<li><a href="%s">%s</a>%s</li>
+`
+)
+
+// Generates the title and body of a section when the EPUB is written, so that
+// they reflect the metadata and sections of the EPUB at that time
+type sectionGenerator struct {
+	// Optional; the title is left unchanged if not set
+	title func() string
+	body  func() string
+}
+
+// GenerateTOCPage adds a visible table of contents page to the EPUB, as many
+// reading systems have a poor table of contents interface. The page lists the
+// same sections as the navigation document, with working links, and is
+// generated each time the EPUB is written so that it includes the sections
+// added afterwards. It appears in the reading order where it was added.
+//
+// The internal path to an already-added CSS file (as returned by AddCSS) to be
+// used for the page is optional. The list of sections is an <ol> element with
+// the class "toc", which can be used to style it; it's numbered using the
+// numbering system of the language of the EPUB, e.g. ١ ٢ ٣ in Arabic. The
+// authors of sections set using SetSectionAuthor follow their title in a
+// <span> element with the class "author".
+//
+// The relative path to the page is returned, as for AddSection.
+func (e *Epub) GenerateTOCPage(internalCSSPath string) (string, error) {
+	filename, err := e.AddSection("", e.label(LabelContents, ""), tocPageFilename, internalCSSPath)
+	if err != nil {
+		return "", err
+	}
+
+	x := e.sections[len(e.sections)-1].xhtml
+	e.sections[len(e.sections)-1].generator = &sectionGenerator{
+		title: func() string {
+			return e.label(LabelContents, "")
+		},
+		body: func() string {
+			items := ""
+			for _, s := range e.sections {
+				// Same sections as the navigation document, except the page itself
+				if !e.inTOC(s) || s.filename == filename {
+					continue
+				}
+				author := ""
+				if a := s.xhtml.meta(xhtmlMetaAuthor); a != "" {
+					author = fmt.Sprintf(tocPageAuthorTemplate, escapeText(a))
+				}
+				items += fmt.Sprintf(tocPageItemTemplate, escapeAttribute(s.filename), escapeText(s.xhtml.Title()), author)
+			}
+
+			return fmt.Sprintf(tocPageBodyTemplate, escapeText(x.Title()), e.listStyleAttribute(), items)
+		},
+	}
+
+	return filename, nil
+}
+
+// Generate the sections that have a generator. The titles are all generated
+// first, as the bodies can depend on them (e.g. a table of contents page).
+func (e *Epub) renderGeneratedSections() {
+	for _, section := range e.sections {
+		if section.generator != nil && section.generator.title != nil {
+			section.xhtml.setTitle(section.generator.title())
+		}
+	}
+	for _, section := range e.sections {
+		if section.generator != nil {
+			section.xhtml.setBody(section.generator.body())
+		}
+	}
+}
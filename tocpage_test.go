@@ -0,0 +1,52 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTOCPage(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.AddSection("<p>Title page</p>", "", "title.xhtml", "")
+	filename, err := e.GenerateTOCPage("")
+	if err != nil {
+		t.Errorf("Unexpected error generating TOC page: %s", err)
+	}
+	e.AddSection("<p>One</p>", "Chapter <1>", "one.xhtml", "")
+	e.AddSection("<p>Two</p>", "Chapter 2", "two.xhtml", "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Errorf("Unexpected error reading TOC page: %s", err)
+	}
+	testBody := `<h1>Contents</h1>
+<ol class="toc">
+<li><a href="one.xhtml">Chapter &lt;1&gt;</a></li>
+<li><a href="two.xhtml">Chapter 2</a></li>
+</ol>`
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf(
+			"TOC page doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testBody)
+	}
+
+	// The page is in the spine after the title page
+	spine := e.Spine()
+	if len(spine) != 4 || spine[1].IDRef != tocPageFilename {
+		t.Errorf("TOC page isn't in the spine where it was added\nGot: %+v", spine)
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	_, err = e.GenerateTOCPage("")
+	if _, ok := err.(*FilenameAlreadyUsedError); !ok {
+		t.Errorf("Expected error FilenameAlreadyUsedError not returned. Returned instead: %+v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package epub
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	trackingScriptRegexp    = regexp.MustCompile(`(?is)<script\b[^>]*?(?:/>|>(.*?)</script\s*>)`)
+	trackingIframeRegexp    = regexp.MustCompile(`(?is)<iframe\b[^>]*?(?:/>|>.*?</iframe\s*>)`)
+	emptyNoscriptRegexp     = regexp.MustCompile(`(?is)<noscript\b[^>]*>\s*</noscript\s*>`)
+	trackingURLAttrRegexp   = regexp.MustCompile(`(?is)<(?:a|area|img|source|video|audio|iframe)\b[^>]*>`)
+	trackingPixelSizeRegexp = regexp.MustCompile(`^\s*[01](?:px)?\s*$`)
+)
+
+// Hosts and paths of analytics and advertising services, matched against the
+// lowercased host and path of URLs
+var trackingURLPatterns = []string{
+	"analytics.twitter.com",
+	"bat.bing.com",
+	"chartbeat.com",
+	"clarity.ms",
+	"connect.facebook.net",
+	"doubleclick.net",
+	"facebook.com/tr",
+	"google-analytics.com",
+	"googletagmanager.com",
+	"googlesyndication.com",
+	"hotjar.com",
+	"matomo.js",
+	"mixpanel.com",
+	"piwik.js",
+	"pixel.wp.com",
+	"plausible.io",
+	"quantserve.com",
+	"scorecardresearch.com",
+	"segment.com",
+	"segment.io",
+	"stats.wp.com",
+}
+
+// Calls made by inline analytics scripts
+var trackingScriptPatterns = []string{
+	"_gaq.push",
+	"_paq.push",
+	"analytics.track(",
+	"fbq(",
+	"ga('create'",
+	`ga("create"`,
+	"gtag(",
+	"hotjar",
+	"mixpanel.",
+}
+
+// Query parameters used to track clicks, in addition to the utm_ parameters
+var trackingQueryParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"msclkid": true,
+}
+
+// StripTracking removes the tracking from HTML content, e.g. a web page
+// ingested as a section, since it can't work offline and is a privacy
+// problem:
+//   - Analytics scripts, recognized by the URL of the script or the calls made
+//     by inline scripts (e.g. gtag), and the iframes of analytics services
+//   - Tracking pixels: images whose width and height are both 0 or 1 pixel, or
+//     whose URL is an analytics service, as well as the noscript elements left
+//     empty
+//   - The utm_ query parameters of links and media URLs, as well as the click
+//     identifiers fbclid, gclid, mc_cid, mc_eid and msclkid
+//
+// Other scripts and the rest of the content are left unchanged.
+func StripTracking(html string) string {
+	html = trackingScriptRegexp.ReplaceAllStringFunc(html, func(script string) string {
+		m := trackingScriptRegexp.FindStringSubmatch(script)
+		src, _ := tagAttribute(script[:strings.Index(script, ">")+1], "src")
+		if isTrackingURL(unescapeText(src)) || isTrackingScript(m[1]) {
+			return ""
+		}
+		return script
+	})
+	html = trackingIframeRegexp.ReplaceAllStringFunc(html, func(iframe string) string {
+		src, _ := tagAttribute(iframe[:strings.Index(iframe, ">")+1], "src")
+		if isTrackingURL(unescapeText(src)) {
+			return ""
+		}
+		return iframe
+	})
+	html = htmlImgRegexp.ReplaceAllStringFunc(html, func(img string) string {
+		src, _ := tagAttribute(img, "src")
+		width, _ := tagAttribute(img, "width")
+		height, _ := tagAttribute(img, "height")
+		if isTrackingURL(unescapeText(src)) ||
+			(trackingPixelSizeRegexp.MatchString(width) && trackingPixelSizeRegexp.MatchString(height)) {
+			return ""
+		}
+		return img
+	})
+	html = emptyNoscriptRegexp.ReplaceAllString(html, "")
+
+	return trackingURLAttrRegexp.ReplaceAllStringFunc(html, func(tag string) string {
+		for _, name := range []string{"href", "src"} {
+			value, ok := tagAttribute(tag, name)
+			if !ok {
+				continue
+			}
+			stripped := stripTrackingParams(unescapeText(value))
+			if stripped != unescapeText(value) {
+				tag = setTagAttribute(tag, name, escapeAttribute(stripped))
+			}
+		}
+		return tag
+	})
+}
+
+// SetStripTracking sets whether the tracking is removed from the sections when
+// the EPUB is written, as StripTracking does, e.g. for EPUBs built from web
+// content. The sections themselves are left unchanged.
+func (e *Epub) SetStripTracking(strip bool) {
+	e.stripTracking = strip
+}
+
+// Whether a URL is the URL of an analytics or advertising service
+func isTrackingURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return false
+	}
+	s := strings.ToLower(u.Host + u.Path)
+	for _, pattern := range trackingURLPatterns {
+		if strings.Contains(s, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Whether the content of an inline script calls an analytics service
+func isTrackingScript(content string) bool {
+	for _, pattern := range trackingScriptPatterns {
+		if strings.Contains(content, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remove the tracking query parameters of a URL, keeping the order of the
+// others
+func stripTrackingParams(rawURL string) string {
+	i := strings.Index(rawURL, "?")
+	if i == -1 {
+		return rawURL
+	}
+	query := rawURL[i+1:]
+	fragment := ""
+	if j := strings.Index(query, "#"); j != -1 {
+		query, fragment = query[:j], query[j:]
+	}
+
+	params := []string{}
+	for _, param := range strings.Split(query, "&") {
+		key := strings.ToLower(strings.SplitN(param, "=", 2)[0])
+		if param == "" || strings.HasPrefix(key, "utm_") || trackingQueryParams[key] {
+			continue
+		}
+		params = append(params, param)
+	}
+
+	s := rawURL[:i]
+	if len(params) > 0 {
+		s += "?" + strings.Join(params, "&")
+	}
+
+	return s + fragment
+}
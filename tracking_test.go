@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripTracking(t *testing.T) {
+	tests := []struct {
+		html     string
+		expected string
+	}{
+		{
+			`<p>Text</p><script async="async" src="https://www.googletagmanager.com/gtag/js?id=G-1"></script>`,
+			`<p>Text</p>`,
+		},
+		{
+			`<script>window.dataLayer = []; gtag('config', 'G-1');</script><script src="app.js"></script>`,
+			`<script src="app.js"></script>`,
+		},
+		{
+			`<noscript><img height="1" width="1" src="https://example.com/p.gif" /></noscript><img src="a.png" width="1" height="200" />`,
+			`<img src="a.png" width="1" height="200" />`,
+		},
+		{
+			`<img src="https://pixel.wp.com/g.gif?v=1" alt="" />`,
+			``,
+		},
+		{
+			`<iframe src="https://www.googletagmanager.com/ns.html?id=GTM-1" height="0" width="0"></iframe><iframe src="https://example.com/embed"></iframe>`,
+			`<iframe src="https://example.com/embed"></iframe>`,
+		},
+		{
+			`<a href="https://example.com/post?id=1&amp;utm_source=feed&amp;UTM_MEDIUM=rss&amp;fbclid=x#comments">Post</a>`,
+			`<a href="https://example.com/post?id=1#comments">Post</a>`,
+		},
+		{
+			`<a href="https://example.com/?utm_campaign=spring">Home</a><a href="https://example.com/?q=utm_source">Search</a>`,
+			`<a href="https://example.com/">Home</a><a href="https://example.com/?q=utm_source">Search</a>`,
+		},
+	}
+	for _, test := range tests {
+		if got := StripTracking(test.html); got != test.expected {
+			t.Errorf("Stripped HTML doesn't match\nGot: %s\nExpected: %s", got, test.expected)
+		}
+	}
+}
+
+func TestSetStripTracking(t *testing.T) {
+	body := `<p><a href="https://example.com/?utm_source=feed">Link</a></p><img src="https://example.com/p.gif" width="0" height="0" />`
+	e := NewEpub(testEpubTitle)
+	e.SetStripTracking(true)
+	filename, _ := e.AddSection(body, testSectionTitle, "", "")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, filename))
+	if err != nil {
+		t.Fatalf("Unexpected error reading section: %s", err)
+	}
+	testBody := `<p><a href="https://example.com/">Link</a></p>` + "\n</body>"
+	if !strings.Contains(string(contents), testBody) {
+		t.Errorf("Section body doesn't match\nGot: %s\nExpected to contain: %s", contents, testBody)
+	}
+	// The section itself is left unchanged
+	if !strings.Contains(e.sections[0].xhtml.xml.Body.XML, "utm_source") {
+		t.Errorf("Section was changed by SetStripTracking")
+	}
+}
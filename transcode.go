@@ -0,0 +1,122 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MediaTranscodingError is thrown by Write and WriteAudiobook if an audio or
+// video file can't be transcoded.
+type MediaTranscodingError struct {
+	Source string // The source of the file that couldn't be transcoded
+	Err    error  // The underlying error that was thrown
+}
+
+func (e *MediaTranscodingError) Error() string {
+	return fmt.Sprintf("Error transcoding %q: %+v", e.Source, e.Err)
+}
+
+// MediaTranscoder converts audio and video files to formats that more reading
+// systems can play (e.g. OGG to MP3 or AAC, MKV to MP4), typically by running
+// a tool such as ffmpeg.
+type MediaTranscoder interface {
+	// OutputExt returns the file extension (e.g. ".mp3") of the file produced
+	// from a file with the provided extension, or an empty string if files with
+	// this extension don't need to be transcoded. It's called when audio and
+	// video files are added.
+	OutputExt(ext string) string
+	// Transcode converts the file read from r, which has the provided
+	// extension, and writes the result to w. It's called when the EPUB is
+	// written.
+	Transcode(w io.Writer, r io.Reader, ext string) error
+}
+
+// SetMediaTranscoder sets the transcoder used for the audio and video files
+// added afterwards. The files are transcoded when the EPUB is written.
+//
+// The extension of the internal filename of a file that needs to be transcoded
+// is replaced by the one of the transcoded file (e.g. chapter.ogg becomes
+// chapter.mp3), so the path returned by AddAudio or AddVideo must be used to
+// reference it.
+func (e *Epub) SetMediaTranscoder(transcoder MediaTranscoder) {
+	e.transcoder = transcoder
+}
+
+// Add a media file to the EPUB, to be transcoded when the EPUB is written if
+// the transcoder requires it
+func (e *Epub) addTranscodedMedia(source string, internalFilename string, mediaFileFormat string, mediaFolderName string, mediaMap map[string]string) (string, error) {
+	ext := mediaSourceExt(source)
+	outputExt := ""
+	if e.transcoder != nil {
+		outputExt = strings.ToLower(e.transcoder.OutputExt(ext))
+	}
+	if outputExt == "" || outputExt == ext {
+		return e.addMedia(source, internalFilename, mediaFileFormat, mediaFolderName, mediaMap)
+	}
+
+	if internalFilename == "" {
+		internalFilename = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source)) + outputExt
+		if _, ok := mediaMap[internalFilename]; ok || validateFilename(internalFilename) != nil || strings.HasPrefix(source, dataURLPrefix) {
+			internalFilename = generateMediaFilename(mediaFileFormat, outputExt, mediaMap)
+		}
+	} else {
+		internalFilename = strings.TrimSuffix(internalFilename, filepath.Ext(internalFilename)) + outputExt
+	}
+
+	mediaPath, err := e.addMedia(source, internalFilename, mediaFileFormat, mediaFolderName, mediaMap)
+	if err != nil {
+		return "", err
+	}
+	e.transcodedMedia[path.Join(mediaFolderName, internalFilename)] = ext
+
+	return mediaPath, nil
+}
+
+// Get a media file from its source and save it to the destination path,
+// transcoding it if needed. Remote sources that can't be retrieved are
+// refreshed using the source refresher if one is set.
+func (e *Epub) copyMedia(mediaFolderName string, mediaFilename string, source string, destFilePath string) error {
+	err := e.copyMediaOnce(mediaFolderName, mediaFilename, source, destFilePath)
+	if _, ok := err.(*FileRetrievalError); !ok || e.sourceRefresher == nil || !isRemoteSource(source) {
+		return err
+	}
+
+	refreshed, refreshErr := e.sourceRefresher(source)
+	if refreshErr != nil {
+		return &FileRetrievalError{Source: source, Err: refreshErr}
+	}
+	return e.copyMediaOnce(mediaFolderName, mediaFilename, refreshed, destFilePath)
+}
+
+func (e *Epub) copyMediaOnce(mediaFolderName string, mediaFilename string, source string, destFilePath string) error {
+	ext, ok := e.transcodedMedia[path.Join(mediaFolderName, mediaFilename)]
+	if !ok {
+		return copyMediaSource(e.context(), e.httpClient(), source, destFilePath, e.verifyCopies)
+	}
+
+	r, err := openMediaSource(e.context(), e.httpClient(), source)
+	if err != nil {
+		return &FileRetrievalError{Source: source, Err: err}
+	}
+	defer r.Close()
+
+	w, err := os.Create(destFilePath)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to create file: %s", err))
+	}
+	defer w.Close()
+
+	var originalSize int64
+	tr := io.TeeReader(r, &countingWriter{w: ioutil.Discard, count: &originalSize})
+	if err := e.transcoder.Transcode(w, tr, ext); err != nil {
+		return &MediaTranscodingError{Source: source, Err: err}
+	}
+	e.recordTransform(mediaFolderName, mediaFilename, originalSize, ResourceTransformTranscoded)
+
+	return nil
+}
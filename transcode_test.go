@@ -0,0 +1,116 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testTranscoder struct {
+	err error
+}
+
+func (t *testTranscoder) OutputExt(ext string) string {
+	if ext == ".ogg" {
+		return ".mp3"
+	}
+	return ""
+}
+
+func (t *testTranscoder) Transcode(w io.Writer, r io.Reader, ext string) error {
+	if t.err != nil {
+		return t.err
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.ToUpper(data))
+	return err
+}
+
+func TestSetMediaTranscoder(t *testing.T) {
+	sourceDir, err := ioutil.TempDir("", tempDirPrefix)
+	if err != nil {
+		t.Fatalf("Unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(sourceDir)
+	oggPath := filepath.Join(sourceDir, "chapter.ogg")
+	if err := ioutil.WriteFile(oggPath, []byte("ogg data"), filePermissions); err != nil {
+		t.Fatalf("Unexpected error writing audio file: %s", err)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.SetMediaTranscoder(&testTranscoder{})
+
+	audioPath, err := e.AddAudio(oggPath, "")
+	if err != nil {
+		t.Errorf("Unexpected error adding audio: %s", err)
+	}
+	testAudioPath := filepath.Join("..", AudioFolderName, "chapter.mp3")
+	if audioPath != testAudioPath {
+		t.Errorf(
+			"Audio path doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			audioPath,
+			testAudioPath)
+	}
+	videoPath, _ := e.AddVideo(newDataURL("video/mp4", []byte("mp4 data")), "clip.mp4")
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	for p, expected := range map[string]string{audioPath: "OGG DATA", videoPath: "mp4 data"} {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, p))
+		if err != nil {
+			t.Errorf("Unexpected error reading media file: %s", err)
+		}
+		if string(contents) != expected {
+			t.Errorf(
+				"Media file %s doesn't match\n"+
+					"Got: %s\n"+
+					"Expected: %s",
+				p,
+				contents,
+				expected)
+		}
+	}
+
+	cleanup(testEpubFilename, tempDir)
+
+	testErr := errors.New("unsupported codec")
+	e.SetMediaTranscoder(&testTranscoder{err: testErr})
+	e.AddAudio(oggPath, "other.ogg")
+	err = e.Write(testEpubFilename)
+	if transcodingErr, ok := err.(*MediaTranscodingError); !ok || transcodingErr.Err != testErr {
+		t.Errorf("Expected error MediaTranscodingError not returned. Returned instead: %+v", err)
+	}
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestSetMediaTranscoderFilenames(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetMediaTranscoder(&testTranscoder{})
+
+	// The filename that would be generated next is already used
+	if _, err := e.AddAudio(newDataURL("audio/mpeg", []byte("mp3 data")), "audio0002.mp3"); err != nil {
+		t.Fatalf("Unexpected error adding audio: %s", err)
+	}
+	audioPath, err := e.AddAudio(newDataURL("audio/ogg", []byte("ogg data")), "")
+	if err != nil {
+		t.Fatalf("Unexpected error adding audio: %s", err)
+	}
+	testAudioPath := filepath.Join("..", AudioFolderName, "audio0003.mp3")
+	if audioPath != testAudioPath {
+		t.Errorf(
+			"Audio path doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			audioPath,
+			testAudioPath)
+	}
+}
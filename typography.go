@@ -0,0 +1,155 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FontFeature is an OpenType feature of a font, identified by its tag. Any tag
+// can be used (e.g. FontFeature("ss01") for a stylistic set); the constants
+// below also have a high-level CSS equivalent, which is preferred by reading
+// systems that support it.
+//
+// Spec: https://www.w3.org/TR/css-fonts-3/#font-rend-props
+type FontFeature string
+
+// Common font features
+const (
+	FontFeatureAllSmallCaps           FontFeature = "c2sc"
+	FontFeatureDiscretionaryLigatures FontFeature = "dlig"
+	FontFeatureFractions              FontFeature = "frac"
+	FontFeatureLiningNumerals         FontFeature = "lnum"
+	FontFeatureOldStyleNumerals       FontFeature = "onum"
+	FontFeatureProportionalNumerals   FontFeature = "pnum"
+	FontFeatureSmallCaps              FontFeature = "smcp"
+	FontFeatureTabularNumerals        FontFeature = "tnum"
+)
+
+// The high-level CSS properties (font-variant-*) equivalent to font features
+var fontFeatureVariants = map[FontFeature][2]string{
+	FontFeatureAllSmallCaps:           {"font-variant-caps", "all-small-caps"},
+	FontFeatureDiscretionaryLigatures: {"font-variant-ligatures", "discretionary-ligatures"},
+	FontFeatureFractions:              {"font-variant-numeric", "diagonal-fractions"},
+	FontFeatureLiningNumerals:         {"font-variant-numeric", "lining-nums"},
+	FontFeatureOldStyleNumerals:       {"font-variant-numeric", "oldstyle-nums"},
+	FontFeatureProportionalNumerals:   {"font-variant-numeric", "proportional-nums"},
+	FontFeatureSmallCaps:              {"font-variant-caps", "small-caps"},
+	FontFeatureTabularNumerals:        {"font-variant-numeric", "tabular-nums"},
+}
+
+// FontFeatureCSS returns CSS that applies font features to the elements
+// matching the selector, optionally using the provided font family (e.g. an
+// embedded font that supports these features).
+//
+// The CSS degrades gracefully across reading systems: the high-level
+// font-variant-* properties are used when supported (reading systems
+// synthesize small caps if the font lacks them), font-feature-settings is
+// used otherwise, and font-variant: small-caps (CSS 2) is added for older
+// reading systems when small caps are requested.
+func FontFeatureCSS(selector string, fontFamily string, features ...FontFeature) string {
+	declarations := []string{}
+	if fontFamily != "" {
+		declarations = append(declarations, fmt.Sprintf(`font-family: "%s", serif;`, strings.Replace(fontFamily, `"`, `\"`, -1)))
+	}
+
+	// Combine the values of each high-level property, in order of appearance
+	variantProperties := []string{}
+	variantValues := map[string][]string{}
+	tags := []string{}
+	smallCaps := false
+	for _, feature := range features {
+		tags = append(tags, fmt.Sprintf("%q", string(feature)))
+		switch feature {
+		case FontFeatureAllSmallCaps:
+			// All small caps also needs small caps for lowercase letters
+			tags = append(tags, fmt.Sprintf("%q", string(FontFeatureSmallCaps)))
+			smallCaps = true
+		case FontFeatureSmallCaps:
+			smallCaps = true
+		}
+
+		variant, ok := fontFeatureVariants[feature]
+		if !ok {
+			continue
+		}
+		if _, ok := variantValues[variant[0]]; !ok {
+			variantProperties = append(variantProperties, variant[0])
+		}
+		variantValues[variant[0]] = append(variantValues[variant[0]], variant[1])
+	}
+	tags = uniqueStrings(tags)
+
+	if smallCaps {
+		declarations = append(declarations, "font-variant: small-caps;")
+	}
+	conditions := []string{}
+	for _, property := range variantProperties {
+		value := strings.Join(variantValues[property], " ")
+		declarations = append(declarations, fmt.Sprintf("%s: %s;", property, value))
+		conditions = append(conditions, fmt.Sprintf("(%s: %s)", property, value))
+	}
+
+	featureSettings := []string{
+		fmt.Sprintf("-webkit-font-feature-settings: %s;", strings.Join(tags, ", ")),
+		fmt.Sprintf("font-feature-settings: %s;", strings.Join(tags, ", ")),
+	}
+	// Features without a high-level equivalent can only use
+	// font-feature-settings, which must then list all the features
+	if len(tags) == 0 {
+		featureSettings = nil
+	} else if countVariantFeatures(features) < len(features) {
+		declarations = append(declarations, featureSettings...)
+		featureSettings = nil
+	}
+
+	css := cssRule(selector, declarations, "")
+	if len(featureSettings) > 0 {
+		css += fmt.Sprintf("@supports not (%s) {\n", strings.Join(conditions, " and "))
+		css += cssRule(selector, featureSettings, "  ")
+		css += "}\n"
+	}
+
+	return css
+}
+
+// AddFontFeatures applies font features to the elements matching the selector
+// using the default stylesheet, which is linked to every section. See
+// FontFeatureCSS for the CSS that is generated.
+func (e *Epub) AddFontFeatures(selector string, fontFamily string, features ...FontFeature) {
+	e.fontFeatureCSS = append(e.fontFeatureCSS, FontFeatureCSS(selector, fontFamily, features...))
+}
+
+// Format a CSS rule
+func cssRule(selector string, declarations []string, indent string) string {
+	css := indent + selector + " {\n"
+	for _, declaration := range declarations {
+		css += indent + "  " + declaration + "\n"
+	}
+	css += indent + "}\n"
+
+	return css
+}
+
+// Count the features that have a high-level equivalent
+func countVariantFeatures(features []FontFeature) int {
+	count := 0
+	for _, feature := range features {
+		if _, ok := fontFeatureVariants[feature]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+// Remove duplicate strings, keeping the first occurrence
+func uniqueStrings(a []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, s := range a {
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testFontFeatureCSS = `.smallcaps {
+  font-family: "Redacted Script", serif;
+  font-variant: small-caps;
+  font-variant-caps: small-caps;
+  font-variant-numeric: oldstyle-nums tabular-nums;
+}
+@supports not ((font-variant-caps: small-caps) and (font-variant-numeric: oldstyle-nums tabular-nums)) {
+  .smallcaps {
+    -webkit-font-feature-settings: "smcp", "onum", "tnum";
+    font-feature-settings: "smcp", "onum", "tnum";
+  }
+}
+`
+	testCustomFontFeatureCSS = `h1 {
+  font-variant-ligatures: discretionary-ligatures;
+  -webkit-font-feature-settings: "dlig", "ss01";
+  font-feature-settings: "dlig", "ss01";
+}
+`
+)
+
+func TestFontFeatureCSS(t *testing.T) {
+	css := FontFeatureCSS(".smallcaps", testDefaultFontFamily, FontFeatureSmallCaps, FontFeatureOldStyleNumerals, FontFeatureTabularNumerals)
+	if css != testFontFeatureCSS {
+		t.Errorf(
+			"Font feature CSS doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			css,
+			testFontFeatureCSS)
+	}
+
+	// Features without a high-level equivalent can't use @supports
+	css = FontFeatureCSS("h1", "", FontFeatureDiscretionaryLigatures, FontFeature("ss01"))
+	if css != testCustomFontFeatureCSS {
+		t.Errorf(
+			"Font feature CSS doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			css,
+			testCustomFontFeatureCSS)
+	}
+
+	e := NewEpub(testEpubTitle)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
+	e.AddFontFeatures(".smallcaps", testDefaultFontFamily, FontFeatureSmallCaps, FontFeatureOldStyleNumerals, FontFeatureTabularNumerals)
+
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+	defer cleanup(testEpubFilename, tempDir)
+
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, CSSFolderName, defaultCSSFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading default CSS file: %s", err)
+	}
+	if !strings.Contains(string(contents), testFontFeatureCSS) {
+		t.Errorf(
+			"Default CSS file doesn't match\n"+
+				"Got: %s\n"+
+				"Expected to contain: %s",
+			contents,
+			testFontFeatureCSS)
+	}
+}
@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// uuid is a minimal RFC 4122 UUID implementation, which avoids depending on an
+// external package for the few UUID operations the EPUB identifier needs.
+type uuid [16]byte
+
+// RFC 4122 namespace for URLs
+var uuidNamespaceURL = mustParseUUID("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+
+// Generate a random (version 4) UUID using crypto/rand
+func newUUIDv4() (uuid, error) {
+	u := uuid{}
+	if _, err := rand.Read(u[:]); err != nil {
+		return u, err
+	}
+	u.setVersion(4)
+
+	return u, nil
+}
+
+func mustNewUUIDv4() uuid {
+	u, err := newUUIDv4()
+	if err != nil {
+		panic(fmt.Sprintf("Error generating UUID: %s", err))
+	}
+	return u
+}
+
+// Generate a name-based (version 5) UUID
+func newUUIDv5(namespace uuid, name string) uuid {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	u := uuid{}
+	copy(u[:], h.Sum(nil))
+	u.setVersion(5)
+
+	return u
+}
+
+// Parse a UUID in its canonical string form, e.g.
+// 6ba7b811-9dad-11d1-80b4-00c04fd430c8. Braces and a urn:uuid: prefix are
+// tolerated.
+func parseUUID(s string) (uuid, error) {
+	u := uuid{}
+
+	text := strings.TrimPrefix(strings.ToLower(s), urnUUIDPrefix)
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "{"), "}")
+	if len(text) != 36 || text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+		return u, fmt.Errorf("Invalid UUID: %s", s)
+	}
+
+	b, err := hex.DecodeString(strings.Replace(text, "-", "", -1))
+	if err != nil {
+		return u, fmt.Errorf("Invalid UUID: %s", s)
+	}
+	copy(u[:], b)
+
+	return u, nil
+}
+
+func mustParseUUID(s string) uuid {
+	u, err := parseUUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// Set the version and the RFC 4122 variant bits
+func (u *uuid) setVersion(version byte) {
+	u[6] = (u[6] & 0x0f) | version<<4
+	u[8] = (u[8] & 0x3f) | 0x80
+}
+
+func (u uuid) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+
+	return string(b)
+}
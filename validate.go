@@ -0,0 +1,276 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InvalidContentError is returned by Validate for each problem found in the
+// EPUB, such as a broken link or a missing image.
+type InvalidContentError struct {
+	Path   string // The path of the file inside the EPUB, e.g. EPUB/package.opf
+	Reason string // What the problem is
+}
+
+func (e *InvalidContentError) Error() string {
+	return fmt.Sprintf("Invalid content in %s: %s", e.Path, e.Reason)
+}
+
+// ValidationError is thrown by Write if validation is enabled using
+// SetValidateOnWrite and the EPUB has problems.
+type ValidationError struct {
+	Errors []error // The problems found, as returned by Validate
+}
+
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("EPUB validation failed: %s", strings.Join(messages, "; "))
+}
+
+// The parts of the package file that are validated
+type validationPkg struct {
+	Items []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Itemrefs []struct {
+		Idref string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// A reference from an XHTML file to another file
+type validationRef struct {
+	element string
+	target  string
+}
+
+// The IDs and references of an XHTML file
+type validationDoc struct {
+	ids  map[string]bool
+	refs []validationRef
+}
+
+// Attributes of the elements that reference other files
+var validationRefAttributes = map[string]bool{
+	"data": true,
+	"href": true,
+	"src":  true,
+}
+
+// Validate builds the EPUB as Write does, without zipping it, and checks it for
+// common problems that reading systems and validators such as epubcheck reject:
+//   - Sections that aren't well-formed XHTML
+//   - Links to files or IDs that don't exist
+//   - Images, stylesheets and other resources referenced but not added
+//   - IDs used more than once in a section
+//   - Files missing from the manifest, manifest items without a file and spine
+//     items that aren't in the manifest
+//
+// An InvalidContentError is returned for each problem found, or the error
+// returned by the build if it fails. The list is empty if no problem was
+// found. Links to remote resources aren't checked.
+func (e *Epub) Validate() []error {
+	if e.discarded() {
+		return []error{&EpubDiscardedError{}}
+	}
+
+	tempDir, err := e.newTempDir()
+	if err != nil {
+		return []error{err}
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			panic(fmt.Sprintf("Error removing temp directory: %s", err))
+		}
+	}()
+
+	restore, err := e.build(tempDir)
+	defer restore()
+	if err, ok := err.(*ValidationError); ok {
+		return err.Errors
+	}
+	if err != nil {
+		return []error{err}
+	}
+	// The EPUB was already validated by build
+	if e.validateOnWrite {
+		return []error{}
+	}
+
+	return validateBuild(tempDir)
+}
+
+// SetValidateOnWrite sets whether the EPUB is validated as Validate does when
+// it's written. If problems are found, Write returns ValidationError and the
+// EPUB isn't written. Validation is disabled by default.
+func (e *Epub) SetValidateOnWrite(validate bool) {
+	e.validateOnWrite = validate
+}
+
+// Validate the files of the EPUB in the temporary directory
+func validateBuild(tempDir string) []error {
+	errs := []error{}
+	invalid := func(p string, format string, a ...interface{}) {
+		errs = append(errs, &InvalidContentError{
+			Path:   path.Join(contentFolderName, p),
+			Reason: fmt.Sprintf(format, a...),
+		})
+	}
+
+	contentDir := filepath.Join(tempDir, contentFolderName)
+	contents, err := ioutil.ReadFile(filepath.Join(contentDir, pkgFilename))
+	if err != nil {
+		panic(fmt.Sprintf("Error reading package file: %s", err))
+	}
+	var p validationPkg
+	if err := xml.Unmarshal(contents, &p); err != nil {
+		invalid(pkgFilename, "malformed XML: %s", err)
+		return errs
+	}
+
+	// Manifest consistency
+	ids := map[string]bool{}
+	manifest := map[string]bool{}
+	for _, item := range p.Items {
+		if ids[item.ID] {
+			invalid(pkgFilename, "duplicate manifest item ID %q", item.ID)
+		}
+		ids[item.ID] = true
+		manifest[item.Href] = true
+		if _, err := os.Stat(filepath.Join(contentDir, filepath.FromSlash(item.Href))); err != nil {
+			invalid(pkgFilename, "manifest item %q has no file", item.Href)
+		}
+	}
+	for _, itemref := range p.Itemrefs {
+		if !ids[itemref.Idref] {
+			invalid(pkgFilename, "spine item %q isn't in the manifest", itemref.Idref)
+		}
+	}
+	files := []string{}
+	err = filepath.Walk(contentDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(contentDir, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Error reading EPUB files: %s", err))
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if f != pkgFilename && !manifest[f] {
+			invalid(f, "file isn't in the manifest")
+		}
+	}
+
+	// XHTML files, in manifest order
+	docs := map[string]*validationDoc{}
+	xhtmlFiles := []string{}
+	for _, item := range p.Items {
+		if item.MediaType != mediaTypeXhtml {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(contentDir, filepath.FromSlash(item.Href)))
+		if err != nil {
+			continue
+		}
+		doc, err := parseValidationDoc(contents, func(id string) {
+			invalid(item.Href, "duplicate ID %q", id)
+		})
+		if err != nil {
+			invalid(item.Href, "malformed XHTML: %s", err)
+			continue
+		}
+		docs[item.Href] = doc
+		xhtmlFiles = append(xhtmlFiles, item.Href)
+	}
+
+	// References
+	for _, f := range xhtmlFiles {
+		for _, ref := range docs[f].refs {
+			u, err := url.Parse(ref.target)
+			if err != nil {
+				invalid(f, "invalid reference %q", ref.target)
+				continue
+			}
+			if u.Scheme != "" || u.Host != "" {
+				continue
+			}
+			target := f
+			if u.Path != "" {
+				target = path.Join(path.Dir(f), u.Path)
+			}
+			if !manifest[target] {
+				if ref.element == "a" {
+					invalid(f, "link to missing file %q", ref.target)
+				} else {
+					invalid(f, "<%s> references missing file %q", ref.element, ref.target)
+				}
+				continue
+			}
+			if u.Fragment == "" {
+				continue
+			}
+			if doc, ok := docs[target]; ok && !doc.ids[u.Fragment] {
+				invalid(f, "link to missing ID %q", ref.target)
+			}
+		}
+	}
+
+	return errs
+}
+
+// Parse an XHTML file, collecting its IDs and references. duplicate is called
+// for each ID used more than once.
+func parseValidationDoc(contents []byte, duplicate func(id string)) (*validationDoc, error) {
+	doc := &validationDoc{ids: map[string]bool{}}
+
+	d := xml.NewDecoder(bytes.NewReader(contents))
+	for {
+		t, err := d.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		start, ok := t.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			switch {
+			case attr.Name.Local == "id" && attr.Name.Space == "":
+				if doc.ids[attr.Value] {
+					duplicate(attr.Value)
+				}
+				doc.ids[attr.Value] = true
+			case validationRefAttributes[attr.Name.Local] && attr.Value != "":
+				doc.refs = append(doc.refs, validationRef{
+					element: start.Name.Local,
+					target:  attr.Value,
+				})
+			}
+		}
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"os"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	cssPath, _ := e.AddCSS(testCoverCSSSource, testCoverCSSFilename)
+	e.SetCover(imagePath, cssPath)
+	e.AddTitlePage(TitlePageLayoutCentered, "")
+	e.GenerateTOCPage("")
+	chapter, _ := e.AddSection(`<h1 id="top">Chapter</h1><p><img src="`+imagePath+`" alt="" /></p>`, testSectionTitle, "chapter.xhtml", cssPath)
+	e.AddSection(`<p><a href="`+chapter+`#top">Chapter</a> <a href="https://example.com/">Remote</a></p>`, testSectionTitle, "links.xhtml", "")
+	e.AddFootnote("links.xhtml", "Remote", "<p>Note</p>")
+	e.AddPageBreak(chapter, "1", "top")
+
+	if errs := e.Validate(); len(errs) != 0 {
+		t.Errorf("Unexpected validation errors: %v", errs)
+	}
+
+	e = NewEpub(testEpubTitle)
+	e.AddSection(`<p id="a">A</p><p id="a"><img src="../images/missing.png" alt="" /></p>`, testSectionTitle, "section0001.xhtml", "")
+	e.AddSection(`<p><a href="section0001.xhtml#b">B</a> <a href="missing.xhtml">C</a></p>`, testSectionTitle, "section0002.xhtml", "")
+	e.AddSection(`<p>Unclosed<br></p>`, testSectionTitle, "section0003.xhtml", "")
+
+	testErrors := []string{
+		`Invalid content in EPUB/xhtml/section0001.xhtml: duplicate ID "a"`,
+		`Invalid content in EPUB/xhtml/section0003.xhtml: malformed XHTML: XML syntax error on line 8: element <br> closed by </p>`,
+		`Invalid content in EPUB/xhtml/section0001.xhtml: <img> references missing file "../images/missing.png"`,
+		`Invalid content in EPUB/xhtml/section0002.xhtml: link to missing ID "section0001.xhtml#b"`,
+		`Invalid content in EPUB/xhtml/section0002.xhtml: link to missing file "missing.xhtml"`,
+	}
+	errs := e.Validate()
+	if len(errs) != len(testErrors) {
+		t.Fatalf("Validation errors don't match\nGot: %v\nExpected: %v", errs, testErrors)
+	}
+	for i, err := range errs {
+		if err.Error() != testErrors[i] {
+			t.Errorf("Validation error doesn't match\nGot: %s\nExpected: %s", err, testErrors[i])
+		}
+	}
+	if _, ok := errs[0].(*InvalidContentError); !ok {
+		t.Errorf("Validation error type doesn't match\nGot: %T\nExpected: %T", errs[0], &InvalidContentError{})
+	}
+
+	e.SetValidateOnWrite(true)
+	err := e.Write(testEpubFilename)
+	if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("Write error type doesn't match\nGot: %T\nExpected: %T", err, &ValidationError{})
+	}
+	if _, err := os.Stat(testEpubFilename); err == nil {
+		t.Error("EPUB was written despite validation errors")
+		os.Remove(testEpubFilename)
+	}
+}
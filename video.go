@@ -0,0 +1,190 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultVideoTrackKind = "captions"
+	videoElementTemplate  = `<video src="%s" controls="controls"%s>
+%s<a href="%s">%s</a>
+</video>`
+	videoTrackTemplate = `<track kind="%s" src="%s"%s />
+`
+)
+
+// VideoTrack is a timed text track of a video, such as captions, which
+// reading systems show along with the video.
+type VideoTrack struct {
+	// The WebVTT file, either a URL, a data URL, or a path to a local file
+	Source string `json:"source"`
+	// The kind of track: captions (the default), subtitles, descriptions,
+	// chapters, or metadata
+	Kind string `json:"kind"`
+	// The language of the track, e.g. en
+	Lang string `json:"lang,omitempty"`
+	// The title of the track shown by reading systems, e.g. English
+	Label string `json:"label,omitempty"`
+	// Whether the track is enabled by default
+	Default bool `json:"default,omitempty"`
+}
+
+// VideoPosterExtractor extracts a frame of a video to use as its poster image.
+// It's used by ExtractVideoPoster, as there is no video decoder in the
+// standard library. The video is streamed from its source rather than loaded
+// in memory. The image must be encoded as GIF, JPEG, or PNG, and its file
+// extension (e.g. ".png") must be returned along with it.
+type VideoPosterExtractor func(video io.Reader) (data []byte, ext string, err error)
+
+// The poster and tracks of a video
+type epubVideo struct {
+	posterPath string
+	tracks     []videoTrack
+}
+
+type videoTrack struct {
+	VideoTrack
+	// Path to the track file relative to the EPUB section files
+	path string
+}
+
+// SetVideoPoster sets the poster of a video, the image shown before the video
+// is played.
+//
+// The internal paths to an already-added video file (as returned by AddVideo)
+// and image file (as returned by AddImage) are required. If either doesn't
+// match a file that has been added, FilenameNotFoundError will be returned.
+func (e *Epub) SetVideoPoster(internalVideoPath string, internalImagePath string) error {
+	video, err := e.video(internalVideoPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := e.images[filepath.Base(internalImagePath)]; !ok {
+		return &FilenameNotFoundError{Filename: filepath.Base(internalImagePath)}
+	}
+
+	video.posterPath = internalImagePath
+
+	return nil
+}
+
+// ExtractVideoPoster extracts the poster of a video using the provided
+// function, adds it to the EPUB as an image and sets it as the poster of the
+// video. The path to the image is returned in the format:
+// ../ImageFolderName/internalFilename
+//
+// The internal path to an already-added video file (as returned by AddVideo)
+// is required. If it doesn't match a video that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) ExtractVideoPoster(internalVideoPath string, extractor VideoPosterExtractor) (string, error) {
+	if _, err := e.video(internalVideoPath); err != nil {
+		return "", err
+	}
+
+	source := e.videos[filepath.Base(internalVideoPath)]
+	r, err := openMediaSource(e.context(), e.httpClient(), source)
+	if err != nil {
+		return "", &FileRetrievalError{Source: source, Err: err}
+	}
+	defer r.Close()
+
+	image, ext, err := extractor(r)
+	if err != nil {
+		return "", &FileRetrievalError{Source: source, Err: err}
+	}
+
+	mediaType, ok := extensionMediaTypes[strings.ToLower(ext)]
+	if !ok || !strings.HasPrefix(mediaType, "image/") {
+		return "", &FileRetrievalError{Source: source, Err: fmt.Errorf("unsupported poster image extension: %q", ext)}
+	}
+	imagePath, err := e.AddImage(newDataURL(mediaType, image), "")
+	if err != nil {
+		return "", err
+	}
+
+	return imagePath, e.SetVideoPoster(internalVideoPath, imagePath)
+}
+
+// AddVideoTrack adds a WebVTT file to the EPUB as a track of a video and
+// returns a relative path to the track file in the format:
+// ../VideoFolderName/internalFilename
+//
+// The internal path to an already-added video file (as returned by AddVideo)
+// is required. If it doesn't match a video that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) AddVideoTrack(internalVideoPath string, track VideoTrack) (string, error) {
+	video, err := e.video(internalVideoPath)
+	if err != nil {
+		return "", err
+	}
+
+	trackPath, err := e.addMedia(track.Source, "", trackFileFormat, VideoFolderName, e.videos)
+	if err != nil {
+		return "", err
+	}
+	if track.Kind == "" {
+		track.Kind = defaultVideoTrackKind
+	}
+	video.tracks = append(video.tracks, videoTrack{
+		VideoTrack: track,
+		path:       trackPath,
+	})
+
+	return trackPath, nil
+}
+
+// VideoElement returns the <video> element to use to embed a video in the
+// body of a section, including its poster and tracks, e.g.
+//
+//	<video src="../video/video0001.mp4" controls="controls" poster="../images/image0001.png">
+//	<track kind="captions" src="../video/track0001.vtt" srclang="en" label="English" />
+//	<a href="../video/video0001.mp4">video0001.mp4</a>
+//	</video>
+//
+// The link is shown by reading systems that don't support video.
+//
+// The internal path to an already-added video file (as returned by AddVideo)
+// is required. If it doesn't match a video that has been added,
+// FilenameNotFoundError will be returned.
+func (e *Epub) VideoElement(internalVideoPath string) (string, error) {
+	video, err := e.video(internalVideoPath)
+	if err != nil {
+		return "", err
+	}
+
+	poster := ""
+	if video.posterPath != "" {
+		poster = fmt.Sprintf(` poster="%s"`, escapeAttribute(video.posterPath))
+	}
+
+	tracks := ""
+	for _, track := range video.tracks {
+		attributes := ""
+		if track.Lang != "" {
+			attributes += fmt.Sprintf(` srclang="%s"`, escapeAttribute(track.Lang))
+		}
+		if track.Label != "" {
+			attributes += fmt.Sprintf(` label="%s"`, escapeAttribute(track.Label))
+		}
+		if track.Default {
+			attributes += ` default="default"`
+		}
+		tracks += fmt.Sprintf(videoTrackTemplate, escapeAttribute(track.Kind), escapeAttribute(track.path), attributes)
+	}
+
+	src := escapeAttribute(internalVideoPath)
+	return fmt.Sprintf(videoElementTemplate, src, poster, tracks, src, escapeText(filepath.Base(internalVideoPath))), nil
+}
+
+// Get the poster and tracks of a video
+func (e *Epub) video(internalVideoPath string) (*epubVideo, error) {
+	video, ok := e.videoInfo[filepath.Base(internalVideoPath)]
+	if !ok {
+		return nil, &FilenameNotFoundError{Filename: filepath.Base(internalVideoPath)}
+	}
+
+	return video, nil
+}
@@ -0,0 +1,144 @@
+package epub
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddVideo(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	videoPath, err := e.AddVideo(newDataURL("video/mp4", []byte("video data")), "clip.mp4")
+	if err != nil {
+		t.Errorf("Unexpected error adding video: %s", err)
+	}
+
+	posterPath, err := e.ExtractVideoPoster(videoPath, func(video io.Reader) ([]byte, string, error) {
+		data, err := ioutil.ReadAll(video)
+		if err != nil || string(data) != "video data" {
+			t.Errorf("Video data doesn't match\nGot: %s", data)
+		}
+		data, err = ioutil.ReadFile(testImageFromFileSource)
+		return data, ".webp", err
+	})
+	if err == nil || posterPath != "" {
+		t.Errorf("Expected error for unsupported poster extension, got %q", posterPath)
+	}
+
+	posterPath, err = e.ExtractVideoPoster(videoPath, func(video io.Reader) ([]byte, string, error) {
+		data, err := ioutil.ReadFile(testImageFromFileSource)
+		return data, ".png", err
+	})
+	if err != nil {
+		t.Errorf("Unexpected error extracting video poster: %s", err)
+	}
+
+	trackPath, err := e.AddVideoTrack(videoPath, VideoTrack{
+		Source:  newDataURL("text/vtt", []byte("WEBVTT\n")),
+		Lang:    "en",
+		Label:   "English",
+		Default: true,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error adding video track: %s", err)
+	}
+
+	element, err := e.VideoElement(videoPath)
+	if err != nil {
+		t.Errorf("Unexpected error getting video element: %s", err)
+	}
+	testElement := `<video src="../video/clip.mp4" controls="controls" poster="` + posterPath + `">
+<track kind="captions" src="` + trackPath + `" srclang="en" label="English" default="default" />
+<a href="../video/clip.mp4">clip.mp4</a>
+</video>`
+	if element != testElement {
+		t.Errorf(
+			"Video element doesn't match\n"+
+				"Got: %s\n"+
+				"Expected: %s",
+			element,
+			testElement)
+	}
+
+	e.AddSection(element, "Video", "", "")
+	tempDir := writeAndExtractEpub(t, e, testEpubFilename)
+
+	for _, p := range []string{videoPath, trackPath, posterPath} {
+		if _, err := os.Stat(filepath.Join(tempDir, contentFolderName, xhtmlFolderName, p)); err != nil {
+			t.Errorf("Unexpected error reading file %s: %s", p, err)
+		}
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(tempDir, contentFolderName, pkgFilename))
+	if err != nil {
+		t.Errorf("Unexpected error reading package file: %s", err)
+	}
+	for _, item := range []string{
+		`href="video/clip.mp4" media-type="video/mp4"`,
+		`href="video/track0002.vtt" media-type="text/vtt"`,
+	} {
+		if !strings.Contains(string(contents), item) {
+			t.Errorf("Package file doesn't contain manifest item %s\nGot: %s", item, contents)
+		}
+	}
+
+	cleanup(testEpubFilename, tempDir)
+}
+
+func TestAudioAndVideoMediaTypes(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	for _, mediaType := range []string{"audio/mpeg", "audio/mp4", "audio/ogg"} {
+		if _, err := e.AddAudio(newDataURL(mediaType, []byte("audio data")), ""); err != nil {
+			t.Errorf("Unexpected error adding %s audio: %s", mediaType, err)
+		}
+	}
+	for _, mediaType := range []string{"video/mp4", "video/webm", "video/ogg"} {
+		if _, err := e.AddVideo(newDataURL(mediaType, []byte("video data")), ""); err != nil {
+			t.Errorf("Unexpected error adding %s video: %s", mediaType, err)
+		}
+	}
+
+	mediaTypes := map[string]string{}
+	for _, item := range e.Manifest() {
+		mediaTypes[item.Href] = item.MediaType
+	}
+	for href, mediaType := range map[string]string{
+		"audio/audio0001.mp3":  "audio/mpeg",
+		"audio/audio0002.m4a":  "audio/mp4",
+		"audio/audio0003.ogg":  "audio/ogg",
+		"video/video0001.mp4":  "video/mp4",
+		"video/video0002.webm": "video/webm",
+		"video/video0003.ogv":  "video/ogg",
+	} {
+		if mediaTypes[href] != mediaType {
+			t.Errorf("Media type of %s doesn't match\nGot: %s\nExpected: %s", href, mediaTypes[href], mediaType)
+		}
+	}
+}
+
+func TestVideoErrors(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	imagePath, _ := e.AddImage(testImageFromFileSource, "")
+	videoPath, _ := e.AddVideo(newDataURL("video/mp4", []byte("video data")), "")
+
+	if err := e.SetVideoPoster("../video/missing.mp4", imagePath); err == nil {
+		t.Error("Expected error FilenameNotFoundError not returned for missing video")
+	}
+	if err := e.SetVideoPoster(videoPath, "../images/missing.png"); err == nil {
+		t.Error("Expected error FilenameNotFoundError not returned for missing image")
+	}
+	if _, err := e.VideoElement("../video/missing.mp4"); err == nil {
+		t.Error("Expected error FilenameNotFoundError not returned by VideoElement")
+	}
+
+	testErr := errors.New("no frames")
+	_, err := e.ExtractVideoPoster(videoPath, func(video io.Reader) ([]byte, string, error) {
+		return nil, "", testErr
+	})
+	if retrievalErr, ok := err.(*FileRetrievalError); !ok || retrievalErr.Err != testErr {
+		t.Errorf("Expected error FileRetrievalError not returned. Returned instead: %+v", err)
+	}
+}
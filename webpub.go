@@ -0,0 +1,146 @@
+package epub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+const (
+	webPubContext   = "https://readium.org/webpub-manifest/context.jsonld"
+	webPubMediaType = "application/webpub+json"
+	webPubType      = "http://schema.org/Book"
+)
+
+// webPubManifest implements the Readium Web Publication Manifest
+//
+// Spec: https://readium.org/webpub-manifest/
+type webPubManifest struct {
+	Context      string         `json:"@context"`
+	Metadata     webPubMetadata `json:"metadata"`
+	Links        []webPubLink   `json:"links"`
+	ReadingOrder []webPubLink   `json:"readingOrder"`
+	Resources    []webPubLink   `json:"resources,omitempty"`
+	Toc          []webPubLink   `json:"toc,omitempty"`
+}
+
+type webPubMetadata struct {
+	Type               string `json:"@type"`
+	Identifier         string `json:"identifier"`
+	Title              string `json:"title"`
+	Author             string `json:"author,omitempty"`
+	Language           string `json:"language,omitempty"`
+	Description        string `json:"description,omitempty"`
+	Publisher          string `json:"publisher,omitempty"`
+	Published          string `json:"published,omitempty"`
+	Modified           string `json:"modified"`
+	ReadingProgression string `json:"readingProgression,omitempty"`
+}
+
+type webPubLink struct {
+	Href     string       `json:"href"`
+	Type     string       `json:"type,omitempty"`
+	Rel      string       `json:"rel,omitempty"`
+	Title    string       `json:"title,omitempty"`
+	Children []webPubLink `json:"children,omitempty"`
+}
+
+// WebPubManifest returns a Readium Web Publication Manifest
+// (https://readium.org/webpub-manifest/) describing the EPUB, which allows
+// Readium-based web readers to consume the content directly. Links in the
+// manifest are relative to the root of the EPUB container, so the manifest
+// should be served from the root of the unzipped EPUB.
+func (e *Epub) WebPubManifest() ([]byte, error) {
+	m := webPubManifest{
+		Context: webPubContext,
+		Metadata: webPubMetadata{
+			Type:               webPubType,
+			Identifier:         e.Identifier(),
+			Title:              e.title,
+			Author:             e.author,
+			Language:           e.lang,
+			Description:        e.desc,
+			Publisher:          e.publisher,
+			Published:          e.pkg.xml.Metadata.Date,
+			Modified:           e.modifiedTime().Format(time.RFC3339),
+			ReadingProgression: e.ppd,
+		},
+		Links: []webPubLink{
+			{
+				Rel:  "self",
+				Href: "manifest.json",
+				Type: webPubMediaType,
+			},
+		},
+		ReadingOrder: []webPubLink{},
+	}
+
+	for _, item := range e.Spine() {
+		m.ReadingOrder = append(m.ReadingOrder, webPubLink{
+			Href: path.Join(contentFolderName, item.Href),
+			Type: item.MediaType,
+		})
+	}
+
+	for _, item := range e.Manifest() {
+		if item.MediaType == mediaTypeXhtml && item.ID != tocNavItemID {
+			// Sections are already part of the reading order
+			continue
+		}
+		link := webPubLink{
+			Href: path.Join(contentFolderName, item.Href),
+			Type: item.MediaType,
+		}
+		for _, property := range item.Properties {
+			switch property {
+			case coverImageProperties:
+				link.Rel = "cover"
+			case tocNavItemProperties:
+				link.Rel = "contents"
+			}
+		}
+		m.Resources = append(m.Resources, link)
+	}
+
+	// The key is the filename of the closest ancestor in the TOC, empty for
+	// the top-level sections
+	tocChildren := map[string][]epubSection{}
+	for _, section := range e.spineSections() {
+		if e.inTOC(section) {
+			parent := e.tocParent(section)
+			tocChildren[parent] = append(tocChildren[parent], section)
+		}
+	}
+	m.Toc = webPubTocLinks(tocChildren, "")
+
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Get the TOC links of the sections nested under the provided parent, with
+// their own sub-sections as children
+func webPubTocLinks(tocChildren map[string][]epubSection, parent string) []webPubLink {
+	var links []webPubLink
+	for _, section := range tocChildren[parent] {
+		links = append(links, webPubLink{
+			Href:     path.Join(contentFolderName, xhtmlFolderName, section.filename),
+			Title:    section.xhtml.Title(),
+			Children: webPubTocLinks(tocChildren, section.filename),
+		})
+	}
+
+	return links
+}
+
+// WriteWebPubManifest writes a Readium Web Publication Manifest describing the
+// EPUB to the provided path. See WebPubManifest for more information.
+func (e *Epub) WriteWebPubManifest(destFilePath string) error {
+	output, err := e.WebPubManifest()
+	if err != nil {
+		return err
+	}
+	// It's generally nice to have files end with a newline
+	output = append(output, "\n"...)
+
+	return ioutil.WriteFile(destFilePath, output, filePermissions)
+}
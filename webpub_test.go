@@ -0,0 +1,93 @@
+package epub
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWebPubManifest(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	e.SetAuthor(testEpubAuthor)
+	e.SetPpd(testEpubPpd)
+	e.AddSection(testSectionBody, testSectionTitle, testSectionFilename, "")
+	e.AddSection(testSectionBody, "", "", "")
+	imagePath, _ := e.AddImage(testImageFromFileSource, testImageFromFileFilename)
+	e.SetCover(imagePath, "")
+
+	output, err := e.WebPubManifest()
+	if err != nil {
+		t.Fatalf("Unexpected error generating WebPub manifest: %s", err)
+	}
+
+	m := webPubManifest{}
+	if err := json.Unmarshal(output, &m); err != nil {
+		t.Fatalf("Unexpected error unmarshalling WebPub manifest: %s", err)
+	}
+
+	if m.Metadata.Title != testEpubTitle || m.Metadata.Author != testEpubAuthor ||
+		m.Metadata.Identifier != e.Identifier() || m.Metadata.ReadingProgression != testEpubPpd {
+		t.Errorf("WebPub manifest metadata doesn't match: %+v", m.Metadata)
+	}
+
+	testReadingOrder := []string{
+		"EPUB/xhtml/cover.xhtml",
+		"EPUB/xhtml/section0001.xhtml",
+		"EPUB/xhtml/section0002.xhtml",
+	}
+	if len(m.ReadingOrder) != len(testReadingOrder) {
+		t.Fatalf("Unexpected WebPub reading order: %+v", m.ReadingOrder)
+	}
+	for i, href := range testReadingOrder {
+		if m.ReadingOrder[i].Href != href {
+			t.Errorf(
+				"WebPub reading order doesn't match\n"+
+					"Got: %s\n"+
+					"Expected: %s",
+				m.ReadingOrder[i].Href,
+				href)
+		}
+	}
+
+	if len(m.Toc) != 1 || m.Toc[0].Title != testSectionTitle {
+		t.Errorf("Unexpected WebPub TOC: %+v", m.Toc)
+	}
+
+	foundCover := false
+	for _, link := range m.Resources {
+		if link.Rel == "cover" && link.Href == "EPUB/images/"+testImageFromFileFilename {
+			foundCover = true
+		}
+	}
+	if !foundCover {
+		t.Errorf("WebPub cover resource not found: %+v", m.Resources)
+	}
+}
+
+func TestWebPubManifestNestedTOC(t *testing.T) {
+	e := NewEpub(testEpubTitle)
+	chapter, _ := e.AddSection(testSectionBody, "Chapter 1", "", "")
+	e.AddSubSection(chapter, testSectionBody, "Section 1.1", "", "")
+	hidden, _ := e.AddSubSection(chapter, testSectionBody, "Hidden", "", "")
+	e.AddSubSection(hidden, testSectionBody, "Section 1.2.1", "", "")
+	e.AddSection(testSectionBody, "Chapter 2", "", "")
+	e.SetSectionInTOC(hidden, false)
+
+	output, err := e.WebPubManifest()
+	if err != nil {
+		t.Fatalf("Unexpected error generating WebPub manifest: %s", err)
+	}
+	m := webPubManifest{}
+	if err := json.Unmarshal(output, &m); err != nil {
+		t.Fatalf("Unexpected error unmarshalling WebPub manifest: %s", err)
+	}
+
+	// Sub-sections of hidden sections are nested under the closest ancestor
+	testToc := `[{"href":"EPUB/xhtml/section0001.xhtml","title":"Chapter 1","children":[` +
+		`{"href":"EPUB/xhtml/section0002.xhtml","title":"Section 1.1"},` +
+		`{"href":"EPUB/xhtml/section0004.xhtml","title":"Section 1.2.1"}]},` +
+		`{"href":"EPUB/xhtml/section0005.xhtml","title":"Chapter 2"}]`
+	toc, _ := json.Marshal(m.Toc)
+	if string(toc) != testToc {
+		t.Errorf("WebPub TOC doesn't match\nGot: %s\nExpected: %s", toc, testToc)
+	}
+}
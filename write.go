@@ -2,17 +2,31 @@ package epub
 
 import (
 	"archive/zip"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
-// UnableToCreateEpubError is thrown by Write if it cannot create the destination EPUB file
+// IncompatibleVersionError is thrown by Write if the EPUB uses features that
+// aren't supported by the EPUB version it's being written as (see SetVersion).
+type IncompatibleVersionError struct {
+	Version  string   // The EPUB version being written
+	Features []string // The incompatible features that are used
+}
+
+func (e *IncompatibleVersionError) Error() string {
+	return fmt.Sprintf("Features not supported by EPUB %s: %s", e.Version, strings.Join(e.Features, ", "))
+}
+
+// UnableToCreateEpubError is thrown by Write if it cannot create or write the
+// destination EPUB file
 type UnableToCreateEpubError struct {
 	Path string // The path that was given to Write to create the EPUB
 	Err  error  // The underlying error that was thrown
@@ -24,6 +38,11 @@ func (e *UnableToCreateEpubError) Error() string {
 
 var extensionMediaTypes = map[string]string{
 	".css":   mediaTypeCSS,
+	".m4a":   "audio/mp4",
+	".mp3":   "audio/mpeg",
+	".mp4":   "video/mp4",
+	".ogg":   "audio/ogg",
+	".ogv":   "video/ogg",
 	".gif":   "image/gif",
 	".jpeg":  mediaTypeJpeg,
 	".jpg":   mediaTypeJpeg,
@@ -31,6 +50,8 @@ var extensionMediaTypes = map[string]string{
 	".png":   "image/png",
 	".svg":   "image/svg+xml",
 	".ttf":   "application/font-sfnt",
+	".vtt":   "text/vtt",
+	".webm":  "video/webm",
 	".woff":  "application/font-woff",
 	".woff2": "font/woff2",
 }
@@ -65,16 +86,217 @@ const (
 )
 
 // Write writes the EPUB file. The destination path must be the full path to
-// the resulting file, including filename and extension.
+// the resulting file, including filename and extension, or the URL of a blob
+// in a blob store registered using RegisterBlobStore. The file is only created
+// once the EPUB is built, so it isn't created if an error is returned before.
+//
+// As many reading systems don't support responsive images, they are replaced
+// by images with a single source: the largest candidate of srcset attributes,
+// <picture> elements and CSS image-set() functions is used.
 func (e *Epub) Write(destFilePath string) error {
-	tempDir, err := ioutil.TempDir("", tempDirPrefix)
+	return e.WriteWithContext(context.Background(), destFilePath)
+}
+
+// WriteWithContext writes the EPUB file as Write does. The context is used to
+// retrieve the remote sources of the files (e.g. images added from URLs), so
+// that a deadline can be set for the whole build or it can be canceled. Once
+// the context is done, the EPUB isn't written any further and the error of the
+// context is returned, either directly or as the underlying error of
+// FileRetrievalError; the file isn't created if the EPUB wasn't built yet.
+func (e *Epub) WriteWithContext(ctx context.Context, destFilePath string) error {
+	// The EPUB is written to a temp file before being uploaded to a blob store
+	blobURL := ""
+	store := blobStoreFor(destFilePath)
+	if store != nil {
+		blobURL = destFilePath
+		var err error
+		destFilePath, err = e.newBlobTempFile()
+		if err != nil {
+			return err
+		}
+		defer os.Remove(destFilePath)
+	}
+
+	f := &lazyFileWriter{path: destFilePath}
+	_, err := e.writeTo(ctx, f)
+	if closeErr := f.close(); f.err == nil {
+		f.err = closeErr
+	}
+	if f.err != nil {
+		return &UnableToCreateEpubError{
+			Path: destFilePath,
+			Err:  f.err,
+		}
+	}
+	if _, ok := err.(*EpubDiscardedError); ok && f.f != nil {
+		// Don't leave a partially written EPUB
+		os.Remove(destFilePath)
+	}
+	if _, ok := err.(*SizeBudgetExceededError); err != nil && !ok {
+		return err
+	}
+
+	if store != nil {
+		if err := uploadBlob(store, destFilePath, blobURL); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// WriteTo writes the EPUB to w, e.g. the response of an HTTP handler, and
+// returns the number of bytes written. The files of the EPUB are still
+// prepared in a temp directory, but the archive is streamed to w as it's
+// zipped, unless a size budget is set: the archive is then built in memory, as
+// it may need to be built again, and written once it fits.
+//
+// Nothing is written to w if an error is returned while building the EPUB. If
+// the EPUB exceeds its size budget, it's written and SizeBudgetExceededError
+// is returned. The errors of w are returned as-is.
+func (e *Epub) WriteTo(w io.Writer) (int64, error) {
+	return e.writeTo(context.Background(), w)
+}
+
+func (e *Epub) writeTo(ctx context.Context, w io.Writer) (n int64, err error) {
+	ctx, done, err := e.startWrite(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Must be deferred first, as it may release the content of the EPUB
+	defer done()
+	defer func() {
+		if e.discarded() {
+			err = &EpubDiscardedError{}
+		}
+	}()
+
+	e.ctx = ctx
+	defer func() {
+		e.ctx = nil
+	}()
+
+	tempDir, err := e.newTempDir()
+	if err != nil {
+		return 0, err
+	}
 	defer func() {
 		if err := os.RemoveAll(tempDir); err != nil {
 			panic(fmt.Sprintf("Error removing temp directory: %s", err))
 		}
 	}()
+
+	restore, err := e.build(tempDir)
+	defer restore()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	cw := &countingWriter{w: w, count: &count}
+
+	if e.sizeBudget <= 0 {
+		// Must be called after all the files have been written to the temp
+		// directory
+		err = e.writeEpub(tempDir, cw)
+		return count, err
+	}
+
+	buf := &bytes.Buffer{}
+	err = e.writeEpub(tempDir, buf)
+	if err != nil {
+		return 0, err
+	}
+	// Must be called last, as it may write the EPUB again
+	budgetErr := e.fitSizeBudget(tempDir, buf)
+	if _, err := io.Copy(cw, buf); err != nil {
+		return count, err
+	}
+
+	return count, budgetErr
+}
+
+// Prepare the files of the EPUB in the temporary directory. The returned
+// function removes the sections added while building (e.g. the back matter)
+// and must be called once the EPUB has been built, even if an error is
+// returned.
+func (e *Epub) build(tempDir string) (func(), error) {
+	if e.optionErr != nil {
+		return func() {}, e.optionErr
+	}
+
+	if e.sandbox != nil {
+		if err := e.sandboxSources(); err != nil {
+			return func() {}, err
+		}
+		if err := e.sandboxFootnotes(); err != nil {
+			return func() {}, err
+		}
+	}
+
+	e.resourceTransforms = map[string]*resourceTransform{}
+	e.cssWarnings = nil
+	e.buildWarnings = nil
+	e.diskUsage = 0
+
+	sectionCount, err := e.addBackMatter()
+	restore := func() {
+		e.sections = e.sections[:sectionCount]
+		e.removeAutoCover()
+	}
+	if err != nil {
+		return restore, err
+	}
+
+	// Must be called first so that the rendered sections are used by the
+	// following steps
+	err = e.renderSectionTemplates()
 	if err != nil {
-		panic(fmt.Sprintf("Error creating temp directory: %s", err))
+		return restore, err
+	}
+	e.renderGeneratedSections()
+
+	// Must be called after:
+	// renderSectionTemplates()
+	e.addAutoCover()
+
+	if e.version == EPUBVersion2 {
+		if features := e.epub2IncompatibleFeatures(); len(features) > 0 {
+			return restore, &IncompatibleVersionError{
+				Version:  e.version,
+				Features: features,
+			}
+		}
+	}
+
+	e.resolveIdentifier()
+
+	// Must be called after:
+	// resolveIdentifier()
+	err = e.checkISBNs()
+	if err != nil {
+		return restore, err
+	}
+	err = e.checkDates()
+	if err != nil {
+		return restore, err
+	}
+
+	// Must be called before:
+	// writeImages()
+	err = e.addEmojiImages()
+	if err != nil {
+		return restore, err
+	}
+
+	// Must be called after:
+	// addAutoCover()
+	// addBackMatter()
+	restoreMetadata := e.normalizeMetadata()
+	restoreSections := restore
+	restore = func() {
+		restoreMetadata()
+		restoreSections()
 	}
 
 	writeMimetype(tempDir)
@@ -88,26 +310,59 @@ func (e *Epub) Write(destFilePath string) error {
 	// createEpubFolders()
 	err = e.writeCSSFiles(tempDir)
 	if err != nil {
-		return err
+		return restore, err
 	}
 
 	// Must be called after:
 	// createEpubFolders()
 	err = e.writeFonts(tempDir)
 	if err != nil {
-		return err
+		return restore, err
 	}
 
+	// Must be called after:
+	// resolveIdentifier()
+	// writeFonts()
+	e.writeObfuscatedFonts(tempDir)
+
 	// Must be called after:
 	// createEpubFolders()
 	err = e.writeImages(tempDir)
 	if err != nil {
-		return err
+		return restore, err
+	}
+
+	// Must be called after:
+	// writeImages()
+	e.checkTarget(tempDir)
+
+	// Must be called after:
+	// createEpubFolders()
+	err = e.writeAudio(tempDir)
+	if err != nil {
+		return restore, err
 	}
 
 	// Must be called after:
 	// createEpubFolders()
-	e.writeSections(tempDir)
+	err = e.writeVideo(tempDir)
+	if err != nil {
+		return restore, err
+	}
+
+	// Must be called after:
+	// createEpubFolders()
+	err = e.writeForeign(tempDir)
+	if err != nil {
+		return restore, err
+	}
+
+	// Must be called after:
+	// createEpubFolders()
+	err = e.writeSections(tempDir)
+	if err != nil {
+		return restore, err
+	}
 
 	// Must be called after:
 	// createEpubFolders()
@@ -116,19 +371,64 @@ func (e *Epub) Write(destFilePath string) error {
 
 	// Must be called after:
 	// createEpubFolders()
+	e.writeMediaOverlays(tempDir)
+
+	// Must be called after:
+	// createEpubFolders()
+	// writeAudio()
 	// writeCSSFiles()
+	// writeForeign()
 	// writeImages()
 	// writeSections()
 	// writeToc()
+	// writeVideo()
 	e.writePackageFile(tempDir)
 
-	// Must be called last
-	err = e.writeEpub(tempDir, destFilePath)
+	// Must be called after:
+	// writePackageFile()
+	// writeSections()
+	// writeToc()
+	e.normalizeTextFiles(tempDir)
+
+	if e.validateOnWrite {
+		if errs := validateBuild(tempDir); len(errs) > 0 {
+			return restore, &ValidationError{Errors: errs}
+		}
+	}
+
+	return restore, e.checkDiskUsage(tempDir)
+}
+
+// Creates a file when it's first written to, keeping the first error
+type lazyFileWriter struct {
+	path string
+	f    *os.File
+	err  error
+}
+
+func (l *lazyFileWriter) Write(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.f == nil {
+		l.f, l.err = os.Create(l.path)
+		if l.err != nil {
+			return 0, l.err
+		}
+	}
+
+	n, err := l.f.Write(p)
 	if err != nil {
-		return err
+		l.err = err
 	}
+	return n, err
+}
 
-	return nil
+func (l *lazyFileWriter) close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
 }
 
 // Create the EPUB folder structure in a temp directory
@@ -185,6 +485,17 @@ func writeContainerFile(tempDir string) {
 	}
 }
 
+// Get audio files from their source and save them in the temporary directory
+func (e *Epub) writeAudio(tempDir string) error {
+	return e.writeMedia(tempDir, e.audio, AudioFolderName)
+}
+
+// Get video and track files from their source and save them in the temporary
+// directory
+func (e *Epub) writeVideo(tempDir string) error {
+	return e.writeMedia(tempDir, e.videos, VideoFolderName)
+}
+
 // Write the CSS files to the temporary directory and add them to the package
 // file
 func (e *Epub) writeCSSFiles(tempDir string) error {
@@ -193,114 +504,197 @@ func (e *Epub) writeCSSFiles(tempDir string) error {
 		return err
 	}
 
+	// Sorted so that the warnings are always in the same order
+	cssFilenames := []string{}
+	for cssFilename := range e.css {
+		cssFilenames = append(cssFilenames, cssFilename)
+	}
+	sort.Strings(cssFilenames)
+
+	for _, cssFilename := range cssFilenames {
+		cssFilePath := filepath.Join(tempDir, contentFolderName, CSSFolderName, cssFilename)
+		css, err := ioutil.ReadFile(cssFilePath)
+		if err != nil {
+			panic(fmt.Sprintf("Error reading CSS file: %s", err))
+		}
+		// Reading systems may not detect the encoding of CSS files that aren't
+		// in UTF-8
+		decoded, transcoded, err := decodeText(css)
+		if err != nil {
+			return &FileRetrievalError{Source: e.css[cssFilename], Err: err}
+		}
+		if transcoded {
+			e.recordTransform(CSSFolderName, cssFilename, int64(len(css)), ResourceTransformTranscoded)
+		}
+		// Many reading systems don't support image-set()
+		simplified := simplifyImageSets(decoded)
+		if e.convertPrintCSS {
+			converted, warnings := ConvertPrintCSS(simplified)
+			for _, w := range warnings {
+				w.Filename = cssFilename
+				e.cssWarnings = append(e.cssWarnings, w)
+			}
+			if converted != simplified {
+				e.recordTransform(CSSFolderName, cssFilename, int64(len(css)), ResourceTransformReflowed)
+			}
+			simplified = converted
+		}
+		if simplified != string(css) {
+			if err := ioutil.WriteFile(cssFilePath, []byte(simplified), filePermissions); err != nil {
+				panic(fmt.Sprintf("Error writing CSS file: %s", err))
+			}
+		}
+	}
+
+	if css := e.defaultCSS(); css != "" {
+		// The CSS folder doesn't exist yet if no CSS files were added
+		cssFolderPath := filepath.Join(tempDir, contentFolderName, CSSFolderName)
+		if err := os.MkdirAll(cssFolderPath, dirPermissions); err != nil {
+			panic(fmt.Sprintf("Unable to create directory: %s", err))
+		}
+
+		cssFilePath := filepath.Join(cssFolderPath, defaultCSSFilename)
+		if err := ioutil.WriteFile(cssFilePath, []byte(css), filePermissions); err != nil {
+			panic(fmt.Sprintf("Error writing default CSS file: %s", err))
+		}
+	}
+
 	// Clean up the cover temp file if one was created
 	os.Remove(e.cover.cssTempFile)
 
 	return nil
 }
 
-// Write the EPUB file itself by zipping up everything from a temp directory
-func (e *Epub) writeEpub(tempDir string, destFilePath string) error {
-	f, err := os.Create(destFilePath)
-	if err != nil {
-		return &UnableToCreateEpubError{
-			Path: destFilePath,
-			Err:  err,
-		}
-	}
+// Write the EPUB file itself by zipping up everything from a temp directory.
+// Only the errors of w are returned.
+func (e *Epub) writeEpub(tempDir string, w io.Writer) (err error) {
+	resources := []*ResourceReport{}
+	// Must run last, once the sizes are known
 	defer func() {
-		if err := f.Close(); err != nil {
-			panic(err)
+		e.buildReport = &BuildReport{
+			CSSWarnings: e.cssWarnings,
+			Warnings:    e.buildWarnings,
+		}
+		for _, r := range resources {
+			e.buildReport.Resources = append(e.buildReport.Resources, *r)
 		}
 	}()
 
-	z := zip.NewWriter(f)
+	z := zip.NewWriter(w)
+	registerCountingCompressors(z, &resources)
 	defer func() {
-		if err := z.Close(); err != nil {
-			panic(err)
+		if closeErr := z.Close(); err == nil {
+			err = closeErr
 		}
 	}()
 
-	skipMimetypeFile := false
-
-	var addFileToZip = func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	addFile := func(path string, relativePath string, method uint16) error {
+		if err := e.context().Err(); err != nil {
 			return err
 		}
-
-		// Get the path of the file relative to the folder we're zipping
-		relativePath, err := filepath.Rel(tempDir, path)
-		relativePath = filepath.ToSlash(relativePath)
+		info, err := os.Stat(path)
 		if err != nil {
-			// tempDir and path are both internal, so we shouldn't get here
-			panic(fmt.Sprintf("Error closing EPUB file: %s", err))
+			panic(fmt.Sprintf("Error opening file being added to EPUB: %s", err))
 		}
-
-		// Only include regular files, not directories
-		if !info.Mode().IsRegular() {
-			return nil
+		r := &ResourceReport{
+			Path:              relativePath,
+			OriginalSize:      info.Size(),
+			Size:              info.Size(),
+			CompressionMethod: CompressionMethodDeflate,
 		}
-
-		var w io.Writer
-		if path == filepath.Join(tempDir, mimetypeFilename) {
-			// Skip the mimetype file if it's already been written
-			if skipMimetypeFile == true {
-				return nil
-			}
-			// The mimetype file must be uncompressed according to the EPUB spec
-			w, err = z.CreateHeader(&zip.FileHeader{
-				Name:   relativePath,
-				Method: zip.Store,
-			})
-		} else {
-			w, err = z.Create(relativePath)
+		if method == zip.Store {
+			r.CompressionMethod = CompressionMethodStore
 		}
-		if err != nil {
-			panic(fmt.Sprintf("Error creating zip writer: %s", err))
+		if t, ok := e.resourceTransforms[relativePath]; ok {
+			r.OriginalSize = t.originalSize
+			r.Transforms = t.transforms
 		}
+		resources = append(resources, r)
 
-		r, err := os.Open(path)
-		if err != nil {
-			panic(fmt.Sprintf("Error opening file being added to EPUB: %s", err))
-		}
-		defer func() {
-			if err := r.Close(); err != nil {
-				panic(err)
-			}
-		}()
+		return addFileToZip(z, path, relativePath, method, e.zipTimestamp())
+	}
+
+	// Add the mimetype file first
+	mimetypeFilePath := filepath.Join(tempDir, mimetypeFilename)
+	// The mimetype file must be uncompressed according to the EPUB spec
+	if err := addFile(mimetypeFilePath, mimetypeFilename, zip.Store); err != nil {
+		return err
+	}
 
-		_, err = io.Copy(w, r)
+	paths := []string{}
+	err = filepath.Walk(tempDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			panic(fmt.Sprintf("Error copying contents of file being added EPUB: %s", err))
+			return err
+		}
+		// Only include regular files, not directories. Skip the mimetype file
+		// since it's already been written.
+		if !info.Mode().IsRegular() || path == mimetypeFilePath {
+			return nil
 		}
 
+		paths = append(paths, relativeZipPath(tempDir, path))
 		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Unable to add file to EPUB: %s", err))
 	}
 
-	// Add the mimetype file first
-	mimetypeFilePath := filepath.Join(tempDir, mimetypeFilename)
-	mimetypeInfo, err := os.Lstat(mimetypeFilePath)
-	if err != nil {
-		panic(fmt.Sprintf("Unable to get FileInfo for mimetype file: %s", err))
+	for _, relativePath := range e.orderZipEntries(tempDir, paths) {
+		if err := addFile(filepath.Join(tempDir, filepath.FromSlash(relativePath)), relativePath, zip.Deflate); err != nil {
+			return err
+		}
 	}
-	err = addFileToZip(mimetypeFilePath, mimetypeInfo, nil)
+
+	return nil
+}
+
+// Get the path of a file relative to the folder we're zipping
+func relativeZipPath(dir string, path string) string {
+	relativePath, err := filepath.Rel(dir, path)
 	if err != nil {
-		panic(fmt.Sprintf("Unable to add mimetype file to EPUB: %s", err))
+		// dir and path are both internal, so we shouldn't get here
+		panic(fmt.Sprintf("Error getting relative path of file being zipped: %s", err))
 	}
 
-	skipMimetypeFile = true
+	return filepath.ToSlash(relativePath)
+}
 
-	err = filepath.Walk(tempDir, addFileToZip)
+// Add a file to a zip archive using the provided compression method and
+// timestamp, which is omitted if zero. Only the errors writing the archive are
+// returned.
+func addFileToZip(z *zip.Writer, path string, relativePath string, method uint16, modified time.Time) error {
+	w, err := z.CreateHeader(&zip.FileHeader{
+		Name:     relativePath,
+		Method:   method,
+		Modified: modified,
+	})
 	if err != nil {
-		panic(fmt.Sprintf("Unable to add file to EPUB: %s", err))
+		return err
 	}
 
-	return nil
+	r, err := os.Open(path)
+	if err != nil {
+		panic(fmt.Sprintf("Error opening file being added to EPUB: %s", err))
+	}
+	defer func() {
+		if err := r.Close(); err != nil {
+			panic(err)
+		}
+	}()
+
+	_, err = io.Copy(w, r)
+	return err
 }
 
-// Get fonts from their source and save them in the temporary directory
+// Get fonts from their source and save them in the temporary directory. The
+// emoji fallback font is subsetted if needed.
 func (e *Epub) writeFonts(tempDir string) error {
-	return e.writeMedia(tempDir, e.fonts, FontFolderName)
+	err := e.writeMedia(tempDir, e.fonts, FontFolderName)
+	if err != nil {
+		return err
+	}
+
+	return e.subsetEmojiFont(tempDir)
 }
 
 // Get images from their source and save them in the temporary directory
@@ -316,59 +710,33 @@ func (e *Epub) writeMedia(tempDir string, mediaMap map[string]string, mediaFolde
 			panic(fmt.Sprintf("Unable to create directory: %s", err))
 		}
 
-		for mediaFilename, mediaSource := range mediaMap {
-			// Get the media file from the source
-			u, err := url.Parse(mediaSource)
-			if err != nil {
-				return &FileRetrievalError{Source: mediaSource, Err: err}
-			}
-
-			var r io.ReadCloser
-			var resp *http.Response
-			// If it's a URL
-			if u.Scheme == "http" || u.Scheme == "https" {
-				resp, err = http.Get(mediaSource)
-				if err != nil {
-					return &FileRetrievalError{Source: mediaSource, Err: err}
-				}
-				r = resp.Body
+		mediaFilenames := []string{}
+		for mediaFilename := range mediaMap {
+			mediaFilenames = append(mediaFilenames, mediaFilename)
+		}
+		sort.Strings(mediaFilenames)
+		fetched := e.fetchMedia(mediaFolderName, mediaFolderPath, mediaFilenames, mediaMap)
 
-				// Otherwise, assume it's a local file
-			} else {
-				r, err = os.Open(mediaSource)
-			}
-			if err != nil {
-				return &FileRetrievalError{Source: mediaSource, Err: err}
+		for _, mediaFilename := range mediaFilenames {
+			if err := e.context().Err(); err != nil {
+				return err
 			}
-
 			mediaFilePath := filepath.Join(
 				mediaFolderPath,
 				mediaFilename,
 			)
 
-			// Add the file to the EPUB temp directory
-			w, err := os.Create(mediaFilePath)
-			if err != nil {
-				panic(fmt.Sprintf("Unable to create file: %s", err))
+			// Get the media file from the source and add it to the EPUB temp
+			// directory, unless it was already retrieved
+			err, ok := fetched[mediaFilename]
+			if !ok {
+				err = e.copyMedia(mediaFolderName, mediaFilename, mediaMap[mediaFilename], mediaFilePath)
 			}
-
-			_, err = io.Copy(w, r)
-			// Close the reader and writer manually. If we use a defer instead,
-			// they won't close until the function exits.
-			func() {
-				if err := r.Close(); err != nil {
-					panic(err)
-				}
-			}()
-			func() {
-				if err := w.Close(); err != nil {
-					panic(err)
-				}
-			}()
 			if err != nil {
-				// There shouldn't be any problem with the writer, but the reader
-				// might have an issue
-				return &FileRetrievalError{Source: mediaSource, Err: err}
+				return err
+			}
+			if err := e.useDisk(mediaFilePath); err != nil {
+				return err
 			}
 
 			mediaType := extensionMediaTypes[strings.ToLower(filepath.Ext(mediaFilename))]
@@ -377,15 +745,6 @@ func (e *Epub) writeMedia(tempDir string, mediaMap map[string]string, mediaFolde
 					"Unmatched file extension, media type not set for file: %s",
 					mediaFilename))
 			}
-
-			// The cover image has a special value for the properties attribute
-			mediaProperties := ""
-			if mediaFilename == e.cover.imageFilename {
-				mediaProperties = coverImageProperties
-			}
-
-			// Add the file to the OPF manifest
-			e.pkg.addToManifest(mediaFilename, filepath.Join(mediaFolderName, mediaFilename), mediaType, mediaProperties)
 		}
 	}
 
@@ -404,48 +763,121 @@ func writeMimetype(tempDir string) {
 	}
 }
 
+// Write the package file to the temporary directory. The manifest and spine
+// are rebuilt from the content of the EPUB each time so that writing the same
+// EPUB more than once doesn't duplicate entries.
 func (e *Epub) writePackageFile(tempDir string) {
-	e.pkg.write(tempDir)
+	e.pkg.setOtherIdentifiers(e.identifiers)
+	e.pkg.setSectionCreators(e.otherAuthors())
+	e.pkg.setContributors(e.contributors)
+	e.pkg.resetManifestAndSpine()
+	for _, item := range e.Manifest() {
+		e.pkg.addToManifest(item.ID, item.Href, item.MediaType, strings.Join(item.Properties, " "), item.MediaOverlay, item.Fallback)
+	}
+	for _, item := range e.Spine() {
+		e.pkg.addToSpine(item.IDRef, strings.Join(item.Properties, " "), item.Linear)
+	}
+
+	e.setGuide()
+	e.pkg.setMediaDurations(e.spineSections(), e.mediaOverlays)
+	e.pkg.setModified(e.modifiedTime().Format(modifiedDateFormat))
+
+	if e.version == EPUBVersion2 {
+		e.pkg.writeEPUB2(tempDir, e.cover.imageFilename)
+		return
+	}
+	e.pkg.write(tempDir, e.cover.imageFilename)
 }
 
 // Write the section files to the temporary directory and add the sections to
-// the TOC and package files
-func (e *Epub) writeSections(tempDir string) {
-	if len(e.sections) > 0 {
-		// If a cover was set, add it to the package spine first so it shows up
-		// first in the reading order
-		if e.cover.xhtmlFilename != "" {
-			e.pkg.addToSpine(e.cover.xhtmlFilename)
-		}
+// the TOC
+func (e *Epub) writeSections(tempDir string) error {
+	hasDefaultCSS := e.defaultCSS() != ""
+	// The TOC is built again each time the EPUB is written
+	e.toc.reset()
 
+	if len(e.sections) > 0 {
 		for i, section := range e.sections {
-			// Set the title of the cover page XHTML to the title of the EPUB
+			// Set the title of the cover page XHTML to the title of the EPUB,
+			// as written in the package file
 			if section.filename == e.cover.xhtmlFilename {
-				section.xhtml.setTitle(e.Title())
+				section.xhtml.setTitle(e.pkg.xml.Metadata.Title)
+			}
+
+			x := section.xhtml
+			body := x.xml.Body.XML
+			if e.stripTracking {
+				body = StripTracking(body)
+			}
+			body = e.replaceEmoji(simplifyImageSets(simplifyResponsiveImages(body)))
+			body, err := e.replaceAliases(section.filename, body)
+			if err != nil {
+				return err
+			}
+			body = e.applyFootnotes(section.filename, body)
+			body = e.applyPageBreaks(section.filename, body)
+			body = processInlineSVG(section.filename, body)
+			if body != x.xml.Body.XML {
+				x = x.withBody(body)
+				if e.hasFootnotes(section.filename) || (e.hasPageBreaks(section.filename) && e.version != EPUBVersion2) {
+					x.setXmlnsEpub(xmlnsEpub)
+				}
+			}
+			if hasDefaultCSS {
+				x = x.withDefaultCSS(defaultCSSPath())
+			}
+			if section.generator != nil {
+				x = x.withDir(e.textDirection())
 			}
 
 			sectionFilePath := filepath.Join(tempDir, contentFolderName, xhtmlFolderName, section.filename)
-			section.xhtml.write(sectionFilePath)
+			if section.writer != nil {
+				var err error
+				if e.version == EPUBVersion2 {
+					err = x.writeXHTML11Stream(sectionFilePath, section.writer)
+				} else {
+					err = x.writeStream(sectionFilePath, section.writer)
+				}
+				if err != nil {
+					return &SectionWriterError{
+						Filename: section.filename,
+						Err:      err,
+					}
+				}
+			} else if e.version == EPUBVersion2 {
+				x.writeXHTML11(sectionFilePath)
+			} else {
+				x.write(sectionFilePath)
+			}
 
 			relativePath := filepath.Join(xhtmlFolderName, section.filename)
 			// Don't add pages without titles or the cover to the TOC
-			if section.xhtml.Title() != "" && section.filename != e.cover.xhtmlFilename {
-				e.toc.addSection(i, section.xhtml.Title(), relativePath)
-			}
-			// The cover page should have already been added to the spine first
-			if section.filename != e.cover.xhtmlFilename {
-				e.pkg.addToSpine(section.filename)
+			if e.inTOC(section) {
+				parentPath := ""
+				if parent := e.tocParent(section); parent != "" {
+					parentPath = filepath.Join(xhtmlFolderName, parent)
+				}
+				e.toc.addSection(i, section.xhtml.Title(), relativePath, parentPath)
 			}
-			e.pkg.addToManifest(section.filename, relativePath, mediaTypeXhtml, "")
+			e.addTOCEntries(i, section)
 		}
 	}
+
+	return nil
 }
 
-// Write the TOC file to the temporary directory and add the TOC entries to the
-// package file
+// Write the TOC files to the temporary directory
 func (e *Epub) writeToc(tempDir string) {
-	e.pkg.addToManifest(tocNavItemID, tocNavFilename, mediaTypeXhtml, tocNavItemProperties)
-	e.pkg.addToManifest(tocNcxItemID, tocNcxFilename, mediaTypeNcx, "")
-
+	e.toc.setHeading(e.label(LabelTableOfContents, ""))
+	e.toc.setPageListHeading(e.label(LabelPageList, ""))
+	e.toc.setLandmarksHeading(e.label(LabelLandmarks, ""))
+	e.addPageList()
+	e.addLandmarks()
+	e.toc.setDir(e.textDirection())
+	// EPUB 2 doesn't have a navigation document
+	if e.version == EPUBVersion2 {
+		e.toc.writeNcxDoc(tempDir)
+		return
+	}
 	e.toc.write(tempDir)
 }
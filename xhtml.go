@@ -1,16 +1,27 @@
 package epub
 
 import (
+	"bufio"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"strings"
 )
 
 const (
 	xhtmlDoctype = `<!DOCTYPE html>
 `
-	xhtmlLinkRel  = "stylesheet"
-	xhtmlTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+	// Used by EPUB 2
+	xhtml11Doctype = `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">
+`
+	xhtmlLinkRel      = "stylesheet"
+	xhtmlMetaAuthor   = "author"
+	xhtmlMetaViewport = "viewport"
+	// Marks where a streamed body goes in the marshalled XHTML
+	xhtmlStreamedBody = "\x00body\x00"
+	xhtmlTemplate     = `<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE html>
 <html xmlns="http://www.w3.org/1999/xhtml">
   <head>
@@ -30,13 +41,23 @@ type xhtml struct {
 type xhtmlRoot struct {
 	XMLName   xml.Name      `xml:"http://www.w3.org/1999/xhtml html"`
 	XmlnsEpub string        `xml:"xmlns:epub,attr,omitempty"`
+	Dir       string        `xml:"dir,attr,omitempty"`
 	Head      xhtmlHead     `xml:"head"`
 	Body      xhtmlInnerxml `xml:"body"`
 }
 
 type xhtmlHead struct {
 	Title string `xml:"title"`
-	Link  *xhtmlLink
+	Meta  []xhtmlMeta
+	Link  []xhtmlLink
+}
+
+// The <meta> element, e.g. the viewport of fixed-layout documents
+// Ex: <meta name="viewport" content="width=1200, height=1800" />
+type xhtmlMeta struct {
+	XMLName xml.Name `xml:"meta"`
+	Name    string   `xml:"name,attr"`
+	Content string   `xml:"content,attr"`
 }
 
 // The <link> element, used to link to stylesheets
@@ -87,17 +108,101 @@ func (x *xhtml) setBody(body string) {
 }
 
 func (x *xhtml) setCSS(path string) {
-	x.xml.Head.Link = &xhtmlLink{
+	x.xml.Head.Link = []xhtmlLink{
+		{
+			Rel:  xhtmlLinkRel,
+			Type: mediaTypeCSS,
+			Href: path,
+		},
+	}
+}
+
+// Link an additional stylesheet, after those already linked
+func (x *xhtml) addCSS(path string) {
+	x.xml.Head.Link = append(x.xml.Head.Link, xhtmlLink{
 		Rel:  xhtmlLinkRel,
 		Type: mediaTypeCSS,
 		Href: path,
-	}
+	})
+}
+
+// Get a copy of the XHTML document that links to the provided stylesheet
+// before any stylesheet it already links to, so that the latter take
+// precedence
+func (x *xhtml) withDefaultCSS(path string) *xhtml {
+	c := x.clone()
+	c.xml.Head.Link = append([]xhtmlLink{
+		{
+			Rel:  xhtmlLinkRel,
+			Type: mediaTypeCSS,
+			Href: path,
+		},
+	}, x.xml.Head.Link...)
+
+	return c
+}
+
+// Get a copy of the XHTML document with a different body, leaving the
+// original unchanged
+func (x *xhtml) withBody(body string) *xhtml {
+	c := x.clone()
+	c.xml.Body.XML = body
+
+	return c
+}
+
+// Get a shallow copy of the XHTML document
+func (x *xhtml) clone() *xhtml {
+	root := *x.xml
+	return &xhtml{xml: &root}
 }
 
 func (x *xhtml) setTitle(title string) {
 	x.xml.Head.Title = title
 }
 
+// Set the viewport, which defines the dimensions of fixed-layout documents
+func (x *xhtml) setViewport(width int, height int) {
+	x.setMeta(xhtmlMetaViewport, fmt.Sprintf("width=%d, height=%d", width, height))
+}
+
+// Get the content of a <meta> element identified by its name
+func (x *xhtml) meta(name string) string {
+	for _, m := range x.xml.Head.Meta {
+		if m.Name == name {
+			return m.Content
+		}
+	}
+
+	return ""
+}
+
+// Set a <meta> element identified by its name. Empty content removes the
+// element.
+func (x *xhtml) setMeta(name string, content string) {
+	meta := []xhtmlMeta{}
+	for _, m := range x.xml.Head.Meta {
+		if m.Name != name {
+			meta = append(meta, m)
+		}
+	}
+	if content != "" {
+		meta = append(meta, xhtmlMeta{
+			Name:    name,
+			Content: content,
+		})
+	}
+	x.xml.Head.Meta = meta
+}
+
+// Get a copy of the XHTML document with a text direction, e.g. rtl
+func (x *xhtml) withDir(dir string) *xhtml {
+	c := x.clone()
+	c.xml.Dir = dir
+
+	return c
+}
+
 func (x *xhtml) setXmlnsEpub(xmlns string) {
 	x.xml.XmlnsEpub = xmlns
 }
@@ -108,17 +213,48 @@ func (x *xhtml) Title() string {
 
 // Write the XHTML file to the specified path
 func (x *xhtml) write(xhtmlFilePath string) {
-	xhtmlFileContent, err := xml.MarshalIndent(x.xml, "", "  ")
+	x.writeXML(xhtmlFilePath, x.xml, xhtmlDoctype)
+}
+
+// Write the XHTML file to the specified path as XHTML 1.1, as used by EPUB 2
+func (x *xhtml) writeXHTML11(xhtmlFilePath string) {
+	x.writeXML(xhtmlFilePath, x.xhtml11Root(), xhtml11Doctype)
+}
+
+// Write the XHTML file to the specified path, using the provided function to
+// stream the body
+func (x *xhtml) writeStream(xhtmlFilePath string, writeBody SectionWriter) error {
+	return x.streamXML(xhtmlFilePath, x.xml, xhtmlDoctype, writeBody)
+}
+
+// Write the XHTML file to the specified path as XHTML 1.1, using the provided
+// function to stream the body
+func (x *xhtml) writeXHTML11Stream(xhtmlFilePath string, writeBody SectionWriter) error {
+	return x.streamXML(xhtmlFilePath, x.xhtml11Root(), xhtml11Doctype, writeBody)
+}
+
+// Get a copy of the root element without the EPUB namespace, as used by
+// XHTML 1.1
+func (x *xhtml) xhtml11Root() *xhtmlRoot {
+	root := *x.xml
+	root.XmlnsEpub = ""
+
+	return &root
+}
+
+// Marshal the XHTML and write it to the specified path
+func (x *xhtml) writeXML(xhtmlFilePath string, root *xhtmlRoot, doctype string) {
+	xhtmlFileContent, err := xml.MarshalIndent(root, "", "  ")
 	if err != nil {
 		panic(fmt.Sprintf(
 			"Error marshalling XML for XHTML file: %s\n"+
 				"\tXML=%#v",
 			err,
-			x.xml))
+			root))
 	}
 
 	// Add the doctype declaration to the output
-	xhtmlFileContent = append([]byte(xhtmlDoctype), xhtmlFileContent...)
+	xhtmlFileContent = append([]byte(doctype), xhtmlFileContent...)
 	// Add the xml header to the output
 	xhtmlFileContent = append([]byte(xml.Header), xhtmlFileContent...)
 	// It's generally nice to have files end with a newline
@@ -128,3 +264,41 @@ func (x *xhtml) write(xhtmlFilePath string) {
 		panic(fmt.Sprintf("Error writing XHTML file: %s", err))
 	}
 }
+
+// Marshal the XHTML and write it to the specified path, using the provided
+// function to write the body directly to the file
+func (x *xhtml) streamXML(xhtmlFilePath string, root *xhtmlRoot, doctype string, writeBody SectionWriter) error {
+	streamedRoot := *root
+	streamedRoot.Body.XML = "\n" + xhtmlStreamedBody + "\n"
+	xhtmlFileContent, err := xml.MarshalIndent(&streamedRoot, "", "  ")
+	if err != nil {
+		panic(fmt.Sprintf(
+			"Error marshalling XML for XHTML file: %s\n"+
+				"\tXML=%#v",
+			err,
+			root))
+	}
+	parts := strings.SplitN(string(xhtmlFileContent), xhtmlStreamedBody, 2)
+
+	f, err := os.OpenFile(xhtmlFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, filePermissions)
+	if err != nil {
+		panic(fmt.Sprintf("Error writing XHTML file: %s", err))
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if _, err := io.WriteString(w, xml.Header+doctype+parts[0]); err != nil {
+		panic(fmt.Sprintf("Error writing XHTML file: %s", err))
+	}
+	if err := writeBody(w); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, parts[1]+"\n"); err != nil {
+		panic(fmt.Sprintf("Error writing XHTML file: %s", err))
+	}
+	if err := w.Flush(); err != nil {
+		panic(fmt.Sprintf("Error writing XHTML file: %s", err))
+	}
+
+	return nil
+}